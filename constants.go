@@ -100,6 +100,18 @@ const (
 	// ComponentKube is a kubernetes proxy
 	ComponentKube = "proxy:kube"
 
+	// ComponentApp is an application proxy
+	ComponentApp = "proxy:app"
+
+	// ComponentDatabase is a database proxy
+	ComponentDatabase = "proxy:db"
+
+	// ComponentWindowsDesktop is a Windows desktop access gateway
+	ComponentWindowsDesktop = "windows_desktop"
+
+	// ComponentDiscovery is the cloud instance discovery service
+	ComponentDiscovery = "discovery"
+
 	// ComponentAuth is the cluster CA node (auth server API)
 	ComponentAuth = "auth"
 
@@ -260,6 +272,10 @@ const (
 	// OFF means no second factor.for Two-Factor Authentication.
 	OFF = "off"
 
+	// WebAuthn means the WebAuthn Two-Factor Authentication standard. Not
+	// yet implemented; see lib/auth/webauthn for why and what's missing.
+	WebAuthn = "webauthn"
+
 	// Local means authentication will happen locally within the Teleport cluster.
 	Local = "local"
 
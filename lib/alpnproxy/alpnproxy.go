@@ -0,0 +1,213 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package alpnproxy implements protocol dispatch on a single shared TLS
+// listener using the ALPN extension (RFC 7301) of the TLS ClientHello.
+//
+// Every Teleport service that speaks TLS -- the web UI, the SSH proxy, the
+// reverse tunnel, Kubernetes access, database access, and the Auth API --
+// can register the ALPN protocol it wants to be reached by. Router then
+// peeks the negotiated protocol of each incoming connection without
+// completing the TLS handshake itself, and hands the still-encrypted
+// connection to whichever backend registered it. Each backend performs its
+// own TLS handshake exactly as it does today; Router never sees plaintext.
+// This lets an entire cluster be exposed behind a single TCP port and a
+// plain L4 load balancer that only forwards bytes.
+//
+//	router, _ := alpnproxy.New(alpnproxy.Config{Listener: listener})
+//	webListener := router.Add(alpnproxy.ProtocolHTTP, alpnproxy.ProtocolHTTP2)
+//	kubeListener := router.Add(alpnproxy.ProtocolKube)
+//	go router.Serve()
+package alpnproxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/defaults"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	log "github.com/sirupsen/logrus"
+)
+
+// Protocol identifiers negotiated via the ALPN extension of the TLS
+// ClientHello. A backend registers one (or more) of these with Router.Add
+// to receive the connections that negotiated it.
+const (
+	// ProtocolHTTP is negotiated by plain HTTP/1.1 clients, including most
+	// web browsers that don't request h2.
+	ProtocolHTTP = "http/1.1"
+	// ProtocolHTTP2 is negotiated by HTTP/2 clients.
+	ProtocolHTTP2 = "h2"
+	// ProtocolReverseTunnel is negotiated by Teleport proxies dialing the
+	// reverse tunnel of another proxy.
+	ProtocolReverseTunnel = "teleport-reversetunnel"
+	// ProtocolSSH is negotiated by tsh when it dials the proxy's SSH
+	// endpoint over TLS instead of a separate SSH port.
+	ProtocolSSH = "teleport-ssh"
+	// ProtocolKube is negotiated by kubectl talking to Teleport's
+	// Kubernetes access proxy.
+	ProtocolKube = "teleport-kube"
+	// ProtocolDB is negotiated by database clients talking to Teleport's
+	// database access proxy.
+	ProtocolDB = "teleport-db"
+	// ProtocolAuth is negotiated by clients of the Auth API.
+	ProtocolAuth = "teleport-auth"
+)
+
+// Config is a Router configuration.
+type Config struct {
+	// Listener is the raw, TLS-framed listener to demultiplex, for example
+	// the TLS side of a multiplexer.Mux.
+	Listener net.Listener
+	// Context is a context to signal stops and cancellations.
+	Context context.Context
+	// ReadDeadline is the deadline for reading the ClientHello off a new
+	// connection. Set to defaults.ReadHeadersTimeout if unspecified.
+	ReadDeadline time.Duration
+	// Clock is used to control time in tests.
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults verifies the configuration and sets defaults.
+func (c *Config) CheckAndSetDefaults() error {
+	if c.Listener == nil {
+		return trace.BadParameter("missing parameter Listener")
+	}
+	if c.Context == nil {
+		c.Context = context.Background()
+	}
+	if c.ReadDeadline == 0 {
+		c.ReadDeadline = defaults.ReadHeadersTimeout
+	}
+	if c.Clock == nil {
+		c.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// Router demultiplexes the TLS connections accepted on Config.Listener by
+// the ALPN protocols negotiated in their ClientHello.
+type Router struct {
+	*log.Entry
+	Config
+
+	mu        sync.Mutex
+	listeners map[string]*listener
+
+	context context.Context
+	cancel  context.CancelFunc
+}
+
+// New returns a Router that will demultiplex connections accepted on
+// cfg.Listener once Serve is called.
+func New(cfg Config) (*Router, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ctx, cancel := context.WithCancel(cfg.Context)
+	return &Router{
+		Entry: log.WithFields(log.Fields{
+			trace.Component: teleport.Component("alpn"),
+		}),
+		Config:    cfg,
+		listeners: make(map[string]*listener),
+		context:   ctx,
+		cancel:    cancel,
+	}, nil
+}
+
+// Add registers interest in one or more ALPN protocols and returns a
+// listener that receives the still-encrypted connections that negotiated
+// one of them. Registering the empty string ("") handles connections that
+// didn't request ALPN at all, or requested a protocol nothing else
+// registered.
+func (r *Router) Add(protocols ...string) net.Listener {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l := newListener(r.context, r.Listener.Addr())
+	for _, protocol := range protocols {
+		r.listeners[protocol] = l
+	}
+	return l
+}
+
+// Close stops Serve and closes all listeners returned by Add.
+func (r *Router) Close() error {
+	r.cancel()
+	return nil
+}
+
+// Serve accepts connections from Config.Listener, routes each one to the
+// listener registered for its negotiated ALPN protocol, and blocks until
+// the listener is closed.
+func (r *Router) Serve() error {
+	for {
+		conn, err := r.Listener.Accept()
+		if err != nil {
+			select {
+			case <-r.context.Done():
+				return nil
+			default:
+				return trace.Wrap(err)
+			}
+		}
+		go r.handleConn(conn)
+	}
+}
+
+func (r *Router) handleConn(conn net.Conn) {
+	conn.SetReadDeadline(r.Clock.Now().Add(r.ReadDeadline))
+	hello, replay, err := peekClientHello(conn)
+	if err != nil {
+		r.Debugf("Failed to read TLS ClientHello: %v.", err)
+		conn.Close()
+		return
+	}
+	replay.SetReadDeadline(time.Time{})
+
+	dst := r.match(hello)
+	if dst == nil {
+		r.Debugf("No listener registered for ALPN protocols %v (SNI %q), closing connection.",
+			hello.SupportedProtos, hello.ServerName)
+		replay.Close()
+		return
+	}
+	select {
+	case dst.connC <- replay:
+	case <-r.context.Done():
+		replay.Close()
+	}
+}
+
+// match returns the listener registered for the first of hello's
+// negotiated protocols that has one, falling back to the default ("")
+// listener if none matched or the client sent no ALPN protocols at all.
+func (r *Router) match(hello *tls.ClientHelloInfo) *listener {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, protocol := range hello.SupportedProtos {
+		if l, ok := r.listeners[protocol]; ok {
+			return l
+		}
+	}
+	return r.listeners[""]
+}
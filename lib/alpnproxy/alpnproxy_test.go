@@ -0,0 +1,151 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alpnproxy
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/utils"
+
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type RouterSuite struct{}
+
+var _ = check.Suite(&RouterSuite{})
+
+func (s *RouterSuite) SetUpSuite(c *check.C) {
+	utils.InitLoggerForTests()
+}
+
+// TestRouting verifies that connections negotiating different ALPN
+// protocols over the same listener are dispatched to the listener
+// registered for that protocol, while still-encrypted connections are
+// handed off untouched for the backend to terminate TLS itself.
+func (s *RouterSuite) TestRouting(c *check.C) {
+	ports, err := utils.GetFreeTCPPorts(1)
+	c.Assert(err, check.IsNil)
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", ports[0]))
+	c.Assert(err, check.IsNil)
+
+	router, err := New(Config{Listener: listener})
+	c.Assert(err, check.IsNil)
+	go router.Serve()
+	defer router.Close()
+
+	// Default backend: receives connections that didn't ask for a
+	// registered protocol, mirroring today's plain HTTPS web traffic.
+	defaultBackend := &httptest.Server{
+		Listener: router.Add(""),
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "default backend")
+		})},
+	}
+	defaultBackend.StartTLS()
+	defer defaultBackend.Close()
+
+	// A second backend registered under a custom ALPN protocol, standing
+	// in for a non-HTTP protocol like teleport-kube or teleport-db that
+	// terminates its own TLS and speaks its own framing once routed here.
+	creds, err := utils.GenerateSelfSignedCert([]string{"127.0.0.1"})
+	c.Assert(err, check.IsNil)
+	cert, err := tls.X509KeyPair(creds.Cert, creds.PrivateKey)
+	c.Assert(err, check.IsNil)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	c.Assert(err, check.IsNil)
+
+	customListener := router.Add("test-proto")
+	go serveEcho(customListener, &tls.Config{Certificates: []tls.Certificate{cert}})
+
+	// A plain request with no special ALPN protocol should land on the
+	// default backend.
+	defaultClient := testClient(defaultBackend, nil)
+	re, err := defaultClient.Get(defaultBackend.URL)
+	c.Assert(err, check.IsNil)
+	body, err := ioutil.ReadAll(re.Body)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(body), check.Equals, "default backend")
+
+	// A connection negotiating "test-proto" should be routed to the
+	// custom backend instead, even though it dials the exact same
+	// listener address.
+	certpool := x509.NewCertPool()
+	certpool.AddCert(leaf)
+	conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+		RootCAs:    certpool,
+		ServerName: "127.0.0.1",
+		NextProtos: []string{"test-proto"},
+	})
+	c.Assert(err, check.IsNil)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello\n"))
+	c.Assert(err, check.IsNil)
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	c.Assert(err, check.IsNil)
+	c.Assert(reply, check.Equals, "echo: hello\n")
+}
+
+// serveEcho accepts connections off listener, terminates TLS itself with
+// tlsConfig, and echoes back each line it reads. It stands in for a
+// non-HTTP backend protocol routed by Router.
+func serveEcho(listener net.Listener, tlsConfig *tls.Config) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			tlsConn := tls.Server(conn, tlsConfig)
+			defer tlsConn.Close()
+			line, err := bufio.NewReader(tlsConn).ReadString('\n')
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(tlsConn, "echo: %s", line)
+		}()
+	}
+}
+
+func testClient(srv *httptest.Server, alpnProtocols []string) *http.Client {
+	cert, err := x509.ParseCertificate(srv.TLS.Certificates[0].Certificate[0])
+	if err != nil {
+		panic(err)
+	}
+	certpool := x509.NewCertPool()
+	certpool.AddCert(cert)
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:    certpool,
+				ServerName: cert.IPAddresses[0].String(),
+				NextProtos: alpnProtocols,
+			},
+		},
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alpnproxy
+
+import (
+	"context"
+	"net"
+
+	"github.com/gravitational/trace"
+)
+
+// listener is a net.Listener whose Accept is fed by Router.handleConn
+// instead of a socket. It's the per-protocol destination returned by
+// Router.Add.
+type listener struct {
+	addr   net.Addr
+	connC  chan net.Conn
+	cancel context.CancelFunc
+	ctx    context.Context
+}
+
+func newListener(ctx context.Context, addr net.Addr) *listener {
+	ctx, cancel := context.WithCancel(ctx)
+	return &listener{
+		addr:   addr,
+		connC:  make(chan net.Conn),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Accept implements net.Listener.
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.connC:
+		return conn, nil
+	case <-l.ctx.Done():
+		return nil, trace.ConnectionProblem(l.ctx.Err(), "listener closed")
+	}
+}
+
+// Close implements net.Listener.
+func (l *listener) Close() error {
+	l.cancel()
+	return nil
+}
+
+// Addr implements net.Listener.
+func (l *listener) Addr() net.Addr {
+	return l.addr
+}
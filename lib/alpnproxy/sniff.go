@@ -0,0 +1,106 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alpnproxy
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/gravitational/trace"
+)
+
+// errAbortHandshake is returned by the tls.Config.GetConfigForClient
+// callback installed by peekClientHello to stop the handshake the instant
+// the ClientHello has been read, before any bytes are written back to the
+// client and before any certificate is selected. It never escapes
+// peekClientHello as a real error.
+var errAbortHandshake = errors.New("alpnproxy: aborting handshake after ClientHello")
+
+// peekClientHello reads the TLS ClientHello off conn far enough to learn
+// its SNI server name and ALPN protocols, without completing the
+// handshake or writing anything back to the client. It returns the parsed
+// ClientHelloInfo along with a net.Conn that replays the bytes consumed
+// during peeking before falling through to conn -- callers use that
+// connection, not the original, so the still-encrypted handshake can be
+// completed later by whichever backend the connection is routed to.
+func peekClientHello(conn net.Conn) (*tls.ClientHelloInfo, net.Conn, error) {
+	rec := &recordingConn{Conn: conn}
+	var hello *tls.ClientHelloInfo
+	srv := tls.Server(rec, &tls.Config{
+		GetConfigForClient: func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+			// Copy out the fields we need; info is only valid for the
+			// duration of this callback.
+			hello = &tls.ClientHelloInfo{
+				ServerName:      info.ServerName,
+				SupportedProtos: append([]string{}, info.SupportedProtos...),
+			}
+			return nil, errAbortHandshake
+		},
+	})
+	err := srv.Handshake()
+	if hello == nil {
+		if err == nil {
+			err = trace.BadParameter("client did not send a TLS ClientHello")
+		}
+		return nil, nil, trace.Wrap(err)
+	}
+	return hello, &replayConn{Conn: conn, replay: rec.buf.Bytes()}, nil
+}
+
+// recordingConn is a net.Conn that records every byte read off the
+// underlying connection so it can be replayed to the eventual backend.
+type recordingConn struct {
+	net.Conn
+	buf bytes.Buffer
+}
+
+func (r *recordingConn) Read(p []byte) (int, error) {
+	n, err := r.Conn.Read(p)
+	if n > 0 {
+		r.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// Write is never expected to be called: GetConfigForClient aborts the
+// handshake before the server would write anything back. Guard against it
+// anyway so a future Go TLS stack change fails loudly instead of leaking
+// a plaintext write to the client.
+func (r *recordingConn) Write(p []byte) (int, error) {
+	return 0, trace.BadParameter("alpnproxy: unexpected write during ClientHello sniffing")
+}
+
+// replayConn is a net.Conn that first returns the bytes recorded by
+// recordingConn, then falls through to reads on the original connection.
+type replayConn struct {
+	net.Conn
+	replay []byte
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	if len(c.replay) > 0 {
+		n := copy(p, c.replay)
+		c.replay = c.replay[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
+
+var _ io.Reader = (*replayConn)(nil)
@@ -30,7 +30,11 @@ type Announcer interface {
 
 	// UpsertProxy registers proxy presence, permanently if ttl is 0 or
 	// for the specified duration with second resolution if it's >= 1 second
-	UpsertProxy(s services.Server) error
+	UpsertProxy(s services.Server) (*services.KeepAlive, error)
+
+	// KeepAliveProxy extends the TTL of a proxy's presence entry without
+	// resending its full Server resource
+	KeepAliveProxy(ctx context.Context, h services.KeepAlive) error
 
 	// UpsertAuthServer registers auth server presence, permanently if ttl is 0 or
 	// for the specified duration with second resolution if it's >= 1 second
@@ -167,10 +171,15 @@ func (w *Wrapper) NewKeepAliver(ctx context.Context) (services.KeepAliver, error
 }
 
 // UpsertProxy is part of auth.AccessPoint implementation
-func (w *Wrapper) UpsertProxy(s services.Server) error {
+func (w *Wrapper) UpsertProxy(s services.Server) (*services.KeepAlive, error) {
 	return w.Write.UpsertProxy(s)
 }
 
+// KeepAliveProxy is part of auth.AccessPoint implementation
+func (w *Wrapper) KeepAliveProxy(ctx context.Context, h services.KeepAlive) error {
+	return w.Write.KeepAliveProxy(ctx, h)
+}
+
 // UpsertTunnelConnection is a part of auth.AccessPoint implementation
 func (w *Wrapper) UpsertTunnelConnection(conn services.TunnelConnection) error {
 	return w.Write.UpsertTunnelConnection(conn)
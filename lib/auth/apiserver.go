@@ -44,6 +44,9 @@ type APIConfig struct {
 	SessionService session.Service
 	AuditLog       events.IAuditLog
 	Authorizer     Authorizer
+	// AuthzHooks are additional authorization checks run, in order, after a
+	// request has passed RBAC. See AuthzHook for details.
+	AuthzHooks []AuthzHook
 }
 
 // APIServer implements http API server for AuthServer interface
@@ -67,6 +70,7 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	// Operations on certificate authorities
 	srv.GET("/:version/domain", srv.withAuth(srv.getDomainName))
 	srv.GET("/:version/cacert", srv.withAuth(srv.getClusterCACert))
+	srv.GET("/:version/cluster-alerts", srv.withAuth(srv.getClusterAlerts))
 
 	srv.POST("/:version/authorities/:type", srv.withAuth(srv.upsertCertAuthority))
 	srv.POST("/:version/authorities/:type/rotate", srv.withAuth(srv.rotateCertAuthority))
@@ -97,6 +101,8 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	srv.POST("/:version/users/:user/ssh/authenticate", srv.withAuth(srv.authenticateSSHUser))
 	srv.GET("/:version/users/:user/web/sessions/:sid", srv.withAuth(srv.getWebSession))
 	srv.DELETE("/:version/users/:user/web/sessions/:sid", srv.withAuth(srv.deleteWebSession))
+	srv.GET("/:version/users/:user/web/sessions", srv.withAuth(srv.getWebSessions))
+	srv.DELETE("/:version/users/:user/web/sessions", srv.withAuth(srv.deleteAllWebSessions))
 	srv.GET("/:version/signuptokens/:token", srv.withAuth(srv.getSignupTokenData))
 	srv.POST("/:version/signuptokens/users", srv.withAuth(srv.createUserWithToken))
 	srv.POST("/:version/signuptokens", srv.withAuth(srv.createSignupToken))
@@ -111,6 +117,7 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	srv.POST("/:version/authservers", srv.withAuth(srv.upsertAuthServer))
 	srv.GET("/:version/authservers", srv.withAuth(srv.getAuthServers))
 	srv.POST("/:version/proxies", srv.withAuth(srv.upsertProxy))
+	srv.POST("/:version/proxies/keepalive", srv.withAuth(srv.keepAliveProxy))
 	srv.GET("/:version/proxies", srv.withAuth(srv.getProxies))
 	srv.DELETE("/:version/proxies", srv.withAuth(srv.deleteAllProxies))
 	srv.DELETE("/:version/proxies/:name", srv.withAuth(srv.deleteProxy))
@@ -125,11 +132,20 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	srv.POST("/:version/server/credentials", srv.withAuth(srv.generateServerKeys))
 
 	srv.POST("/:version/remoteclusters", srv.withAuth(srv.createRemoteCluster))
+	srv.PUT("/:version/remoteclusters", srv.withAuth(srv.upsertRemoteCluster))
 	srv.GET("/:version/remoteclusters/:cluster", srv.withAuth(srv.getRemoteCluster))
 	srv.GET("/:version/remoteclusters", srv.withAuth(srv.getRemoteClusters))
 	srv.DELETE("/:version/remoteclusters/:cluster", srv.withAuth(srv.deleteRemoteCluster))
 	srv.DELETE("/:version/remoteclusters", srv.withAuth(srv.deleteAllRemoteClusters))
 
+	srv.POST("/:version/pendingoperations", srv.withAuth(srv.createPendingOperation))
+	srv.PUT("/:version/pendingoperations", srv.withAuth(srv.upsertPendingOperation))
+	srv.GET("/:version/pendingoperations/:name", srv.withAuth(srv.getPendingOperation))
+	srv.GET("/:version/pendingoperations", srv.withAuth(srv.getPendingOperations))
+	srv.DELETE("/:version/pendingoperations/:name", srv.withAuth(srv.deletePendingOperation))
+	srv.POST("/:version/pendingoperations/:name/approve", srv.withAuth(srv.approvePendingOperation))
+	srv.POST("/:version/pendingoperations/:name/deny", srv.withAuth(srv.denyPendingOperation))
+
 	// Reverse tunnels
 	srv.POST("/:version/reversetunnels", srv.withAuth(srv.upsertReverseTunnel))
 	srv.GET("/:version/reversetunnels", srv.withAuth(srv.getReverseTunnels))
@@ -255,6 +271,17 @@ func (s *APIServer) withAuth(handler HandlerWithAuthFunc) httprouter.Handle {
 
 			return nil, trace.AccessDenied(accessDeniedMsg + "[00]")
 		}
+		for _, hook := range s.AuthzHooks {
+			if err := hook.CheckAccess(r.Context(), *authContext, r); err != nil {
+				s.AuthServer.EmitAuditEvent(events.AuthzHookFailure, events.EventFields{
+					events.AuthzHookName:   hook.Name(),
+					events.AuthzHookReason: err.Error(),
+					events.EventUser:       authContext.User.GetName(),
+				})
+				log.Warnf("Authorization hook %v denied request: %v.", hook.Name(), err)
+				return nil, trace.AccessDenied(accessDeniedMsg + "[01]")
+			}
+		}
 		auth := &AuthWithRoles{
 			authServer: s.AuthServer,
 			user:       authContext.User,
@@ -317,9 +344,11 @@ func (s *APIServer) upsertServer(auth ClientI, role teleport.Role, w http.Respon
 			return nil, trace.Wrap(err)
 		}
 	case teleport.RoleProxy:
-		if err := auth.UpsertProxy(server); err != nil {
+		handle, err := auth.UpsertProxy(server)
+		if err != nil {
 			return nil, trace.Wrap(err)
 		}
+		return handle, nil
 	}
 	return message("ok"), nil
 }
@@ -336,6 +365,19 @@ func (s *APIServer) keepAliveNode(auth ClientI, w http.ResponseWriter, r *http.R
 	return message("ok"), nil
 }
 
+// keepAliveProxy updates a proxy's TTL in the backend without resending its
+// full Server resource
+func (s *APIServer) keepAliveProxy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var handle services.KeepAlive
+	if err := httplib.ReadJSON(r, &handle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.KeepAliveProxy(r.Context(), handle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
 type upsertNodesReq struct {
 	Nodes     json.RawMessage `json:"nodes"`
 	Namespace string          `json:"namespace"`
@@ -383,6 +425,13 @@ func (s *APIServer) getNodes(auth ClientI, w http.ResponseWriter, r *http.Reques
 	if skipValidation {
 		opts = append(opts, services.SkipValidation())
 	}
+	if rawLabels := r.URL.Query().Get("labels"); rawLabels != "" {
+		var labels map[string]string
+		if err := json.Unmarshal([]byte(rawLabels), &labels); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		opts = append(opts, services.WithLabels(labels))
+	}
 
 	servers, err := auth.GetNodes(namespace, opts...)
 	if err != nil {
@@ -633,6 +682,35 @@ func (s *APIServer) deleteWebSession(auth ClientI, w http.ResponseWriter, r *htt
 	return message(fmt.Sprintf("session '%v' for user '%v' deleted", sid, user)), nil
 }
 
+// getWebSessions lists a user's web sessions, for example to show the
+// devices a user is currently signed in on
+func (s *APIServer) getWebSessions(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	user := p.ByName("user")
+	sessions, err := auth.GetWebSessions(user)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]json.RawMessage, len(sessions))
+	for i, sess := range sessions {
+		raw, err := services.GetWebSessionMarshaler().MarshalWebSession(sess, services.WithVersion(version))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out[i] = json.RawMessage(raw)
+	}
+	return out, nil
+}
+
+// deleteAllWebSessions logs a user out of every device at once by revoking
+// all of their web sessions
+func (s *APIServer) deleteAllWebSessions(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	user := p.ByName("user")
+	if err := auth.DeleteAllWebSessions(user); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("all sessions for user '%v' deleted", user)), nil
+}
+
 // sessionV1 is a V1 style web session, used in legacy v1 API
 type sessionV1 struct {
 	// ID is a session ID
@@ -1081,6 +1159,15 @@ func (s *APIServer) getClusterCACert(auth ClientI, w http.ResponseWriter, r *htt
 	return localCA, nil
 }
 
+// getClusterAlerts returns the set of cluster alerts currently active
+func (s *APIServer) getClusterAlerts(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	alerts, err := auth.GetClusterAlerts()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return alerts, nil
+}
+
 // getU2FAppID returns the U2F AppID in the auth configuration
 func (s *APIServer) getU2FAppID(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	cap, err := auth.GetAuthPreference()
@@ -1580,11 +1667,12 @@ type createGithubConnectorRawReq struct {
 	Connector json.RawMessage `json:"connector"`
 }
 
-/* createGithubConnector creates a new Github connector
+/*
+createGithubConnector creates a new Github connector
 
-   POST /:version/github/connectors
+	POST /:version/github/connectors
 
-   Success response: {"message": "ok"}
+	Success response: {"message": "ok"}
 */
 func (s *APIServer) createGithubConnector(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	var req createGithubConnectorRawReq
@@ -1607,11 +1695,12 @@ type upsertGithubConnectorRawReq struct {
 	Connector json.RawMessage `json:"connector"`
 }
 
-/* upsertGithubConnector creates or updates a Github connector
+/*
+upsertGithubConnector creates or updates a Github connector
 
-   PUT /:version/github/connectors
+	PUT /:version/github/connectors
 
-   Success response: {"message": "ok"}
+	Success response: {"message": "ok"}
 */
 func (s *APIServer) upsertGithubConnector(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	var req upsertGithubConnectorRawReq
@@ -1628,11 +1717,12 @@ func (s *APIServer) upsertGithubConnector(auth ClientI, w http.ResponseWriter, r
 	return message("ok"), nil
 }
 
-/* getGithubConnectors returns a list of all configured Github connectors
+/*
+getGithubConnectors returns a list of all configured Github connectors
 
-   GET /:version/github/connectors
+	GET /:version/github/connectors
 
-   Success response: []services.GithubConnector
+	Success response: []services.GithubConnector
 */
 func (s *APIServer) getGithubConnectors(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	withSecrets, _, err := httplib.ParseBool(r.URL.Query(), "with_secrets")
@@ -1654,11 +1744,12 @@ func (s *APIServer) getGithubConnectors(auth ClientI, w http.ResponseWriter, r *
 	return items, nil
 }
 
-/* getGithubConnector returns the specified Github connector
+/*
+getGithubConnector returns the specified Github connector
 
-   GET /:version/github/connectors/:id
+	GET /:version/github/connectors/:id
 
-   Success response: services.GithubConnector
+	Success response: services.GithubConnector
 */
 func (s *APIServer) getGithubConnector(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	withSecrets, _, err := httplib.ParseBool(r.URL.Query(), "with_secrets")
@@ -1672,11 +1763,12 @@ func (s *APIServer) getGithubConnector(auth ClientI, w http.ResponseWriter, r *h
 	return rawMessage(services.GetGithubConnectorMarshaler().Marshal(connector, services.PreserveResourceID()))
 }
 
-/* deleteGithubConnector deletes the specified Github connector
+/*
+deleteGithubConnector deletes the specified Github connector
 
-   DELETE /:version/github/connectors/:id
+	DELETE /:version/github/connectors/:id
 
-   Success response: {"message": "ok"}
+	Success response: {"message": "ok"}
 */
 func (s *APIServer) deleteGithubConnector(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	if err := auth.DeleteGithubConnector(p.ByName("id")); err != nil {
@@ -1691,11 +1783,12 @@ type createGithubAuthRequestReq struct {
 	Req services.GithubAuthRequest `json:"req"`
 }
 
-/* createGithubAuthRequest creates a new request for Github OAuth2 flow
+/*
+createGithubAuthRequest creates a new request for Github OAuth2 flow
 
-   POST /:version/github/requests/create
+	POST /:version/github/requests/create
 
-   Success response: services.GithubAuthRequest
+	Success response: services.GithubAuthRequest
 */
 func (s *APIServer) createGithubAuthRequest(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	var req createGithubAuthRequestReq
@@ -1735,11 +1828,12 @@ type githubAuthRawResponse struct {
 	HostSigners []json.RawMessage `json:"host_signers"`
 }
 
-/* validateGithubAuthRequest validates Github auth callback redirect
+/*
+validateGithubAuthRequest validates Github auth callback redirect
 
-   POST /:version/github/requests/validate
+	POST /:version/github/requests/validate
 
-   Success response: githubAuthRawResponse
+	Success response: githubAuthRawResponse
 */
 func (s *APIServer) validateGithubAuthCallback(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	var req validateGithubAuthCallbackReq
@@ -1780,9 +1874,10 @@ func (s *APIServer) validateGithubAuthCallback(auth ClientI, w http.ResponseWrit
 // HTTP GET /:version/events?query
 //
 // Query fields:
-//	'from'  : time filter in RFC3339 format
-//	'to'    : time filter in RFC3339 format
-//  ...     : other fields are passed directly to the audit backend
+//
+//		'from'  : time filter in RFC3339 format
+//		'to'    : time filter in RFC3339 format
+//	 ...     : other fields are passed directly to the audit backend
 func (s *APIServer) searchEvents(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	var err error
 	to := time.Now().In(time.UTC)
@@ -1946,8 +2041,9 @@ func (s *APIServer) uploadSessionRecording(auth ClientI, w http.ResponseWriter,
 
 // HTTP GET /:version/sessions/:id/stream?offset=x&bytes=y
 // Query parameters:
-//   "offset"   : bytes from the beginning
-//   "bytes"    : number of bytes to read (it won't return more than 512Kb)
+//
+//	"offset"   : bytes from the beginning
+//	"bytes"    : number of bytes to read (it won't return more than 512Kb)
 func (s *APIServer) getSessionChunk(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	sid, err := session.ParseID(p.ByName("id"))
 	if err != nil {
@@ -1984,7 +2080,8 @@ func (s *APIServer) getSessionChunk(auth ClientI, w http.ResponseWriter, r *http
 
 // HTTP GET /:version/sessions/:id/events?maxage=n
 // Query:
-//    'after' : cursor value to return events newer than N. Defaults to 0, (return all)
+//
+//	'after' : cursor value to return events newer than N. Defaults to 0, (return all)
 func (s *APIServer) getSessionEvents(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	sid, err := session.ParseID(p.ByName("id"))
 	if err != nil {
@@ -2353,6 +2450,28 @@ func (s *APIServer) createRemoteCluster(auth ClientI, w http.ResponseWriter, r *
 	return message("ok"), nil
 }
 
+type upsertRemoteClusterRawReq struct {
+	// RemoteCluster is marshalled remote cluster resource
+	RemoteCluster json.RawMessage `json:"remote_cluster"`
+}
+
+// upsertRemoteCluster creates or updates a remote cluster, e.g. to toggle
+// its enabled state
+func (s *APIServer) upsertRemoteCluster(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req upsertRemoteClusterRawReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	conn, err := services.UnmarshalRemoteCluster(req.RemoteCluster)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.UpsertRemoteCluster(conn); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
 // getRemoteClusters returns a list of remote clusters
 func (s *APIServer) getRemoteClusters(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	clusters, err := auth.GetRemoteClusters()
@@ -2397,6 +2516,101 @@ func (s *APIServer) deleteAllRemoteClusters(auth ClientI, w http.ResponseWriter,
 	return message("ok"), nil
 }
 
+type createPendingOperationRawReq struct {
+	// PendingOperation is marshalled pending operation resource
+	PendingOperation json.RawMessage `json:"pending_operation"`
+}
+
+// createPendingOperation creates a pending operation
+func (s *APIServer) createPendingOperation(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req createPendingOperationRawReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	op, err := services.UnmarshalPendingOperation(req.PendingOperation)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.CreatePendingOperation(op); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type upsertPendingOperationRawReq struct {
+	// PendingOperation is marshalled pending operation resource
+	PendingOperation json.RawMessage `json:"pending_operation"`
+}
+
+// upsertPendingOperation creates or updates a pending operation
+func (s *APIServer) upsertPendingOperation(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req upsertPendingOperationRawReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	op, err := services.UnmarshalPendingOperation(req.PendingOperation)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.UpsertPendingOperation(op); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+// getPendingOperations returns a list of pending operations
+func (s *APIServer) getPendingOperations(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	ops, err := auth.GetPendingOperations()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	items := make([]json.RawMessage, len(ops))
+	for i, op := range ops {
+		data, err := services.MarshalPendingOperation(op, services.WithVersion(version), services.PreserveResourceID())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		items[i] = data
+	}
+	return items, nil
+}
+
+// getPendingOperation returns a pending operation by name
+func (s *APIServer) getPendingOperation(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	op, err := auth.GetPendingOperation(p.ByName("name"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rawMessage(services.MarshalPendingOperation(op, services.WithVersion(version), services.PreserveResourceID()))
+}
+
+// deletePendingOperation deletes a pending operation by name
+func (s *APIServer) deletePendingOperation(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	err := auth.DeletePendingOperation(p.ByName("name"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+// approvePendingOperation approves a pending operation, applying it.
+func (s *APIServer) approvePendingOperation(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	err := auth.ApprovePendingOperation(p.ByName("name"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+// denyPendingOperation denies a pending operation. It is never applied.
+func (s *APIServer) denyPendingOperation(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	err := auth.DenyPendingOperation(p.ByName("name"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
 func (s *APIServer) processKubeCSR(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	var req KubeCSR
 
@@ -38,6 +38,7 @@ import (
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/inventory"
 	"github.com/gravitational/teleport/lib/limiter"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/services/local"
@@ -50,6 +51,7 @@ import (
 	"github.com/coreos/go-oidc/oidc"
 	"github.com/gravitational/trace"
 	"github.com/jonboulle/clockwork"
+	"github.com/pborman/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	saml2 "github.com/russellhaering/gosaml2"
 	"github.com/tstranex/u2f"
@@ -103,6 +105,8 @@ func NewAuthServer(cfg *InitConfig, opts ...AuthServerOption) (*AuthServer, erro
 		githubClients:   make(map[string]*githubClient),
 		cancelFunc:      cancelFunc,
 		closeCtx:        closeCtx,
+		Inventory:       inventory.NewController(),
+		electionID:      uuid.New(),
 		AuthServices: AuthServices{
 			Trust:                cfg.Trust,
 			Presence:             cfg.Presence,
@@ -206,6 +210,15 @@ type AuthServer struct {
 	cache AuthCache
 
 	limiter *limiter.ConnectionsLimiter
+
+	// Inventory tracks the set of agents currently checked in with this
+	// auth server via heartbeats
+	Inventory *inventory.Controller
+
+	// electionID identifies this auth server instance in the leader
+	// election used to decide which instance runs exclusive background
+	// jobs, see isLeader.
+	electionID string
 }
 
 // SetCache sets cache used by auth server
@@ -226,7 +239,9 @@ func (a *AuthServer) GetCache() AuthCache {
 }
 
 // runPeriodicOperations runs some periodic bookkeeping operations
-// performed by auth server
+// performed by auth server. Ticks where this instance does not hold the
+// leader lease (see isLeader) are skipped, so the work only happens on one
+// auth server instance at a time no matter how many are running.
 func (a *AuthServer) runPeriodicOperations() {
 	// run periodic functions with a semi-random period
 	// to avoid contention on the database in case if there are multiple
@@ -242,6 +257,9 @@ func (a *AuthServer) runPeriodicOperations() {
 		case <-a.closeCtx.Done():
 			return
 		case <-ticker.C:
+			if !a.isLeader(a.closeCtx) {
+				continue
+			}
 			err := a.autoRotateCertAuthorities()
 			if err != nil {
 				if trace.IsCompareFailed(err) {
@@ -681,8 +699,15 @@ func (s *AuthServer) CheckU2FSignResponse(user string, response *u2f.SignRespons
 	return nil
 }
 
-// ExtendWebSession creates a new web session for a user based on a valid previous sessionID,
-// method is used to renew the web session for a user
+// ExtendWebSession creates a new web session for a user based on a valid
+// previous sessionID, method is used to renew the web session for a user.
+// Renewal slides the session's expiry forward by a fresh session TTL, but
+// never past an absolute maximum fixed at login time (prevSession's
+// LoginTime plus the user's role's MaxSessionTTL), so a session that's kept
+// alive purely by repeated activity still expires no later than that
+// ceiling. The ceiling is fixed at login and does not move on renewal,
+// unlike prevSession's own Expires field, which is what's being slid
+// forward here and so can't be used as the ceiling itself.
 func (s *AuthServer) ExtendWebSession(user string, prevSessionID string) (services.WebSession, error) {
 	prevSession, err := s.GetWebSession(user, prevSessionID)
 	if err != nil {
@@ -692,16 +717,38 @@ func (s *AuthServer) ExtendWebSession(user string, prevSessionID string) (servic
 	// consider absolute expiry time that may be set for this session
 	// by some external identity serivce, so we can not renew this session
 	// any more without extra logic for renewal with external OIDC provider
-	expiresAt := prevSession.GetExpiryTime()
-	if !expiresAt.IsZero() && expiresAt.Before(s.clock.Now().UTC()) {
+	maxExpiry := prevSession.GetExpiryTime()
+	if !maxExpiry.IsZero() && maxExpiry.Before(s.clock.Now().UTC()) {
 		return nil, trace.NotFound("web session has expired")
 	}
 
+	// recompute the absolute ceiling from the fixed LoginTime and the
+	// user's current role's MaxSessionTTL, rather than from prevSession's
+	// own Expires, which already holds the last renewal's slid-forward
+	// value and so can't be reused as a ceiling without collapsing every
+	// renewal back to the original expiry.
+	if loginTime := prevSession.GetLoginTime(); !loginTime.IsZero() {
+		userResource, err := s.GetUser(user)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		roles, err := services.FetchRoles(userResource.GetRoles(), s.Access, userResource.GetTraits())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		maxExpiry = loginTime.Add(roles.AdjustSessionTTL(defaults.MaxCertDuration))
+	}
+
 	sess, err := s.NewWebSession(user)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	expiresAt := sess.GetExpiryTime()
+	if !maxExpiry.IsZero() && expiresAt.After(maxExpiry) {
+		expiresAt = maxExpiry
+	}
 	sess.SetExpiryTime(expiresAt)
+	sess.SetLoginTime(prevSession.GetLoginTime())
 	bearerTokenTTL := utils.MinTTL(utils.ToTTL(s.clock, expiresAt), BearerTokenTTL)
 	sess.SetBearerTokenExpiryTime(s.clock.Now().UTC().Add(bearerTokenTTL))
 	if err := s.UpsertWebSession(user, sess); err != nil {
@@ -1231,6 +1278,7 @@ func (s *AuthServer) NewWebSession(username string) (services.WebSession, error)
 		Expires:            s.clock.Now().UTC().Add(sessionTTL),
 		BearerToken:        bearerToken,
 		BearerTokenExpires: s.clock.Now().UTC().Add(bearerTokenTTL),
+		LoginTime:          s.clock.Now().UTC(),
 	}), nil
 }
 
@@ -1268,6 +1316,28 @@ func (s *AuthServer) DeleteWebSession(user string, id string) error {
 	return trace.Wrap(s.Identity.DeleteWebSession(user, id))
 }
 
+// GetWebSessions returns all of a user's web sessions, with secrets
+// stripped, for example to list the devices a user is currently signed in
+// on
+func (s *AuthServer) GetWebSessions(user string) ([]services.WebSession, error) {
+	sessions, err := s.Identity.GetWebSessions(user)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]services.WebSession, len(sessions))
+	for i, sess := range sessions {
+		out[i] = sess.WithoutSecrets()
+	}
+	return out, nil
+}
+
+// DeleteAllWebSessions logs a user out of every device at once by revoking
+// all of their web sessions, and with them the bearer tokens bundled
+// inside each one
+func (s *AuthServer) DeleteAllWebSessions(user string) error {
+	return trace.Wrap(s.Identity.DeleteAllWebSessions(user))
+}
+
 // NewWatcher returns a new event watcher. In case of an auth server
 // this watcher will return events as seen by the auth server's
 // in memory cache, not the backend.
@@ -1359,9 +1429,29 @@ func (a *AuthServer) GetNamespaces() ([]services.Namespace, error) {
 	return a.GetCache().GetNamespaces()
 }
 
-// GetNodes is a part of auth.AccessPoint implementation
+// GetNodes is a part of auth.AccessPoint implementation. If opts carry
+// services.WithLabels, only servers matching all of the given labels are
+// returned, so a predicate/label query filters the inventory on the auth
+// server rather than shipping every node to the caller.
 func (a *AuthServer) GetNodes(namespace string, opts ...services.MarshalOption) ([]services.Server, error) {
-	return a.GetCache().GetNodes(namespace, opts...)
+	servers, err := a.GetCache().GetNodes(namespace, opts...)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	cfg, err := services.CollectOptions(opts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(cfg.Labels) == 0 {
+		return servers, nil
+	}
+	filtered := make([]services.Server, 0, len(servers))
+	for _, server := range servers {
+		if server.MatchAgainst(cfg.Labels) {
+			filtered = append(filtered, server)
+		}
+	}
+	return filtered, nil
 }
 
 // GetReverseTunnels is a part of auth.AccessPoint implementation
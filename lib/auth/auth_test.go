@@ -32,6 +32,7 @@ import (
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/fixtures"
 	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/services/local"
 	"github.com/gravitational/teleport/lib/services/suite"
 	"github.com/gravitational/teleport/lib/utils"
 
@@ -144,6 +145,68 @@ func (s *AuthSuite) TestSessions(c *C) {
 	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%#v", err))
 }
 
+// TestExtendWebSessionSlides verifies that renewing a web session actually
+// slides its expiry forward with each renewal, up to but never past the
+// absolute ceiling fixed at login (LoginTime + the user's role's
+// MaxSessionTTL).
+func (s *AuthSuite) TestExtendWebSessionSlides(c *C) {
+	c.Assert(s.a.UpsertCertAuthority(
+		suite.NewTestCA(services.UserCA, "me.localhost")), IsNil)
+	c.Assert(s.a.UpsertCertAuthority(
+		suite.NewTestCA(services.HostCA, "me.localhost")), IsNil)
+
+	user := "sliding-user"
+	pass := []byte("abc123")
+	_, role, err := CreateUserAndRole(s.a, user, []string{user})
+	c.Assert(err, IsNil)
+
+	// give the role a MaxSessionTTL well above defaults.CertDuration so
+	// that a session's expiry isn't immediately clamped to the ceiling on
+	// its very first renewal.
+	options := role.GetOptions()
+	options.MaxSessionTTL = services.NewDuration(24 * time.Hour)
+	role.SetOptions(options)
+	c.Assert(s.a.UpsertRole(role), IsNil)
+
+	c.Assert(s.a.UpsertPassword(user, pass), IsNil)
+
+	clock := clockwork.NewFakeClock()
+	s.a.SetClock(clock)
+
+	ws, err := s.a.AuthenticateWebUser(AuthenticateUserRequest{
+		Username: user,
+		Pass:     &PassCreds{Password: pass},
+	})
+	c.Assert(err, IsNil)
+	loginExpiry := ws.GetExpiryTime()
+	ceiling := ws.GetLoginTime().Add(24 * time.Hour)
+
+	// advance time and renew: the expiry should slide forward past its
+	// original value, not stay pinned to it.
+	clock.Advance(time.Hour)
+	ws1, err := s.a.ExtendWebSession(user, ws.GetName())
+	c.Assert(err, IsNil)
+	c.Assert(ws1.GetExpiryTime().After(loginExpiry), Equals, true,
+		Commentf("renewal should slide expiry past the original login expiry"))
+	c.Assert(ws1.GetExpiryTime().After(ceiling), Equals, false,
+		Commentf("renewal should never slide expiry past the absolute ceiling"))
+
+	// renew again after more activity: expiry should keep sliding forward.
+	clock.Advance(time.Hour)
+	ws2, err := s.a.ExtendWebSession(user, ws1.GetName())
+	c.Assert(err, IsNil)
+	c.Assert(ws2.GetExpiryTime().After(ws1.GetExpiryTime()), Equals, true,
+		Commentf("a second renewal should slide expiry further forward"))
+
+	// once activity stops for long enough that a fresh session TTL would
+	// land past the ceiling, renewal should clamp to the ceiling exactly.
+	clock.Advance(23 * time.Hour)
+	ws3, err := s.a.ExtendWebSession(user, ws2.GetName())
+	c.Assert(err, IsNil)
+	c.Assert(ws3.GetExpiryTime().Equal(ceiling), Equals, true,
+		Commentf("renewal should clamp to the absolute ceiling once a fresh TTL would exceed it"))
+}
+
 func (s *AuthSuite) TestUserLock(c *C) {
 	c.Assert(s.a.UpsertCertAuthority(
 		suite.NewTestCA(services.UserCA, "me.localhost")), IsNil)
@@ -656,3 +719,38 @@ func (s *AuthSuite) TestMigrateRoleRules(c *C) {
 			tc.ExpectedAllowRules, Commentf(tc.Comment))
 	}
 }
+
+// TestMigrateRoleVersions ensures that a role stored at an old schema
+// version gets re-saved at the current one, and that a role already stored
+// at the current version is left alone.
+func (s *AuthSuite) TestMigrateRoleVersions(c *C) {
+	legacy := services.RoleV2{
+		Kind:    services.KindRole,
+		Version: services.V2,
+		Metadata: services.Metadata{
+			Name:      "legacy-role",
+			Namespace: defaults.Namespace,
+		},
+		Spec: services.RoleSpecV2{
+			MaxSessionTTL: services.NewDuration(20 * time.Hour),
+		},
+	}
+	value, err := json.Marshal(legacy)
+	c.Assert(err, IsNil)
+	_, err = s.bk.Put(context.TODO(), backend.Item{
+		Key:   backend.Key("roles", legacy.Metadata.Name, "params"),
+		Value: value,
+	})
+	c.Assert(err, IsNil)
+
+	current, err := services.NewRole("current-role", services.RoleSpecV3{})
+	c.Assert(err, IsNil)
+	c.Assert(s.a.UpsertRole(current), IsNil)
+
+	c.Assert(migrateRoleVersions(s.a), IsNil)
+
+	versions, err := local.NewAccessService(s.bk).GetRoleVersions()
+	c.Assert(err, IsNil)
+	c.Assert(versions["legacy-role"], Equals, services.V3)
+	c.Assert(versions["current-role"], Equals, services.V3)
+}
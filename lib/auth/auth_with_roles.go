@@ -24,6 +24,7 @@ import (
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/inventory"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/utils"
@@ -66,8 +67,16 @@ func (a *AuthWithRoles) currentUserAction(username string) error {
 // If not, it checks if the requester has the meta KindAuthConnector access
 // (which grants access to all connectors).
 func (a *AuthWithRoles) authConnectorAction(namespace string, resource string, verb string) error {
-	if err := a.checker.CheckAccessToRule(&services.Context{User: a.user}, namespace, resource, verb, false); err != nil {
-		if err := a.checker.CheckAccessToRule(&services.Context{User: a.user}, namespace, services.KindAuthConnector, verb, false); err != nil {
+	return a.authConnectorActionWithContext(&services.Context{User: a.user}, namespace, resource, verb)
+}
+
+// authConnectorActionWithContext is authConnectorAction, but lets the caller
+// supply a context carrying the connector being accessed, so a "where"
+// clause on the role can restrict access by the connector's labels, e.g. to
+// delegate administration of a subset of connectors to a team.
+func (a *AuthWithRoles) authConnectorActionWithContext(ctx *services.Context, namespace string, resource string, verb string) error {
+	if err := a.checker.CheckAccessToRule(ctx, namespace, resource, verb, false); err != nil {
+		if err := a.checker.CheckAccessToRule(ctx, namespace, services.KindAuthConnector, verb, false); err != nil {
 			return trace.Wrap(err)
 		}
 	}
@@ -362,6 +371,10 @@ func (a *AuthWithRoles) KeepAliveNode(ctx context.Context, handle services.KeepA
 	if err := a.action(defaults.Namespace, services.KindNode, services.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
+	a.authServer.Inventory.RegisterHeartbeat(inventory.Hello{
+		ServerID: serverName,
+		Services: []string{string(teleport.RoleNode)},
+	})
 	return a.authServer.KeepAliveNode(ctx, handle)
 }
 
@@ -552,14 +565,39 @@ func (a *AuthWithRoles) GetAuthServers() ([]services.Server, error) {
 	return a.authServer.GetAuthServers()
 }
 
-func (a *AuthWithRoles) UpsertProxy(s services.Server) error {
+func (a *AuthWithRoles) UpsertProxy(s services.Server) (*services.KeepAlive, error) {
 	if err := a.action(defaults.Namespace, services.KindProxy, services.VerbCreate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindProxy, services.VerbUpdate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.UpsertProxy(s)
+}
+
+func (a *AuthWithRoles) KeepAliveProxy(ctx context.Context, handle services.KeepAlive) error {
+	if !a.hasBuiltinRole(string(teleport.RoleProxy)) {
+		return trace.AccessDenied("[10] access denied")
+	}
+	clusterName, err := a.GetDomainName()
+	if err != nil {
 		return trace.Wrap(err)
 	}
+	serverName, err := ExtractHostID(a.user.GetName(), clusterName)
+	if err != nil {
+		return trace.AccessDenied("[10] access denied")
+	}
+	if serverName != handle.ServerName {
+		return trace.AccessDenied("[10] access denied")
+	}
 	if err := a.action(defaults.Namespace, services.KindProxy, services.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
-	return a.authServer.UpsertProxy(s)
+	a.authServer.Inventory.RegisterHeartbeat(inventory.Hello{
+		ServerID: serverName,
+		Services: []string{string(teleport.RoleProxy)},
+	})
+	return a.authServer.KeepAliveProxy(ctx, handle)
 }
 
 func (a *AuthWithRoles) GetProxies() ([]services.Server, error) {
@@ -623,7 +661,11 @@ func (a *AuthWithRoles) DeleteReverseTunnel(domainName string) error {
 }
 
 func (a *AuthWithRoles) DeleteToken(token string) error {
-	if err := a.action(defaults.Namespace, services.KindToken, services.VerbDelete); err != nil {
+	ctx := &services.Context{User: a.user}
+	if t, err := a.authServer.GetToken(token); err == nil {
+		ctx.Resource = t
+	}
+	if err := a.actionWithContext(ctx, defaults.Namespace, services.KindToken, services.VerbDelete); err != nil {
 		return trace.Wrap(err)
 	}
 	return a.authServer.DeleteToken(token)
@@ -647,10 +689,11 @@ func (a *AuthWithRoles) GetToken(token string) (services.ProvisionToken, error)
 }
 
 func (a *AuthWithRoles) UpsertToken(token services.ProvisionToken) error {
-	if err := a.action(defaults.Namespace, services.KindToken, services.VerbCreate); err != nil {
+	ctx := &services.Context{User: a.user, Resource: token}
+	if err := a.actionWithContext(ctx, defaults.Namespace, services.KindToken, services.VerbCreate); err != nil {
 		return trace.Wrap(err)
 	}
-	if err := a.action(defaults.Namespace, services.KindToken, services.VerbUpdate); err != nil {
+	if err := a.actionWithContext(ctx, defaults.Namespace, services.KindToken, services.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
 	return a.authServer.UpsertToken(token)
@@ -732,6 +775,20 @@ func (a *AuthWithRoles) DeleteWebSession(user string, sid string) error {
 	return a.authServer.DeleteWebSession(user, sid)
 }
 
+func (a *AuthWithRoles) GetWebSessions(user string) ([]services.WebSession, error) {
+	if err := a.currentUserAction(user); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetWebSessions(user)
+}
+
+func (a *AuthWithRoles) DeleteAllWebSessions(user string) error {
+	if err := a.currentUserAction(user); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteAllWebSessions(user)
+}
+
 func (a *AuthWithRoles) GetUsers() ([]services.User, error) {
 	if err := a.action(defaults.Namespace, services.KindUser, services.VerbList); err != nil {
 		return nil, trace.Wrap(err)
@@ -867,10 +924,11 @@ func (a *AuthWithRoles) UpsertUser(u services.User) error {
 }
 
 func (a *AuthWithRoles) UpsertOIDCConnector(connector services.OIDCConnector) error {
-	if err := a.authConnectorAction(defaults.Namespace, services.KindOIDC, services.VerbCreate); err != nil {
+	ctx := &services.Context{User: a.user, Resource: connector}
+	if err := a.authConnectorActionWithContext(ctx, defaults.Namespace, services.KindOIDC, services.VerbCreate); err != nil {
 		return trace.Wrap(err)
 	}
-	if err := a.authConnectorAction(defaults.Namespace, services.KindOIDC, services.VerbUpdate); err != nil {
+	if err := a.authConnectorActionWithContext(ctx, defaults.Namespace, services.KindOIDC, services.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
 	return a.authServer.UpsertOIDCConnector(connector)
@@ -916,7 +974,11 @@ func (a *AuthWithRoles) ValidateOIDCAuthCallback(q url.Values) (*OIDCAuthRespons
 }
 
 func (a *AuthWithRoles) DeleteOIDCConnector(connectorID string) error {
-	if err := a.authConnectorAction(defaults.Namespace, services.KindOIDC, services.VerbDelete); err != nil {
+	ctx := &services.Context{User: a.user}
+	if connector, err := a.authServer.Identity.GetOIDCConnector(connectorID, false); err == nil {
+		ctx.Resource = connector
+	}
+	if err := a.authConnectorActionWithContext(ctx, defaults.Namespace, services.KindOIDC, services.VerbDelete); err != nil {
 		return trace.Wrap(err)
 	}
 	return a.authServer.DeleteOIDCConnector(connectorID)
@@ -930,10 +992,11 @@ func (a *AuthWithRoles) CreateSAMLConnector(connector services.SAMLConnector) er
 }
 
 func (a *AuthWithRoles) UpsertSAMLConnector(connector services.SAMLConnector) error {
-	if err := a.authConnectorAction(defaults.Namespace, services.KindSAML, services.VerbCreate); err != nil {
+	ctx := &services.Context{User: a.user, Resource: connector}
+	if err := a.authConnectorActionWithContext(ctx, defaults.Namespace, services.KindSAML, services.VerbCreate); err != nil {
 		return trace.Wrap(err)
 	}
-	if err := a.authConnectorAction(defaults.Namespace, services.KindSAML, services.VerbUpdate); err != nil {
+	if err := a.authConnectorActionWithContext(ctx, defaults.Namespace, services.KindSAML, services.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
 	return a.authServer.UpsertSAMLConnector(connector)
@@ -979,7 +1042,11 @@ func (a *AuthWithRoles) ValidateSAMLResponse(re string) (*SAMLAuthResponse, erro
 }
 
 func (a *AuthWithRoles) DeleteSAMLConnector(connectorID string) error {
-	if err := a.authConnectorAction(defaults.Namespace, services.KindSAML, services.VerbDelete); err != nil {
+	ctx := &services.Context{User: a.user}
+	if connector, err := a.authServer.Identity.GetSAMLConnector(connectorID, false); err == nil {
+		ctx.Resource = connector
+	}
+	if err := a.authConnectorActionWithContext(ctx, defaults.Namespace, services.KindSAML, services.VerbDelete); err != nil {
 		return trace.Wrap(err)
 	}
 	return a.authServer.DeleteSAMLConnector(connectorID)
@@ -1165,10 +1232,11 @@ func (a *AuthWithRoles) CreateRole(role services.Role) error {
 
 // UpsertRole creates or updates role
 func (a *AuthWithRoles) UpsertRole(role services.Role) error {
-	if err := a.action(defaults.Namespace, services.KindRole, services.VerbCreate); err != nil {
+	ctx := &services.Context{User: a.user, Resource: role}
+	if err := a.actionWithContext(ctx, defaults.Namespace, services.KindRole, services.VerbCreate); err != nil {
 		return trace.Wrap(err)
 	}
-	if err := a.action(defaults.Namespace, services.KindRole, services.VerbUpdate); err != nil {
+	if err := a.actionWithContext(ctx, defaults.Namespace, services.KindRole, services.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
 	return a.authServer.UpsertRole(role)
@@ -1188,7 +1256,11 @@ func (a *AuthWithRoles) GetRole(name string) (services.Role, error) {
 
 // DeleteRole deletes role by name
 func (a *AuthWithRoles) DeleteRole(name string) error {
-	if err := a.action(defaults.Namespace, services.KindRole, services.VerbDelete); err != nil {
+	ctx := &services.Context{User: a.user}
+	if role, err := a.authServer.GetRole(name); err == nil {
+		ctx.Resource = role
+	}
+	if err := a.actionWithContext(ctx, defaults.Namespace, services.KindRole, services.VerbDelete); err != nil {
 		return trace.Wrap(err)
 	}
 	return a.authServer.DeleteRole(name)
@@ -1245,6 +1317,14 @@ func (a *AuthWithRoles) GetClusterName(opts ...services.MarshalOption) (services
 	return a.authServer.GetClusterName()
 }
 
+// GetClusterAlerts returns the set of cluster alerts currently active.
+func (a *AuthWithRoles) GetClusterAlerts() ([]services.ClusterAlert, error) {
+	if err := a.action(defaults.Namespace, services.KindClusterAlert, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetClusterAlerts()
+}
+
 // SetClusterName sets the name of the cluster. SetClusterName can only be called once.
 func (a *AuthWithRoles) SetClusterName(c services.ClusterName) error {
 	if err := a.action(defaults.Namespace, services.KindClusterName, services.VerbCreate); err != nil {
@@ -1436,6 +1516,16 @@ func (a *AuthWithRoles) CreateRemoteCluster(conn services.RemoteCluster) error {
 	return a.authServer.CreateRemoteCluster(conn)
 }
 
+func (a *AuthWithRoles) UpsertRemoteCluster(conn services.RemoteCluster) error {
+	if err := a.action(defaults.Namespace, services.KindRemoteCluster, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindRemoteCluster, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.UpsertRemoteCluster(conn)
+}
+
 func (a *AuthWithRoles) GetRemoteCluster(clusterName string) (services.RemoteCluster, error) {
 	if err := a.action(defaults.Namespace, services.KindRemoteCluster, services.VerbRead); err != nil {
 		return nil, trace.Wrap(err)
@@ -1467,6 +1557,104 @@ func (a *AuthWithRoles) DeleteAllRemoteClusters() error {
 	return a.authServer.DeleteAllRemoteClusters()
 }
 
+func (a *AuthWithRoles) CreatePendingOperation(op services.PendingOperation) error {
+	if err := a.action(defaults.Namespace, services.KindPendingOperation, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.checkPendingOperationAction(op); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.CreatePendingOperation(op)
+}
+
+func (a *AuthWithRoles) UpsertPendingOperation(op services.PendingOperation) error {
+	if err := a.action(defaults.Namespace, services.KindPendingOperation, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindPendingOperation, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.checkPendingOperationAction(op); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.UpsertPendingOperation(op)
+}
+
+// checkPendingOperationAction checks that the caller has RBAC access to the
+// actual destructive action op describes (role delete, trusted cluster
+// delete, or CA rotation), not just the pending_operation verbs that gate
+// participating in the two-person-rule workflow. Without this, a user
+// granted only pending_operation verbs could create or approve an
+// operation that deletes a role or trusted cluster, or rotates a CA, that
+// they have no RBAC access to at all -- applyPendingOperation runs on the
+// raw AuthServer and so never re-checks this on its own.
+func (a *AuthWithRoles) checkPendingOperationAction(op services.PendingOperation) error {
+	switch op.GetAction() {
+	case services.PendingOperationDeleteRole:
+		ctx := &services.Context{User: a.user}
+		if role, err := a.authServer.GetRole(op.GetTarget()); err == nil {
+			ctx.Resource = role
+		}
+		return trace.Wrap(a.actionWithContext(ctx, defaults.Namespace, services.KindRole, services.VerbDelete))
+	case services.PendingOperationDeleteTrustedCluster:
+		return trace.Wrap(a.action(defaults.Namespace, services.KindTrustedCluster, services.VerbDelete))
+	case services.PendingOperationRotateCertAuthority:
+		if err := a.action(defaults.Namespace, services.KindCertAuthority, services.VerbCreate); err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(a.action(defaults.Namespace, services.KindCertAuthority, services.VerbUpdate))
+	default:
+		return trace.BadParameter("unsupported pending operation action %q", op.GetAction())
+	}
+}
+
+func (a *AuthWithRoles) GetPendingOperations(opts ...services.MarshalOption) ([]services.PendingOperation, error) {
+	if err := a.action(defaults.Namespace, services.KindPendingOperation, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetPendingOperations(opts...)
+}
+
+func (a *AuthWithRoles) GetPendingOperation(name string) (services.PendingOperation, error) {
+	if err := a.action(defaults.Namespace, services.KindPendingOperation, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetPendingOperation(name)
+}
+
+func (a *AuthWithRoles) DeletePendingOperation(name string) error {
+	if err := a.action(defaults.Namespace, services.KindPendingOperation, services.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeletePendingOperation(name)
+}
+
+// ApprovePendingOperation enforces the two-person rule (the caller must not
+// be the administrator who requested the operation) and, once satisfied,
+// applies the underlying action.
+func (a *AuthWithRoles) ApprovePendingOperation(name string) error {
+	if err := a.action(defaults.Namespace, services.KindPendingOperation, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	op, err := a.authServer.GetPendingOperation(name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.checkPendingOperationAction(op); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.ApprovePendingOperation(name, a.user.GetName())
+}
+
+// DenyPendingOperation enforces the two-person rule and marks the operation
+// denied; it is never applied.
+func (a *AuthWithRoles) DenyPendingOperation(name string) error {
+	if err := a.action(defaults.Namespace, services.KindPendingOperation, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DenyPendingOperation(name, a.user.GetName())
+}
+
 // ProcessKubeCSR processes CSR request against Kubernetes CA, returns
 // signed certificate if sucessful.
 func (a *AuthWithRoles) ProcessKubeCSR(req KubeCSR) (*KubeCSRResponse, error) {
@@ -0,0 +1,45 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gravitational/trace"
+
+	. "gopkg.in/check.v1"
+)
+
+type AuthzHooksSuite struct{}
+
+var _ = Suite(&AuthzHooksSuite{})
+
+func (s *AuthzHooksSuite) TestAuthzHookFunc(c *C) {
+	var called bool
+	hook := NewAuthzHookFunc("my-hook", func(ctx context.Context, authContext AuthContext, r *http.Request) error {
+		called = true
+		return trace.AccessDenied("denied by policy")
+	})
+
+	c.Assert(hook.Name(), Equals, "my-hook")
+
+	err := hook.CheckAccess(context.Background(), AuthContext{}, httptest.NewRequest(http.MethodGet, "/", nil))
+	c.Assert(called, Equals, true)
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+}
@@ -44,6 +44,7 @@ import (
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/teleport/lib/utils/proxy"
 
 	empty "github.com/golang/protobuf/ptypes/empty"
 	"github.com/gravitational/roundtrip"
@@ -53,6 +54,7 @@ import (
 	"github.com/tstranex/u2f"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 )
 
 const (
@@ -121,15 +123,15 @@ func DecodeClusterName(serverName string) (string, error) {
 
 // NewAddrDialer returns new dialer from a list of addresses
 func NewAddrDialer(addrs []utils.NetAddr) DialContext {
-	dialer := net.Dialer{
-		Timeout:   defaults.DefaultDialTimeout,
-		KeepAlive: defaults.ReverseTunnelAgentHeartbeatPeriod,
-	}
 	return func(in context.Context, network, _ string) (net.Conn, error) {
 		var err error
 		var conn net.Conn
 		for _, addr := range addrs {
-			conn, err = dialer.DialContext(in, network, addr.Addr)
+			// if http_proxy/https_proxy is set, dial the auth server through
+			// the proxy so node registration and API clients work from
+			// networks that only permit egress through a corporate proxy.
+			dialer := proxy.DialerFromEnvironment(addr.Addr)
+			conn, err = dialer.DialTimeout(network, addr.Addr, defaults.DefaultDialTimeout)
 			if err == nil {
 				return conn, nil
 			}
@@ -223,7 +225,7 @@ func NewClient(addr string, dialer Dialer, params ...roundtrip.ClientParam) (*Cl
 		dialer = net.Dial
 	}
 	transport := &http.Transport{
-		Dial: dialer,
+		Dial:                  dialer,
 		ResponseHeaderTimeout: defaults.DefaultDialTimeout,
 	}
 	params = append(params,
@@ -255,7 +257,18 @@ func (c *Client) setClosed() {
 	atomic.StoreInt32(&c.closedFlag, 1)
 }
 
-// grpc returns grpc client
+// grpc returns grpc client, dialing and caching the underlying connection on
+// first use. The dialed connection is kept alive and reused (multiplexed)
+// for every subsequent gRPC call and stream from this Client, rather than
+// paying connection setup cost per request, and the per-dial timeout passed
+// in by grpc-go is propagated as a deadline on the underlying network dial
+// instead of being silently dropped.
+//
+// Most of the remaining HTTP/JSON endpoints below (GetNodes, GetUsers, and
+// the other large listing calls) are not migrated to this gRPC service: that
+// would require new streaming RPCs and messages added to auth.proto and
+// auth.pb.go regenerated with protoc, which isn't available in this
+// environment.
 func (c *Client) grpc() (proto.AuthServiceClient, error) {
 	// it's ok to lock here, because Dial below is not locking
 	c.Lock()
@@ -268,7 +281,9 @@ func (c *Client) grpc() (proto.AuthServiceClient, error) {
 		if c.isClosed() {
 			return nil, trace.ConnectionProblem(nil, "client is closed")
 		}
-		c, err := c.dialContext(context.TODO(), "tcp", addr)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		c, err := c.dialContext(ctx, "tcp", addr)
 		if err != nil {
 			log.Debugf("Dial to addr %v failed: %v.", addr, err)
 		}
@@ -278,7 +293,15 @@ func (c *Client) grpc() (proto.AuthServiceClient, error) {
 	tlsConfig.NextProtos = []string{http2.NextProtoTLS}
 	conn, err := grpc.Dial(teleport.APIDomain,
 		dialer,
-		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		// keepalive pings let a single dialed connection be reused (multiplexed)
+		// across many requests and streams instead of redialing per call, and
+		// detect a dead auth server without waiting on a TCP-level timeout.
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                defaults.ServerKeepAliveTTL,
+			Timeout:             defaults.DefaultDialTimeout,
+			PermitWithoutStream: true,
+		}))
 	if err != nil {
 		return nil, trail.FromGRPC(err)
 	}
@@ -429,6 +452,27 @@ func (c *Client) GetClusterCACert() (*LocalCAResponse, error) {
 	return &localCA, nil
 }
 
+// GetClusterAlerts returns the set of cluster alerts currently active
+func (c *Client) GetClusterAlerts() ([]services.ClusterAlert, error) {
+	out, err := c.Get(c.Endpoint("cluster-alerts"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	alerts := make([]services.ClusterAlert, 0, len(items))
+	for _, item := range items {
+		var alert services.ClusterAlertV1
+		if err := json.Unmarshal(item, &alert); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		alerts = append(alerts, &alert)
+	}
+	return alerts, nil
+}
+
 func (c *Client) Close() error {
 	c.Lock()
 	defer c.Unlock()
@@ -911,9 +955,18 @@ func (c *Client) GetNodes(namespace string, opts ...services.MarshalOption) ([]s
 		return nil, trace.Wrap(err)
 	}
 
-	out, err := c.Get(c.Endpoint("namespaces", namespace, "nodes"), url.Values{
+	query := url.Values{
 		"skip_validation": []string{fmt.Sprintf("%t", cfg.SkipValidation)},
-	})
+	}
+	if len(cfg.Labels) > 0 {
+		rawLabels, err := json.Marshal(cfg.Labels)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		query.Set("labels", string(rawLabels))
+	}
+
+	out, err := c.Get(c.Endpoint("namespaces", namespace, "nodes"), query)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -1150,6 +1203,107 @@ func (c *Client) CreateRemoteCluster(rc services.RemoteCluster) error {
 	return trace.Wrap(err)
 }
 
+// UpsertRemoteCluster creates or updates a remote cluster, e.g. to toggle
+// its enabled state
+func (c *Client) UpsertRemoteCluster(rc services.RemoteCluster) error {
+	data, err := services.MarshalRemoteCluster(rc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	args := &upsertRemoteClusterRawReq{
+		RemoteCluster: data,
+	}
+	_, err = c.PutJSON(c.Endpoint("remoteclusters"), args)
+	return trace.Wrap(err)
+}
+
+// CreatePendingOperation creates a pending operation awaiting a second
+// administrator's approval under the two-person rule.
+func (c *Client) CreatePendingOperation(op services.PendingOperation) error {
+	data, err := services.MarshalPendingOperation(op)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	args := &createPendingOperationRawReq{
+		PendingOperation: data,
+	}
+	_, err = c.PostJSON(c.Endpoint("pendingoperations"), args)
+	return trace.Wrap(err)
+}
+
+// UpsertPendingOperation creates or updates a pending operation
+func (c *Client) UpsertPendingOperation(op services.PendingOperation) error {
+	data, err := services.MarshalPendingOperation(op)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	args := &upsertPendingOperationRawReq{
+		PendingOperation: data,
+	}
+	_, err = c.PutJSON(c.Endpoint("pendingoperations"), args)
+	return trace.Wrap(err)
+}
+
+// GetPendingOperations returns a list of pending operations
+func (c *Client) GetPendingOperations(opts ...services.MarshalOption) ([]services.PendingOperation, error) {
+	out, err := c.Get(c.Endpoint("pendingoperations"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ops := make([]services.PendingOperation, len(items))
+	for i, raw := range items {
+		op, err := services.UnmarshalPendingOperation(raw, services.SkipValidation())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		ops[i] = op
+	}
+	return ops, nil
+}
+
+// GetPendingOperation returns a pending operation by name
+func (c *Client) GetPendingOperation(name string) (services.PendingOperation, error) {
+	if name == "" {
+		return nil, trace.BadParameter("missing parameter name")
+	}
+	out, err := c.Get(c.Endpoint("pendingoperations", name), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return services.UnmarshalPendingOperation(out.Bytes(), services.SkipValidation())
+}
+
+// DeletePendingOperation deletes a pending operation by name
+func (c *Client) DeletePendingOperation(name string) error {
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	_, err := c.Delete(c.Endpoint("pendingoperations", name))
+	return trace.Wrap(err)
+}
+
+// ApprovePendingOperation approves a pending operation, applying it.
+func (c *Client) ApprovePendingOperation(name string) error {
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	_, err := c.PostJSON(c.Endpoint("pendingoperations", name, "approve"), struct{}{})
+	return trace.Wrap(err)
+}
+
+// DenyPendingOperation denies a pending operation. It is never applied.
+func (c *Client) DenyPendingOperation(name string) error {
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	_, err := c.PostJSON(c.Endpoint("pendingoperations", name, "deny"), struct{}{})
+	return trace.Wrap(err)
+}
+
 // UpsertAuthServer is used by auth servers to report their presence
 // to other auth servers in form of hearbeat expiring after ttl period.
 func (c *Client) UpsertAuthServer(s services.Server) error {
@@ -1187,15 +1341,29 @@ func (c *Client) GetAuthServers() ([]services.Server, error) {
 
 // UpsertProxy is used by proxies to report their presence
 // to other auth servers in form of hearbeat expiring after ttl period.
-func (c *Client) UpsertProxy(s services.Server) error {
+func (c *Client) UpsertProxy(s services.Server) (*services.KeepAlive, error) {
 	data, err := services.GetServerMarshaler().MarshalServer(s)
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, trace.Wrap(err)
 	}
 	args := &upsertServerRawReq{
 		Server: data,
 	}
-	_, err = c.PostJSON(c.Endpoint("proxies"), args)
+	out, err := c.PostJSON(c.Endpoint("proxies"), args)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var keepAlive services.KeepAlive
+	if err := json.Unmarshal(out.Bytes(), &keepAlive); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &keepAlive, nil
+}
+
+// KeepAliveProxy extends the TTL of a proxy's presence entry without
+// resending its full Server resource
+func (c *Client) KeepAliveProxy(ctx context.Context, keepAlive services.KeepAlive) error {
+	_, err := c.PostJSON(c.Endpoint("proxies", "keepalive"), keepAlive)
 	return trace.Wrap(err)
 }
 
@@ -1381,6 +1549,35 @@ func (c *Client) GetWebSessionInfo(user string, sid string) (services.WebSession
 	return services.GetWebSessionMarshaler().UnmarshalWebSession(out.Bytes())
 }
 
+// GetWebSessions returns all web sessions for a user, for example to list
+// the devices a user is currently signed in on
+func (c *Client) GetWebSessions(user string) ([]services.WebSession, error) {
+	out, err := c.Get(c.Endpoint("users", user, "web", "sessions"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sessions := make([]services.WebSession, len(items))
+	for i, item := range items {
+		session, err := services.GetWebSessionMarshaler().UnmarshalWebSession(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		sessions[i] = session
+	}
+	return sessions, nil
+}
+
+// DeleteAllWebSessions logs a user out of every device at once by revoking
+// all of their web sessions
+func (c *Client) DeleteAllWebSessions(user string) error {
+	_, err := c.Delete(c.Endpoint("users", user, "web", "sessions"))
+	return trace.Wrap(err)
+}
+
 // DeleteWebSession deletes a web session for this user by id
 func (c *Client) DeleteWebSession(user string, sid string) error {
 	_, err := c.Delete(c.Endpoint("users", user, "web", "sessions", sid))
@@ -1445,7 +1642,7 @@ func (c *Client) GenerateKeyPair(pass string) ([]byte, []byte, error) {
 	return kp.PrivKey, []byte(kp.PubKey), err
 }
 
-// GenerateHostCert takes the public key in the Open SSH ``authorized_keys``
+// GenerateHostCert takes the public key in the Open SSH “authorized_keys“
 // plain text format, signs it using Host Certificate Authority private key and returns the
 // resulting certificate.
 func (c *Client) GenerateHostCert(
@@ -2454,6 +2651,12 @@ type WebService interface {
 	CreateWebSession(user string) (services.WebSession, error)
 	// DeleteWebSession deletes a web session for this user by id
 	DeleteWebSession(user string, sid string) error
+	// GetWebSessions returns all web sessions for a user, for example to
+	// list the devices a user is currently signed in on
+	GetWebSessions(user string) ([]services.WebSession, error)
+	// DeleteAllWebSessions logs a user out of every device at once by
+	// revoking all of their web sessions
+	DeleteAllWebSessions(user string) error
 }
 
 // IdentityService manages identities and users
@@ -2649,6 +2852,9 @@ type ClientI interface {
 	// GetClusterCACert returns the CAs for the local cluster without signing keys.
 	GetClusterCACert() (*LocalCAResponse, error)
 
+	// GetClusterAlerts returns the set of cluster alerts currently active
+	GetClusterAlerts() ([]services.ClusterAlert, error)
+
 	// GenerateServerKeys generates new host private keys and certificates (signed
 	// by the host certificate authority) for a node
 	GenerateServerKeys(GenerateServerKeysRequest) (*PackedKeys, error)
@@ -2662,4 +2868,13 @@ type ClientI interface {
 	// ProcessKubeCSR processes CSR request against Kubernetes CA, returns
 	// signed certificate if sucessful.
 	ProcessKubeCSR(req KubeCSR) (*KubeCSRResponse, error)
+
+	// ApprovePendingOperation approves a pending operation created under the
+	// two-person rule, and applies it. It returns an access denied error if
+	// the caller created the operation themselves.
+	ApprovePendingOperation(name string) error
+
+	// DenyPendingOperation denies a pending operation created under the
+	// two-person rule. The operation is never applied.
+	DenyPendingOperation(name string) error
 }
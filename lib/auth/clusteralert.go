@@ -0,0 +1,74 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// GetClusterAlerts returns the set of cluster alerts currently active,
+// computed on the fly from cluster state rather than stored — a CA
+// rotation in progress, agents connected with a version other than this
+// auth server's, and so on.
+//
+// Alerts are not yet dismissible per user: that requires a new backend
+// collection keyed by user and alert name to track acknowledgements,
+// which is a reasonable follow-up but out of scope here.
+func (a *AuthServer) GetClusterAlerts() ([]services.ClusterAlert, error) {
+	var alerts []services.ClusterAlert
+
+	cas, err := a.GetCertAuthorities(services.HostCA, false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, ca := range cas {
+		rotation := ca.GetRotation()
+		if rotation.State == services.RotationStateInProgress {
+			alert, err := services.NewClusterAlert(
+				fmt.Sprintf("ca-rotation-%v", ca.GetClusterName()),
+				services.AlertSeverityMedium,
+				fmt.Sprintf("host CA rotation for cluster %q is in progress (phase: %v)", ca.GetClusterName(), rotation.Phase),
+			)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			alerts = append(alerts, alert)
+		}
+	}
+
+	for _, handle := range a.Inventory.Connected() {
+		if handle.Hello.Version != "" && handle.Hello.Version != teleport.Version {
+			alert, err := services.NewClusterAlert(
+				fmt.Sprintf("agent-version-%v", handle.Hello.ServerID),
+				services.AlertSeverityLow,
+				fmt.Sprintf("agent %q is running version %v, this auth server is running %v",
+					handle.Hello.ServerID, handle.Hello.Version, teleport.Version),
+			)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return alerts, nil
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/services/local"
 	"github.com/gravitational/teleport/lib/sshca"
 	"github.com/gravitational/teleport/lib/sshutils"
 	"github.com/gravitational/teleport/lib/tlsca"
@@ -413,6 +414,41 @@ func migrateLegacyResources(cfg InitConfig, asrv *AuthServer) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	err = migrateRoleVersions(asrv)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// migrateRoleVersions re-saves any role that isn't stored at the current
+// role schema version, so that future releases can evolve role semantics
+// (e.g. a new RoleConditions field) by registering an up-conversion with
+// services.RegisterRoleVersion and rely on this to flush every stored role
+// through it exactly once, rather than requiring callers to detect and
+// convert stale versions on every read.
+//
+// GetRoles always returns roles up-converted to the current version, so
+// checking role.GetVersion() here would never find a stale one; the raw
+// stored version has to come from GetRoleVersions instead.
+func migrateRoleVersions(asrv *AuthServer) error {
+	versions, err := local.NewAccessService(asrv.bk).GetRoleVersions()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roles, err := asrv.GetRoles()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, role := range roles {
+		if versions[role.GetName()] == services.V3 {
+			continue
+		}
+		log.Infof("Migrating role %q from version %q to %q.", role.GetName(), versions[role.GetName()], services.V3)
+		if err := asrv.UpsertRole(role); err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+
+	"github.com/gravitational/trace"
+)
+
+// leaderLeaseTTL is how long a leader's lease is valid for without being
+// renewed. It is a multiple of the periodic operations tick so a leader
+// that is still running comfortably renews its lease well before it expires.
+var leaderLeaseTTL = 6 * defaults.HighResPollingPeriod
+
+// leaderKey is the backend key that holds the current leader's electionID.
+var leaderKey = backend.Key("authservers", "leader")
+
+// isLeader returns true if this auth server instance currently holds the
+// leader lease, claiming or renewing it as necessary.
+//
+// Any number of auth server instances can serve API traffic at once, but
+// some background jobs (for example certificate authority rotation) should
+// only run on one instance at a time to avoid redundant work and backend
+// contention as the cluster scales out. Those jobs should call isLeader at
+// the top of their tick and skip the tick when it returns false, the same
+// way runPeriodicOperations does.
+//
+// Leadership is a lease, not a durable role: it is held in the backend as a
+// single key with a TTL, claimed with Create when absent or expired, and
+// renewed with CompareAndSwap by whichever instance currently holds it. If
+// the leader stops renewing (it crashed, or lost connectivity to the
+// backend), the lease simply expires and another instance claims it on its
+// next tick; there is no separate failover step.
+func (a *AuthServer) isLeader(ctx context.Context) bool {
+	expires := a.clock.Now().Add(leaderLeaseTTL)
+	lease := backend.Item{
+		Key:     leaderKey,
+		Value:   []byte(a.electionID),
+		Expires: expires,
+	}
+
+	current, err := a.bk.Get(ctx, leaderKey)
+	if trace.IsNotFound(err) {
+		_, err = a.bk.Create(ctx, lease)
+		if err != nil && !trace.IsAlreadyExists(err) {
+			log.Warningf("Leader election: failed to claim lease: %v.", err)
+		}
+		return err == nil
+	}
+	if err != nil {
+		log.Warningf("Leader election: failed to read lease: %v.", err)
+		return false
+	}
+
+	if string(current.Value) != a.electionID && a.clock.Now().Before(current.Expires) {
+		// another instance is the leader and its lease has not expired yet
+		return false
+	}
+
+	// either we are the current leader renewing our own lease, or the
+	// previous leader's lease has expired and we are claiming it
+	_, err = a.bk.CompareAndSwap(ctx, *current, lease)
+	if err != nil && !trace.IsCompareFailed(err) {
+		log.Warningf("Leader election: failed to renew or claim lease: %v.", err)
+	}
+	return err == nil
+}
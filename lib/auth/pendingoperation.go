@@ -0,0 +1,85 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"encoding/json"
+
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// ApprovePendingOperation enforces the two-person rule: approvedBy must be
+// an administrator other than the one who requested the operation. Once
+// that holds, the pending operation is applied and marked approved.
+func (a *AuthServer) ApprovePendingOperation(name string, approvedBy string) error {
+	op, err := a.GetPendingOperation(name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if op.GetStatus() != services.PendingOperationPending {
+		return trace.BadParameter("pending operation %q is already %v", name, op.GetStatus())
+	}
+	if op.GetRequestedBy() == approvedBy {
+		return trace.AccessDenied("%v requested this operation and cannot approve it; a different administrator must approve it", approvedBy)
+	}
+	if err := a.applyPendingOperation(op); err != nil {
+		return trace.Wrap(err)
+	}
+	op.SetStatus(services.PendingOperationApproved)
+	op.SetApprovedBy(approvedBy)
+	return trace.Wrap(a.UpsertPendingOperation(op))
+}
+
+// DenyPendingOperation enforces the two-person rule and marks the operation
+// denied without applying it.
+func (a *AuthServer) DenyPendingOperation(name string, deniedBy string) error {
+	op, err := a.GetPendingOperation(name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if op.GetStatus() != services.PendingOperationPending {
+		return trace.BadParameter("pending operation %q is already %v", name, op.GetStatus())
+	}
+	if op.GetRequestedBy() == deniedBy {
+		return trace.AccessDenied("%v requested this operation and cannot deny it; a different administrator must review it", deniedBy)
+	}
+	op.SetStatus(services.PendingOperationDenied)
+	op.SetApprovedBy(deniedBy)
+	return trace.Wrap(a.UpsertPendingOperation(op))
+}
+
+// applyPendingOperation performs the destructive action a pending operation
+// describes. Adding a new two-person-rule-gated action means registering it
+// here and in services.pendingOperationActions.
+func (a *AuthServer) applyPendingOperation(op services.PendingOperation) error {
+	switch op.GetAction() {
+	case services.PendingOperationDeleteRole:
+		return trace.Wrap(a.DeleteRole(op.GetTarget()))
+	case services.PendingOperationDeleteTrustedCluster:
+		return trace.Wrap(a.DeleteTrustedCluster(op.GetTarget()))
+	case services.PendingOperationRotateCertAuthority:
+		var req RotateRequest
+		if err := json.Unmarshal([]byte(op.GetParams()), &req); err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(a.RotateCertAuthority(req))
+	default:
+		return trace.BadParameter("unsupported pending operation action %q", op.GetAction())
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+// PendingOperationRBACSuite checks that the two-person-rule pending
+// operation workflow still enforces RBAC on the destructive action a
+// pending operation describes, not just the pending_operation verbs that
+// gate participating in the review itself.
+type PendingOperationRBACSuite struct {
+	server *TestTLSServer
+}
+
+var _ = Suite(&PendingOperationRBACSuite{})
+
+func (s *PendingOperationRBACSuite) SetUpTest(c *C) {
+	authServer, err := NewTestAuthServer(TestAuthServerConfig{
+		ClusterName: "localhost",
+		Dir:         c.MkDir(),
+	})
+	c.Assert(err, IsNil)
+	s.server, err = authServer.NewTestTLSServer()
+	c.Assert(err, IsNil)
+}
+
+func (s *PendingOperationRBACSuite) TearDownTest(c *C) {
+	c.Assert(s.server.Close(), IsNil)
+}
+
+// newReviewer creates a user whose only grant is the pending_operation
+// verbs, the "second reviewer" role this feature was designed for -- it
+// should never be enough, on its own, to create or approve an operation
+// against a resource the user has no RBAC access to.
+func (s *PendingOperationRBACSuite) newReviewer(c *C, name string) *Client {
+	role, err := services.NewRole(name+"-role", services.RoleSpecV3{
+		Allow: services.RoleConditions{
+			Namespaces: []string{defaults.Namespace},
+			Rules: []services.Rule{
+				services.NewRule(services.KindPendingOperation, services.RW()),
+			},
+		},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(s.server.Auth().UpsertRole(role), IsNil)
+
+	user, err := services.NewUser(name)
+	c.Assert(err, IsNil)
+	user.AddRole(role.GetName())
+	c.Assert(s.server.Auth().UpsertUser(user), IsNil)
+
+	clt, err := s.server.NewClient(TestIdentity{I: LocalUser{Username: name}})
+	c.Assert(err, IsNil)
+	return clt
+}
+
+func (s *PendingOperationRBACSuite) newDeleteRoleOp(c *C, requestedBy, target string) services.PendingOperation {
+	op, err := services.NewPendingOperation(
+		"delete-"+target, services.PendingOperationDeleteRole, target, "", requestedBy)
+	c.Assert(err, IsNil)
+	return op
+}
+
+func (s *PendingOperationRBACSuite) TestCreateRequiresUnderlyingRBAC(c *C) {
+	_, _, err := CreateUserAndRole(s.server.Auth(), "doomed-role", []string{"doomed-role"})
+	c.Assert(err, IsNil)
+
+	reviewer := s.newReviewer(c, "requester")
+	op := s.newDeleteRoleOp(c, "requester", "doomed-role")
+
+	err = reviewer.CreatePendingOperation(op)
+	c.Assert(err, NotNil)
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%#v", err))
+}
+
+func (s *PendingOperationRBACSuite) TestApproveRequiresUnderlyingRBAC(c *C) {
+	_, _, err := CreateUserAndRole(s.server.Auth(), "doomed-role", []string{"doomed-role"})
+	c.Assert(err, IsNil)
+
+	op := s.newDeleteRoleOp(c, "requester", "doomed-role")
+	c.Assert(s.server.Auth().CreatePendingOperation(op), IsNil)
+
+	approver := s.newReviewer(c, "approver")
+	err = approver.ApprovePendingOperation(op.GetName())
+	c.Assert(err, NotNil)
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%#v", err))
+
+	// the role must still exist: approval must not have applied the
+	// operation before the RBAC check failed.
+	_, err = s.server.Auth().GetRole("doomed-role")
+	c.Assert(err, IsNil)
+}
+
+func (s *PendingOperationRBACSuite) TestApproveSucceedsWithUnderlyingRBAC(c *C) {
+	_, _, err := CreateUserAndRole(s.server.Auth(), "doomed-role", []string{"doomed-role"})
+	c.Assert(err, IsNil)
+
+	op := s.newDeleteRoleOp(c, "requester", "doomed-role")
+	c.Assert(s.server.Auth().CreatePendingOperation(op), IsNil)
+
+	admin, err := s.server.NewClient(TestBuiltin(teleport.RoleAdmin))
+	c.Assert(err, IsNil)
+	c.Assert(admin.ApprovePendingOperation(op.GetName()), IsNil)
+
+	_, err = s.server.Auth().GetRole("doomed-role")
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%#v", err))
+}
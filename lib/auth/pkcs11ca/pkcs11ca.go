@@ -0,0 +1,81 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pkcs11ca is an extension point for an sshca.Authority backed by
+// CA private keys held on a PKCS#11 token (an HSM or cloud KMS exposing a
+// PKCS#11 interface) rather than in Teleport's process memory. Because
+// AuthServer only ever talks to its CA through the sshca.Authority
+// interface (see lib/sshca), a real implementation could be swapped in for
+// the software-backed lib/auth/native.Keygen without changing any other
+// auth server code.
+//
+// No cryptoki bindings are vendored in this tree yet, so New is the only
+// thing here: it validates a Config and then always returns
+// trace.NotImplemented, rather than silently falling back to in-memory
+// keys.
+package pkcs11ca
+
+import (
+	"github.com/gravitational/teleport/lib/sshca"
+
+	"github.com/gravitational/trace"
+)
+
+// Config configures access to a PKCS#11 token used to hold CA private keys.
+type Config struct {
+	// Path is the filesystem path to the PKCS#11 module (cryptoki library)
+	// to load, for example "/usr/lib/softhsm/libsofthsm2.so".
+	Path string
+
+	// TokenLabel identifies the token on the HSM that holds, or will hold,
+	// the CA keys.
+	TokenLabel string
+
+	// SlotNumber identifies the token by slot number instead of label, if
+	// set.
+	SlotNumber *int
+
+	// Pin is the PIN used to authenticate to the token.
+	Pin string
+}
+
+// CheckAndSetDefaults validates the PKCS#11 configuration.
+func (c *Config) CheckAndSetDefaults() error {
+	if c.Path == "" {
+		return trace.BadParameter("pkcs11: module_path is required")
+	}
+	if c.TokenLabel == "" && c.SlotNumber == nil {
+		return trace.BadParameter("pkcs11: either token_label or slot_number is required")
+	}
+	return nil
+}
+
+// New returns an sshca.Authority that generates and signs with CA keys held
+// on the PKCS#11 token described by cfg.
+//
+// This build of Teleport was compiled without PKCS#11 support: no cryptoki
+// bindings are vendored in this tree. New always fails with a clear error
+// rather than silently falling back to in-memory keys, so that a cluster
+// administrator who configured an HSM never ends up with CA keys they
+// believed were hardware-backed stored in plain PEM instead.
+func New(cfg Config) (sshca.Authority, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return nil, trace.NotImplemented(
+		"this build of Teleport was compiled without PKCS#11 support; " +
+			"HSM-backed CA keys require a build with cryptoki bindings and a configured PKCS#11 module")
+}
@@ -276,18 +276,33 @@ func (a *AuthServer) addCertAuthorities(trustedCluster services.TrustedCluster,
 			}
 			remoteCertAuthority.SetRoleMap(trustedCluster.GetRoleMap())
 		}
+	}
 
-		// we use create here instead of upsert to prevent people from wiping out
-		// their own ca if it has the same name as the remote ca
-		err := a.CreateCertAuthority(remoteCertAuthority)
-		if err != nil {
+	// remoteCAs usually contains both a host and a user CA. Create them in a
+	// single backend transaction where possible, so a crash partway through
+	// does not leave the trust relationship half-established. We use create
+	// here instead of upsert to prevent people from wiping out their own ca
+	// if it has the same name as the remote ca.
+	if batchCreator, ok := a.Trust.(certAuthorityBatchCreator); ok {
+		return trace.Wrap(batchCreator.CreateCertAuthorities(remoteCAs...))
+	}
+	for _, remoteCertAuthority := range remoteCAs {
+		if err := a.CreateCertAuthority(remoteCertAuthority); err != nil {
 			return trace.Wrap(err)
 		}
 	}
-
 	return nil
 }
 
+// certAuthorityBatchCreator is implemented by services.Trust
+// implementations that can create several certificate authorities
+// atomically, such as *local.CA. Not part of the services.Trust interface
+// itself, since not every implementation (e.g. the gRPC client) can
+// support it.
+type certAuthorityBatchCreator interface {
+	CreateCertAuthorities(cas ...services.CertAuthority) error
+}
+
 // DeleteRemoteCluster deletes remote cluster resource, all certificate authorities
 // associated with it
 func (a *AuthServer) DeleteRemoteCluster(clusterName string) error {
@@ -0,0 +1,92 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webauthn is a scaffold for the WebAuthn second factor ceremonies
+// (registration and authentication) that are meant to eventually replace
+// the U2F-only implementation in lib/auth. It is not wired up yet: this
+// version of Teleport has no vendored WebAuthn relying-party library and no
+// libfido2 bindings for tsh, so the functions below report
+// trace.NotImplemented rather than attempt a partial protocol
+// implementation. U2F (see lib/auth/auth.go's U2FSignRequest/
+// CheckU2FSignResponse and lib/auth/new_web_user.go's
+// CreateSignupU2FRegisterRequest/CreateUserWithU2FToken) remains the only
+// supported second factor hardware key mechanism.
+//
+// The shapes below mirror the U2F ceremony functions they're meant to
+// replace, so that wiring in a real implementation later is a matter of
+// filling in these bodies and switching services.AuthPreference's
+// SecondFactor over to a "webauthn" value, without changing call sites in
+// lib/auth or lib/web.
+package webauthn
+
+import (
+	"github.com/gravitational/trace"
+)
+
+// RegisterChallenge is the options a browser or libfido2-enabled tsh would
+// pass to navigator.credentials.create()/fido2_cred_make() to register a
+// new authenticator, analogous to u2f.RegisterRequest.
+type RegisterChallenge struct {
+	// User is the Teleport user registering an authenticator.
+	User string
+}
+
+// RegisterResponse is the attestation a browser or tsh would produce in
+// response to a RegisterChallenge, analogous to u2f.RegisterResponse.
+type RegisterResponse struct {
+	// User is the Teleport user that completed registration.
+	User string
+}
+
+// LoginChallenge is the options passed to
+// navigator.credentials.get()/fido2_cred_assert() to authenticate with an
+// already-registered authenticator, analogous to u2f.SignRequest.
+type LoginChallenge struct {
+	// User is the Teleport user attempting to authenticate.
+	User string
+}
+
+// LoginResponse is the assertion produced in response to a LoginChallenge,
+// analogous to u2f.SignResponse.
+type LoginResponse struct {
+	// User is the Teleport user that completed authentication.
+	User string
+}
+
+// BeginRegistration starts a WebAuthn registration ceremony for user,
+// returning the challenge a browser or tsh would use to create a new
+// authenticator.
+func BeginRegistration(user string) (*RegisterChallenge, error) {
+	return nil, trace.NotImplemented("WebAuthn is not supported in this Teleport version; use U2F instead")
+}
+
+// FinishRegistration validates a RegisterResponse against the challenge
+// issued by BeginRegistration and persists the new authenticator.
+func FinishRegistration(user string, resp *RegisterResponse) error {
+	return trace.NotImplemented("WebAuthn is not supported in this Teleport version; use U2F instead")
+}
+
+// BeginLogin starts a WebAuthn authentication ceremony for user, returning
+// the challenge a browser or tsh would use to produce an assertion.
+func BeginLogin(user string) (*LoginChallenge, error) {
+	return nil, trace.NotImplemented("WebAuthn is not supported in this Teleport version; use U2F instead")
+}
+
+// FinishLogin validates a LoginResponse against the challenge issued by
+// BeginLogin.
+func FinishLogin(user string, resp *LoginResponse) error {
+	return trace.NotImplemented("WebAuthn is not supported in this Teleport version; use U2F instead")
+}
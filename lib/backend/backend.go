@@ -88,6 +88,13 @@ type Backend interface {
 type Batch interface {
 	// PutRange puts range of items in one transaction
 	PutRange(ctx context.Context, items []Item) error
+
+	// CreateRange creates a range of items in one transaction, failing
+	// and leaving the backend unmodified if any of the items already
+	// exists. Useful for multi-key writes, such as creating several
+	// certificate authorities at once, that should either all succeed
+	// or not be applied at all.
+	CreateRange(ctx context.Context, items []Item) error
 }
 
 // Lease represents a lease on the item that can be used
@@ -125,6 +132,14 @@ type Watch struct {
 	// MetricComponent if set will start reporting
 	// with a given component metric
 	MetricComponent string
+	// StartAfterEventID is an optional resume token. When set, the watcher
+	// replays any buffered events with a greater Item.ID instead of
+	// sending OpInit, so a reconnecting client that kept up with the
+	// stream can skip re-fetching the full current state. If the
+	// requested event has already been evicted from the buffer, the
+	// watcher falls back to the usual OpInit and the client must fetch
+	// the full state itself.
+	StartAfterEventID int64
 }
 
 // String returns a user-friendly description
@@ -233,19 +233,50 @@ func (c *CircularBuffer) NewWatcher(ctx context.Context, watch Watch) (Watcher,
 		capacity: watch.QueueSize,
 	}
 	c.Debugf("Add %v.", w)
-	select {
-	case w.eventsC <- Event{Type: OpInit}:
-	case <-c.ctx.Done():
-		return nil, trace.BadParameter("buffer is closed")
-	default:
-		c.Warningf("Closing %v, buffer overflow.", w)
-		w.Close()
-		return nil, trace.BadParameter("buffer overflow")
+	replay, resumed := c.replayEvents(watch.StartAfterEventID)
+	if !resumed {
+		replay = []Event{{Type: OpInit}}
+	}
+	for _, event := range replay {
+		select {
+		case w.eventsC <- event:
+		case <-c.ctx.Done():
+			return nil, trace.BadParameter("buffer is closed")
+		default:
+			c.Warningf("Closing %v, buffer overflow.", w)
+			w.Close()
+			return nil, trace.BadParameter("buffer overflow")
+		}
 	}
 	c.watchers.add(w)
 	return w, nil
 }
 
+// replayEvents returns the buffered events with Item.ID greater than
+// afterID, and true, if the buffer has retained every event since afterID
+// with no gap. It returns false if afterID is 0 (no resume requested), the
+// buffer is empty, or the requested event has already been evicted from
+// the buffer, in which case the caller must fall back to a full resync.
+func (c *CircularBuffer) replayEvents(afterID int64) ([]Event, bool) {
+	if afterID == 0 || c.size == 0 {
+		return nil, false
+	}
+	oldestID := c.events[c.start].Item.ID
+	if afterID+1 < oldestID {
+		// at least one event between afterID and the oldest event
+		// retained in the buffer has already been evicted
+		return nil, false
+	}
+	var replay []Event
+	for i := 0; i < c.size; i++ {
+		index := (c.start + i) % len(c.events)
+		if c.events[index].Item.ID > afterID {
+			replay = append(replay, c.events[index])
+		}
+	}
+	return replay, true
+}
+
 func max(a, b int) int {
 	if a > b {
 		return b
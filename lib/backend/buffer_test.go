@@ -244,6 +244,65 @@ func (s *BufferSuite) TestWatcherReset(c *check.C) {
 	}
 }
 
+// TestWatcherResume tests that a watcher with StartAfterEventID set
+// replays buffered events instead of receiving OpInit, as long as the
+// resume point has not been evicted from the buffer.
+func (s *BufferSuite) TestWatcherResume(c *check.C) {
+	ctx := context.TODO()
+	b, err := NewCircularBuffer(ctx, 3)
+	c.Assert(err, check.IsNil)
+	defer b.Close()
+
+	b.Push(Event{Item: Item{Key: []byte{Separator}, ID: 1}})
+	b.Push(Event{Item: Item{Key: []byte{Separator}, ID: 2}})
+	b.Push(Event{Item: Item{Key: []byte{Separator}, ID: 3}})
+
+	// resuming after 1 should replay events 2 and 3, not OpInit
+	w, err := b.NewWatcher(ctx, Watch{StartAfterEventID: 1})
+	c.Assert(err, check.IsNil)
+	defer w.Close()
+
+	select {
+	case e := <-w.Events():
+		c.Assert(e.Item.ID, check.Equals, int64(2))
+	case <-time.After(100 * time.Millisecond):
+		c.Fatalf("Timeout waiting for event.")
+	}
+	select {
+	case e := <-w.Events():
+		c.Assert(e.Item.ID, check.Equals, int64(3))
+	case <-time.After(100 * time.Millisecond):
+		c.Fatalf("Timeout waiting for event.")
+	}
+
+	// resuming after an event that has already been evicted from the
+	// 3-element buffer falls back to OpInit
+	w2, err := b.NewWatcher(ctx, Watch{StartAfterEventID: 0})
+	c.Assert(err, check.IsNil)
+	defer w2.Close()
+	select {
+	case e := <-w2.Events():
+		c.Assert(e.Type, check.Equals, OpInit)
+	case <-time.After(100 * time.Millisecond):
+		c.Fatalf("Timeout waiting for event.")
+	}
+
+	b.Push(Event{Item: Item{Key: []byte{Separator}, ID: 4}})
+	b.Push(Event{Item: Item{Key: []byte{Separator}, ID: 5}})
+	// buffer now holds ids 3, 4, 5; resuming after 1 would miss event 2,
+	// which has already been evicted, so the watcher must fall back to
+	// OpInit instead of silently skipping it
+	w3, err := b.NewWatcher(ctx, Watch{StartAfterEventID: 1})
+	c.Assert(err, check.IsNil)
+	defer w3.Close()
+	select {
+	case e := <-w3.Events():
+		c.Assert(e.Type, check.Equals, OpInit)
+	case <-time.After(100 * time.Millisecond):
+		c.Fatalf("Timeout waiting for event.")
+	}
+}
+
 // TestWatcherTree tests buffer watcher tree
 func (s *BufferSuite) TestWatcherTree(c *check.C) {
 	t := newWatcherTree()
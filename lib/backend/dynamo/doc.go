@@ -9,5 +9,10 @@ limitations:
 
 * Paging is not implemented, hence all range operations are limited
   to 1MB result set
+
+* billing_mode "pay_per_request" (on-demand capacity) and
+  continuous_backups (point-in-time recovery) are not available: the
+  vendored aws-sdk-go predates the BillingMode and UpdateContinuousBackups
+  APIs. New() returns a NotImplemented error if either is requested.
 */
 package dynamo
@@ -66,8 +66,24 @@ type DynamoConfig struct {
 	PollStreamPeriod time.Duration `json:"poll_stream_period,omitempty"`
 	// RetryPeriod is a period between dynamo backend retries on failures
 	RetryPeriod time.Duration `json:"retry_period"`
+	// BillingMode is the DynamoDB billing mode: either "provisioned"
+	// (default, uses ReadCapacityUnits/WriteCapacityUnits) or
+	// "pay_per_request" for on-demand capacity that scales automatically.
+	BillingMode string `json:"billing_mode,omitempty"`
+	// ContinuousBackups enables point-in-time recovery (PITR) on the table
+	// at creation time.
+	ContinuousBackups bool `json:"continuous_backups,omitempty"`
 }
 
+const (
+	// billingModeProvisioned is the default DynamoDB billing mode that uses
+	// a fixed, pre-allocated read/write capacity.
+	billingModeProvisioned = "provisioned"
+	// billingModePayPerRequest is the on-demand DynamoDB billing mode that
+	// scales capacity automatically with load.
+	billingModePayPerRequest = "pay_per_request"
+)
+
 // CheckAndSetDefaults is a helper returns an error if the supplied configuration
 // is not enough to connect to DynamoDB
 func (cfg *DynamoConfig) CheckAndSetDefaults() error {
@@ -90,6 +106,13 @@ func (cfg *DynamoConfig) CheckAndSetDefaults() error {
 	if cfg.RetryPeriod == 0 {
 		cfg.RetryPeriod = defaults.HighResPollingPeriod
 	}
+	if cfg.BillingMode == "" {
+		cfg.BillingMode = billingModeProvisioned
+	}
+	if cfg.BillingMode != billingModeProvisioned && cfg.BillingMode != billingModePayPerRequest {
+		return trace.BadParameter("DynamoDB: billing_mode must be %q or %q, got %q",
+			billingModeProvisioned, billingModePayPerRequest, cfg.BillingMode)
+	}
 	return nil
 }
 
@@ -183,6 +206,20 @@ func New(ctx context.Context, params backend.Params) (*DynamoDBBackend, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	// The vendored aws-sdk-go in this build predates BillingMode on
+	// CreateTableInput and the UpdateContinuousBackups API, so on-demand
+	// capacity and point-in-time recovery can't actually be requested from
+	// DynamoDB yet. Fail fast instead of silently falling back to
+	// provisioned capacity / no backups.
+	if cfg.BillingMode == billingModePayPerRequest {
+		return nil, trace.NotImplemented(
+			"DynamoDB billing_mode %q requires a newer aws-sdk-go than is vendored in this build", billingModePayPerRequest)
+	}
+	if cfg.ContinuousBackups {
+		return nil, trace.NotImplemented(
+			"DynamoDB continuous_backups requires a newer aws-sdk-go than is vendored in this build")
+	}
+
 	buf, err := backend.NewCircularBuffer(ctx, cfg.BufferSize)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -383,7 +420,10 @@ func (b *DynamoDBBackend) DeleteRange(ctx context.Context, startKey, endKey []by
 			},
 		}
 
-		if _, err = b.svc.BatchWriteItemWithContext(ctx, &input); err != nil {
+		if err = b.retryOnThrottle(ctx, func() error {
+			_, err := b.svc.BatchWriteItemWithContext(ctx, &input)
+			return convertError(err)
+		}); err != nil {
 			return trace.Wrap(err)
 		}
 	}
@@ -447,8 +487,10 @@ func (b *DynamoDBBackend) CompareAndSwap(ctx context.Context, expected backend.I
 			B: expected.Value,
 		},
 	})
-	_, err = b.svc.PutItemWithContext(ctx, &input)
-	err = convertError(err)
+	err = b.retryOnThrottle(ctx, func() error {
+		_, err := b.svc.PutItemWithContext(ctx, &input)
+		return convertError(err)
+	})
 	if err != nil {
 		// in this case let's use more specific compare failed error
 		if trace.IsAlreadyExists(err) {
@@ -509,8 +551,10 @@ func (b *DynamoDBBackend) KeepAlive(ctx context.Context, lease backend.Lease, ex
 		UpdateExpression: aws.String("SET Expires = :expires"),
 	}
 	input.SetConditionExpression("attribute_exists(FullPath) AND (attribute_not_exists(Expires) OR Expires >= :timestamp)")
-	_, err := b.svc.UpdateItemWithContext(ctx, input)
-	err = convertError(err)
+	err := b.retryOnThrottle(ctx, func() error {
+		_, err := b.svc.UpdateItemWithContext(ctx, input)
+		return convertError(err)
+	})
 	if trace.IsCompareFailed(err) {
 		err = trace.NotFound(err.Error())
 	}
@@ -553,6 +597,34 @@ func (b *DynamoDBBackend) Clock() clockwork.Clock {
 	return b.clock
 }
 
+// retryOnThrottle retries fn with jittered backoff while DynamoDB reports
+// request throttling (converted to trace.ConnectionProblem by
+// convertError), so a burst of traffic that exceeds provisioned capacity
+// degrades gracefully instead of failing outright.
+func (b *DynamoDBBackend) retryOnThrottle(ctx context.Context, fn func() error) error {
+	retry, err := utils.NewLinear(utils.LinearConfig{
+		Step:   b.RetryPeriod / 10,
+		Max:    b.RetryPeriod,
+		Jitter: utils.NewHalfJitter(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	const maxAttempts = 5
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !trace.IsConnectionProblem(err) || attempt == maxAttempts {
+			return err
+		}
+		select {
+		case <-retry.After():
+			retry.Inc()
+		case <-ctx.Done():
+			return trace.ConnectionProblem(ctx.Err(), "context is closing")
+		}
+	}
+}
+
 func (b *DynamoDBBackend) newLease(item backend.Item) *backend.Lease {
 	var lease backend.Lease
 	if item.Expires.IsZero() {
@@ -775,8 +847,10 @@ func (b *DynamoDBBackend) create(ctx context.Context, item backend.Item, mode in
 	default:
 		return trace.BadParameter("unrecognized mode")
 	}
-	_, err = b.svc.PutItemWithContext(ctx, &input)
-	err = convertError(err)
+	err = b.retryOnThrottle(ctx, func() error {
+		_, err := b.svc.PutItemWithContext(ctx, &input)
+		return convertError(err)
+	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -0,0 +1,26 @@
+/*
+Package encryptedbk implements an at-rest encryption wrapper that can be
+placed around any backend.Backend implementation (DynamoDB, etcd, the
+file-based backends, etc). Item values are sealed with AES-256-GCM before
+being handed to the wrapped backend, and opened again on read, so secrets
+such as CA private keys are never written to the backing store in
+plaintext. Keys and other unencrypted metadata are left untouched, since
+backends route, range and expire on them.
+
+The AEAD used for sealing/opening is supplied via Config.AEAD, or built
+from a local 32 byte key file via Config.KeyFile. Config.AEAD is the
+extension point for a KMS-derived key: this snapshot does not vendor a
+KMS client, so callers that want a KMS-wrapped data key should unwrap it
+out-of-band (e.g. with their cloud provider's CLI or SDK) and construct
+Config.AEAD from the result before calling New.
+
+CompareAndSwap opens the ciphertext currently stored for the key and
+compares the resulting plaintext against expected.Value, rather than
+comparing ciphertext directly, since sealing is randomized (a fresh nonce
+per call) and two seals of the same plaintext would never byte-match.
+
+This wrapper does not implement backend.Batch; wrapping a backend that
+supports atomic multi-key writes loses that capability, the same
+trade-off made by backend.Wrapper.
+*/
+package encryptedbk
@@ -0,0 +1,128 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryptedbk
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/backend/memory"
+	"github.com/gravitational/teleport/lib/backend/test"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+func TestEncryptedBackend(t *testing.T) { check.TestingT(t) }
+
+func newTestAEAD(c *check.C) cipher.AEAD {
+	block, err := aes.NewCipher(make([]byte, keySize))
+	c.Assert(err, check.IsNil)
+	aead, err := cipher.NewGCM(block)
+	c.Assert(err, check.IsNil)
+	return aead
+}
+
+type EncryptedBackendSuite struct {
+	bk    *EncryptedBackend
+	suite test.BackendSuite
+}
+
+var _ = check.Suite(&EncryptedBackendSuite{})
+
+func (s *EncryptedBackendSuite) SetUpSuite(c *check.C) {
+	utils.InitLoggerForTests(testing.Verbose())
+	newBackend := func() (backend.Backend, error) {
+		mem, err := memory.New(memory.Config{})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return New(Config{Backend: mem, AEAD: newTestAEAD(c)})
+	}
+	s.suite.NewBackend = newBackend
+}
+
+func (s *EncryptedBackendSuite) SetUpTest(c *check.C) {
+	bk, err := s.suite.NewBackend()
+	c.Assert(err, check.IsNil)
+	s.bk = bk.(*EncryptedBackend)
+	s.suite.B = s.bk
+}
+
+func (s *EncryptedBackendSuite) TearDownTest(c *check.C) {
+	if s.bk != nil {
+		c.Assert(s.bk.Close(), check.IsNil)
+	}
+}
+
+func (s *EncryptedBackendSuite) TestCRUD(c *check.C) {
+	s.suite.CRUD(c)
+}
+
+func (s *EncryptedBackendSuite) TestRange(c *check.C) {
+	s.suite.Range(c)
+}
+
+func (s *EncryptedBackendSuite) TestCompareAndSwap(c *check.C) {
+	s.suite.CompareAndSwap(c)
+}
+
+func (s *EncryptedBackendSuite) TestExpiration(c *check.C) {
+	s.suite.Expiration(c)
+}
+
+func (s *EncryptedBackendSuite) TestKeepAlive(c *check.C) {
+	s.suite.KeepAlive(c)
+}
+
+func (s *EncryptedBackendSuite) TestEvents(c *check.C) {
+	s.suite.Events(c)
+}
+
+func (s *EncryptedBackendSuite) TestWatchersClose(c *check.C) {
+	s.suite.WatchersClose(c)
+}
+
+func (s *EncryptedBackendSuite) TestDeleteRange(c *check.C) {
+	s.suite.DeleteRange(c)
+}
+
+func (s *EncryptedBackendSuite) TestLocking(c *check.C) {
+	s.suite.Locking(c)
+}
+
+// TestValuesAreEncrypted verifies that item values are not stored in
+// plaintext in the wrapped backend.
+func (s *EncryptedBackendSuite) TestValuesAreEncrypted(c *check.C) {
+	_, err := s.bk.Put(context.Background(), backend.Item{
+		Key:   []byte("/secret"),
+		Value: []byte("super-secret-value"),
+	})
+	c.Assert(err, check.IsNil)
+
+	raw, err := s.bk.Backend.Get(context.Background(), []byte("/secret"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(raw.Value), check.Not(check.Equals), "super-secret-value")
+
+	out, err := s.bk.Get(context.Background(), []byte("/secret"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(out.Value), check.Equals, "super-secret-value")
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryptedbk
+
+import (
+	"github.com/gravitational/teleport/lib/backend"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// decryptingWatcher wraps a backend.Watcher, decrypting the item value
+// carried by each OpPut event before handing it to the caller
+type decryptingWatcher struct {
+	backend.Watcher
+	events chan backend.Event
+}
+
+func newDecryptingWatcher(w backend.Watcher, b *EncryptedBackend) *decryptingWatcher {
+	dw := &decryptingWatcher{
+		Watcher: w,
+		events:  make(chan backend.Event),
+	}
+	go dw.relay(b)
+	return dw
+}
+
+func (w *decryptingWatcher) relay(b *EncryptedBackend) {
+	defer close(w.events)
+	for {
+		select {
+		case <-w.Watcher.Done():
+			return
+		case event, ok := <-w.Watcher.Events():
+			if !ok {
+				return
+			}
+			if event.Type == backend.OpPut {
+				plain, err := b.decryptItem(event.Item)
+				if err != nil {
+					log.Warningf("Failed to decrypt watch event for %q, skipping: %v", string(event.Item.Key), err)
+					continue
+				}
+				event.Item = plain
+			}
+			select {
+			case w.events <- event:
+			case <-w.Watcher.Done():
+				return
+			}
+		}
+	}
+}
+
+// Events returns channel with decrypted events
+func (w *decryptingWatcher) Events() <-chan backend.Event {
+	return w.events
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firestore
+
+import "time"
+
+const (
+	// defaultRetryPeriod is the base delay used for exponential backoff
+	// when a write loses a transaction to contention.
+	defaultRetryPeriod = 100 * time.Millisecond
+	// maxRetries caps how many times a contended write is retried before
+	// giving up and returning the underlying error.
+	maxRetries = 5
+	// maxRetryPeriod caps the backoff delay so a run of retries cannot
+	// block a caller indefinitely.
+	maxRetryPeriod = 5 * time.Second
+)
+
+// retryBackoff returns the delay to wait before retrying a write that lost
+// a transaction to contention, doubling basePeriod on every attempt
+// (attempt is 0-indexed) and capping it at maxRetryPeriod.
+func retryBackoff(basePeriod time.Duration, attempt int) time.Duration {
+	delay := basePeriod
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxRetryPeriod {
+			return maxRetryPeriod
+		}
+	}
+	return delay
+}
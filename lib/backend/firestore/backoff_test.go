@@ -0,0 +1,45 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+func TestFirestore(t *testing.T) { check.TestingT(t) }
+
+type FirestoreSuite struct{}
+
+var _ = check.Suite(&FirestoreSuite{})
+
+func (s *FirestoreSuite) TestRetryBackoff(c *check.C) {
+	c.Assert(retryBackoff(100*time.Millisecond, 0), check.Equals, 100*time.Millisecond)
+	c.Assert(retryBackoff(100*time.Millisecond, 1), check.Equals, 200*time.Millisecond)
+	c.Assert(retryBackoff(100*time.Millisecond, 2), check.Equals, 400*time.Millisecond)
+	c.Assert(retryBackoff(time.Second, 10), check.Equals, maxRetryPeriod)
+}
+
+func (s *FirestoreSuite) TestNewReportsNotImplemented(c *check.C) {
+	_, err := New(nil, backend.Params{"project_id": "test-project", "collection": "teleport"})
+	c.Assert(trace.IsNotImplemented(err), check.Equals, true)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// BackendName is the name of this backend as it appears in the
+// `storage/type` section of the Teleport YAML config.
+const BackendName = "firestore"
+
+// GetName returns the backend type as it appears in the `storage/type`
+// section of the Teleport YAML config.
+func GetName() string {
+	return BackendName
+}
+
+// Config represents the configuration section for the firestore backend.
+type Config struct {
+	// ProjectID is the GCP project that owns the Firestore database.
+	ProjectID string `json:"project_id,omitempty"`
+	// DatabaseID is the Firestore database ID, "(default)" unless the
+	// project uses a named database.
+	DatabaseID string `json:"database_id,omitempty"`
+	// CollectionName is the name of the collection documents are stored
+	// under.
+	CollectionName string `json:"collection,omitempty"`
+	// BufferSize is a default buffer size used to pull events.
+	BufferSize int `json:"buffer_size,omitempty"`
+	// RetryPeriod is the base delay used for exponential backoff when a
+	// write loses a transaction to contention.
+	RetryPeriod time.Duration `json:"retry_period,omitempty"`
+	// Clock allows to override the clock used in the backend.
+	Clock clockwork.Clock `json:"-"`
+}
+
+// CheckAndSetDefaults is a helper that returns an error if the supplied
+// configuration is not enough to connect to Firestore.
+func (cfg *Config) CheckAndSetDefaults() error {
+	if cfg.ProjectID == "" {
+		return trace.BadParameter("specify GCP project using 'project_id' parameter")
+	}
+	if cfg.CollectionName == "" {
+		return trace.BadParameter("specify collection name using 'collection' parameter")
+	}
+	if cfg.DatabaseID == "" {
+		cfg.DatabaseID = "(default)"
+	}
+	if cfg.BufferSize == 0 {
+		cfg.BufferSize = backend.DefaultBufferSize
+	}
+	if cfg.RetryPeriod == 0 {
+		cfg.RetryPeriod = defaultRetryPeriod
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// New returns a new instance of the firestore backend.
+//
+// The Firestore client library is not vendored in this tree (see doc.go),
+// so this always returns trace.NotImplemented; CheckAndSetDefaults is
+// still exercised so that configuration mistakes are reported the same
+// way they would be once the client is wired up.
+func New(ctx context.Context, params backend.Params) (*Backend, error) {
+	var cfg *Config
+	if err := utils.ObjectToStruct(params, &cfg); err != nil {
+		return nil, trace.BadParameter("Firestore configuration is invalid: %v", err)
+	}
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return nil, trace.NotImplemented(
+		"the firestore backend requires cloud.google.com/go/firestore, which is not vendored in this build")
+}
+
+// Backend is intended to use Google Cloud Firestore to implement the
+// backend.Backend storage interface. It is not functional yet, see New.
+type Backend struct {
+	Config
+	clock clockwork.Clock
+	buf   *backend.CircularBuffer
+}
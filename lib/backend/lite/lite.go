@@ -521,6 +521,50 @@ func (l *LiteBackend) putRangeInTransaction(ctx context.Context, tx *sql.Tx, ite
 	return nil
 }
 
+// CreateRange creates a range of items in one transaction, failing and
+// leaving the backend unmodified if any of the items already exists
+func (l *LiteBackend) CreateRange(ctx context.Context, items []backend.Item) error {
+	for i := range items {
+		if items[i].Key == nil {
+			return trace.BadParameter("missing parameter key in item %v", i)
+		}
+	}
+	err := l.inTransaction(ctx, func(tx *sql.Tx) error {
+		var eventsStmt *sql.Stmt
+		var err error
+		if !l.EventsOff {
+			eventsStmt, err = tx.PrepareContext(ctx, "INSERT INTO events(type, created, kv_key, kv_modified, kv_expires, kv_value) values(?, ?, ?, ?, ?, ?)")
+			if err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		stmt, err := tx.PrepareContext(ctx, "INSERT INTO kv(key, modified, expires, value) values(?, ?, ?, ?)")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for i := range items {
+			created := l.clock.Now().UTC()
+			recordID := id(created)
+			if !l.Mirror {
+				recordID = items[i].ID
+			}
+			if !l.EventsOff {
+				if _, err := eventsStmt.ExecContext(ctx, backend.OpPut, created, string(items[i].Key), recordID, expires(items[i].Expires), items[i].Value); err != nil {
+					return trace.Wrap(err)
+				}
+			}
+			if _, err := stmt.ExecContext(ctx, string(items[i].Key), recordID, expires(items[i].Expires), items[i].Value); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
 // Update updates value in the backend
 func (l *LiteBackend) Update(ctx context.Context, i backend.Item) (*backend.Lease, error) {
 	if i.Key == nil {
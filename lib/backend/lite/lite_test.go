@@ -98,6 +98,10 @@ func (s *LiteSuite) TestPutRange(c *check.C) {
 	s.suite.PutRange(c)
 }
 
+func (s *LiteSuite) TestCreateRange(c *check.C) {
+	s.suite.CreateRange(c)
+}
+
 func (s *LiteSuite) TestLocking(c *check.C) {
 	s.suite.Locking(c)
 }
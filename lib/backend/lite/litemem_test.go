@@ -95,6 +95,10 @@ func (s *LiteMemSuite) TestPutRange(c *check.C) {
 	s.suite.PutRange(c)
 }
 
+func (s *LiteMemSuite) TestCreateRange(c *check.C) {
+	s.suite.CreateRange(c)
+}
+
 func (s *LiteMemSuite) TestLocking(c *check.C) {
 	s.suite.Locking(c)
 }
@@ -35,6 +35,9 @@ import (
 const (
 	// defaultBTreeDegreee is a default degree of a B-Tree
 	defaultBTreeDegree = 8
+	// defaultSnapshotInterval is a default interval between snapshots
+	// when SnapshotPath is set
+	defaultSnapshotInterval = time.Minute
 )
 
 // Config holds configuration for the backend
@@ -57,6 +60,15 @@ type Config struct {
 	// which will use record IDs for Put and PutRange passed from
 	// the resources, not generate a new one
 	Mirror bool
+	// SnapshotPath, if set, turns on disk persistence for this
+	// otherwise in-memory backend: events are appended to a write-
+	// ahead log and periodically compacted into a full snapshot in
+	// this directory, so a single-node deployment can recover its
+	// dataset after a restart without running external storage.
+	SnapshotPath string
+	// SnapshotInterval is how often a full snapshot is taken and the
+	// write-ahead log is truncated. Only used if SnapshotPath is set.
+	SnapshotInterval time.Duration
 }
 
 // CheckAndSetDefaults checks and sets default values
@@ -76,6 +88,9 @@ func (cfg *Config) CheckAndSetDefaults() error {
 	if cfg.Component == "" {
 		cfg.Component = teleport.ComponentMemory
 	}
+	if cfg.SnapshotInterval == 0 {
+		cfg.SnapshotInterval = defaultSnapshotInterval
+	}
 	return nil
 }
 
@@ -102,6 +117,27 @@ func New(cfg Config) (*Memory, error) {
 		ctx:    ctx,
 		buf:    buf,
 	}
+	if cfg.SnapshotPath != "" {
+		p, err := newPersister(cfg.SnapshotPath)
+		if err != nil {
+			cancel()
+			return nil, trace.Wrap(err)
+		}
+		events, err := p.loadEvents()
+		if err != nil {
+			cancel()
+			return nil, trace.Wrap(err)
+		}
+		// replay with persistence still unset: these events are
+		// already durable on disk, re-appending them would just
+		// grow the WAL without adding any information
+		for _, event := range events {
+			m.processEvent(event)
+		}
+		m.removeExpired()
+		m.persist = p
+		go m.runPeriodicSnapshot()
+	}
 	return m, nil
 }
 
@@ -122,6 +158,9 @@ type Memory struct {
 	buf *backend.CircularBuffer
 	//  nextID is a next record ID
 	nextID int64
+	// persist is the write-ahead log and snapshot writer used when
+	// Config.SnapshotPath is set, nil otherwise
+	persist *persister
 }
 
 // Close closes memory backend
@@ -130,6 +169,9 @@ func (m *Memory) Close() error {
 	m.Lock()
 	defer m.Unlock()
 	m.buf.Close()
+	if m.persist != nil {
+		return m.persist.Close()
+	}
 	return nil
 }
 
@@ -155,10 +197,12 @@ func (m *Memory) Create(ctx context.Context, i backend.Item) (*backend.Lease, er
 	if m.tree.Get(&btreeItem{Item: i}) != nil {
 		return nil, trace.AlreadyExists("key %q already exists", string(i.Key))
 	}
-	m.processEvent(backend.Event{
+	if err := m.processEvent(backend.Event{
 		Type: backend.OpPut,
 		Item: i,
-	})
+	}); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return m.newLease(i), nil
 }
 
@@ -196,7 +240,9 @@ func (m *Memory) Update(ctx context.Context, i backend.Item) (*backend.Lease, er
 		Type: backend.OpPut,
 		Item: i,
 	}
-	m.processEvent(event)
+	if err := m.processEvent(event); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	if !m.EventsOff {
 		m.buf.Push(event)
 	}
@@ -216,7 +262,9 @@ func (m *Memory) Put(ctx context.Context, i backend.Item) (*backend.Lease, error
 		Type: backend.OpPut,
 		Item: i,
 	}
-	m.processEvent(event)
+	if err := m.processEvent(event); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	if !m.EventsOff {
 		m.buf.Push(event)
 	}
@@ -242,7 +290,43 @@ func (m *Memory) PutRange(ctx context.Context, items []backend.Item) error {
 		if !m.Mirror {
 			event.Item.ID = m.generateID()
 		}
-		m.processEvent(event)
+		if err := m.processEvent(event); err != nil {
+			return trace.Wrap(err)
+		}
+		if !m.EventsOff {
+			m.buf.Push(event)
+		}
+	}
+	return nil
+}
+
+// CreateRange creates a range of items in one transaction, failing and
+// leaving the backend unmodified if any of the items already exists
+func (m *Memory) CreateRange(ctx context.Context, items []backend.Item) error {
+	for i := range items {
+		if items[i].Key == nil {
+			return trace.BadParameter("missing parameter key in item %v", i)
+		}
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.removeExpired()
+	for _, item := range items {
+		if m.tree.Get(&btreeItem{Item: item}) != nil {
+			return trace.AlreadyExists("key %q already exists", string(item.Key))
+		}
+	}
+	for _, item := range items {
+		event := backend.Event{
+			Type: backend.OpPut,
+			Item: item,
+		}
+		if !m.Mirror {
+			event.Item.ID = m.generateID()
+		}
+		if err := m.processEvent(event); err != nil {
+			return trace.Wrap(err)
+		}
 		if !m.EventsOff {
 			m.buf.Push(event)
 		}
@@ -268,7 +352,9 @@ func (m *Memory) Delete(ctx context.Context, key []byte) error {
 			Key: key,
 		},
 	}
-	m.processEvent(event)
+	if err := m.processEvent(event); err != nil {
+		return trace.Wrap(err)
+	}
 	if !m.EventsOff {
 		m.buf.Push(event)
 	}
@@ -289,10 +375,12 @@ func (m *Memory) DeleteRange(ctx context.Context, startKey, endKey []byte) error
 	m.removeExpired()
 	re := m.getRange(ctx, startKey, endKey, backend.NoLimit)
 	for _, item := range re.Items {
-		m.processEvent(backend.Event{
+		if err := m.processEvent(backend.Event{
 			Type: backend.OpDelete,
 			Item: item,
-		})
+		}); err != nil {
+			return trace.Wrap(err)
+		}
 	}
 	return nil
 }
@@ -333,7 +421,9 @@ func (m *Memory) KeepAlive(ctx context.Context, lease backend.Lease, expires tim
 		Type: backend.OpPut,
 		Item: item,
 	}
-	m.processEvent(event)
+	if err := m.processEvent(event); err != nil {
+		return trace.Wrap(err)
+	}
 	if !m.EventsOff {
 		m.buf.Push(event)
 	}
@@ -366,7 +456,9 @@ func (m *Memory) CompareAndSwap(ctx context.Context, expected backend.Item, repl
 		Type: backend.OpPut,
 		Item: replaceWith,
 	}
-	m.processEvent(event)
+	if err := m.processEvent(event); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	if !m.EventsOff {
 		m.buf.Push(event)
 	}
@@ -431,7 +523,16 @@ func (m *Memory) removeExpired() int {
 	return removed
 }
 
-func (m *Memory) processEvent(event backend.Event) {
+// processEvent applies event to the in-memory tree and heap. If
+// persistence is enabled, the event is written to the write-ahead
+// log and fsynced first, so a failure to persist leaves the backend
+// state untouched and is reported to the caller.
+func (m *Memory) processEvent(event backend.Event) error {
+	if m.persist != nil {
+		if err := m.persist.append(event); err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	switch event.Type {
 	case backend.OpPut:
 		item := &btreeItem{Item: event.Item, index: -1}
@@ -481,4 +582,5 @@ func (m *Memory) processEvent(event backend.Event) {
 	default:
 		// skip unsupported record
 	}
+	return nil
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package memory
 
 import (
+	"context"
 	"testing"
 
 	"github.com/gravitational/teleport/lib/backend"
@@ -97,6 +98,10 @@ func (s *MemorySuite) TestPutRange(c *check.C) {
 	s.suite.PutRange(c)
 }
 
+func (s *MemorySuite) TestCreateRange(c *check.C) {
+	s.suite.CreateRange(c)
+}
+
 func (s *MemorySuite) TestLocking(c *check.C) {
 	s.suite.Locking(c)
 }
@@ -108,3 +113,37 @@ func (s *MemorySuite) TestConcurrentOperations(c *check.C) {
 	s.suite.B2 = bk
 	s.suite.ConcurrentOperations(c)
 }
+
+// TestPersistence verifies that a backend started with SnapshotPath set
+// recovers its dataset, both from a snapshot and from the write-ahead
+// log recorded since the last snapshot, after a restart.
+func (s *MemorySuite) TestPersistence(c *check.C) {
+	dir := c.MkDir()
+
+	bk, err := New(Config{SnapshotPath: dir})
+	c.Assert(err, check.IsNil)
+
+	item := backend.Item{Key: []byte("/snapshot"), Value: []byte("v1")}
+	_, err = bk.Put(context.Background(), item)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(bk.takeSnapshot(), check.IsNil)
+
+	walOnly := backend.Item{Key: []byte("/wal"), Value: []byte("v2")}
+	_, err = bk.Put(context.Background(), walOnly)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(bk.Close(), check.IsNil)
+
+	bk2, err := New(Config{SnapshotPath: dir})
+	c.Assert(err, check.IsNil)
+	defer bk2.Close()
+
+	out, err := bk2.Get(context.Background(), item.Key)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(out.Value), check.Equals, "v1")
+
+	out, err = bk2.Get(context.Background(), walOnly.Key)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(out.Value), check.Equals, "v2")
+}
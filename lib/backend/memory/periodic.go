@@ -0,0 +1,57 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+
+	"github.com/google/btree"
+)
+
+// runPeriodicSnapshot periodically compacts the write-ahead log
+// accumulated since the last snapshot into a full snapshot of the
+// current dataset, keeping the WAL, and the time needed to replay
+// it on startup, bounded
+func (m *Memory) runPeriodicSnapshot() {
+	t := time.NewTicker(m.SnapshotInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-t.C:
+			if err := m.takeSnapshot(); err != nil {
+				m.Warningf("Failed to take snapshot: %v", err)
+			}
+		}
+	}
+}
+
+func (m *Memory) takeSnapshot() error {
+	m.Lock()
+	defer m.Unlock()
+	m.removeExpired()
+	var events []backend.Event
+	m.tree.Ascend(func(i btree.Item) bool {
+		item := i.(*btreeItem)
+		events = append(events, backend.Event{Type: backend.OpPut, Item: item.Item})
+		return true
+	})
+	return m.persist.snapshot(events)
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/teleport/lib/backend"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// snapshotFile is the name of the full snapshot file written
+	// to SnapshotPath on every snapshot cycle
+	snapshotFile = "snapshot.json"
+	// walFile is the name of the write-ahead log file that
+	// accumulates events applied since the last snapshot
+	walFile = "wal.log"
+)
+
+// persister writes a write-ahead log of events to disk and
+// periodically compacts it into a full snapshot, so a memory
+// backend started with Config.SnapshotPath set can recover its
+// dataset after a restart without running an external database.
+type persister struct {
+	snapshotPath string
+	walPath      string
+	wal          *os.File
+}
+
+// newPersister opens (creating if necessary) the WAL file used to
+// record events for the backend rooted at dir
+func newPersister(dir string) (*persister, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	p := &persister{
+		snapshotPath: filepath.Join(dir, snapshotFile),
+		walPath:      filepath.Join(dir, walFile),
+	}
+	wal, err := os.OpenFile(p.walPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	p.wal = wal
+	return p, nil
+}
+
+// loadEvents reads the last snapshot, if any, followed by the WAL
+// recorded since that snapshot, and returns the combined list of
+// events to replay, in order, to reconstruct the dataset
+func (p *persister) loadEvents() ([]backend.Event, error) {
+	var events []backend.Event
+	snapshot, err := p.readSnapshot()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	events = append(events, snapshot...)
+	wal, err := p.readWAL()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	events = append(events, wal...)
+	return events, nil
+}
+
+func (p *persister) readSnapshot() ([]backend.Event, error) {
+	f, err := os.Open(p.snapshotPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	return readEventLines(f)
+}
+
+func (p *persister) readWAL() ([]backend.Event, error) {
+	f, err := os.Open(p.walPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	return readEventLines(f)
+}
+
+func readEventLines(f *os.File) ([]backend.Event, error) {
+	var events []backend.Event
+	scanner := bufio.NewScanner(f)
+	// the default bufio.Scanner buffer is too small for large item values
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var event backend.Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, trace.Wrap(err, "corrupted persistence file %v", f.Name())
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return events, nil
+}
+
+// append writes event to the WAL and fsyncs it before returning, so
+// the event is durable by the time the caller's write completes
+func (p *persister) append(event backend.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	data = append(data, '\n')
+	if _, err := p.wal.Write(data); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(p.wal.Sync())
+}
+
+// snapshot writes out the full, current dataset as a new snapshot
+// file and truncates the WAL, since every event in it is now
+// reflected in the snapshot
+func (p *persister) snapshot(events []backend.Event) error {
+	tmp := p.snapshotPath + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	w := bufio.NewWriter(f)
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			f.Close()
+			return trace.Wrap(err)
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			f.Close()
+			return trace.ConvertSystemError(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return trace.ConvertSystemError(err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return trace.ConvertSystemError(err)
+	}
+	if err := f.Close(); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if err := os.Rename(tmp, p.snapshotPath); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if err := p.wal.Truncate(0); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if _, err := p.wal.Seek(0, 0); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
+
+// Close closes the underlying WAL file
+func (p *persister) Close() error {
+	return trace.ConvertSystemError(p.wal.Close())
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backend_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/backend/memory"
+)
+
+// TestExportImport verifies that every item written to one backend is
+// recovered, including its ID, after an Export/Import round-trip
+// through a different backend instance.
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	src, err := memory.New(memory.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	for i := 0; i < 10; i++ {
+		_, err := src.Put(ctx, backend.Item{
+			Key:   []byte(fmt.Sprintf("/key-%v", i)),
+			Value: []byte(fmt.Sprintf("value-%v", i)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var archive bytes.Buffer
+	exported, err := backend.Export(ctx, src, bufio.NewWriter(&archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported != 10 {
+		t.Fatalf("expected 10 exported items, got %v", exported)
+	}
+
+	dst, err := memory.New(memory.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	imported, err := backend.Import(ctx, dst, bufio.NewReader(&archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 10 {
+		t.Fatalf("expected 10 imported items, got %v", imported)
+	}
+
+	for i := 0; i < 10; i++ {
+		key := []byte(fmt.Sprintf("/key-%v", i))
+		srcItem, err := src.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dstItem, err := dst.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(srcItem.Value) != string(dstItem.Value) {
+			t.Fatalf("expected %q, got %q", srcItem.Value, dstItem.Value)
+		}
+		if srcItem.ID != dstItem.ID {
+			t.Fatalf("expected ID %v, got %v", srcItem.ID, dstItem.ID)
+		}
+	}
+}
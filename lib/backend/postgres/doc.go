@@ -0,0 +1,35 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package postgres implements a backend.Backend backed by PostgreSQL or
+// CockroachDB, so that self-hosted clusters can store state in an HA SQL
+// database they already run instead of etcd or DynamoDB.
+//
+// This package deliberately talks to the database exclusively through the
+// standard library's database/sql, and does not import any concrete SQL
+// driver. No PostgreSQL client library is vendored in this tree, so a
+// binary that wants to use this backend must blank-import one itself, for
+// example:
+//
+//	import _ "github.com/lib/pq"
+//
+// and set Config.Driver to the name that driver registers (lib/pq
+// registers "postgres"). Because LISTEN/NOTIFY and CockroachDB changefeeds
+// are driver- and dialect-specific extensions that database/sql has no
+// portable API for, the change feed required for NewWatcher is implemented
+// by polling the events table, following the same pattern already used by
+// the sqlite backend in lib/backend/lite.
+package postgres
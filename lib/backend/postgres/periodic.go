@@ -0,0 +1,158 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+
+	"github.com/gravitational/trace"
+)
+
+const notSet = -2
+
+// runPeriodicOperations polls the events table for new rows and feeds them
+// into the watcher buffer, and reaps expired keys and old events. See
+// doc.go for why polling is used instead of LISTEN/NOTIFY or changefeeds.
+func (b *Backend) runPeriodicOperations() {
+	t := time.NewTicker(b.PollStreamPeriod)
+	defer t.Stop()
+
+	rowid := int64(notSet)
+	for {
+		select {
+		case <-b.ctx.Done():
+			b.closeDatabase()
+			return
+		case <-t.C:
+			if err := b.removeExpiredKeys(); err != nil {
+				if trace.IsConnectionProblem(err) {
+					b.Debugf("Failed to remove expired keys: %v", err)
+				} else {
+					b.Warningf("Failed to remove expired keys: %v", err)
+				}
+			}
+			if b.EventsOff {
+				continue
+			}
+			if err := b.removeOldEvents(); err != nil {
+				b.Warningf("Failed to remove old events: %v", err)
+			}
+			var err error
+			rowid, err = b.pollEvents(rowid)
+			if err != nil {
+				b.Warningf("Failed to poll events: %v", err)
+			}
+		}
+	}
+}
+
+func (b *Backend) removeExpiredKeys() error {
+	now := b.clock.Now().UTC()
+	return b.inTransaction(b.ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(b.ctx,
+			"SELECT key FROM kv WHERE expires IS NOT NULL AND expires <= $1 ORDER BY key LIMIT $2",
+			now, b.BufferSize)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		var keys [][]byte
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return trace.Wrap(err)
+			}
+			keys = append(keys, []byte(key))
+		}
+		rows.Close()
+		for _, key := range keys {
+			if err := b.deleteInTransaction(b.ctx, key, tx); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (b *Backend) removeOldEvents() error {
+	expiryTime := b.clock.Now().UTC().Add(-1 * backend.DefaultEventsTTL)
+	return b.inTransaction(b.ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(b.ctx, "DELETE FROM events WHERE created <= $1", expiryTime)
+		return trace.Wrap(err)
+	})
+}
+
+func (b *Backend) pollEvents(rowid int64) (int64, error) {
+	if rowid == notSet {
+		err := b.inTransaction(b.ctx, func(tx *sql.Tx) error {
+			row := tx.QueryRowContext(b.ctx, "SELECT id FROM events ORDER BY id DESC LIMIT 1")
+			if err := row.Scan(&rowid); err != nil {
+				if err != sql.ErrNoRows {
+					return trace.Wrap(err)
+				}
+				rowid = -1
+			}
+			return nil
+		})
+		if err != nil {
+			return rowid, trace.Wrap(err)
+		}
+		b.Debugf("Initialized event ID iterator to %v", rowid)
+		b.signalWatchStart()
+	}
+
+	var events []backend.Event
+	lastID := rowid
+	err := b.inTransaction(b.ctx, func(tx *sql.Tx) error {
+		limit := b.BufferSize / 2
+		if limit <= 0 {
+			limit = 1
+		}
+		rows, err := tx.QueryContext(b.ctx,
+			"SELECT id, type, kv_key, kv_value, kv_modified, kv_expires FROM events WHERE id > $1 ORDER BY id LIMIT $2",
+			rowid, limit)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var event backend.Event
+			var key string
+			var expiresAt sql.NullTime
+			if err := rows.Scan(&lastID, &event.Type, &key, &event.Item.Value, &event.Item.ID, &expiresAt); err != nil {
+				return trace.Wrap(err)
+			}
+			event.Item.Key = []byte(key)
+			if expiresAt.Valid {
+				event.Item.Expires = expiresAt.Time
+			}
+			events = append(events, event)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return rowid, trace.Wrap(err)
+	}
+	b.buf.PushBatch(events)
+	if len(events) != 0 {
+		return lastID, nil
+	}
+	return rowid, nil
+}
@@ -0,0 +1,650 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// BackendName is the name of this backend as it appears in the
+	// `storage/type` section of the Teleport YAML config.
+	BackendName = "postgres"
+	// defaultDriver is the database/sql driver name registered by
+	// github.com/lib/pq, the most common PostgreSQL driver for Go.
+	defaultDriver = "postgres"
+)
+
+// GetName returns the backend type as it appears in the `storage/type`
+// section of the Teleport YAML config.
+func GetName() string {
+	return BackendName
+}
+
+// Config represents the configuration section for the postgres backend.
+type Config struct {
+	// ConnString is the connection string (DSN) used to connect to the
+	// database, in the format understood by the configured Driver.
+	ConnString string `json:"conn_string,omitempty"`
+	// Driver is the name of the database/sql driver to use, as registered
+	// by sql.Register. The driver itself is not vendored in this tree and
+	// must be blank-imported by the consuming binary. Defaults to
+	// "postgres", the name registered by github.com/lib/pq.
+	Driver string `json:"driver,omitempty"`
+	// BufferSize is a default buffer size used to pull events.
+	BufferSize int `json:"buffer_size,omitempty"`
+	// PollStreamPeriod is a polling period for the event stream.
+	PollStreamPeriod time.Duration `json:"poll_stream_period,omitempty"`
+	// EventsOff turns events off.
+	EventsOff bool `json:"events_off,omitempty"`
+	// Clock allows to override the clock used in the backend.
+	Clock clockwork.Clock `json:"-"`
+}
+
+// CheckAndSetDefaults is a helper that returns an error if the supplied
+// configuration is not enough to connect to PostgreSQL.
+func (cfg *Config) CheckAndSetDefaults() error {
+	if cfg.ConnString == "" {
+		return trace.BadParameter("specify connection string using 'conn_string' parameter")
+	}
+	if cfg.Driver == "" {
+		cfg.Driver = defaultDriver
+	}
+	if cfg.BufferSize == 0 {
+		cfg.BufferSize = backend.DefaultBufferSize
+	}
+	if cfg.PollStreamPeriod == 0 {
+		cfg.PollStreamPeriod = backend.DefaultPollStreamPeriod
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// New returns a new instance of the postgres backend.
+func New(ctx context.Context, params backend.Params) (*Backend, error) {
+	var cfg *Config
+	if err := utils.ObjectToStruct(params, &cfg); err != nil {
+		return nil, trace.BadParameter("PostgreSQL configuration is invalid: %v", err)
+	}
+	return NewWithConfig(ctx, *cfg)
+}
+
+// NewWithConfig returns a new instance of the postgres backend using a
+// configuration struct as a parameter.
+func NewWithConfig(ctx context.Context, cfg Config) (*Backend, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	db, err := sql.Open(cfg.Driver, cfg.ConnString)
+	if err != nil {
+		return nil, trace.Wrap(err, "error opening connection to %v driver", cfg.Driver)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return nil, trace.Wrap(err, "error connecting to PostgreSQL, is the '%v' driver blank-imported?", cfg.Driver)
+	}
+	buf, err := backend.NewCircularBuffer(ctx, cfg.BufferSize)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	closeCtx, cancel := context.WithCancel(ctx)
+	watchStarted, signalWatchStart := context.WithCancel(ctx)
+	b := &Backend{
+		Config:           cfg,
+		db:               db,
+		Entry:            log.WithFields(log.Fields{trace.Component: BackendName}),
+		clock:            cfg.Clock,
+		buf:              buf,
+		ctx:              closeCtx,
+		cancel:           cancel,
+		watchStarted:     watchStarted,
+		signalWatchStart: signalWatchStart,
+	}
+	if err := b.createSchema(); err != nil {
+		return nil, trace.Wrap(err, "error creating schema")
+	}
+	go b.runPeriodicOperations()
+	return b, nil
+}
+
+// Backend uses PostgreSQL or CockroachDB to implement the backend.Backend
+// storage interface.
+type Backend struct {
+	Config
+	*log.Entry
+	db *sql.DB
+	// clock is used to generate time, could be swapped in tests for fixed
+	// time.
+	clock clockwork.Clock
+
+	buf              *backend.CircularBuffer
+	ctx              context.Context
+	cancel           context.CancelFunc
+	watchStarted     context.Context
+	signalWatchStart context.CancelFunc
+
+	// closedFlag is set to indicate that the database is closed.
+	closedFlag int32
+}
+
+func (b *Backend) createSchema() error {
+	schemas := []string{
+		`CREATE TABLE IF NOT EXISTS kv (
+           key TEXT PRIMARY KEY,
+           modified BIGINT NOT NULL,
+           expires TIMESTAMPTZ,
+           value BYTEA
+         )`,
+		`CREATE INDEX IF NOT EXISTS kv_expires ON kv (expires)`,
+		`CREATE TABLE IF NOT EXISTS events (
+           id BIGSERIAL PRIMARY KEY,
+           type TEXT NOT NULL,
+           created TIMESTAMPTZ NOT NULL,
+           kv_key TEXT NOT NULL,
+           kv_modified BIGINT NOT NULL,
+           kv_expires TIMESTAMPTZ,
+           kv_value BYTEA
+         )`,
+		`CREATE INDEX IF NOT EXISTS events_created ON events (created)`,
+	}
+	for _, schema := range schemas {
+		if _, err := b.db.ExecContext(b.ctx, schema); err != nil {
+			return trace.Wrap(err, "failing schema step: %v", schema)
+		}
+	}
+	return nil
+}
+
+func (b *Backend) newLease(item backend.Item) *backend.Lease {
+	var lease backend.Lease
+	if item.Expires.IsZero() {
+		return &lease
+	}
+	lease.Key = item.Key
+	return &lease
+}
+
+// SetClock sets the internal backend clock.
+func (b *Backend) SetClock(clock clockwork.Clock) {
+	b.clock = clock
+}
+
+// Clock returns the clock used by the backend.
+func (b *Backend) Clock() clockwork.Clock {
+	return b.clock
+}
+
+// Create creates item if it does not exist.
+func (b *Backend) Create(ctx context.Context, i backend.Item) (*backend.Lease, error) {
+	if len(i.Key) == 0 {
+		return nil, trace.BadParameter("missing parameter key")
+	}
+	err := b.inTransaction(ctx, func(tx *sql.Tx) error {
+		created := b.clock.Now().UTC()
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO kv(key, modified, expires, value) VALUES($1, $2, $3, $4)",
+			string(i.Key), id(created), expires(i.Expires), []byte(i.Value)); err != nil {
+			return trace.Wrap(err)
+		}
+		return b.recordEvent(ctx, tx, backend.OpPut, i, created)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return b.newLease(i), nil
+}
+
+// Put puts value into backend (creates if it does not exist, updates it
+// otherwise).
+func (b *Backend) Put(ctx context.Context, i backend.Item) (*backend.Lease, error) {
+	if i.Key == nil {
+		return nil, trace.BadParameter("missing parameter key")
+	}
+	err := b.inTransaction(ctx, func(tx *sql.Tx) error {
+		created := b.clock.Now().UTC()
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO kv(key, modified, expires, value) VALUES($1, $2, $3, $4)
+             ON CONFLICT (key) DO UPDATE SET modified = $2, expires = $3, value = $4`,
+			string(i.Key), id(created), expires(i.Expires), []byte(i.Value)); err != nil {
+			return trace.Wrap(err)
+		}
+		return b.recordEvent(ctx, tx, backend.OpPut, i, created)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return b.newLease(i), nil
+}
+
+// PutRange puts a range of items in one transaction.
+func (b *Backend) PutRange(ctx context.Context, items []backend.Item) error {
+	for i := range items {
+		if items[i].Key == nil {
+			return trace.BadParameter("missing parameter key in item %v", i)
+		}
+	}
+	return trace.Wrap(b.inTransaction(ctx, func(tx *sql.Tx) error {
+		created := b.clock.Now().UTC()
+		for _, item := range items {
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO kv(key, modified, expires, value) VALUES($1, $2, $3, $4)
+                 ON CONFLICT (key) DO UPDATE SET modified = $2, expires = $3, value = $4`,
+				string(item.Key), id(created), expires(item.Expires), []byte(item.Value)); err != nil {
+				return trace.Wrap(err)
+			}
+			if err := b.recordEvent(ctx, tx, backend.OpPut, item, created); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	}))
+}
+
+// CreateRange creates a range of items in one transaction, failing and
+// leaving the backend unmodified if any of the items already exists.
+func (b *Backend) CreateRange(ctx context.Context, items []backend.Item) error {
+	for i := range items {
+		if items[i].Key == nil {
+			return trace.BadParameter("missing parameter key in item %v", i)
+		}
+	}
+	return trace.Wrap(b.inTransaction(ctx, func(tx *sql.Tx) error {
+		created := b.clock.Now().UTC()
+		for _, item := range items {
+			if _, err := tx.ExecContext(ctx,
+				"INSERT INTO kv(key, modified, expires, value) VALUES($1, $2, $3, $4)",
+				string(item.Key), id(created), expires(item.Expires), []byte(item.Value)); err != nil {
+				return trace.Wrap(err)
+			}
+			if err := b.recordEvent(ctx, tx, backend.OpPut, item, created); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	}))
+}
+
+// CompareAndSwap compares the item with an existing item and replaces it
+// with replaceWith if they match.
+func (b *Backend) CompareAndSwap(ctx context.Context, expected backend.Item, replaceWith backend.Item) (*backend.Lease, error) {
+	if len(expected.Key) == 0 {
+		return nil, trace.BadParameter("missing parameter Key")
+	}
+	if len(replaceWith.Key) == 0 {
+		return nil, trace.BadParameter("missing parameter Key")
+	}
+	if !bytes.Equal(expected.Key, replaceWith.Key) {
+		return nil, trace.BadParameter("expected and replaceWith keys should match")
+	}
+	now := b.clock.Now().UTC()
+	err := b.inTransaction(ctx, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx,
+			"SELECT value FROM kv WHERE key = $1 AND (expires IS NULL OR expires > $2)",
+			string(expected.Key), now)
+		var value []byte
+		if err := row.Scan(&value); err != nil {
+			if err == sql.ErrNoRows {
+				return trace.CompareFailed("key %v is not found", string(expected.Key))
+			}
+			return trace.Wrap(err)
+		}
+		if !bytes.Equal(value, expected.Value) {
+			return trace.CompareFailed("current value does not match expected for %v", string(expected.Key))
+		}
+		created := b.clock.Now().UTC()
+		result, err := tx.ExecContext(ctx,
+			"UPDATE kv SET value = $1, expires = $2, modified = $3 WHERE key = $4",
+			[]byte(replaceWith.Value), expires(replaceWith.Expires), id(created), string(replaceWith.Key))
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if rows, err := result.RowsAffected(); err != nil {
+			return trace.Wrap(err)
+		} else if rows == 0 {
+			return trace.CompareFailed("key %v is not found", string(replaceWith.Key))
+		}
+		return b.recordEvent(ctx, tx, backend.OpPut, replaceWith, created)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return b.newLease(replaceWith), nil
+}
+
+// Update updates the value in the backend, returns NotFound error if the
+// item does not exist.
+func (b *Backend) Update(ctx context.Context, i backend.Item) (*backend.Lease, error) {
+	if i.Key == nil {
+		return nil, trace.BadParameter("missing parameter key")
+	}
+	err := b.inTransaction(ctx, func(tx *sql.Tx) error {
+		created := b.clock.Now().UTC()
+		result, err := tx.ExecContext(ctx,
+			"UPDATE kv SET value = $1, expires = $2, modified = $3 WHERE key = $4",
+			[]byte(i.Value), expires(i.Expires), id(created), string(i.Key))
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if rows == 0 {
+			return trace.NotFound("key %v is not found", string(i.Key))
+		}
+		return b.recordEvent(ctx, tx, backend.OpPut, i, created)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return b.newLease(i), nil
+}
+
+// Get returns a single item, or a NotFound error.
+func (b *Backend) Get(ctx context.Context, key []byte) (*backend.Item, error) {
+	if len(key) == 0 {
+		return nil, trace.BadParameter("missing parameter key")
+	}
+	var item backend.Item
+	err := b.inTransaction(ctx, func(tx *sql.Tx) error {
+		return b.getInTransaction(ctx, key, tx, &item)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &item, nil
+}
+
+func (b *Backend) getInTransaction(ctx context.Context, key []byte, tx *sql.Tx, item *backend.Item) error {
+	now := b.clock.Now().UTC()
+	row := tx.QueryRowContext(ctx,
+		"SELECT key, value, expires, modified FROM kv WHERE key = $1 AND (expires IS NULL OR expires > $2)",
+		string(key), now)
+	var k string
+	var expiresAt sql.NullTime
+	if err := row.Scan(&k, &item.Value, &expiresAt, &item.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return trace.NotFound("key %v is not found", string(key))
+		}
+		return trace.Wrap(err)
+	}
+	item.Key = []byte(k)
+	if expiresAt.Valid {
+		item.Expires = expiresAt.Time
+	}
+	return nil
+}
+
+// GetRange returns query range.
+func (b *Backend) GetRange(ctx context.Context, startKey []byte, endKey []byte, limit int) (*backend.GetResult, error) {
+	if len(startKey) == 0 {
+		return nil, trace.BadParameter("missing parameter startKey")
+	}
+	if len(endKey) == 0 {
+		return nil, trace.BadParameter("missing parameter endKey")
+	}
+	if limit <= 0 {
+		limit = backend.DefaultLargeLimit
+	}
+	var result backend.GetResult
+	now := b.clock.Now().UTC()
+	err := b.inTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT key, value, expires, modified FROM kv
+             WHERE key >= $1 AND key <= $2 AND (expires IS NULL OR expires > $3)
+             ORDER BY key LIMIT $4`,
+			string(startKey), string(endKey), now, limit)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var i backend.Item
+			var k string
+			var expiresAt sql.NullTime
+			if err := rows.Scan(&k, &i.Value, &expiresAt, &i.ID); err != nil {
+				return trace.Wrap(err)
+			}
+			i.Key = []byte(k)
+			if expiresAt.Valid {
+				i.Expires = expiresAt.Time
+			}
+			result.Items = append(result.Items, i)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &result, nil
+}
+
+// KeepAlive updates the TTL on the lease.
+func (b *Backend) KeepAlive(ctx context.Context, lease backend.Lease, expiresAt time.Time) error {
+	if len(lease.Key) == 0 {
+		return trace.BadParameter("lease key is not specified")
+	}
+	return b.inTransaction(ctx, func(tx *sql.Tx) error {
+		created := b.clock.Now().UTC()
+		result, err := tx.ExecContext(ctx,
+			"UPDATE kv SET expires = $1, modified = $2 WHERE key = $3",
+			expiresAt.UTC(), id(created), string(lease.Key))
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if rows == 0 {
+			return trace.NotFound("key %v is not found", string(lease.Key))
+		}
+		return b.recordEvent(ctx, tx, backend.OpPut, backend.Item{Key: lease.Key, Expires: expiresAt}, created)
+	})
+}
+
+func (b *Backend) deleteInTransaction(ctx context.Context, key []byte, tx *sql.Tx) error {
+	result, err := tx.ExecContext(ctx, "DELETE FROM kv WHERE key = $1", string(key))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if rows == 0 {
+		return trace.NotFound("key %v is not found", string(key))
+	}
+	if b.EventsOff {
+		return nil
+	}
+	created := b.clock.Now().UTC()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO events(type, created, kv_key, kv_modified) VALUES($1, $2, $3, $4)",
+		backend.OpDelete, created, string(key), id(created))
+	return trace.Wrap(err)
+}
+
+// Delete deletes item by key, returns NotFound error if item does not
+// exist.
+func (b *Backend) Delete(ctx context.Context, key []byte) error {
+	if len(key) == 0 {
+		return trace.BadParameter("missing parameter key")
+	}
+	return b.inTransaction(ctx, func(tx *sql.Tx) error {
+		return b.deleteInTransaction(ctx, key, tx)
+	})
+}
+
+// DeleteRange deletes range of items with keys between startKey and
+// endKey.
+func (b *Backend) DeleteRange(ctx context.Context, startKey, endKey []byte) error {
+	if len(startKey) == 0 {
+		return trace.BadParameter("missing parameter startKey")
+	}
+	if len(endKey) == 0 {
+		return trace.BadParameter("missing parameter endKey")
+	}
+	return b.inTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			"SELECT key FROM kv WHERE key >= $1 AND key <= $2", string(startKey), string(endKey))
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		var keys [][]byte
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return trace.Wrap(err)
+			}
+			keys = append(keys, []byte(key))
+		}
+		rows.Close()
+		for _, key := range keys {
+			if err := b.deleteInTransaction(ctx, key, tx); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	})
+}
+
+// NewWatcher returns a new event watcher.
+func (b *Backend) NewWatcher(ctx context.Context, watch backend.Watch) (backend.Watcher, error) {
+	if b.EventsOff {
+		return nil, trace.BadParameter("events are turned off for this backend")
+	}
+	select {
+	case <-b.watchStarted.Done():
+	case <-ctx.Done():
+		return nil, trace.ConnectionProblem(ctx.Err(), "context is closing")
+	}
+	return b.buf.NewWatcher(ctx, watch)
+}
+
+// Close closes all associated resources.
+func (b *Backend) Close() error {
+	b.cancel()
+	return b.closeDatabase()
+}
+
+// CloseWatchers closes all the watchers without closing the backend.
+func (b *Backend) CloseWatchers() {
+	b.buf.Reset()
+}
+
+func (b *Backend) isClosed() bool {
+	return atomic.LoadInt32(&b.closedFlag) == 1
+}
+
+func (b *Backend) setClosed() {
+	atomic.StoreInt32(&b.closedFlag, 1)
+}
+
+func (b *Backend) closeDatabase() error {
+	b.setClosed()
+	b.buf.Close()
+	return b.db.Close()
+}
+
+func (b *Backend) recordEvent(ctx context.Context, tx *sql.Tx, eventType backend.OpType, i backend.Item, created time.Time) error {
+	if b.EventsOff {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO events(type, created, kv_key, kv_modified, kv_expires, kv_value) VALUES($1, $2, $3, $4, $5, $6)",
+		eventType, created, string(i.Key), id(created), expires(i.Expires), []byte(i.Value))
+	return trace.Wrap(err)
+}
+
+func (b *Backend) inTransaction(ctx context.Context, f func(tx *sql.Tx) error) (err error) {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return trace.Wrap(convertError(err))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			b.Errorf("Unexpected panic in inTransaction: %v, trying to rollback.", r)
+			err = trace.BadParameter("panic: %v", r)
+			if e2 := tx.Rollback(); e2 != nil {
+				b.Errorf("Failed to rollback: %v.", e2)
+			}
+			return
+		}
+		if err != nil {
+			if isUniqueViolation(err) {
+				err = trace.AlreadyExists(err.Error())
+			}
+			if !b.isClosed() && !trace.IsNotFound(err) && !trace.IsCompareFailed(err) && !trace.IsAlreadyExists(err) {
+				b.Warningf("Unexpected error in inTransaction: %v, rolling back.", trace.DebugReport(err))
+			}
+			if e2 := tx.Rollback(); e2 != nil {
+				b.Errorf("Failed to rollback too: %v.", e2)
+			}
+			return
+		}
+		if e2 := tx.Commit(); e2 != nil {
+			err = trace.Wrap(e2)
+		}
+	}()
+	err = f(tx)
+	return
+}
+
+func id(t time.Time) int64 {
+	return t.UTC().UnixNano()
+}
+
+func expires(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+func convertError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if strings.Contains(err.Error(), "sql: database is closed") {
+		return trace.ConnectionProblem(err, "database is closed")
+	}
+	return err
+}
+
+// isUniqueViolation makes a best-effort attempt to recognize a unique
+// constraint violation by its error message. It cannot rely on a
+// driver-specific error type (e.g. *pq.Error) because no concrete driver
+// is imported by this package, see doc.go.
+func isUniqueViolation(err error) bool {
+	msg := trace.Unwrap(err).Error()
+	return strings.Contains(msg, "duplicate key") || strings.Contains(msg, "unique constraint")
+}
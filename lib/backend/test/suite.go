@@ -242,6 +242,40 @@ func (s *BackendSuite) PutRange(c *check.C) {
 	ExpectItems(c, result.Items, expected)
 }
 
+// CreateRange tests scenarios with create range
+func (s *BackendSuite) CreateRange(c *check.C) {
+	ctx := context.Background()
+	prefix := MakePrefix()
+
+	b, ok := s.B.(backend.Batch)
+	if !ok {
+		c.Fatalf("Backend should support Batch interface for this test")
+	}
+
+	items := []backend.Item{
+		{Key: prefix("/prefix/a"), Value: []byte("val a")},
+		{Key: prefix("/prefix/b"), Value: []byte("val b")},
+	}
+	err := b.CreateRange(ctx, items)
+	c.Assert(err, check.IsNil)
+
+	result, err := s.B.GetRange(ctx, prefix("/prefix"), backend.RangeEnd(prefix("/prefix")), backend.NoLimit)
+	c.Assert(err, check.IsNil)
+	ExpectItems(c, result.Items, items)
+
+	// creating a range that overlaps with an existing key should fail and
+	// leave the backend unmodified
+	err = b.CreateRange(ctx, []backend.Item{
+		{Key: prefix("/prefix/c"), Value: []byte("val c")},
+		{Key: prefix("/prefix/a"), Value: []byte("val a2")},
+	})
+	fixtures.ExpectAlreadyExists(c, err)
+
+	result, err = s.B.GetRange(ctx, prefix("/prefix"), backend.RangeEnd(prefix("/prefix")), backend.NoLimit)
+	c.Assert(err, check.IsNil)
+	ExpectItems(c, result.Items, items)
+}
+
 // CompareAndSwap tests compare and swap functionality
 func (s *BackendSuite) CompareAndSwap(c *check.C) {
 	prefix := MakePrefix()
@@ -120,6 +120,12 @@ type Cache struct {
 	presenceCache      services.Presence
 	eventsCache        services.Events
 
+	// lastEventID is the Item.ID of the last event successfully applied
+	// to the cache, used as a resume token to avoid a full re-fetch on
+	// the next reconnect. Only ever touched by the single update()
+	// goroutine that runs fetchAndWatch.
+	lastEventID int64
+
 	// closedFlag is set to indicate that the services are closed
 	closedFlag int32
 }
@@ -433,10 +439,11 @@ func (c *Cache) notify(event CacheEvent) {
 //
 func (c *Cache) fetchAndWatch(retry utils.Retry, reloadC <-chan time.Time) error {
 	watcher, err := c.Events.NewWatcher(c.ctx, services.Watch{
-		QueueSize:       c.QueueSize,
-		Name:            c.Component,
-		Kinds:           c.watchKinds(),
-		MetricComponent: c.MetricComponent,
+		QueueSize:         c.QueueSize,
+		Name:              c.Component,
+		Kinds:             c.watchKinds(),
+		MetricComponent:   c.MetricComponent,
+		StartAfterEventID: c.lastEventID,
 	})
 	if err != nil {
 		c.notify(CacheEvent{Type: WatcherFailed})
@@ -457,6 +464,14 @@ func (c *Cache) fetchAndWatch(retry utils.Retry, reloadC <-chan time.Time) error
 	// To avoid this, before doing fetch,
 	// cache process makes sure the connection is established
 	// by receiving init event first.
+	//
+	// If lastEventID was set and the watcher had enough buffered
+	// history to resume from it without a gap, it skips OpInit
+	// entirely and sends the first missed event instead: the cache
+	// contents are still consistent with what came before, so there
+	// is no need to pay for a full fetch, just apply the replayed
+	// events like any other event in the main loop below.
+	var firstEvent services.Event
 	select {
 	case <-watcher.Done():
 		return trace.ConnectionProblem(watcher.Error(), "watcher is closed")
@@ -465,14 +480,19 @@ func (c *Cache) fetchAndWatch(retry utils.Retry, reloadC <-chan time.Time) error
 		return nil
 	case <-c.ctx.Done():
 		return trace.ConnectionProblem(c.ctx.Err(), "context is closing")
-	case event := <-watcher.Events():
-		if event.Type != backend.OpInit {
-			return trace.BadParameter("expected init event, got %v instead", event.Type)
-		}
+	case firstEvent = <-watcher.Events():
 	}
-	err = c.fetch()
-	if err != nil {
-		return trace.Wrap(err)
+	if firstEvent.Type == backend.OpInit {
+		if err := c.fetch(); err != nil {
+			return trace.Wrap(err)
+		}
+	} else {
+		c.Debugf("Resumed watch after event %v, skipping full fetch.", c.lastEventID)
+		if err := c.processEvent(firstEvent); err != nil {
+			return trace.Wrap(err)
+		}
+		c.recordEventID(firstEvent)
+		c.notify(CacheEvent{Event: firstEvent, Type: EventProcessed})
 	}
 	retry.Reset()
 	c.wrapper.SetReadError(nil)
@@ -491,11 +511,23 @@ func (c *Cache) fetchAndWatch(retry utils.Retry, reloadC <-chan time.Time) error
 			if err != nil {
 				return trace.Wrap(err)
 			}
+			c.recordEventID(event)
 			c.notify(CacheEvent{Event: event, Type: EventProcessed})
 		}
 	}
 }
 
+// recordEventID remembers the resource ID of the last applied event so the
+// next watch can resume from it, see Watch.StartAfterEventID.
+func (c *Cache) recordEventID(event services.Event) {
+	if event.Resource == nil {
+		return
+	}
+	if id := event.Resource.GetResourceID(); id != 0 {
+		c.lastEventID = id
+	}
+}
+
 // eraseAll erases all the data from cache collections
 func (c *Cache) eraseAll() error {
 	var errors []error
@@ -1012,7 +1012,7 @@ func (s *CacheSuite) TestProxies(c *check.C) {
 	defer p.Close()
 
 	server := suite.NewServer(services.KindProxy, "srv1", "127.0.0.1:2022", defaults.Namespace)
-	err := p.presenceS.UpsertProxy(server)
+	_, err := p.presenceS.UpsertProxy(server)
 	c.Assert(err, check.IsNil)
 
 	out, err := p.presenceS.GetProxies()
@@ -1037,7 +1037,7 @@ func (s *CacheSuite) TestProxies(c *check.C) {
 	// update srv parameters
 	srv.SetAddr("127.0.0.2:2033")
 
-	err = p.presenceS.UpsertProxy(srv)
+	_, err = p.presenceS.UpsertProxy(srv)
 	c.Assert(err, check.IsNil)
 
 	out, err = p.presenceS.GetProxies()
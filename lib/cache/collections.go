@@ -264,7 +264,7 @@ func (c *proxy) fetch() error {
 
 	for _, resource := range resources {
 		c.setTTL(resource)
-		if err := c.presenceCache.UpsertProxy(resource); err != nil {
+		if _, err := c.presenceCache.UpsertProxy(resource); err != nil {
 			return trace.Wrap(err)
 		}
 	}
@@ -290,7 +290,7 @@ func (c *proxy) processEvent(event services.Event) error {
 			return trace.BadParameter("unexpected type %T", event.Resource)
 		}
 		c.setTTL(resource)
-		if err := c.presenceCache.UpsertProxy(resource); err != nil {
+		if _, err := c.presenceCache.UpsertProxy(resource); err != nil {
 			return trace.Wrap(err)
 		}
 	default:
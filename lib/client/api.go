@@ -37,6 +37,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 	"unicode/utf8"
@@ -209,10 +210,21 @@ type Config struct {
 	// that uses local cache to validate hosts
 	HostKeyCallback ssh.HostKeyCallback
 
+	// Prompt, if set, is used for password, OTP, U2F, and host key
+	// confirmation prompts instead of the default terminal ones. Embedding
+	// applications (GUIs, IDE plugins) can use this to route prompts
+	// through their own UI.
+	Prompt Prompt
+
 	// KeyDir defines where temporary session keys will be stored.
 	// if empty, they'll go to ~/.tsh
 	KeysDir string
 
+	// AddKeysToAgentOnly tells the client to never write session keys to
+	// disk: keys live only in the running SSH agent for the lifetime of
+	// the process, for endpoints with strict key-handling policies.
+	AddKeysToAgentOnly bool
+
 	// Env is a map of environmnent variables to send when opening session
 	Env map[string]string
 
@@ -266,6 +278,9 @@ type ProfileStatus struct {
 	// ProxyURL is the URL the web client is accessible at.
 	ProxyURL url.URL
 
+	// SSHProxyAddr is the host:port the SSH proxy can be accessed at.
+	SSHProxyAddr string
+
 	// Username is the Teleport username.
 	Username string
 
@@ -291,7 +306,16 @@ func (p *ProfileStatus) IsExpired(clock clockwork.Clock) bool {
 }
 
 // RetryWithRelogin is a helper error handling method,
-// attempts to relogin and retry the function once
+// attempts to relogin and retry the function once.
+//
+// This also catches sessions that were live and then killed mid-flight by
+// the server's disconnect_expired_cert policy: the client only sees the
+// underlying connection closed, with none of the handshake/cert-specific
+// error types below, so hasExpiredCachedCert is consulted as a fallback to
+// recognize "my cert is now expired" even when the error itself gives no
+// hint. Reusing the SSO session, where the IdP still has it active, is
+// inherent to tc.Login triggering an ordinary SSO login again, not
+// anything special done here.
 func RetryWithRelogin(ctx context.Context, tc *TeleportClient, fn func() error) error {
 	err := fn()
 	if err == nil {
@@ -299,7 +323,7 @@ func RetryWithRelogin(ctx context.Context, tc *TeleportClient, fn func() error)
 	}
 	// Assume that failed handshake is a result of expired credentials,
 	// retry the login procedure
-	if !utils.IsHandshakeFailedError(err) && !utils.IsCertExpiredError(err) && !trace.IsBadParameter(err) && trace.IsTrustError(err) {
+	if !utils.IsHandshakeFailedError(err) && !utils.IsCertExpiredError(err) && !trace.IsBadParameter(err) && trace.IsTrustError(err) && !tc.hasExpiredCachedCert() {
 		return err
 	}
 	key, err := tc.Login(ctx, true)
@@ -387,12 +411,13 @@ func readProfile(profileDir string, profileName string) (*ProfileStatus, error)
 			Scheme: "https",
 			Host:   profile.WebProxyAddr,
 		},
-		Username:   profile.Username,
-		Logins:     cert.ValidPrincipals,
-		ValidUntil: validUntil,
-		Extensions: extensions,
-		Roles:      roles,
-		Cluster:    profile.Name(),
+		SSHProxyAddr: profile.SSHProxyAddr,
+		Username:     profile.Username,
+		Logins:       cert.ValidPrincipals,
+		ValidUntil:   validUntil,
+		Extensions:   extensions,
+		Roles:        roles,
+		Cluster:      profile.Name(),
 	}, nil
 }
 
@@ -609,7 +634,8 @@ func (c *Config) SaveProfile(profileAliasHost, profileDir string, profileOptions
 // ParseProxyHost parses the proxyHost string and updates the config.
 //
 // Format of proxyHost string:
-//   proxy_web_addr:<proxy_web_port>,<proxy_ssh_port>
+//
+//	proxy_web_addr:<proxy_web_port>,<proxy_ssh_port>
 func (c *Config) ParseProxyHost(proxyHost string) error {
 	host, port, err := net.SplitHostPort(proxyHost)
 	if err != nil {
@@ -797,13 +823,20 @@ func NewClient(c *Config) (tc *TeleportClient, err error) {
 	} else {
 		// initialize the local agent (auth agent which uses local SSH keys signed by the CA):
 		webProxyHost, _ := tc.WebProxyHostPort()
-		tc.localAgent, err = NewLocalAgent(c.KeysDir, webProxyHost, c.Username)
+		var agentOpts []LocalAgentOption
+		if c.AddKeysToAgentOnly {
+			agentOpts = append(agentOpts, WithMemLocalKeyStore())
+		}
+		tc.localAgent, err = NewLocalAgent(c.KeysDir, webProxyHost, c.Username, agentOpts...)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
 		if tc.HostKeyCallback == nil {
 			tc.HostKeyCallback = tc.localAgent.CheckHostSignature
 		}
+		if tc.Config.Prompt != nil {
+			tc.localAgent.hostPromptFunc = tc.Config.Prompt.PromptHostKey
+		}
 	}
 
 	return tc, nil
@@ -825,6 +858,22 @@ func (tc *TeleportClient) LocalAgent() *LocalKeyAgent {
 	return tc.localAgent
 }
 
+// hasExpiredCachedCert returns true if the locally cached certificate for
+// this client is already expired. It's used to recognize a session dropped
+// by the server's disconnect_expired_cert policy, which closes the
+// underlying connection without returning any cert-specific error.
+func (tc *TeleportClient) hasExpiredCachedCert() bool {
+	key, err := tc.LocalAgent().GetKey()
+	if err != nil {
+		return false
+	}
+	validBefore, err := key.TLSCertValidBefore()
+	if err != nil {
+		return false
+	}
+	return time.Now().After(validBefore)
+}
+
 // getTargetNodes returns a list of node addresses this SSH command needs to
 // operate on.
 func (tc *TeleportClient) getTargetNodes(ctx context.Context, proxy *ProxyClient) ([]string, error) {
@@ -929,7 +978,7 @@ func (tc *TeleportClient) startPortForwarding(ctx context.Context, nodeClient *N
 				log.Errorf("Failed to bind to %v: %v.", addr, err)
 				continue
 			}
-			go nodeClient.dynamicListenAndForward(ctx, socket)
+			go nodeClient.DynamicListenAndForward(ctx, socket)
 		}
 	}
 	return nil
@@ -1015,28 +1064,29 @@ func (tc *TeleportClient) Join(ctx context.Context, namespace string, sessionID
 	return tc.runShell(nc, session)
 }
 
-// Play replays the recorded session
-func (tc *TeleportClient) Play(ctx context.Context, namespace, sessionId string) (err error) {
+// fetchSessionRecording fetches the events and raw output stream for a
+// recorded session, shared by Play and ExportSession.
+func (tc *TeleportClient) fetchSessionRecording(ctx context.Context, namespace, sessionId string) ([]events.EventFields, []byte, *session.ID, error) {
 	if namespace == "" {
-		return trace.BadParameter(auth.MissingNamespaceError)
+		return nil, nil, nil, trace.BadParameter(auth.MissingNamespaceError)
 	}
 	sid, err := session.ParseID(sessionId)
 	if err != nil {
-		return fmt.Errorf("'%v' is not a valid session ID (must be GUID)", sid)
+		return nil, nil, nil, fmt.Errorf("'%v' is not a valid session ID (must be GUID)", sid)
 	}
 	// connect to the auth server (site) who made the recording
 	proxyClient, err := tc.ConnectToProxy(ctx)
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, nil, nil, trace.Wrap(err)
 	}
 	site, err := proxyClient.ConnectToCurrentCluster(ctx, false)
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, nil, nil, trace.Wrap(err)
 	}
 	// request events for that session (to get timing data)
 	sessionEvents, err := site.GetSessionEvents(namespace, *sid, 0, true)
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, nil, nil, trace.Wrap(err)
 	}
 
 	// read the stream into a buffer:
@@ -1044,7 +1094,7 @@ func (tc *TeleportClient) Play(ctx context.Context, namespace, sessionId string)
 	for err == nil {
 		tmp, err := site.GetSessionChunk(namespace, *sid, len(stream), events.MaxChunkBytes)
 		if err != nil {
-			return trace.Wrap(err)
+			return nil, nil, nil, trace.Wrap(err)
 		}
 		if len(tmp) == 0 {
 			err = io.EOF
@@ -1053,6 +1103,120 @@ func (tc *TeleportClient) Play(ctx context.Context, namespace, sessionId string)
 		stream = append(stream, tmp...)
 	}
 
+	return sessionEvents, stream, sid, nil
+}
+
+// fetchSessionRecordingFromFile loads the events and raw output stream for a
+// recorded session from a local tarball (as produced by "tctl sessions
+// export" or downloaded from the cluster ahead of time), without contacting
+// the cluster. The tarball is expected to be named "<session-id>.tar", the
+// same layout the auth server unpacks on upload.
+func fetchSessionRecordingFromFile(tarballPath string) ([]events.EventFields, []byte, error) {
+	sid, err := session.ParseID(strings.TrimSuffix(filepath.Base(tarballPath), ".tar"))
+	if err != nil {
+		return nil, nil, trace.BadParameter("%q is not a valid session recording tarball, expected a file named <session-id>.tar", tarballPath)
+	}
+
+	tarball, err := os.Open(tarballPath)
+	if err != nil {
+		return nil, nil, trace.ConvertSystemError(err)
+	}
+	defer tarball.Close()
+
+	dataDir, err := ioutil.TempDir("", "tsh-play-")
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	const offlineServerID = "offline"
+	if err := utils.Extract(tarball, filepath.Join(dataDir, offlineServerID, events.SessionLogsDir, defaults.Namespace)); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	auditLog, err := events.NewAuditLog(events.AuditLogConfig{
+		DataDir:  dataDir,
+		ServerID: offlineServerID,
+	})
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	defer auditLog.Close()
+
+	sessionEvents, err := auditLog.GetSessionEvents(defaults.Namespace, *sid, 0, true)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	var stream []byte
+	for {
+		chunk, err := auditLog.GetSessionChunk(defaults.Namespace, *sid, len(stream), events.MaxChunkBytes)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		if len(chunk) == 0 {
+			break
+		}
+		stream = append(stream, chunk...)
+	}
+
+	return sessionEvents, stream, nil
+}
+
+// ExportSession converts a recorded session into the given format
+// ("asciicast" or "text") and returns the resulting file contents.
+func (tc *TeleportClient) ExportSession(ctx context.Context, namespace, sessionId, format string) ([]byte, error) {
+	sessionEvents, stream, _, err := tc.fetchSessionRecording(ctx, namespace, sessionId)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return exportSessionEvents(sessionEvents, stream, format)
+}
+
+func exportSessionEvents(sessionEvents []events.EventFields, stream []byte, format string) ([]byte, error) {
+	switch format {
+	case "asciicast":
+		return events.ExportAsciicast(sessionEvents, stream)
+	case "text":
+		return events.ExportText(sessionEvents, stream)
+	default:
+		return nil, trace.BadParameter("unsupported export format %q", format)
+	}
+}
+
+// Play replays the recorded session. speed is a playback speed multiplier
+// (1 is real-time, 0 defaults to real-time); skipIdle collapses idle gaps
+// between events.
+func (tc *TeleportClient) Play(ctx context.Context, namespace, sessionId string, speed float64, skipIdle bool) (err error) {
+	sessionEvents, stream, _, err := tc.fetchSessionRecording(ctx, namespace, sessionId)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return replaySession(sessionEvents, stream, speed, skipIdle)
+}
+
+// PlayFromFile replays a recorded session from a local tarball instead of
+// contacting the cluster, for offline/air-gapped audit review.
+func PlayFromFile(tarballPath string, speed float64, skipIdle bool) (err error) {
+	sessionEvents, stream, err := fetchSessionRecordingFromFile(tarballPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return replaySession(sessionEvents, stream, speed, skipIdle)
+}
+
+// ExportSessionFromFile converts a recorded session loaded from a local
+// tarball into the given format ("asciicast" or "text"), without contacting
+// the cluster.
+func ExportSessionFromFile(tarballPath, format string) ([]byte, error) {
+	sessionEvents, stream, err := fetchSessionRecordingFromFile(tarballPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return exportSessionEvents(sessionEvents, stream, format)
+}
+
+func replaySession(sessionEvents []events.EventFields, stream []byte, speed float64, skipIdle bool) (err error) {
 	// configure terminal for direct unbuffered echo-less input:
 	if term.IsTerminal(0) {
 		state, err := term.SetRawTerminal(0)
@@ -1062,6 +1226,10 @@ func (tc *TeleportClient) Play(ctx context.Context, namespace, sessionId string)
 		defer term.RestoreTerminal(0, state)
 	}
 	player := newSessionPlayer(sessionEvents, stream)
+	if speed > 0 {
+		player.speed = speed
+	}
+	player.skipIdle = skipIdle
 	// keys:
 	const (
 		keyCtrlC = 3
@@ -1160,7 +1328,7 @@ func (tc *TeleportClient) ExecuteSCP(ctx context.Context, cmd scp.Command) (err
 }
 
 // SCP securely copies file(s) from one SSH server to another
-func (tc *TeleportClient) SCP(ctx context.Context, args []string, port int, recursive bool, quiet bool) (err error) {
+func (tc *TeleportClient) SCP(ctx context.Context, args []string, port int, recursive bool, quiet bool, preserveAttrs bool, bandwidthLimit int64) (err error) {
 	if len(args) < 2 {
 		return trace.Errorf("Need at least two arguments for scp")
 	}
@@ -1233,10 +1401,12 @@ func (tc *TeleportClient) SCP(ctx context.Context, args []string, port int, recu
 				User:           tc.Username,
 				ProgressWriter: progressWriter,
 				RemoteLocation: dest,
+				BandwidthLimit: bandwidthLimit,
 				Flags: scp.Flags{
 					Target:        []string{src},
 					Recursive:     recursive,
 					DirectoryMode: directoryMode,
+					PreserveAttrs: preserveAttrs,
 				},
 			}
 
@@ -1266,11 +1436,13 @@ func (tc *TeleportClient) SCP(ctx context.Context, args []string, port int, recu
 			scpConfig := scp.Config{
 				User: tc.Username,
 				Flags: scp.Flags{
-					Recursive: recursive,
-					Target:    []string{dest},
+					Recursive:     recursive,
+					Target:        []string{dest},
+					PreserveAttrs: preserveAttrs,
 				},
 				RemoteLocation: src,
 				ProgressWriter: progressWriter,
+				BandwidthLimit: bandwidthLimit,
 			}
 
 			cmd, err := scp.CreateDownloadCommand(scpConfig)
@@ -1312,61 +1484,111 @@ func (tc *TeleportClient) ListNodes(ctx context.Context) ([]services.Server, err
 	return proxyClient.FindServersByLabels(ctx, tc.Namespace, tc.Labels)
 }
 
+// ListAllNodes returns a list of nodes connected to the proxy across the
+// root cluster and all of its leaf clusters, each tagged with the name of
+// the cluster it belongs to.
+func (tc *TeleportClient) ListAllNodes(ctx context.Context) ([]ClusterNode, error) {
+	var err error
+	if tc.Host != "" {
+		tc.Labels, err = ParseLabelSpec(tc.Host)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	proxyClient, err := tc.ConnectToProxy(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer proxyClient.Close()
+
+	return proxyClient.FindServersByLabelsAcrossClusters(ctx, tc.Namespace, tc.Labels)
+}
+
 // runCommand executes a given bash command on a bunch of remote nodes
+// maxConcurrentNodes caps how many hosts a fan-out command (tc.SSH against
+// a label query matching more than one node) runs on at once.
+const maxConcurrentNodes = 10
+
+// runCommand runs command on every address in nodeAddresses, at most
+// maxConcurrentNodes at a time. When fanning out to more than one host, the
+// output of each host is tagged with an "[address]" prefix so results can
+// be told apart, and the command is considered to have failed overall if
+// it fails on any single host.
 func (tc *TeleportClient) runCommand(
 	ctx context.Context, siteName string, nodeAddresses []string, proxyClient *ProxyClient, command []string) error {
 
-	resultsC := make(chan error, len(nodeAddresses))
+	var mu sync.Mutex
+	var exitStatus int
+	var failed []string
+
+	semC := make(chan struct{}, maxConcurrentNodes)
+	doneC := make(chan struct{}, len(nodeAddresses))
 	for _, address := range nodeAddresses {
 		go func(address string) {
-			var (
-				err         error
-				nodeSession *NodeSession
-			)
+			semC <- struct{}{}
 			defer func() {
-				resultsC <- err
+				<-semC
+				doneC <- struct{}{}
 			}()
-			var nodeClient *NodeClient
-			nodeClient, err = proxyClient.ConnectToNode(ctx, address+"@"+tc.Namespace+"@"+siteName, tc.Config.HostLogin, false)
-			if err != nil {
-				fmt.Fprintln(tc.Stderr, err)
-				return
-			}
-			defer nodeClient.Close()
 
-			// run the command on one node:
-			if len(nodeAddresses) > 1 {
-				fmt.Printf("Running command on %v:\n", address)
-			}
-			nodeSession, err = newSession(nodeClient, nil, tc.Config.Env, tc.Stdin, tc.Stdout, tc.Stderr)
-			if err != nil {
-				log.Error(err)
-				return
-			}
-			defer nodeSession.Close()
-			if err = nodeSession.runCommand(ctx, command, tc.OnShellCreated, tc.Config.Interactive); err != nil {
-				originErr := trace.Unwrap(err)
-				exitErr, ok := originErr.(*ssh.ExitError)
-				if ok {
-					tc.ExitStatus = exitErr.ExitStatus()
-				} else {
-					// if an error occurs, but no exit status is passed back, GoSSH returns
-					// a generic error like this. in this case the error message is printed
-					// to stderr by the remote process so we have to quietly return 1:
-					if strings.Contains(originErr.Error(), "exited without exit status") {
-						tc.ExitStatus = 1
-					}
+			status := tc.runCommandOnNode(ctx, siteName, address, len(nodeAddresses) > 1, proxyClient, command)
+			if status != 0 {
+				mu.Lock()
+				if status > exitStatus {
+					exitStatus = status
 				}
+				failed = append(failed, address)
+				mu.Unlock()
 			}
 		}(address)
 	}
-	var lastError error
 	for range nodeAddresses {
-		if err := <-resultsC; err != nil {
-			lastError = err
+		<-doneC
+	}
+
+	tc.ExitStatus = exitStatus
+	if len(failed) > 0 {
+		return trace.Errorf("command failed on %v", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// runCommandOnNode connects to address and runs command on it, returning
+// the command's exit status (0 on success, including when the failure
+// never received an exit status from the remote side).
+func (tc *TeleportClient) runCommandOnNode(
+	ctx context.Context, siteName string, address string, tagOutput bool, proxyClient *ProxyClient, command []string) int {
+
+	stdout, stderr := tc.Stdout, tc.Stderr
+	if tagOutput {
+		prefix := fmt.Sprintf("[%v] ", address)
+		stdout = utils.NewPrefixWriter(tc.Stdout, prefix)
+		stderr = utils.NewPrefixWriter(tc.Stderr, prefix)
+	}
+
+	nodeClient, err := proxyClient.ConnectToNode(ctx, address+"@"+tc.Namespace+"@"+siteName, tc.Config.HostLogin, false)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer nodeClient.Close()
+
+	nodeSession, err := newSession(nodeClient, nil, tc.Config.Env, tc.Stdin, stdout, stderr)
+	if err != nil {
+		log.Error(err)
+		return 1
+	}
+	defer nodeSession.Close()
+
+	if err := nodeSession.runCommand(ctx, command, tc.OnShellCreated, tc.Config.Interactive); err != nil {
+		originErr := trace.Unwrap(err)
+		if exitErr, ok := originErr.(*ssh.ExitError); ok {
+			return exitErr.ExitStatus()
 		}
+		return 1
 	}
-	return trace.Wrap(lastError)
+	return 0
 }
 
 // runShell starts an interactive SSH session/shell.
@@ -1515,7 +1737,6 @@ func (tc *TeleportClient) LogoutAll() error {
 //
 // If 'activateKey' is true, saves the received session cert into the local
 // keystore (and into the ssh-agent) for future use.
-//
 func (tc *TeleportClient) Login(ctx context.Context, activateKey bool) (*Key, error) {
 	// Ping the endpoint to see if it's up and find the type of authentication
 	// supported.
@@ -1844,6 +2065,10 @@ func (tc *TeleportClient) u2fLogin(ctx context.Context, pub []byte) (*auth.SSHLo
 		return nil, trace.Wrap(err)
 	}
 
+	if err := tc.prompt().PromptU2F(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	response, err := tc.credClient.SSHAgentU2FLogin(
 		ctx,
 		tc.Config.Username,
@@ -1931,25 +2156,14 @@ func Username() (string, error) {
 
 // AskOTP prompts the user to enter the OTP token.
 func (tc *TeleportClient) AskOTP() (token string, err error) {
-	fmt.Printf("Enter your OTP token:\n")
-	token, err = lineFromConsole()
-	if err != nil {
-		fmt.Fprintln(tc.Stderr, err)
-		return "", trace.Wrap(err)
-	}
-	return token, nil
+	token, err = tc.prompt().PromptOTP()
+	return token, trace.Wrap(err)
 }
 
 // AskPassword prompts the user to enter the password
 func (tc *TeleportClient) AskPassword() (pwd string, err error) {
-	fmt.Printf("Enter password for Teleport user %v:\n", tc.Config.Username)
-	pwd, err = passwordFromConsole()
-	if err != nil {
-		fmt.Fprintln(tc.Stderr, err)
-		return "", trace.Wrap(err)
-	}
-
-	return pwd, nil
+	pwd, err = tc.prompt().PromptPassword(tc.Config.Username)
+	return pwd, trace.Wrap(err)
 }
 
 // passwordFromConsole reads from stdin without echoing typed characters to stdout
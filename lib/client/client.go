@@ -69,7 +69,6 @@ type NodeClient struct {
 
 // GetSites returns list of the "sites" (AKA teleport clusters) connected to the proxy
 // Each site is returned as an instance of its auth server
-//
 func (proxy *ProxyClient) GetSites() ([]services.Site, error) {
 	proxySession, err := proxy.Client.NewSession()
 	defer proxySession.Close()
@@ -111,30 +110,84 @@ func (proxy *ProxyClient) GetSites() ([]services.Site, error) {
 // FindServersByLabels returns list of the nodes which have labels exactly matching
 // the given label set.
 //
-// A server is matched when ALL labels match.
-// If no labels are passed, ALL nodes are returned.
+// A server is matched when ALL labels match. The filtering happens on the
+// auth server, so a label query does not require downloading the entire
+// node inventory. If no labels are passed, ALL nodes are returned.
 func (proxy *ProxyClient) FindServersByLabels(ctx context.Context, namespace string, labels map[string]string) ([]services.Server, error) {
 	if namespace == "" {
 		return nil, trace.BadParameter(auth.MissingNamespaceError)
 	}
-	nodes := make([]services.Server, 0)
 	site, err := proxy.CurrentClusterAccessPoint(ctx, false)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	siteNodes, err := site.GetNodes(namespace, services.SkipValidation())
+	opts := []services.MarshalOption{services.SkipValidation()}
+	if len(labels) > 0 {
+		opts = append(opts, services.WithLabels(labels))
+	}
+	return site.GetNodes(namespace, opts...)
+}
+
+// ClusterNode pairs a node with the name of the cluster it was discovered
+// in. It is used by node listings that span the root cluster and all of
+// its leaf clusters, where services.Server alone doesn't identify which
+// cluster a node belongs to.
+type ClusterNode struct {
+	Server      services.Server
+	ClusterName string
+}
+
+// FindServersByLabelsAcrossClusters returns nodes matching labels from every
+// cluster reachable through this proxy: the root cluster and all currently
+// connected leaf (trusted) clusters. Each cluster is queried concurrently
+// via its own cached access point; a cluster whose access point can't be
+// reached is skipped with a warning rather than failing the whole request.
+func (proxy *ProxyClient) FindServersByLabelsAcrossClusters(ctx context.Context, namespace string, labels map[string]string) ([]ClusterNode, error) {
+	if namespace == "" {
+		return nil, trace.BadParameter(auth.MissingNamespaceError)
+	}
+	sites, err := proxy.GetSites()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	// look at every node on this site and see which ones match:
-	for _, node := range siteNodes {
-		if node.MatchAgainst(labels) {
-			nodes = append(nodes, node)
+	opts := []services.MarshalOption{services.SkipValidation()}
+	if len(labels) > 0 {
+		opts = append(opts, services.WithLabels(labels))
+	}
+
+	type siteResult struct {
+		clusterName string
+		nodes       []services.Server
+		err         error
+	}
+	resultsCh := make(chan siteResult, len(sites))
+	for _, site := range sites {
+		site := site
+		go func() {
+			accessPoint, err := proxy.ClusterAccessPoint(ctx, site.Name, true)
+			if err != nil {
+				resultsCh <- siteResult{clusterName: site.Name, err: err}
+				return
+			}
+			nodes, err := accessPoint.GetNodes(namespace, opts...)
+			resultsCh <- siteResult{clusterName: site.Name, nodes: nodes, err: err}
+		}()
+	}
+
+	var out []ClusterNode
+	for i := 0; i < len(sites); i++ {
+		res := <-resultsCh
+		if res.err != nil {
+			log.Warningf("Failed to list nodes for cluster %q: %v.", res.clusterName, res.err)
+			continue
+		}
+		for _, node := range res.nodes {
+			out = append(out, ClusterNode{Server: node, ClusterName: res.clusterName})
 		}
 	}
-	return nodes, nil
+	return out, nil
 }
 
 // CurrentClusterAccessPoint returns cluster access point to the currently
@@ -348,6 +401,55 @@ func (proxy *ProxyClient) dialAuthServer(ctx context.Context, clusterName string
 	), nil
 }
 
+// DialHost dials nodeAddress through the proxy's "proxy:" subsystem and
+// returns the raw tunnel as a net.Conn, without performing a second SSH
+// handshake to the target node. It is the primitive behind "tsh proxy
+// ssh", which relays an external SSH client's own handshake through the
+// tunnel to the target node.
+func (proxy *ProxyClient) DialHost(ctx context.Context, nodeAddress string) (net.Conn, error) {
+	log.Infof("Client=%v dialing host=%s", proxy.clientAddr, nodeAddress)
+
+	localAddr, err := utils.ParseAddr("tcp://" + proxy.proxyAddress)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	fakeAddr, err := utils.ParseAddr("tcp://" + nodeAddress)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	proxySession, err := proxy.Client.NewSession()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	proxyWriter, err := proxySession.StdinPipe()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	proxyReader, err := proxySession.StdoutPipe()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	proxyErr, err := proxySession.StderrPipe()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = proxySession.RequestSubsystem("proxy:" + nodeAddress)
+	if err != nil {
+		serverErrorMsg, _ := ioutil.ReadAll(proxyErr)
+		return nil, trace.ConnectionProblem(err, "failed connecting to node %v. %s",
+			nodeName(strings.Split(nodeAddress, "@")[0]), serverErrorMsg)
+	}
+	return utils.NewPipeNetConn(
+		proxyReader,
+		proxyWriter,
+		proxySession,
+		localAddr,
+		fakeAddr,
+	), nil
+}
+
 // ConnectToNode connects to the ssh server via Proxy.
 // It returns connected and authenticated NodeClient
 func (proxy *ProxyClient) ConnectToNode(ctx context.Context, nodeAddress string, user string, quiet bool) (*NodeClient, error) {
@@ -688,9 +790,9 @@ func (c *NodeClient) listenAndForward(ctx context.Context, ln net.Listener, remo
 	}
 }
 
-// dynamicListenAndForward listens for connections, performs a SOCKS5
+// DynamicListenAndForward listens for connections, performs a SOCKS5
 // handshake, and then proxies the connection to the requested address.
-func (c *NodeClient) dynamicListenAndForward(ctx context.Context, ln net.Listener) {
+func (c *NodeClient) DynamicListenAndForward(ctx context.Context, ln net.Listener) {
 	defer ln.Close()
 	defer c.Close()
 
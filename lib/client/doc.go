@@ -0,0 +1,42 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package client implements the Teleport client used by tsh, and is also meant
+to be embeddable in other Go programs (GUIs, IDE plugins, automation tools)
+that need to establish Teleport connectivity as a library rather than shell
+out to tsh.
+
+Config is the typed options struct passed to NewClient; it's the only place
+client behavior is configured, and all of its fields have safe, tsh-compatible
+defaults. Methods on TeleportClient that dial the proxy or a node take a
+context.Context, which governs cancellation and timeouts for that call.
+
+Touching the calling process's environment, signal handlers, or controlling
+terminal is confined to two opt-in code paths, both of which an embedder can
+avoid entirely:
+
+  - Interactive terminal sessions (session.go), used only when TeleportClient.SSH
+    is asked to run an interactive shell (as opposed to a single command or a
+    local-only operation). This is where $TERM is read and SIGWINCH/SIGINT/
+    SIGTSTP handlers are installed, exactly as any interactive terminal
+    program would.
+
+  - The default terminal Prompt (prompt.go), used only when Config.Prompt is
+    left unset. Embedders that set Config.Prompt never hit stdin/stdout for
+    password, OTP, U2F, or host key confirmation; they get callbacks instead.
+*/
+package client
@@ -66,12 +66,38 @@ type LocalKeyAgent struct {
 	proxyHost string
 }
 
-// NewLocalAgent reads all Teleport certificates from disk (using FSLocalKeyStore),
-// creates a LocalKeyAgent, loads all certificates into it, and returns the agent.
-func NewLocalAgent(keyDir string, proxyHost string, username string) (a *LocalKeyAgent, err error) {
-	keystore, err := NewFSLocalKeyStore(keyDir)
-	if err != nil {
-		return nil, trace.Wrap(err)
+// LocalAgentOption customizes the LocalKeyAgent returned by NewLocalAgent.
+type LocalAgentOption func(*localAgentOptions)
+
+type localAgentOptions struct {
+	keyStore LocalKeyStore
+}
+
+// WithMemLocalKeyStore makes the agent keep keys only in process memory
+// and in the running SSH agent, never writing them to disk. This is for
+// endpoints with strict key-handling policies, at the cost of needing to
+// re-authenticate every time the process restarts.
+func WithMemLocalKeyStore() LocalAgentOption {
+	return func(o *localAgentOptions) {
+		o.keyStore = NewMemLocalKeyStore()
+	}
+}
+
+// NewLocalAgent reads all Teleport certificates from disk (using FSLocalKeyStore
+// by default, or an alternate LocalKeyStore selected via options), creates a
+// LocalKeyAgent, loads all certificates into it, and returns the agent.
+func NewLocalAgent(keyDir string, proxyHost string, username string, opts ...LocalAgentOption) (a *LocalKeyAgent, err error) {
+	var options localAgentOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	keystore := options.keyStore
+	if keystore == nil {
+		keystore, err = NewFSLocalKeyStore(keyDir)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
 	}
 
 	a = &LocalKeyAgent{
@@ -0,0 +1,160 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/gravitational/teleport/lib/auth"
+
+	"github.com/gravitational/trace"
+)
+
+// MemLocalKeyStore is a LocalKeyStore implementation that keeps everything
+// in process memory and never touches disk. It's meant for endpoints with
+// strict key-handling policies: keys live only in the running SSH agent
+// for the lifetime of the process and disappear when it exits, instead of
+// being written under ~/.tsh like FSLocalKeyStore does.
+//
+// Known host keys and trusted CA certificates are also kept in memory only,
+// so a process using this store re-verifies them on every run.
+type MemLocalKeyStore struct {
+	mu sync.Mutex
+
+	keys       map[string]*Key
+	knownHosts map[string][]ssh.PublicKey
+	certs      map[string][]auth.TrustedCerts
+}
+
+// NewMemLocalKeyStore creates a new in-memory local keystore object.
+func NewMemLocalKeyStore() *MemLocalKeyStore {
+	return &MemLocalKeyStore{
+		keys:       make(map[string]*Key),
+		knownHosts: make(map[string][]ssh.PublicKey),
+		certs:      make(map[string][]auth.TrustedCerts),
+	}
+}
+
+func memKeyID(proxy, username string) string {
+	return proxy + "|" + username
+}
+
+// AddKey adds the given session key for the proxy and username to the
+// in-memory store.
+func (s *MemLocalKeyStore) AddKey(proxy string, username string, key *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[memKeyID(proxy, username)] = key
+	return nil
+}
+
+// GetKey returns the session key for the given username and proxy.
+func (s *MemLocalKeyStore) GetKey(proxy string, username string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[memKeyID(proxy, username)]
+	if !ok {
+		return nil, trace.NotFound("no session keys for %v in %v", username, proxy)
+	}
+	return key, nil
+}
+
+// DeleteKey removes a specific session key from memory.
+func (s *MemLocalKeyStore) DeleteKey(proxy string, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, memKeyID(proxy, username))
+	return nil
+}
+
+// DeleteKeys removes all session keys from memory.
+func (s *MemLocalKeyStore) DeleteKeys() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = make(map[string]*Key)
+	return nil
+}
+
+// AddKnownHostKeys adds the public key to the list of known hosts for a
+// hostname.
+func (s *MemLocalKeyStore) AddKnownHostKeys(hostname string, keys []ssh.PublicKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.knownHosts[hostname] = append(s.knownHosts[hostname], keys...)
+	return nil
+}
+
+// GetKnownHostKeys returns all public keys for a hostname.
+func (s *MemLocalKeyStore) GetKnownHostKeys(hostname string) ([]ssh.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if hostname == "" {
+		var all []ssh.PublicKey
+		for _, keys := range s.knownHosts {
+			all = append(all, keys...)
+		}
+		return all, nil
+	}
+	return s.knownHosts[hostname], nil
+}
+
+// SaveCerts saves trusted TLS certificates of certificate authorities.
+func (s *MemLocalKeyStore) SaveCerts(proxy string, cas []auth.TrustedCerts) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.certs[proxy] = cas
+	return nil
+}
+
+// GetCerts gets trusted TLS certificates of certificate authorities.
+func (s *MemLocalKeyStore) GetCerts(proxy string) (*x509.CertPool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pool := x509.NewCertPool()
+	for _, ca := range s.certs[proxy] {
+		for _, cert := range ca.TLSCertificates {
+			block, _ := pem.Decode(cert)
+			if block == nil {
+				continue
+			}
+			parsed, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, trace.BadParameter("failed to parse certificate: %v", err)
+			}
+			pool.AddCert(parsed)
+		}
+	}
+	return pool, nil
+}
+
+// GetCertsPEM gets trusted TLS certificates of certificate authorities.
+func (s *MemLocalKeyStore) GetCertsPEM(proxy string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []byte
+	for _, ca := range s.certs[proxy] {
+		for _, cert := range ca.TLSCertificates {
+			out = append(out, cert...)
+			out = append(out, '\n')
+		}
+	}
+	return out, nil
+}
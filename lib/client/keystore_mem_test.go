@@ -0,0 +1,40 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+func (s *KeyStoreTestSuite) TestMemKeyCRUD(c *check.C) {
+	store := NewMemLocalKeyStore()
+	key := s.makeSignedKey(c, false)
+
+	err := store.AddKey("host.a", "bob", key)
+	c.Assert(err, check.IsNil)
+
+	keyCopy, err := store.GetKey("host.a", "bob")
+	c.Assert(err, check.IsNil)
+	c.Assert(key.EqualsTo(keyCopy), check.Equals, true)
+
+	err = store.DeleteKey("host.a", "bob")
+	c.Assert(err, check.IsNil)
+	_, err = store.GetKey("host.a", "bob")
+	c.Assert(err, check.NotNil)
+	c.Assert(trace.IsNotFound(err), check.Equals, true)
+}
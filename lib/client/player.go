@@ -43,6 +43,13 @@ type sessionPlayer struct {
 	state    int
 	position int
 
+	// speed is a playback speed multiplier, e.g. 2 plays back twice as
+	// fast, 0.5 plays back at half speed. Defaults to 1 if unset.
+	speed float64
+	// skipIdle collapses any gap between two print events down to a single
+	// frame, so that pauses in the original session are not replayed.
+	skipIdle bool
+
 	// stopC is used to tell the caller that player has finished playing
 	stopC chan int
 }
@@ -51,6 +58,7 @@ func newSessionPlayer(sessionEvents []events.EventFields, stream []byte) *sessio
 	return &sessionPlayer{
 		stream:        stream,
 		sessionEvents: sessionEvents,
+		speed:         1,
 		stopC:         make(chan int, 0),
 	}
 }
@@ -169,6 +177,12 @@ func (p *sessionPlayer) playRange(from, to int) {
 			}
 			if delay > 1000 {
 				delay = 1000
+				if p.skipIdle {
+					delay = 0
+				}
+			}
+			if p.speed > 0 {
+				delay = time.Duration(float64(delay) / p.speed)
 			}
 			timestampFrame(e.GetString("time"))
 			time.Sleep(time.Millisecond * delay)
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gravitational/trace"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Prompt lets an application embedding TeleportClient (a GUI, an IDE
+// plugin) supply its own interactive prompts in place of tsh's hard-coded
+// terminal ones: a password entry, an OTP/U2F second factor prompt, and a
+// host key trust confirmation. Set Config.Prompt to use one; if unset,
+// TeleportClient falls back to the terminal prompts it's always used.
+type Prompt interface {
+	// PromptPassword asks the user for their Teleport password.
+	PromptPassword(user string) (string, error)
+	// PromptOTP asks the user for their one-time (TOTP/HOTP) token.
+	PromptOTP() (string, error)
+	// PromptU2F is called right before tsh invokes the U2F login flow, so
+	// the caller can tell the user to activate their U2F device.
+	PromptU2F() error
+	// PromptHostKey asks the user whether to trust a host they haven't
+	// connected to before. A non-nil return rejects the host.
+	PromptHostKey(host string, key ssh.PublicKey) error
+}
+
+// terminalPrompt is the Prompt TeleportClient uses when Config.Prompt isn't
+// set, reading from stdin and writing to stdout/stderr.
+type terminalPrompt struct {
+	tc *TeleportClient
+}
+
+// PromptPassword asks the user for their Teleport password.
+func (p *terminalPrompt) PromptPassword(user string) (string, error) {
+	fmt.Printf("Enter password for Teleport user %v:\n", user)
+	pwd, err := passwordFromConsole()
+	if err != nil {
+		fmt.Fprintln(p.tc.Stderr, err)
+		return "", trace.Wrap(err)
+	}
+	return pwd, nil
+}
+
+// PromptOTP asks the user for their OTP token.
+func (p *terminalPrompt) PromptOTP() (string, error) {
+	fmt.Printf("Enter your OTP token:\n")
+	token, err := lineFromConsole()
+	if err != nil {
+		fmt.Fprintln(p.tc.Stderr, err)
+		return "", trace.Wrap(err)
+	}
+	return token, nil
+}
+
+// PromptU2F tells the user to activate their U2F device.
+func (p *terminalPrompt) PromptU2F() error {
+	fmt.Println("Please press the button on your U2F key")
+	return nil
+}
+
+// PromptHostKey asks the user whether to trust a previously-unseen host.
+func (p *terminalPrompt) PromptHostKey(host string, key ssh.PublicKey) error {
+	return p.tc.localAgent.defaultHostPromptFunc(host, key, os.Stdout, os.Stdin)
+}
+
+// prompt returns the configured Prompt, or the default terminal one.
+func (tc *TeleportClient) prompt() Prompt {
+	if tc.Config.Prompt != nil {
+		return tc.Config.Prompt
+	}
+	return &terminalPrompt{tc: tc}
+}
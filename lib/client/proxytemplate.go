@@ -0,0 +1,99 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/gravitational/trace"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProxyTemplatesConfigFile is the name, relative to the tsh profile
+// directory, of the file proxy templates are loaded from.
+const ProxyTemplatesConfigFile = "config/proxy_templates.yaml"
+
+// ProxyTemplate rewrites a requested SSH target host into a (cluster, host)
+// pair. Template must fully match the requested host for the rewrite to
+// apply; Cluster and Host may refer to capture groups from Template using
+// regexp.Expand syntax (e.g. "$1").
+type ProxyTemplate struct {
+	// Template is a regular expression the requested host must fully match
+	// for this template to apply.
+	Template string `yaml:"template"`
+	// Cluster is the leaf cluster to dial through. If empty, the currently
+	// selected cluster is used.
+	Cluster string `yaml:"cluster,omitempty"`
+	// Host is the search expression sent to the resolved cluster in place
+	// of the originally requested host. If empty, the requested host is
+	// used unchanged.
+	Host string `yaml:"host,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// ProxyTemplates is an ordered list of ProxyTemplate rules. The first rule
+// whose Template matches the requested host is applied.
+type ProxyTemplates struct {
+	Templates []ProxyTemplate `yaml:"proxy_templates,omitempty"`
+}
+
+// CheckAndSetDefaults validates and precompiles each template's regular
+// expression.
+func (p *ProxyTemplates) CheckAndSetDefaults() error {
+	for i := range p.Templates {
+		t := &p.Templates[i]
+		if t.Template == "" {
+			return trace.BadParameter("proxy template %v is missing a template expression", i)
+		}
+		re, err := regexp.Compile("^" + t.Template + "$")
+		if err != nil {
+			return trace.BadParameter("proxy template %v has an invalid template expression: %v", i, err)
+		}
+		t.re = re
+	}
+	return nil
+}
+
+// LoadProxyTemplates reads and validates the proxy templates configuration
+// file at path. A missing file isn't an error; it's treated the same as an
+// empty template list so that tsh works unmodified without one.
+func LoadProxyTemplates(path string) (*ProxyTemplates, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ProxyTemplates{}, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	var p ProxyTemplates
+	if err := yaml.Unmarshal(bytes, &p); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := p.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &p, nil
+}
+
+// Apply matches host against each template in order and returns the
+// rewritten (cluster, host) pair from the first match. matched is false if
+// no template matched the host.
+func (p *ProxyTemplates) Apply(host string) (cluster, newHost string, matched bool) {
+	for _, t := range p.Templates {
+		match := t.re.FindStringSubmatchIndex(host)
+		if match == nil {
+			continue
+		}
+		if t.Cluster != "" {
+			cluster = string(t.re.ExpandString(nil, t.Cluster, host, match))
+		}
+		newHost = host
+		if t.Host != "" {
+			newHost = string(t.re.ExpandString(nil, t.Host, host, match))
+		}
+		return cluster, newHost, true
+	}
+	return "", host, false
+}
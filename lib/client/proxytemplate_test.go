@@ -0,0 +1,42 @@
+package client
+
+import (
+	"gopkg.in/check.v1"
+)
+
+type ProxyTemplateTestSuite struct {
+}
+
+var _ = check.Suite(&ProxyTemplateTestSuite{})
+
+func (s *ProxyTemplateTestSuite) TestApply(c *check.C) {
+	pt := &ProxyTemplates{
+		Templates: []ProxyTemplate{
+			{
+				Template: `(.+)\.(eu\.internal)`,
+				Cluster:  "$2",
+				Host:     "$1",
+			},
+			{
+				Template: `(.+)\.us\.internal`,
+				Cluster:  "us",
+			},
+		},
+	}
+	c.Assert(pt.CheckAndSetDefaults(), check.IsNil)
+
+	cluster, host, matched := pt.Apply("node1.eu.internal")
+	c.Assert(matched, check.Equals, true)
+	c.Assert(cluster, check.Equals, "eu.internal")
+	c.Assert(host, check.Equals, "node1")
+
+	cluster, host, matched = pt.Apply("node2.us.internal")
+	c.Assert(matched, check.Equals, true)
+	c.Assert(cluster, check.Equals, "us")
+	c.Assert(host, check.Equals, "node2.us.internal")
+
+	cluster, host, matched = pt.Apply("node3.example.com")
+	c.Assert(matched, check.Equals, false)
+	c.Assert(cluster, check.Equals, "")
+	c.Assert(host, check.Equals, "node3.example.com")
+}
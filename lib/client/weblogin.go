@@ -361,6 +361,11 @@ func (c *CredentialsClient) SSHAgentSSOLogin(login SSHLogin) (*auth.SSHLoginResp
 	// Print to screen in-case the command that launches the browser did not run.
 	fmt.Printf("If browser window does not open automatically, open it by ")
 	fmt.Printf("clicking on the link:\n %v\n", clickableURL)
+	if _, port, err := net.SplitHostPort(login.BindAddr); err == nil {
+		fmt.Printf("If this host has no browser of its own, forward %v to a host that "+
+			"does (e.g. \"ssh -L %v:localhost:%v <this-host>\") and open the link there instead.\n",
+			login.BindAddr, port, port)
+	}
 
 	select {
 	case err := <-rd.ErrorC():
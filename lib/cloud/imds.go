@@ -0,0 +1,275 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cloud implements clients for the EC2, GCE and Azure instance
+// metadata services, for reading the tags/labels a node's own cloud
+// provider attaches to the instance it runs on. Since all three are
+// plain HTTP endpoints reachable from inside the instance, none of this
+// needs a cloud provider SDK.
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// LabelFetcher fetches the labels a cloud provider associates with the
+// instance it's called from.
+type LabelFetcher interface {
+	// GetLabels returns the instance's tags/labels.
+	GetLabels(ctx context.Context) (map[string]string, error)
+}
+
+// httpClient is the subset of *http.Client the fetchers below use, so
+// tests can substitute a fake transport without a real metadata service.
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// EC2LabelFetcher fetches an EC2 instance's tags from the EC2 instance
+// metadata service (IMDSv2), using the two-step token/fetch flow IMDSv2
+// requires.
+type EC2LabelFetcher struct {
+	// BaseURL is the metadata service's base URL. Defaults to the real
+	// EC2 link-local address; overridable in tests.
+	BaseURL string
+	// Client performs the HTTP requests. Defaults to http.DefaultClient.
+	Client httpClient
+}
+
+func (f *EC2LabelFetcher) baseURL() string {
+	if f.BaseURL != "" {
+		return f.BaseURL
+	}
+	return "http://169.254.169.254"
+}
+
+func (f *EC2LabelFetcher) client() httpClient {
+	if f.Client != nil {
+		return f.Client
+	}
+	return http.DefaultClient
+}
+
+// GetLabels returns the instance's EC2 tags, prefixed with "aws/" to
+// namespace them from labels set other ways.
+func (f *EC2LabelFetcher) GetLabels(ctx context.Context) (map[string]string, error) {
+	token, err := f.fetchToken(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	names, err := f.get(ctx, "/latest/meta-data/tags/instance/", token)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	labels := make(map[string]string)
+	for _, name := range strings.Split(strings.TrimSpace(names), "\n") {
+		if name == "" {
+			continue
+		}
+		value, err := f.get(ctx, "/latest/meta-data/tags/instance/"+name, token)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		labels["aws/"+name] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+func (f *EC2LabelFetcher) fetchToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, f.baseURL()+"/latest/api/token", nil)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", trace.BadParameter("unexpected IMDSv2 token response: %v %v", resp.StatusCode, string(body))
+	}
+	return string(body), nil
+}
+
+func (f *EC2LabelFetcher) get(ctx context.Context, path, token string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.baseURL()+path, nil)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", trace.BadParameter("unexpected EC2 metadata response for %v: %v", path, resp.StatusCode)
+	}
+	return string(body), nil
+}
+
+// GCELabelFetcher fetches a GCE instance's custom metadata attributes
+// from the GCE metadata server.
+type GCELabelFetcher struct {
+	// BaseURL is the metadata service's base URL. Defaults to the real
+	// GCE metadata hostname; overridable in tests.
+	BaseURL string
+	// Client performs the HTTP requests. Defaults to http.DefaultClient.
+	Client httpClient
+}
+
+func (f *GCELabelFetcher) baseURL() string {
+	if f.BaseURL != "" {
+		return f.BaseURL
+	}
+	return "http://metadata.google.internal"
+}
+
+func (f *GCELabelFetcher) client() httpClient {
+	if f.Client != nil {
+		return f.Client
+	}
+	return http.DefaultClient
+}
+
+// GetLabels returns the instance's custom metadata attributes, prefixed
+// with "gcp/" to namespace them from labels set other ways.
+func (f *GCELabelFetcher) GetLabels(ctx context.Context) (map[string]string, error) {
+	names, err := f.get(ctx, "/computeMetadata/v1/instance/attributes/?recursive=false")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	labels := make(map[string]string)
+	for _, name := range strings.Split(strings.TrimSpace(names), "\n") {
+		if name == "" {
+			continue
+		}
+		value, err := f.get(ctx, "/computeMetadata/v1/instance/attributes/"+name)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		labels["gcp/"+name] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+func (f *GCELabelFetcher) get(ctx context.Context, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.baseURL()+path, nil)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", trace.BadParameter("unexpected GCE metadata response for %v: %v", path, resp.StatusCode)
+	}
+	return string(body), nil
+}
+
+// AzureLabelFetcher fetches an Azure instance's tags from the Azure
+// instance metadata service.
+type AzureLabelFetcher struct {
+	// BaseURL is the metadata service's base URL. Defaults to the real
+	// Azure link-local address; overridable in tests.
+	BaseURL string
+	// Client performs the HTTP requests. Defaults to http.DefaultClient.
+	Client httpClient
+}
+
+func (f *AzureLabelFetcher) baseURL() string {
+	if f.BaseURL != "" {
+		return f.BaseURL
+	}
+	return "http://169.254.169.254"
+}
+
+func (f *AzureLabelFetcher) client() httpClient {
+	if f.Client != nil {
+		return f.Client
+	}
+	return http.DefaultClient
+}
+
+// azureMetadataResponse is the subset of the Azure instance metadata
+// service's response this fetcher cares about.
+type azureMetadataResponse struct {
+	Compute struct {
+		Tags string `json:"tags"`
+	} `json:"compute"`
+}
+
+// GetLabels returns the instance's Azure tags, prefixed with "azure/" to
+// namespace them from labels set other ways. Azure reports tags as a
+// single "key1:value1;key2:value2" string rather than a list.
+func (f *AzureLabelFetcher) GetLabels(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		f.baseURL()+"/metadata/instance?api-version=2021-02-01", nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Header.Set("Metadata", "true")
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, trace.BadParameter("unexpected Azure metadata response: %v", resp.StatusCode)
+	}
+	var parsed azureMetadataResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(parsed.Compute.Tags, ";") {
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			return nil, trace.BadParameter("malformed Azure tag %q", pair)
+		}
+		labels["azure/"+parts[0]] = parts[1]
+	}
+	return labels, nil
+}
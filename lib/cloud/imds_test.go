@@ -0,0 +1,95 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+func TestCloud(t *testing.T) { check.TestingT(t) }
+
+type LabelFetcherSuite struct{}
+
+var _ = check.Suite(&LabelFetcherSuite{})
+
+func (s *LabelFetcherSuite) TestEC2LabelFetcher(c *check.C) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			w.Write([]byte("fake-token"))
+		case r.URL.Path == "/latest/meta-data/tags/instance/":
+			c.Assert(r.Header.Get("X-aws-ec2-metadata-token"), check.Equals, "fake-token")
+			w.Write([]byte("Name\nEnv\n"))
+		case r.URL.Path == "/latest/meta-data/tags/instance/Name":
+			w.Write([]byte("my-instance"))
+		case r.URL.Path == "/latest/meta-data/tags/instance/Env":
+			w.Write([]byte("prod"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	fetcher := &EC2LabelFetcher{BaseURL: srv.URL}
+	labels, err := fetcher.GetLabels(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(labels, check.DeepEquals, map[string]string{
+		"aws/Name": "my-instance",
+		"aws/Env":  "prod",
+	})
+}
+
+func (s *LabelFetcherSuite) TestGCELabelFetcher(c *check.C) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("Metadata-Flavor"), check.Equals, "Google")
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/attributes/":
+			w.Write([]byte("team\n"))
+		case "/computeMetadata/v1/instance/attributes/team":
+			w.Write([]byte("infra"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	fetcher := &GCELabelFetcher{BaseURL: srv.URL}
+	labels, err := fetcher.GetLabels(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(labels, check.DeepEquals, map[string]string{"gcp/team": "infra"})
+}
+
+func (s *LabelFetcherSuite) TestAzureLabelFetcher(c *check.C) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("Metadata"), check.Equals, "true")
+		w.Write([]byte(`{"compute":{"tags":"team:infra;env:prod"}}`))
+	}))
+	defer srv.Close()
+
+	fetcher := &AzureLabelFetcher{BaseURL: srv.URL}
+	labels, err := fetcher.GetLabels(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(labels, check.DeepEquals, map[string]string{
+		"azure/team": "infra",
+		"azure/env":  "prod",
+	})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultLabelSyncPeriod is how often a LabelImporter refreshes its
+// labels absent an explicit period.
+const defaultLabelSyncPeriod = time.Minute
+
+// LabelImporter periodically refreshes a set of labels from a
+// LabelFetcher and caches the result for concurrent reads, so a node can
+// report its cloud provider's tags/labels as part of its own dynamic
+// labels without blocking a heartbeat on an HTTP call.
+type LabelImporter struct {
+	fetcher LabelFetcher
+	period  time.Duration
+
+	mu     sync.Mutex
+	labels map[string]string
+}
+
+// NewLabelImporter creates a LabelImporter that refreshes its labels
+// from fetcher every period (defaultLabelSyncPeriod if period is zero).
+func NewLabelImporter(fetcher LabelFetcher, period time.Duration) *LabelImporter {
+	if period == 0 {
+		period = defaultLabelSyncPeriod
+	}
+	return &LabelImporter{
+		fetcher: fetcher,
+		period:  period,
+		labels:  make(map[string]string),
+	}
+}
+
+// Get returns the most recently fetched labels.
+func (imp *LabelImporter) Get() map[string]string {
+	imp.mu.Lock()
+	defer imp.mu.Unlock()
+	out := make(map[string]string, len(imp.labels))
+	for k, v := range imp.labels {
+		out[k] = v
+	}
+	return out
+}
+
+// Sync fetches labels once and updates the cache, logging rather than
+// returning an error: a node that isn't actually running on the cloud
+// provider it was configured to import labels from will fail every
+// fetch, and that shouldn't be fatal to the node's own heartbeat.
+func (imp *LabelImporter) Sync(ctx context.Context) {
+	labels, err := imp.fetcher.GetLabels(ctx)
+	if err != nil {
+		log.Warnf("Failed to import cloud labels: %v.", err)
+		return
+	}
+	imp.mu.Lock()
+	imp.labels = labels
+	imp.mu.Unlock()
+}
+
+// Start runs Sync once immediately and then every period until ctx is
+// canceled.
+func (imp *LabelImporter) Start(ctx context.Context) {
+	imp.Sync(ctx)
+	ticker := time.NewTicker(imp.period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			imp.Sync(ctx)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"context"
+
+	"github.com/gravitational/trace"
+
+	"gopkg.in/check.v1"
+)
+
+type fakeFetcher struct {
+	labels map[string]string
+	err    error
+}
+
+func (f *fakeFetcher) GetLabels(ctx context.Context) (map[string]string, error) {
+	return f.labels, f.err
+}
+
+type LabelImporterSuite struct{}
+
+var _ = check.Suite(&LabelImporterSuite{})
+
+func (s *LabelImporterSuite) TestSync(c *check.C) {
+	fetcher := &fakeFetcher{labels: map[string]string{"aws/Env": "prod"}}
+	imp := NewLabelImporter(fetcher, 0)
+	c.Assert(imp.Get(), check.DeepEquals, map[string]string{})
+
+	imp.Sync(context.Background())
+	c.Assert(imp.Get(), check.DeepEquals, map[string]string{"aws/Env": "prod"})
+}
+
+func (s *LabelImporterSuite) TestSyncErrorKeepsPreviousLabels(c *check.C) {
+	fetcher := &fakeFetcher{labels: map[string]string{"aws/Env": "prod"}}
+	imp := NewLabelImporter(fetcher, 0)
+	imp.Sync(context.Background())
+
+	fetcher.err = trace.BadParameter("unreachable")
+	imp.Sync(context.Background())
+	c.Assert(imp.Get(), check.DeepEquals, map[string]string{"aws/Env": "prod"})
+}
@@ -16,8 +16,8 @@ limitations under the License.
 
 // Package 'config' provides facilities for configuring Teleport daemons
 // including
-//	- parsing YAML configuration
-//	- parsing CLI flags
+//   - parsing YAML configuration
+//   - parsing CLI flags
 package config
 
 import (
@@ -35,6 +35,7 @@ import (
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib"
+	"github.com/gravitational/teleport/lib/auth/pkcs11ca"
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/backend/legacy/boltbk"
 	"github.com/gravitational/teleport/lib/backend/legacy/dir"
@@ -94,6 +95,10 @@ type CommandLineFlags struct {
 	// Teleport won't check certificates when connecting to trusted clusters
 	// It's useful for learning Teleport (following quick starts, etc).
 	InsecureMode bool
+
+	// FIPS means Teleport should start in a FedRAMP/FIPS 140-2 compliant
+	// configuration.
+	FIPS bool
 }
 
 // readConfigFile reads /etc/teleport.yaml (or whatever is passed via --config flag)
@@ -222,15 +227,37 @@ func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
 		break // not set
 	case "info":
 		log.SetLevel(log.InfoLevel)
+		utils.SetComponentLogLevel("", log.InfoLevel)
 	case "err", "error":
 		log.SetLevel(log.ErrorLevel)
+		utils.SetComponentLogLevel("", log.ErrorLevel)
 	case teleport.DebugLevel:
 		log.SetLevel(log.DebugLevel)
+		utils.SetComponentLogLevel("", log.DebugLevel)
 	case "warn", "warning":
 		log.SetLevel(log.WarnLevel)
+		utils.SetComponentLogLevel("", log.WarnLevel)
 	default:
 		return trace.BadParameter("unsupported logger severity: '%v'", fc.Logger.Severity)
 	}
+	for component, severity := range fc.Logger.Components {
+		level, err := log.ParseLevel(severity)
+		if err != nil {
+			return trace.BadParameter("unsupported logger severity for component %q: %v", component, severity)
+		}
+		utils.SetComponentLogLevel(component, level)
+	}
+	switch strings.ToLower(fc.Logger.Format) {
+	case "", "text":
+		log.SetFormatter(utils.NewComponentLevelFilter(&trace.TextFormatter{
+			DisableTimestamp: true,
+			EnableColors:     trace.IsTerminal(os.Stderr),
+		}))
+	case "json":
+		log.SetFormatter(utils.NewComponentLevelFilter(&trace.JSONFormatter{}))
+	default:
+		return trace.BadParameter("unsupported logger format: '%v'", fc.Logger.Format)
+	}
 	// apply cache policy for node and proxy
 	cachePolicy, err := fc.CachePolicy.Parse()
 	if err != nil {
@@ -257,6 +284,26 @@ func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
 		cfg.MACAlgorithms = fc.MACAlgorithms
 	}
 
+	// In FIPS mode, fall back to the FIPS 140-2 approved algorithm subset
+	// for anything that wasn't explicitly configured above. fc.Check (run
+	// while parsing the file) has already rejected any explicitly
+	// configured algorithm that isn't FIPS compliant.
+	cfg.FIPS = fc.FIPS
+	if cfg.FIPS {
+		if len(fc.CipherSuites) == 0 {
+			cfg.CipherSuites = utils.FIPSCipherSuites()
+		}
+		if fc.Ciphers == nil {
+			cfg.Ciphers = defaults.FIPSCiphers
+		}
+		if fc.KEXAlgorithms == nil {
+			cfg.KEXAlgorithms = defaults.FIPSKEXAlgorithms
+		}
+		if fc.MACAlgorithms == nil {
+			cfg.MACAlgorithms = defaults.FIPSMACAlgorithms
+		}
+	}
+
 	// Read in how nodes will validate the CA.
 	if fc.CAPin != "" {
 		cfg.CAPin = fc.CAPin
@@ -275,6 +322,9 @@ func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
 		if fc.Limits.MaxUsers > 0 {
 			l.MaxNumberOfUsers = fc.Limits.MaxUsers
 		}
+		if fc.Limits.MaxConnectionsPerUser > 0 {
+			l.MaxConnectionsPerUser = fc.Limits.MaxConnectionsPerUser
+		}
 		for _, rate := range fc.Limits.Rates {
 			l.Rates = append(l.Rates, limiter.Rate{
 				Period:  rate.Period,
@@ -413,13 +463,15 @@ func applyAuthConfig(fc *FileConfig, cfg *service.Config) error {
 
 	// Set cluster-wide configuration from file configuration.
 	cfg.Auth.ClusterConfig, err = services.NewClusterConfig(services.ClusterConfigSpecV3{
-		SessionRecording:      fc.Auth.SessionRecording,
-		ProxyChecksHostKeys:   fc.Auth.ProxyChecksHostKeys,
-		Audit:                 *auditConfig,
-		ClientIdleTimeout:     fc.Auth.ClientIdleTimeout,
-		DisconnectExpiredCert: fc.Auth.DisconnectExpiredCert,
-		KeepAliveInterval:     fc.Auth.KeepAliveInterval,
-		KeepAliveCountMax:     fc.Auth.KeepAliveCountMax,
+		SessionRecording:        fc.Auth.SessionRecording,
+		ProxyChecksHostKeys:     fc.Auth.ProxyChecksHostKeys,
+		Audit:                   *auditConfig,
+		ClientIdleTimeout:       fc.Auth.ClientIdleTimeout,
+		DisconnectExpiredCert:   fc.Auth.DisconnectExpiredCert,
+		KeepAliveInterval:       fc.Auth.KeepAliveInterval,
+		KeepAliveCountMax:       fc.Auth.KeepAliveCountMax,
+		StrictHostCheck:         fc.Auth.StrictHostCheck,
+		AmbiguousHostResolution: fc.Auth.AmbiguousHostResolution,
 	})
 	if err != nil {
 		return trace.Wrap(err)
@@ -435,6 +487,18 @@ func applyAuthConfig(fc *FileConfig, cfg *service.Config) error {
 		}
 	}
 
+	// read in the PKCS#11 keystore configuration, if any, so CA keys are
+	// generated and signed on an HSM instead of in this process.
+	if fc.Auth.CAKeyParams != nil && fc.Auth.CAKeyParams.PKCS11 != nil {
+		p := fc.Auth.CAKeyParams.PKCS11
+		cfg.Auth.KeyStore = pkcs11ca.Config{
+			Path:       p.ModulePath,
+			TokenLabel: p.TokenLabel,
+			SlotNumber: p.SlotNumber,
+			Pin:        p.Pin,
+		}
+	}
+
 	return nil
 }
 
@@ -552,6 +616,29 @@ func applyProxyConfig(fc *FileConfig, cfg *service.Config) error {
 		cfg.Proxy.TunnelPublicAddrs = addrs
 	}
 
+	if fc.Proxy.ACME != nil {
+		cfg.Proxy.ACME.Enabled, err = fc.Proxy.ACME.Enabled()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		cfg.Proxy.ACME.Email = fc.Proxy.ACME.Email
+		cfg.Proxy.ACME.URI = fc.Proxy.ACME.URI
+	}
+
+	if fc.Proxy.DNSResolver != nil {
+		cfg.Proxy.DNSResolver = &utils.DNSResolver{
+			SearchDomains: fc.Proxy.DNSResolver.SearchDomains,
+			AllowedCIDRs:  fc.Proxy.DNSResolver.AllowedCIDRs,
+		}
+		if err := cfg.Proxy.DNSResolver.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	cfg.Proxy.AuthDialTimeout = fc.Proxy.AuthDialTimeout.Duration()
+	cfg.Proxy.NodeDialTimeout = fc.Proxy.NodeDialTimeout.Duration()
+	cfg.Proxy.TunnelDialTimeout = fc.Proxy.TunnelDialTimeout.Duration()
+
 	return nil
 
 }
@@ -838,6 +925,24 @@ func Configure(clf *CommandLineFlags, cfg *service.Config) error {
 		cfg.Proxy.DisableTLS = clf.DisableTLS
 	}
 
+	// apply --fips flag, falling back to the FIPS 140-2 approved algorithm
+	// subset for anything not already pinned by the config file.
+	if clf.FIPS {
+		cfg.FIPS = true
+		if fileConf == nil || len(fileConf.CipherSuites) == 0 {
+			cfg.CipherSuites = utils.FIPSCipherSuites()
+		}
+		if fileConf == nil || fileConf.Ciphers == nil {
+			cfg.Ciphers = defaults.FIPSCiphers
+		}
+		if fileConf == nil || fileConf.KEXAlgorithms == nil {
+			cfg.KEXAlgorithms = defaults.FIPSKEXAlgorithms
+		}
+		if fileConf == nil || fileConf.MACAlgorithms == nil {
+			cfg.MACAlgorithms = defaults.FIPSMACAlgorithms
+		}
+	}
+
 	// apply --debug flag to config:
 	if clf.Debug {
 		cfg.Console = ioutil.Discard
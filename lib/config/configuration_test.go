@@ -350,6 +350,31 @@ teleport:
     - kexAlgoCurve25519SHA256
   mac_algos:
     - hmac-sha2-256-etm@openssh.com
+`,
+			true,
+		},
+		// 2 - unrecognized (misspelled) key, not valid
+		{
+			`
+ssh_service:
+  lables:
+    role: node
+`,
+			true,
+		},
+		// 3 - supported version, valid
+		{
+			`
+version: v2
+teleport:
+`,
+			false,
+		},
+		// 4 - unsupported version, not valid
+		{
+			`
+version: v3
+teleport:
 `,
 			true,
 		},
@@ -368,6 +393,43 @@ teleport:
 	}
 }
 
+func (s *ConfigTestSuite) TestEnvironmentVariableExpansion(c *check.C) {
+	os.Setenv("TELEPORT_TEST_NODENAME", "env-expanded-name")
+	defer os.Unsetenv("TELEPORT_TEST_NODENAME")
+
+	conf, err := ReadConfig(bytes.NewBufferString(`
+teleport:
+  nodename: ${TELEPORT_TEST_NODENAME}
+`))
+	c.Assert(err, check.IsNil)
+	c.Assert(conf.NodeName, check.Equals, "env-expanded-name")
+}
+
+func (s *ConfigTestSuite) TestIncludes(c *check.C) {
+	dir := c.MkDir()
+
+	includedPath := filepath.Join(dir, "auth.yaml")
+	err := ioutil.WriteFile(includedPath, []byte(`
+auth_service:
+  enabled: yes
+`), 0660)
+	c.Assert(err, check.IsNil)
+
+	mainPath := filepath.Join(dir, "teleport.yaml")
+	err = ioutil.WriteFile(mainPath, []byte(`
+includes:
+  - auth.yaml
+teleport:
+  nodename: main-config
+`), 0660)
+	c.Assert(err, check.IsNil)
+
+	conf, err := ReadFromFile(mainPath)
+	c.Assert(err, check.IsNil)
+	c.Assert(conf.NodeName, check.Equals, "main-config")
+	c.Assert(conf.Auth.Enabled(), check.Equals, true)
+}
+
 func (s *ConfigTestSuite) TestApplyConfig(c *check.C) {
 	conf, err := ReadConfig(bytes.NewBufferString(SmallConfigString))
 	c.Assert(err, check.IsNil)
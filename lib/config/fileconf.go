@@ -23,6 +23,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -46,109 +47,133 @@ var (
 	// true  = has sub-keys
 	// false = does not have sub-keys (a leaf)
 	validKeys = map[string]bool{
-		"proxy_protocol":          false,
-		"namespace":               true,
-		"cluster_name":            true,
-		"trusted_clusters":        true,
-		"pid_file":                true,
-		"cert_file":               true,
-		"private_key_file":        true,
-		"cert":                    true,
-		"private_key":             true,
-		"checking_keys":           true,
-		"checking_key_files":      true,
-		"signing_keys":            true,
-		"signing_key_files":       true,
-		"allowed_logins":          true,
-		"teleport":                true,
-		"enabled":                 true,
-		"ssh_service":             true,
-		"proxy_service":           true,
-		"auth_service":            true,
-		"kubernetes":              true,
-		"kubeconfig_file":         true,
-		"auth_token":              true,
-		"auth_servers":            true,
-		"domain_name":             true,
-		"storage":                 false,
-		"nodename":                true,
-		"log":                     true,
-		"period":                  true,
-		"connection_limits":       true,
-		"max_connections":         true,
-		"max_users":               true,
-		"rates":                   true,
-		"commands":                true,
-		"labels":                  false,
-		"output":                  true,
-		"severity":                true,
-		"role":                    true,
-		"name":                    true,
-		"type":                    true,
-		"data_dir":                true,
-		"web_listen_addr":         true,
-		"tunnel_listen_addr":      true,
-		"ssh_listen_addr":         true,
-		"listen_addr":             true,
-		"ca_cert_file":            false,
-		"https_key_file":          true,
-		"https_cert_file":         true,
-		"advertise_ip":            true,
-		"authorities":             true,
-		"keys":                    true,
-		"reverse_tunnels":         true,
-		"addresses":               true,
-		"oidc_connectors":         true,
-		"id":                      true,
-		"issuer_url":              true,
-		"client_id":               true,
-		"client_secret":           true,
-		"redirect_url":            true,
-		"acr_values":              true,
-		"provider":                true,
-		"tokens":                  true,
-		"region":                  true,
-		"table_name":              true,
-		"access_key":              true,
-		"secret_key":              true,
-		"u2f":                     true,
-		"app_id":                  true,
-		"facets":                  true,
-		"authentication":          true,
-		"second_factor":           false,
-		"oidc":                    true,
-		"display":                 false,
-		"scope":                   false,
-		"claims_to_roles":         true,
-		"dynamic_config":          false,
-		"seed_config":             false,
-		"public_addr":             false,
-		"ssh_public_addr":         false,
-		"tunnel_public_addr":      false,
-		"cache":                   true,
-		"ttl":                     false,
-		"issuer":                  false,
-		"permit_user_env":         false,
-		"ciphers":                 false,
-		"kex_algos":               false,
-		"mac_algos":               false,
-		"connector_name":          false,
-		"session_recording":       false,
-		"read_capacity_units":     false,
-		"write_capacity_units":    false,
-		"license_file":            false,
-		"proxy_checks_host_keys":  false,
-		"audit_table_name":        false,
-		"audit_sessions_uri":      false,
-		"audit_events_uri":        false,
-		"pam":                     true,
-		"service_name":            false,
-		"client_idle_timeout":     false,
-		"disconnect_expired_cert": false,
-		"ciphersuites":            false,
-		"ca_pin":                  false,
-		"keep_alive_interval":     false,
-		"keep_alive_count_max":    false,
+		"proxy_protocol":            false,
+		"namespace":                 true,
+		"cluster_name":              true,
+		"trusted_clusters":          true,
+		"pid_file":                  true,
+		"cert_file":                 true,
+		"private_key_file":          true,
+		"cert":                      true,
+		"private_key":               true,
+		"checking_keys":             true,
+		"checking_key_files":        true,
+		"signing_keys":              true,
+		"signing_key_files":         true,
+		"allowed_logins":            true,
+		"teleport":                  true,
+		"enabled":                   true,
+		"ssh_service":               true,
+		"proxy_service":             true,
+		"auth_service":              true,
+		"kubernetes":                true,
+		"kubeconfig_file":           true,
+		"auth_token":                true,
+		"auth_servers":              true,
+		"domain_name":               true,
+		"storage":                   false,
+		"nodename":                  true,
+		"log":                       true,
+		"period":                    true,
+		"connection_limits":         true,
+		"max_connections":           true,
+		"max_users":                 true,
+		"rates":                     true,
+		"commands":                  true,
+		"labels":                    false,
+		"output":                    true,
+		"severity":                  true,
+		"role":                      true,
+		"name":                      true,
+		"type":                      true,
+		"data_dir":                  true,
+		"web_listen_addr":           true,
+		"tunnel_listen_addr":        true,
+		"ssh_listen_addr":           true,
+		"listen_addr":               true,
+		"ca_cert_file":              false,
+		"https_key_file":            true,
+		"https_cert_file":           true,
+		"advertise_ip":              true,
+		"authorities":               true,
+		"keys":                      true,
+		"reverse_tunnels":           true,
+		"addresses":                 true,
+		"oidc_connectors":           true,
+		"id":                        true,
+		"issuer_url":                true,
+		"client_id":                 true,
+		"client_secret":             true,
+		"redirect_url":              true,
+		"acr_values":                true,
+		"provider":                  true,
+		"tokens":                    true,
+		"region":                    true,
+		"table_name":                true,
+		"access_key":                true,
+		"secret_key":                true,
+		"u2f":                       true,
+		"app_id":                    true,
+		"facets":                    true,
+		"authentication":            true,
+		"second_factor":             false,
+		"oidc":                      true,
+		"display":                   false,
+		"scope":                     false,
+		"claims_to_roles":           true,
+		"dynamic_config":            false,
+		"seed_config":               false,
+		"public_addr":               false,
+		"ssh_public_addr":           false,
+		"tunnel_public_addr":        false,
+		"cache":                     true,
+		"ttl":                       false,
+		"issuer":                    false,
+		"permit_user_env":           false,
+		"ciphers":                   false,
+		"kex_algos":                 false,
+		"mac_algos":                 false,
+		"connector_name":            false,
+		"session_recording":         false,
+		"read_capacity_units":       false,
+		"write_capacity_units":      false,
+		"license_file":              false,
+		"proxy_checks_host_keys":    false,
+		"audit_table_name":          false,
+		"audit_sessions_uri":        false,
+		"audit_events_uri":          false,
+		"pam":                       true,
+		"service_name":              false,
+		"client_idle_timeout":       false,
+		"disconnect_expired_cert":   false,
+		"strict_host_check":         false,
+		"ambiguous_host_resolution": false,
+		"ciphersuites":              false,
+		"ca_pin":                    false,
+		"keep_alive_interval":       false,
+		"keep_alive_count_max":      false,
+		"acme":                      true,
+		"email":                     false,
+		"uri":                       false,
+		"version":                   false,
+		"includes":                  false,
+		"dns_resolver":              true,
+		"search_domains":            false,
+		"allowed_cidrs":             false,
+		"auth_dial_timeout":         false,
+		"node_dial_timeout":         false,
+		"tunnel_dial_timeout":       false,
+	}
+
+	// supportedConfigVersions are the values accepted by the top-level
+	// "version" key. v1 (the default when the key is omitted) keeps the
+	// historical, forgiving behavior this package has always had; v2 exists
+	// as a place to anchor future breaking changes to the schema without
+	// surprising files that don't opt in.
+	supportedConfigVersions = map[string]bool{
+		"":   true,
+		"v1": true,
+		"v2": true,
 	}
 )
 
@@ -157,6 +182,15 @@ var (
 //
 // Use config.ReadFromFile() to read the parsed FileConfig from a YAML file.
 type FileConfig struct {
+	// Version is the version of the configuration schema the file was
+	// written against, e.g. "v2". Empty is treated as "v1" for files
+	// written before this field existed.
+	Version string `yaml:"version,omitempty"`
+	// Includes lists paths (resolved relative to the including file) to
+	// other YAML config files whose keys are merged into this one. A key
+	// present in this file always wins over the same key from an include.
+	Includes []string `yaml:"includes,omitempty"`
+
 	Global `yaml:"teleport,omitempty"`
 	Auth   Auth  `yaml:"auth_service,omitempty"`
 	SSH    SSH   `yaml:"ssh_service,omitempty"`
@@ -173,7 +207,7 @@ func ReadFromFile(filePath string) (*FileConfig, error) {
 		return nil, trace.Wrap(err, fmt.Sprintf("failed to open file: %v", filePath))
 	}
 	defer f.Close()
-	return ReadConfig(f)
+	return readConfig(f, filepath.Dir(filePath))
 }
 
 // ReadFromString reads values from base64 encoded byte string
@@ -188,15 +222,37 @@ func ReadFromString(configString string) (*FileConfig, error) {
 
 // ReadConfig reads Teleport configuration from reader in YAML format
 func ReadConfig(reader io.Reader) (*FileConfig, error) {
+	return readConfig(reader, "")
+}
+
+// readConfig backs both ReadConfig and ReadFromFile. baseDir anchors any
+// relative paths listed under "includes"; it is empty when the
+// configuration didn't come from a file on disk, in which case includes
+// must be given as absolute paths.
+func readConfig(reader io.Reader, baseDir string) (*FileConfig, error) {
 	// read & parse YAML config:
 	bytes, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return nil, trace.Wrap(err, "failed reading Teleport configuration")
 	}
+
+	// expand ${VAR} / $VAR references against the process environment
+	// before anything else happens, so they can be used anywhere in the
+	// file, including values that feed into the strict key validation below.
+	bytes = expandEnv(bytes)
+
+	bytes, err = resolveIncludes(bytes, baseDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	var fc FileConfig
 	if err = yaml.Unmarshal(bytes, &fc); err != nil {
 		return nil, trace.BadParameter("failed to parse Teleport configuration: %v", err)
 	}
+	if !supportedConfigVersions[fc.Version] {
+		return nil, trace.BadParameter("unsupported configuration version: %q", fc.Version)
+	}
 	// don't start Teleport with invalid ciphers, kex algorithms, or mac algorithms.
 	err = fc.Check()
 	if err != nil {
@@ -234,6 +290,94 @@ func ReadConfig(reader io.Reader) (*FileConfig, error) {
 	return &fc, nil
 }
 
+// expandEnv replaces ${VAR} and $VAR references in the raw config bytes with
+// the value of the named environment variable, so secrets and per-host
+// values don't have to be hardcoded into the file. Unset variables expand to
+// the empty string, matching familiar shell behavior; use $$ for a literal
+// dollar sign.
+func expandEnv(in []byte) []byte {
+	return []byte(os.Expand(string(in), func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		return os.Getenv(name)
+	}))
+}
+
+// resolveIncludes merges any files listed under the top-level "includes" key
+// into the document, then strips the "includes" key and re-serializes the
+// merged result so the rest of the pipeline can treat it as if it had all
+// been written in a single file. A key already present in the including
+// document always wins over the same key coming from an include; includes
+// are themselves processed recursively, so they may list their own includes.
+func resolveIncludes(raw []byte, baseDir string) ([]byte, error) {
+	var doc YAMLMap
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		// leave malformed YAML alone; the caller will produce a more
+		// specific parse error shortly after this returns.
+		return raw, nil
+	}
+	rawIncludes, ok := doc["includes"]
+	if !ok {
+		return raw, nil
+	}
+	list, ok := rawIncludes.([]interface{})
+	if !ok {
+		return nil, trace.BadParameter("includes: expected a list of file paths")
+	}
+	delete(doc, "includes")
+	for _, item := range list {
+		path, ok := item.(string)
+		if !ok {
+			return nil, trace.BadParameter("includes: expected a list of file paths")
+		}
+		if !filepath.IsAbs(path) {
+			if baseDir == "" {
+				return nil, trace.BadParameter(
+					"includes: %q must be an absolute path when the configuration isn't read from a file", path)
+			}
+			path = filepath.Join(baseDir, path)
+		}
+		includeBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		includeBytes = expandEnv(includeBytes)
+		includeBytes, err = resolveIncludes(includeBytes, filepath.Dir(path))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var includeDoc YAMLMap
+		if err := yaml.Unmarshal(includeBytes, &includeDoc); err != nil {
+			return nil, trace.BadParameter("failed to parse included file %v: %v", path, err)
+		}
+		mergeYAMLMaps(doc, includeDoc)
+	}
+	merged, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return merged, nil
+}
+
+// mergeYAMLMaps copies keys from src into dst wherever dst doesn't already
+// have them, recursing into nested maps. Keys already set in dst are left
+// untouched, so an including file always takes precedence over its includes.
+func mergeYAMLMaps(dst, src YAMLMap) {
+	for k, v := range src {
+		existing, ok := dst[k]
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dstMap, dstIsMap := existing.(YAMLMap)
+		srcMap, srcIsMap := v.(YAMLMap)
+		if dstIsMap && srcIsMap {
+			mergeYAMLMaps(dstMap, srcMap)
+		}
+	}
+}
+
 // MakeSampleFileConfig returns a sample config structure populated by defaults,
 // useful to generate sample configuration files
 func MakeSampleFileConfig() (fc *FileConfig) {
@@ -307,7 +451,8 @@ func (conf *FileConfig) DebugDumpToYAML() string {
 
 // Check ensures that the ciphers, kex algorithms, and mac algorithms set
 // are supported by golang.org/x/crypto/ssh. This ensures we don't start
-// Teleport with invalid configuration.
+// Teleport with invalid configuration. If FIPS mode is on, it further
+// ensures that only FIPS 140-2 approved algorithms were configured.
 func (conf *FileConfig) Check() error {
 	var sc ssh.Config
 	sc.SetDefaults()
@@ -328,6 +473,24 @@ func (conf *FileConfig) Check() error {
 		}
 	}
 
+	if conf.FIPS {
+		for _, c := range conf.Ciphers {
+			if !utils.SliceContainsStr(defaults.FIPSCiphers, c) {
+				return trace.BadParameter("cipher %q is not FIPS 140-2 compliant", c)
+			}
+		}
+		for _, k := range conf.KEXAlgorithms {
+			if !utils.SliceContainsStr(defaults.FIPSKEXAlgorithms, k) {
+				return trace.BadParameter("KEX %q is not FIPS 140-2 compliant", k)
+			}
+		}
+		for _, m := range conf.MACAlgorithms {
+			if !utils.SliceContainsStr(defaults.FIPSMACAlgorithms, m) {
+				return trace.BadParameter("MAC %q is not FIPS 140-2 compliant", m)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -340,9 +503,12 @@ type ConnectionRate struct {
 
 // ConnectionLimits sets up connection limiter
 type ConnectionLimits struct {
-	MaxConnections int64            `yaml:"max_connections"`
-	MaxUsers       int              `yaml:"max_users"`
-	Rates          []ConnectionRate `yaml:"rates,omitempty"`
+	MaxConnections int64 `yaml:"max_connections"`
+	MaxUsers       int   `yaml:"max_users"`
+	// MaxConnectionsPerUser caps the number of simultaneous connections a
+	// single authenticated user may hold open, across all client IPs.
+	MaxConnectionsPerUser int64            `yaml:"max_connections_per_user,omitempty"`
+	Rates                 []ConnectionRate `yaml:"rates,omitempty"`
 }
 
 // Log configures teleport logging
@@ -352,6 +518,13 @@ type Log struct {
 	Output string `yaml:"output,omitempty"`
 	// Severity defines how verbose the log will be. Possible valus are "error", "info", "warn"
 	Severity string `yaml:"severity,omitempty"`
+	// Format controls the log entry encoding. Possible values are "text"
+	// (default) or "json".
+	Format string `yaml:"format,omitempty"`
+	// Components overrides Severity on a per-component basis, e.g.
+	// {"reversetunnel": "debug"} keeps everything else at Severity while
+	// logging the reversetunnel component at debug level.
+	Components map[string]string `yaml:"components,omitempty"`
 }
 
 // Global is 'teleport' (global) section of the config file
@@ -386,6 +559,12 @@ type Global struct {
 
 	// CAPin is the SKPI hash of the CA used to verify the Auth Server.
 	CAPin string `yaml:"ca_pin"`
+
+	// FIPS means Teleport starts in a FedRAMP/FIPS 140-2 compliant
+	// configuration, restricting TLS and SSH algorithms to the FIPS 140-2
+	// approved subset and refusing to start if a non-compliant algorithm
+	// was explicitly configured above.
+	FIPS bool `yaml:"fips,omitempty"`
 }
 
 // CachePolicy is used to control  local cache
@@ -503,6 +682,11 @@ type Auth struct {
 	// relative to the global data dir
 	LicenseFile string `yaml:"license_file,omitempty"`
 
+	// CAKeyParams configures where CA private keys are generated and
+	// stored. If unset, CA keys are generated in this process and stored
+	// as PEM, as Teleport has always done.
+	CAKeyParams *CAKeyParams `yaml:"ca_key_params,omitempty"`
+
 	// FOR INTERNAL USE:
 	// Authorities : 3rd party certificate authorities (CAs) this auth service trusts.
 	Authorities []Authority `yaml:"authorities,omitempty"`
@@ -541,6 +725,17 @@ type Auth struct {
 	// if true, connections with expired client certificates will get disconnected
 	DisconnectExpiredCert services.Bool `yaml:"disconnect_expired_cert"`
 
+	// StrictHostCheck requires the principals in a node's host certificate
+	// to match that node's registered Server resource on every reverse
+	// tunnel registration and Dial, rejecting mismatches.
+	StrictHostCheck services.Bool `yaml:"strict_host_check,omitempty"`
+
+	// AmbiguousHostResolution controls what happens when an SSH dial target
+	// matches more than one registered Server resource by hostname: "error"
+	// (the default) rejects the dial, "most_recent" picks the server that
+	// heartbeated most recently.
+	AmbiguousHostResolution string `yaml:"ambiguous_host_resolution,omitempty"`
+
 	// KubeconfigFile is an optional path to kubeconfig file,
 	// if specified, teleport will use API server address and
 	// trusted certificate authority information from it
@@ -566,6 +761,28 @@ type TrustedCluster struct {
 	TunnelAddr string `yaml:"tunnel_addr,omitempty"`
 }
 
+// CAKeyParams configures where Auth service CA private keys are generated
+// and stored.
+type CAKeyParams struct {
+	// PKCS11 configures a PKCS#11 token (an HSM or cloud KMS exposing a
+	// PKCS#11 interface) to hold CA private keys, with signing operations
+	// delegated to the token instead of happening in this process.
+	PKCS11 *PKCS11Config `yaml:"pkcs11,omitempty"`
+}
+
+// PKCS11Config is the PKCS#11 configuration for an HSM-backed CA keystore.
+type PKCS11Config struct {
+	// ModulePath is the path to the PKCS#11 module (cryptoki library) to load.
+	ModulePath string `yaml:"module_path"`
+	// TokenLabel identifies the token on the HSM that holds, or will hold,
+	// the CA keys.
+	TokenLabel string `yaml:"token_label,omitempty"`
+	// SlotNumber identifies the token by slot number instead of label, if set.
+	SlotNumber *int `yaml:"slot_number,omitempty"`
+	// Pin is the PIN used to authenticate to the token.
+	Pin string `yaml:"pin,omitempty"`
+}
+
 type ClusterName string
 
 func (c ClusterName) Parse() (services.ClusterName, error) {
@@ -753,6 +970,63 @@ type Proxy struct {
 	// endpoint. The hosts in PublicAddr are included in the list of host
 	// principals on the SSH certificate.
 	TunnelPublicAddr utils.Strings `yaml:"tunnel_public_addr,omitempty"`
+
+	// ACME configures automatic certificate management for the proxy's
+	// web listener via an ACME CA such as Let's Encrypt.
+	ACME *ACME `yaml:"acme,omitempty"`
+
+	// DNSResolver configures a fallback resolver used when a requested SSH
+	// target can't be matched against a registered Server resource,
+	// supporting hybrid environments with unregistered legacy hosts.
+	DNSResolver *DNSResolver `yaml:"dns_resolver,omitempty"`
+
+	// AuthDialTimeout, if set, overrides the default dial timeout used when
+	// the proxy connects to an auth server.
+	AuthDialTimeout services.Duration `yaml:"auth_dial_timeout,omitempty"`
+
+	// NodeDialTimeout, if set, overrides the default dial timeout used when
+	// the proxy connects directly to a node's SSH port (i.e. not through a
+	// reverse tunnel).
+	NodeDialTimeout services.Duration `yaml:"node_dial_timeout,omitempty"`
+
+	// TunnelDialTimeout, if set, overrides the default dial timeout used
+	// when the proxy, in recording-at-proxy mode, dials a node to set up
+	// the forwarding server that records the session.
+	TunnelDialTimeout services.Duration `yaml:"tunnel_dial_timeout,omitempty"`
+}
+
+// DNSResolver configures the proxy's fallback DNS resolution for SSH
+// targets that aren't registered Teleport nodes.
+type DNSResolver struct {
+	// SearchDomains is a list of DNS domains to try appending to the
+	// requested host, in order, in addition to the host as given.
+	SearchDomains []string `yaml:"search_domains,omitempty"`
+	// AllowedCIDRs restricts resolved addresses to the given CIDR ranges.
+	// If empty, any resolved address is allowed.
+	AllowedCIDRs []string `yaml:"allowed_cidrs,omitempty"`
+}
+
+// ACME configures the proxy's ACME client.
+type ACME struct {
+	// EnabledFlag turns automatic certificate management on or off.
+	EnabledFlag string `yaml:"enabled,omitempty"`
+	// Email is the contact address given to the ACME CA.
+	Email string `yaml:"email,omitempty"`
+	// URI is the ACME directory URL, defaults to Let's Encrypt's
+	// production directory if unspecified.
+	URI string `yaml:"uri,omitempty"`
+}
+
+// Enabled determines if a given ACME section has been set to 'true'
+func (a *ACME) Enabled() (bool, error) {
+	if a.EnabledFlag == "" {
+		return false, nil
+	}
+	v, err := utils.ParseBool(a.EnabledFlag)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	return v, nil
 }
 
 // Kube is a `kubernetes_service`
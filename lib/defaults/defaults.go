@@ -84,6 +84,16 @@ const (
 	// connection attempts
 	DefaultDialTimeout = 30 * time.Second
 
+	// ReverseTunnelSlowDialThreshold is how long a dial to a target through
+	// the reverse tunnel can take before it's considered slow and logged
+	// with a breakdown of per-phase timing to help diagnose the cause.
+	ReverseTunnelSlowDialThreshold = 1 * time.Second
+
+	// MaxClockSkew is the maximum difference between a node's local clock
+	// and an auth/proxy server's clock before the node's self-test flags
+	// clock drift as a likely cause of certificate validation failures.
+	MaxClockSkew = 30 * time.Second
+
 	// HTTPMaxIdleConns is the max idle connections across all hosts.
 	HTTPMaxIdleConns = 2000
 
@@ -208,6 +218,11 @@ const (
 	// PlaybackRecycleTTL is the TTL for unpacked session playback files
 	PlaybackRecycleTTL = 3 * time.Hour
 
+	// SessionRecordingRetention is the default amount of time a session
+	// recording is kept on disk before the retention janitor removes it.
+	// A value of 0 disables the janitor, preserving recordings indefinitely.
+	SessionRecordingRetention = time.Duration(0)
+
 	// WaitCopyTimeout is how long Teleport will wait for a session to finish
 	// copying data from the PTY after "exit-status" has been received.
 	WaitCopyTimeout = 5 * time.Second
@@ -229,6 +244,11 @@ const (
 	// CallbackTimeout is how long to wait for a response from SSO provider
 	// before timeout.
 	CallbackTimeout = 180 * time.Second
+
+	// HeadlessLoginPort is the fixed local port "tsh login --headless" binds
+	// its SSO callback to, so it can be reached by forwarding the port from
+	// a machine with a browser to a machine that has none.
+	HeadlessLoginPort = 3036
 )
 
 var (
@@ -500,6 +520,17 @@ const (
 	WebsocketResize = "w"
 )
 
+// MaxFileTransferSize is the largest file the web UI will upload or
+// download (over /webapi/sites/:site/namespaces/:namespace/nodes/:server/:login/scp),
+// to keep a single browser-initiated transfer from exhausting proxy memory
+// or bandwidth.
+const MaxFileTransferSize = 1024 * 1024 * 1024 // 1GB
+
+// ExecOutputCaptureSize is the number of bytes of stdout/stderr captured
+// from a non-interactive "exec" request for inclusion in its audit event,
+// to keep a single command's output from bloating the audit log.
+const ExecOutputCaptureSize = 4096
+
 // The following are cryptographic primitives Teleport does not support in
 // it's default configuration.
 const (
@@ -509,6 +540,32 @@ const (
 	HMACSHA196               = "hmac-sha1-96"
 )
 
+// FIPSCiphers is the list of SSH ciphers that Teleport is restricted to when
+// started in FIPS 140-2 mode. Only AES in CTR or GCM mode is allowed; stream
+// ciphers like arcfour and Chacha20-Poly1305 are not FIPS 140-2 approved.
+var FIPSCiphers = []string{
+	"aes128-gcm@openssh.com",
+	"aes128-ctr",
+	"aes192-ctr",
+	"aes256-ctr",
+}
+
+// FIPSKEXAlgorithms is the list of SSH key exchange algorithms Teleport is
+// restricted to when started in FIPS 140-2 mode. Curve25519 is not yet FIPS
+// 140-2 approved, so only NIST curve ECDH is allowed.
+var FIPSKEXAlgorithms = []string{
+	"ecdh-sha2-nistp256",
+	"ecdh-sha2-nistp384",
+	"ecdh-sha2-nistp521",
+}
+
+// FIPSMACAlgorithms is the list of SSH MAC algorithms Teleport is restricted
+// to when started in FIPS 140-2 mode. SHA-1 based MACs are excluded.
+var FIPSMACAlgorithms = []string{
+	"hmac-sha2-256-etm@openssh.com",
+	"hmac-sha2-256",
+}
+
 // WindowsOpenSSHNamedPipe is the address of the named pipe that the
 // OpenSSH agent is on.
 const WindowsOpenSSHNamedPipe = `\\.\pipe\openssh-ssh-agent`
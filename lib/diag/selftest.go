@@ -0,0 +1,221 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diag implements a node-side connection health self-test, used to
+// cut down on the "node won't connect" support back-and-forth by letting an
+// operator run the same checks Teleport's support team would ask for.
+package diag
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// Status is the outcome of a single self-test check.
+type Status string
+
+const (
+	// StatusOK means the check passed.
+	StatusOK Status = "ok"
+	// StatusFailed means the check failed outright.
+	StatusFailed Status = "failed"
+)
+
+// Check is the result of a single self-test check.
+type Check struct {
+	// Name identifies the check, e.g. "auth-server-reachable".
+	Name string
+	// Status is the outcome of the check.
+	Status Status
+	// Details is a human readable explanation, populated on failure (and,
+	// for informational checks like clock skew, on success too).
+	Details string
+}
+
+// Report is the result of a full self-test run.
+type Report struct {
+	// Checks holds the result of every check that was run, in the order
+	// they were run.
+	Checks []Check
+}
+
+// OK returns true if every check in the report passed.
+func (r *Report) OK() bool {
+	for _, check := range r.Checks {
+		if check.Status != StatusOK {
+			return false
+		}
+	}
+	return true
+}
+
+// Config configures a self-test run.
+type Config struct {
+	// AuthServers is the list of auth server addresses this node is
+	// configured to connect to.
+	AuthServers []utils.NetAddr
+	// DataDir is the node's data directory, used to load its host identity
+	// for the certificate validity check.
+	DataDir string
+	// HostUUID is this node's host UUID.
+	HostUUID string
+	// Clock is used to check for clock skew against the auth server, and is
+	// overridden in tests.
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults validates the config and sets default values.
+func (c *Config) CheckAndSetDefaults() error {
+	if len(c.AuthServers) == 0 {
+		return trace.BadParameter("missing parameter AuthServers")
+	}
+	if c.DataDir == "" {
+		return trace.BadParameter("missing parameter DataDir")
+	}
+	if c.HostUUID == "" {
+		return trace.BadParameter("missing parameter HostUUID")
+	}
+	if c.Clock == nil {
+		c.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// RunSelfTest runs the node connection health self-test and returns a
+// report of the outcome. It never returns an error itself; failures are
+// recorded as failed checks in the returned report so that a single
+// unreachable auth server doesn't prevent the rest of the checks from
+// running and being reported.
+func RunSelfTest(cfg Config) (*Report, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	report := &Report{}
+	report.Checks = append(report.Checks, checkAuthServersReachable(cfg.AuthServers))
+	report.Checks = append(report.Checks, checkClockSkew(cfg.AuthServers, cfg.Clock))
+	report.Checks = append(report.Checks, checkCertValidity(cfg.DataDir, cfg.HostUUID, cfg.Clock))
+	return report, nil
+}
+
+// checkAuthServersReachable dials every configured auth server over TCP to
+// confirm the node can at least establish a connection, which rules out
+// firewalls and routing issues before looking any further.
+func checkAuthServersReachable(authServers []utils.NetAddr) Check {
+	var unreachable []string
+	for _, addr := range authServers {
+		conn, err := net.DialTimeout(addr.AddrNetwork, addr.Addr, defaults.DefaultDialTimeout)
+		if err != nil {
+			unreachable = append(unreachable, addr.Addr)
+			continue
+		}
+		conn.Close()
+	}
+	if len(unreachable) > 0 {
+		return Check{
+			Name:    "auth-server-reachable",
+			Status:  StatusFailed,
+			Details: trace.Errorf("could not reach: %v", unreachable).Error(),
+		}
+	}
+	return Check{Name: "auth-server-reachable", Status: StatusOK}
+}
+
+// checkClockSkew sends an HTTPS request to the first reachable auth server
+// and compares the Date header of its response against the local clock.
+// Mismatched clocks are a common, hard to spot cause of certificate
+// validation failures, since SSH and TLS certs are time-bound.
+//
+// The request skips certificate verification: at this point we only care
+// about reaching the server and reading its clock, not authenticating it.
+func checkClockSkew(authServers []utils.NetAddr, clock clockwork.Clock) Check {
+	client := &http.Client{
+		Timeout: defaults.DefaultDialTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	var lastErr error
+	for _, addr := range authServers {
+		resp, err := client.Head(fmt.Sprintf("https://%v/webapi/ping", addr.Addr))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		remoteDate := resp.Header.Get("Date")
+		if remoteDate == "" {
+			lastErr = trace.Errorf("auth server %v did not return a Date header", addr.Addr)
+			continue
+		}
+		remoteNow, err := http.ParseTime(remoteDate)
+		if err != nil {
+			lastErr = trace.Wrap(err)
+			continue
+		}
+		skew := clock.Now().Sub(remoteNow)
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > defaults.MaxClockSkew {
+			return Check{
+				Name:    "clock-skew",
+				Status:  StatusFailed,
+				Details: trace.Errorf("local clock differs from auth server %v by %v, exceeding the %v limit", addr.Addr, skew, defaults.MaxClockSkew).Error(),
+			}
+		}
+		return Check{Name: "clock-skew", Status: StatusOK, Details: fmt.Sprintf("skew from %v: %v", addr.Addr, skew)}
+	}
+	return Check{Name: "clock-skew", Status: StatusFailed, Details: trace.Wrap(lastErr).Error()}
+}
+
+// checkCertValidity loads this node's host identity from disk and confirms
+// its SSH and TLS certificates have not expired.
+func checkCertValidity(dataDir, hostUUID string, clock clockwork.Clock) Check {
+	identity, err := auth.ReadLocalIdentity(dataDir, auth.IdentityID{Role: teleport.RoleNode, HostUUID: hostUUID})
+	if err != nil {
+		return Check{Name: "cert-validity", Status: StatusFailed, Details: trace.Wrap(err).Error()}
+	}
+	now := clock.Now()
+	if identity.Cert != nil {
+		validBefore := time.Unix(int64(identity.Cert.ValidBefore), 0)
+		if identity.Cert.ValidBefore != 0 && now.After(validBefore) {
+			return Check{
+				Name:    "cert-validity",
+				Status:  StatusFailed,
+				Details: trace.Errorf("SSH host certificate expired at %v", validBefore).Error(),
+			}
+		}
+	}
+	if identity.XCert != nil && now.After(identity.XCert.NotAfter) {
+		return Check{
+			Name:    "cert-validity",
+			Status:  StatusFailed,
+			Details: trace.Errorf("TLS certificate expired at %v", identity.XCert.NotAfter).Error(),
+		}
+	}
+	return Check{Name: "cert-validity", Status: StatusOK}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diag
+
+import (
+	"testing"
+
+	"github.com/gravitational/teleport/lib/utils"
+
+	"gopkg.in/check.v1"
+)
+
+func TestDiag(t *testing.T) { check.TestingT(t) }
+
+type SelfTestSuite struct{}
+
+var _ = check.Suite(&SelfTestSuite{})
+
+// TestReportOK makes sure a report is only considered OK when every check
+// in it passed.
+func (s *SelfTestSuite) TestReportOK(c *check.C) {
+	report := &Report{}
+	c.Assert(report.OK(), check.Equals, true)
+
+	report.Checks = append(report.Checks, Check{Status: StatusOK}, Check{Status: StatusOK})
+	c.Assert(report.OK(), check.Equals, true)
+
+	report.Checks = append(report.Checks, Check{Status: StatusFailed})
+	c.Assert(report.OK(), check.Equals, false)
+}
+
+// TestConfigCheckAndSetDefaults makes sure a self-test run can't be started
+// without the parameters it needs to reach an auth server and load a local
+// identity.
+func (s *SelfTestSuite) TestConfigCheckAndSetDefaults(c *check.C) {
+	validAddrs := []utils.NetAddr{{AddrNetwork: "tcp", Addr: "127.0.0.1:3025"}}
+
+	cfg := Config{DataDir: "/var/lib/teleport", HostUUID: "test"}
+	c.Assert(cfg.CheckAndSetDefaults(), check.NotNil)
+
+	cfg = Config{AuthServers: validAddrs, HostUUID: "test"}
+	c.Assert(cfg.CheckAndSetDefaults(), check.NotNil)
+
+	cfg = Config{AuthServers: validAddrs, DataDir: "/var/lib/teleport"}
+	c.Assert(cfg.CheckAndSetDefaults(), check.NotNil)
+
+	cfg = Config{AuthServers: validAddrs, DataDir: "/var/lib/teleport", HostUUID: "test"}
+	c.Assert(cfg.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(cfg.Clock, check.NotNil)
+}
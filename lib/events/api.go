@@ -150,6 +150,12 @@ const (
 	ExecEventCommand = "command"
 	ExecEventCode    = "exitCode"
 	ExecEventError   = "exitError"
+	// ExecEventStdout and ExecEventStderr hold up to
+	// defaults.ExecOutputCaptureSize bytes of the command's stdout/stderr,
+	// so auditors can see what a non-interactive exec returned, not just
+	// what was run. Absent if the command produced no output on that stream.
+	ExecEventStdout = "stdout"
+	ExecEventStderr = "stderr"
 
 	// SubsystemEvent is the result of the execution of a subsystem.
 	SubsystemEvent = "subsystem"
@@ -162,6 +168,69 @@ const (
 	PortForwardSuccess = "success"
 	PortForwardErr     = "error"
 
+	// AgentForwardSignEvent is emitted whenever a forwarded SSH agent is
+	// used to sign a request on behalf of the user.
+	AgentForwardSignEvent      = "agent.sign"
+	AgentForwardKeyFingerprint = "fingerprint"
+
+	// AuthzHookFailureEvent is emitted when a request that passed RBAC is
+	// denied by a registered AuthzHook.
+	AuthzHookFailureEvent = "authz.hook.failure"
+	AuthzHookName         = "hook"
+	AuthzHookReason       = "reason"
+
+	// SessionRecordingDeleteEvent is emitted when a session recording is
+	// removed from disk by the retention janitor.
+	SessionRecordingDeleteEvent = "session.recording.delete"
+	SessionRecordingDeleteSID   = "sid"
+	SessionRecordingDeleteAge   = "age"
+
+	// KubeRequestEvent is emitted when a Kubernetes API request is forwarded
+	// to a cluster's API server, other than exec/attach/port-forward, which
+	// get their own dedicated events.
+	KubeRequestEvent      = "kube.request"
+	KubeRequestVerb       = "verb"
+	KubeRequestPath       = "path"
+	KubeRequestAPIGroup   = "apiGroup"
+	KubeRequestAPIVersion = "apiVersion"
+	KubeRequestResource   = "resource"
+	KubeRequestNamespace  = "namespace"
+	KubeResponseCode      = "responseCode"
+
+	// DatabaseSessionStartEvent is emitted when a client connects to a
+	// database proxied by a db_service.
+	DatabaseSessionStartEvent = "db.session.start"
+	// DatabaseSessionEndEvent is emitted when a database client
+	// disconnects from a db_service.
+	DatabaseSessionEndEvent = "db.session.end"
+	// DatabaseName is the name of the database (as registered with the
+	// db_service) a session was started against.
+	DatabaseName = "db_name"
+	// DatabaseProtocol is the wire protocol of the database a session was
+	// started against, e.g. "postgres".
+	DatabaseProtocol = "db_protocol"
+	// DatabaseUser is the database user the client authenticated as,
+	// where the protocol exposes one (e.g. PostgreSQL's startup message).
+	DatabaseUser = "db_user"
+	// DatabaseSessionQueryEvent is emitted when a db_service forwards a
+	// command to a database that exposes the command in its own wire
+	// protocol (currently only MongoDB; PostgreSQL and MySQL queries are
+	// not parsed out of the opaque byte stream).
+	DatabaseSessionQueryEvent = "db.session.query"
+	// DatabaseQuery is the command or query text a database session
+	// event was captured for.
+	DatabaseQuery = "db_query"
+
+	// WindowsDesktopSessionStartEvent is emitted when a client connects
+	// to a Windows desktop proxied by a windows_desktop_service.
+	WindowsDesktopSessionStartEvent = "windows.desktop.session.start"
+	// WindowsDesktopSessionEndEvent is emitted when a client disconnects
+	// from a Windows desktop proxied by a windows_desktop_service.
+	WindowsDesktopSessionEndEvent = "windows.desktop.session.end"
+	// WindowsDesktop is the name of the desktop (as registered with the
+	// windows_desktop_service) a session was started against.
+	WindowsDesktop = "windows_desktop"
+
 	// AuthAttemptEvent is authentication attempt that either
 	// succeeded or failed based on event status
 	AuthAttemptEvent   = "auth"
@@ -180,6 +249,54 @@ const (
 	// ResizeEvent means that some user resized PTY on the client
 	ResizeEvent  = "resize"
 	TerminalSize = "size" // expressed as 'W:H'
+
+	// SessionEnvironment lists the names of environment variables injected
+	// into the session environment by the user's roles. Values are not
+	// included to avoid leaking secrets into the audit log.
+	SessionEnvironment = "environment"
+
+	// TunnelConnectEvent is emitted when a reverse tunnel agent (node or
+	// trusted cluster) establishes a new connection to a proxy.
+	TunnelConnectEvent = "tunnel.connect"
+	// TunnelDisconnectEvent is emitted when a reverse tunnel agent
+	// connection is marked invalid, either because the agent disconnected
+	// or because it missed its heartbeat window.
+	TunnelDisconnectEvent = "tunnel.disconnect"
+
+	// TunnelType is the type of reverse tunnel, e.g. "node" or "proxy".
+	TunnelType = "tunnel_type"
+	// TunnelProxy is the ID of the proxy the tunnel connection terminates on.
+	TunnelProxy = "proxy_id"
+
+	// TunnelSlowDialEvent is emitted when dialing a target through the
+	// reverse tunnel exceeds the configured slow dial budget.
+	TunnelSlowDialEvent = "tunnel.slow_dial"
+
+	// TunnelRejectEvent is emitted when a reverse tunnel connection or dial
+	// is rejected under strict host checking because the host certificate's
+	// principals don't match the node's registered Server resource.
+	TunnelRejectEvent = "tunnel.reject"
+	// TunnelRejectNode is the node ID a rejected tunnel connection or dial
+	// was for.
+	TunnelRejectNode = "node"
+	// TunnelRejectReason is a human-readable explanation of why a tunnel
+	// connection or dial was rejected.
+	TunnelRejectReason = "reason"
+	// DialDuration is the total time a dial took to complete, formatted as
+	// a Go duration string.
+	DialDuration = "dial_duration"
+	// DialPhases breaks a dial's total duration down by phase, e.g.
+	// connection lookup, TCP dial, SSH handshake, and certificate fetch.
+	DialPhases = "dial_phases"
+
+	// EventHash is the hex-encoded hash of this event, computed over the
+	// previous event's hash and this event's fields. It makes the audit
+	// log tamper-evident: altering or removing an event breaks the chain
+	// for every event that follows it.
+	EventHash = "hash"
+	// EventPrevHash is the hash of the event that directly preceded this
+	// one in emission order, or empty for the first event in a chain.
+	EventPrevHash = "phash"
 )
 
 const (
@@ -129,6 +129,10 @@ type AuditLog struct {
 	// localLog is a local events log used
 	// to emit audit events if no external log has been specified
 	localLog *FileLog
+
+	// chain computes the tamper-evident hash chain stamped on every
+	// emitted event, see EventHash/EventPrevHash.
+	chain hashChain
 }
 
 // AuditLogConfig specifies configuration for AuditLog server
@@ -171,6 +175,17 @@ type AuditLogConfig struct {
 	// deleted
 	PlaybackRecycleTTL time.Duration
 
+	// SessionRecordingRetention is the amount of time a session recording is
+	// kept on disk before the retention janitor deletes it. Zero (the
+	// default) disables the janitor and keeps recordings indefinitely.
+	//
+	// This is a cluster-wide setting. Per-role and per-user retention
+	// overrides would need a new field on the protobuf-generated RoleOptions
+	// message (lib/services/types.pb.go), which requires regenerating that
+	// file from types.proto; that isn't done here, so a single cluster-wide
+	// value is what's enforced today.
+	SessionRecordingRetention time.Duration
+
 	// UploadHandler is a pluggable external upload handler,
 	// used to fetch sessions from external sources
 	UploadHandler UploadHandler
@@ -294,6 +309,7 @@ func NewAuditLog(cfg AuditLogConfig) (*AuditLog, error) {
 	}
 
 	go al.periodicCleanupPlaybacks()
+	go al.periodicCleanupSessionRecordings()
 	go al.periodicSpaceMonitor()
 
 	return al, nil
@@ -699,6 +715,56 @@ func (l *AuditLog) cleanupOldPlaybacks() error {
 	return nil
 }
 
+// cleanupOldSessionRecordings removes session recordings whose index file
+// has not been touched in longer than SessionRecordingRetention, emitting a
+// SessionRecordingDelete audit event for each session removed. It is a
+// no-op when SessionRecordingRetention is unset, matching the existing
+// "zero disables" convention used throughout this config.
+func (l *AuditLog) cleanupOldSessionRecordings() error {
+	if l.SessionRecordingRetention == 0 {
+		return nil
+	}
+	sessionDir := filepath.Join(l.DataDir, l.ServerID, SessionLogsDir, defaults.Namespace)
+	df, err := os.Open(sessionDir)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer df.Close()
+	entries, err := df.Readdir(-1)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	now := l.Clock.Now().UTC()
+	for _, fi := range entries {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".index") {
+			continue
+		}
+		age := now.Sub(fi.ModTime().UTC())
+		if age <= l.SessionRecordingRetention {
+			continue
+		}
+		sid := session.ID(strings.TrimSuffix(fi.Name(), ".index"))
+		index, err := readSessionIndex(l.DataDir, []string{l.ServerID}, defaults.Namespace, sid)
+		if err != nil {
+			l.Warningf("Failed to read session index for %v: %v.", sid, err)
+			continue
+		}
+		for _, fileName := range index.fileNames() {
+			if err := os.Remove(fileName); err != nil {
+				l.Warningf("Failed to remove session recording file %v: %v.", fileName, err)
+			}
+		}
+		if err := l.EmitAuditEvent(SessionRecordingDelete, EventFields{
+			SessionRecordingDeleteSID: string(sid),
+			SessionRecordingDeleteAge: age.String(),
+		}); err != nil {
+			l.Warningf("Failed to emit session recording deletion event for %v: %v.", sid, err)
+		}
+		l.Debugf("Removed session recording %v after %v.", sid, age)
+	}
+	return nil
+}
+
 type readSeekCloser interface {
 	io.Reader
 	io.Seeker
@@ -877,6 +943,22 @@ func (l *AuditLog) fetchSessionEvents(fileName string, afterN int) ([]EventField
 // EmitAuditEvent adds a new event to the log. If emitting fails, a Prometheus
 // counter is incremented.
 func (l *AuditLog) EmitAuditEvent(event Event, fields EventFields) error {
+	// Fill in the common fields (uid, time, code, ...) before computing the
+	// hash so that the hash covers exactly what gets persisted. Emitters
+	// call UpdateEventFields again downstream, but it is a no-op for
+	// fields that are already set.
+	if err := UpdateEventFields(event, fields, l.Clock, l.UIDGenerator); err != nil {
+		l.Warningf("Failed to set up event fields: %v.", err)
+	}
+
+	hash, prevHash, err := l.chain.Next(fields)
+	if err != nil {
+		l.Warningf("Failed to compute audit log hash chain: %v.", err)
+	} else {
+		fields[EventHash] = hash
+		fields[EventPrevHash] = prevHash
+	}
+
 	// If an external logger has been set, use it as the emitter, otherwise
 	// fallback to the local disk based emitter.
 	var emitAuditEvent func(event Event, fields EventFields) error
@@ -888,7 +970,7 @@ func (l *AuditLog) EmitAuditEvent(event Event, fields EventFields) error {
 
 	// Emit the event. If it fails for any reason a Prometheus counter is
 	// incremented.
-	err := emitAuditEvent(event, fields)
+	err = emitAuditEvent(event, fields)
 	if err != nil {
 		auditFailedEmit.Inc()
 		return trace.Wrap(err)
@@ -987,6 +1069,28 @@ func (l *AuditLog) periodicCleanupPlaybacks() {
 	}
 }
 
+// periodicCleanupSessionRecordings runs forever, periodically removing
+// session recordings older than SessionRecordingRetention. It returns
+// immediately, without starting the ticker, if retention is disabled.
+func (l *AuditLog) periodicCleanupSessionRecordings() {
+	if l.SessionRecordingRetention == 0 {
+		return
+	}
+	ticker := time.NewTicker(defaults.LogRotationPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.cleanupOldSessionRecordings(); err != nil {
+				l.Warningf("Error while cleaning up old session recordings: %v.", err)
+			}
+		}
+	}
+}
+
 // periodicSpaceMonitor run forever monitoring how much disk space has been
 // used on disk. Values are emitted to a Prometheus gauge.
 func (l *AuditLog) periodicSpaceMonitor() {
@@ -19,7 +19,6 @@ package events
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -325,8 +324,15 @@ func (a *AuditTestSuite) TestBasicLogging(c *check.C) {
 	// read back what's been written:
 	bytes, err := ioutil.ReadFile(logfile)
 	c.Assert(err, check.IsNil)
-	c.Assert(string(bytes), check.Equals,
-		fmt.Sprintf("{\"apples?\":\"yes\",\"event\":\"user.joined\",\"time\":\"%s\",\"uid\":\"%s\"}\n", now.Format(time.RFC3339), fixtures.UUID))
+
+	var fields EventFields
+	c.Assert(json.Unmarshal(bytes, &fields), check.IsNil)
+	c.Assert(fields.GetString("apples?"), check.Equals, "yes")
+	c.Assert(fields.GetString(EventType), check.Equals, "user.joined")
+	c.Assert(fields.GetTimestamp(), check.Equals, now)
+	c.Assert(fields.GetString(EventID), check.Equals, fixtures.UUID)
+	c.Assert(fields.GetString(EventHash), check.Not(check.Equals), "")
+	c.Assert(fields.GetString(EventPrevHash), check.Equals, "")
 }
 
 // TestLogRotation makes sure that logs are rotated
@@ -360,8 +366,15 @@ func (a *AuditTestSuite) TestLogRotation(c *check.C) {
 		// read back what's been written:
 		bytes, err := ioutil.ReadFile(logfile)
 		c.Assert(err, check.IsNil)
-		contents := fmt.Sprintf("{\"apples?\":\"yes\",\"event\":\"user.joined\",\"time\":\"%s\",\"uid\":\"%s\"}\n", now.Format(time.RFC3339), fixtures.UUID)
-		c.Assert(string(bytes), check.Equals, contents)
+		contents := string(bytes)
+
+		var fields EventFields
+		c.Assert(json.Unmarshal(bytes, &fields), check.IsNil)
+		c.Assert(fields.GetString("apples?"), check.Equals, "yes")
+		c.Assert(fields.GetString(EventType), check.Equals, "user.joined")
+		c.Assert(fields.GetTimestamp(), check.Equals, now)
+		c.Assert(fields.GetString(EventID), check.Equals, fixtures.UUID)
+		c.Assert(fields.GetString(EventHash), check.Not(check.Equals), "")
 
 		// read back the contents using symlink
 		bytes, err = ioutil.ReadFile(filepath.Join(alog.localLog.SymlinkDir, SymlinkFilename))
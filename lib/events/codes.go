@@ -162,6 +162,31 @@ var (
 		Severity: SeverityError,
 		Message:  "User {{.user}} port forwarding request failed: {{.error}}",
 	}
+	// AgentForwardSign is emitted when a forwarded SSH agent signs a
+	// request on behalf of the user.
+	AgentForwardSign = Event{
+		Name:     AgentForwardSignEvent,
+		Code:     AgentForwardSignCode,
+		Severity: SeverityInfo,
+		Message:  "User {{.user}} signed a request with forwarded agent key {{.fingerprint}}",
+	}
+	// AuthzHookFailure is emitted when a request that passed RBAC is denied
+	// by a registered AuthzHook.
+	AuthzHookFailure = Event{
+		Name:     AuthzHookFailureEvent,
+		Code:     AuthzHookFailureCode,
+		Severity: SeverityWarning,
+		Message:  "User {{.user}} denied by authorization hook {{.hook}}: {{.reason}}",
+	}
+	// SessionRecordingDelete is emitted when a session recording is removed
+	// from disk by the retention janitor for exceeding the configured
+	// retention period.
+	SessionRecordingDelete = Event{
+		Name:     SessionRecordingDeleteEvent,
+		Code:     SessionRecordingDeleteCode,
+		Severity: SeverityInfo,
+		Message:  "Session recording {{.sid}} deleted after exceeding retention period ({{.age}})",
+	}
 	// SCPDownload is emitted when a user downloads a file.
 	SCPDownload = Event{
 		Name:     SCPEvent,
@@ -204,6 +229,86 @@ var (
 		Severity: SeverityWarning,
 		Message:  "User {{.user}} failed auth attempt: {{.error}}",
 	}
+	// TunnelConnect is emitted when a reverse tunnel agent connects to a proxy.
+	TunnelConnect = Event{
+		Name:     TunnelConnectEvent,
+		Code:     TunnelConnectCode,
+		Severity: SeverityInfo,
+		Message:  "Reverse tunnel connected from {{.addr.remote}}",
+	}
+	// TunnelDisconnect is emitted when a reverse tunnel agent connection is
+	// marked invalid, whether by disconnect or missed heartbeats.
+	TunnelDisconnect = Event{
+		Name:     TunnelDisconnectEvent,
+		Code:     TunnelDisconnectCode,
+		Severity: SeverityWarning,
+		Message:  "Reverse tunnel disconnected from {{.addr.remote}}: {{.reason}}",
+	}
+	// TunnelSlowDial is emitted when dialing a target through the reverse
+	// tunnel took longer than the configured slow dial budget.
+	TunnelSlowDial = Event{
+		Name:     TunnelSlowDialEvent,
+		Code:     TunnelSlowDialCode,
+		Severity: SeverityWarning,
+		Message:  "Dial to {{.addr.remote}} took {{.dial_duration}}, exceeding the slow dial budget",
+	}
+	// TunnelReject is emitted when a reverse tunnel connection or dial is
+	// rejected because the host certificate's principals don't match the
+	// node's registered Server resource under strict host checking.
+	TunnelReject = Event{
+		Name:     TunnelRejectEvent,
+		Code:     TunnelRejectCode,
+		Severity: SeverityWarning,
+		Message:  "Reverse tunnel rejected for {{.node}}: {{.reason}}",
+	}
+	// KubeRequest is emitted when a Kubernetes API request is forwarded to a
+	// cluster's API server.
+	KubeRequest = Event{
+		Name:     KubeRequestEvent,
+		Code:     KubeRequestCode,
+		Severity: SeverityInfo,
+		Message:  "User {{.user}} sent {{.verb}} request to {{.path}}",
+	}
+	// DatabaseSessionStart is emitted when a client connects to a database
+	// proxied by a db_service.
+	DatabaseSessionStart = Event{
+		Name:     DatabaseSessionStartEvent,
+		Code:     DatabaseSessionStartCode,
+		Severity: SeverityInfo,
+		Message:  "User {{.user}} connected to database {{.db_name}}",
+	}
+	// DatabaseSessionEnd is emitted when a database client disconnects
+	// from a db_service.
+	DatabaseSessionEnd = Event{
+		Name:     DatabaseSessionEndEvent,
+		Code:     DatabaseSessionEndCode,
+		Severity: SeverityInfo,
+		Message:  "User {{.user}} disconnected from database {{.db_name}}",
+	}
+	// DatabaseSessionQuery is emitted when a db_service forwards a
+	// command to a database whose wire protocol exposes the command.
+	DatabaseSessionQuery = Event{
+		Name:     DatabaseSessionQueryEvent,
+		Code:     DatabaseSessionQueryCode,
+		Severity: SeverityInfo,
+		Message:  "User {{.user}} executed {{.db_query}} on database {{.db_name}}",
+	}
+	// WindowsDesktopSessionStart is emitted when a client connects to a
+	// Windows desktop proxied by a windows_desktop_service.
+	WindowsDesktopSessionStart = Event{
+		Name:     WindowsDesktopSessionStartEvent,
+		Code:     WindowsDesktopSessionStartCode,
+		Severity: SeverityInfo,
+		Message:  "User {{.user}} connected to Windows desktop {{.windows_desktop}}",
+	}
+	// WindowsDesktopSessionEnd is emitted when a client disconnects from
+	// a Windows desktop proxied by a windows_desktop_service.
+	WindowsDesktopSessionEnd = Event{
+		Name:     WindowsDesktopSessionEndEvent,
+		Code:     WindowsDesktopSessionEndCode,
+		Severity: SeverityInfo,
+		Message:  "User {{.user}} disconnected from Windows desktop {{.windows_desktop}}",
+	}
 )
 
 var (
@@ -257,4 +362,32 @@ var (
 	ClientDisconnectCode = "T3006I"
 	// AuthAttemptFailureCode is the auth attempt failure event code.
 	AuthAttemptFailureCode = "T3007W"
+	// TunnelConnectCode is the reverse tunnel connect event code.
+	TunnelConnectCode = "T3008I"
+	// TunnelDisconnectCode is the reverse tunnel disconnect event code.
+	TunnelDisconnectCode = "T3008W"
+	// KubeRequestCode is the Kubernetes API request event code.
+	KubeRequestCode = "T3009I"
+	// DatabaseSessionStartCode is the database session start event code.
+	DatabaseSessionStartCode = "T3010I"
+	// DatabaseSessionEndCode is the database session end event code.
+	DatabaseSessionEndCode = "T3011I"
+	// DatabaseSessionQueryCode is the database session query event code.
+	DatabaseSessionQueryCode = "T3012I"
+	// WindowsDesktopSessionStartCode is the desktop session start event code.
+	WindowsDesktopSessionStartCode = "T3013I"
+	// WindowsDesktopSessionEndCode is the desktop session end event code.
+	WindowsDesktopSessionEndCode = "T3014I"
+	// TunnelSlowDialCode is the reverse tunnel slow dial event code.
+	TunnelSlowDialCode = "T3015W"
+	// AgentForwardSignCode is the forwarded agent signing event code.
+	AgentForwardSignCode = "T3016I"
+	// AuthzHookFailureCode is the authorization hook denial event code.
+	AuthzHookFailureCode = "T3017W"
+	// SessionRecordingDeleteCode is the session recording retention deletion
+	// event code.
+	SessionRecordingDeleteCode = "T3018I"
+	// TunnelRejectCode is the reverse tunnel principal mismatch rejection
+	// event code.
+	TunnelRejectCode = "T3019W"
 )
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// asciicastHeader is the asciicast v2 header record, written as the first
+// line of the file. See https://github.com/asciinema/asciinema/blob/master/doc/asciicast-v2.md.
+type asciicastHeader struct {
+	Version   int     `json:"version"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Timestamp int64   `json:"timestamp,omitempty"`
+	Title     string  `json:"title,omitempty"`
+	// Unused but documented is left out of the struct on purpose -- we only
+	// emit the fields asciinema actually reads.
+}
+
+// ExportAsciicast converts a recorded session (its events and raw output
+// stream) into an asciinema v2 recording. The returned bytes are the
+// complete file contents: a header line followed by one "[time, \"o\",
+// data]" line per print event.
+func ExportAsciicast(sessionEvents []EventFields, stream []byte) ([]byte, error) {
+	var out bytes.Buffer
+
+	width, height := 80, 25
+	for _, e := range sessionEvents {
+		if e.GetString(EventType) != SessionStartEvent {
+			continue
+		}
+		if w, h, err := parseTerminalSize(e.GetString(TerminalSize)); err == nil {
+			width, height = w, h
+		}
+		break
+	}
+
+	header, err := json.Marshal(asciicastHeader{
+		Version: 2,
+		Width:   width,
+		Height:  height,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out.Write(header)
+	out.WriteByte('\n')
+
+	for _, e := range sessionEvents {
+		if e.GetString(EventType) != SessionPrintEvent {
+			continue
+		}
+		offset := e.GetInt(SessionByteOffset)
+		length := e.GetInt(SessionPrintEventBytes)
+		if offset < 0 || offset+length > len(stream) {
+			continue
+		}
+		data := string(stream[offset : offset+length])
+		seconds := float64(e.GetInt(SessionEventTimestamp)) / 1000.0
+		record, err := json.Marshal([]interface{}{seconds, "o", data})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out.Write(record)
+		out.WriteByte('\n')
+	}
+
+	return out.Bytes(), nil
+}
+
+// ExportText converts a recorded session into a plain text transcript: the
+// raw terminal output with timing and control sequences stripped out, in
+// the order the session produced it.
+func ExportText(sessionEvents []EventFields, stream []byte) ([]byte, error) {
+	var out bytes.Buffer
+	for _, e := range sessionEvents {
+		if e.GetString(EventType) != SessionPrintEvent {
+			continue
+		}
+		offset := e.GetInt(SessionByteOffset)
+		length := e.GetInt(SessionPrintEventBytes)
+		if offset < 0 || offset+length > len(stream) {
+			continue
+		}
+		out.Write(stream[offset : offset+length])
+	}
+	return out.Bytes(), nil
+}
+
+// parseTerminalSize parses a "W:H" terminal size string, as stored in the
+// TerminalSize event field.
+func parseTerminalSize(size string) (width, height int, err error) {
+	parts := strings.Split(size, ":")
+	if len(parts) != 2 {
+		return 0, 0, trace.BadParameter("invalid terminal size %q", size)
+	}
+	if _, err := fmt.Sscanf(parts[0], "%d", &width); err != nil {
+		return 0, 0, trace.Wrap(err)
+	}
+	if _, err := fmt.Sscanf(parts[1], "%d", &height); err != nil {
+		return 0, 0, trace.Wrap(err)
+	}
+	return width, height, nil
+}
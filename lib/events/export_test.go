@@ -0,0 +1,55 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"strings"
+
+	"gopkg.in/check.v1"
+)
+
+type ExportSuite struct{}
+
+var _ = check.Suite(&ExportSuite{})
+
+func (s *ExportSuite) TestExportText(c *check.C) {
+	stream := []byte("hello world")
+	sessionEvents := []EventFields{
+		{EventType: SessionPrintEvent, SessionByteOffset: 0, SessionPrintEventBytes: 5},
+		{EventType: SessionPrintEvent, SessionByteOffset: 5, SessionPrintEventBytes: 6},
+	}
+
+	text, err := ExportText(sessionEvents, stream)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(text), check.Equals, "hello world")
+}
+
+func (s *ExportSuite) TestExportAsciicast(c *check.C) {
+	stream := []byte("hi")
+	sessionEvents := []EventFields{
+		{EventType: SessionStartEvent, TerminalSize: "100:30"},
+		{EventType: SessionPrintEvent, SessionByteOffset: 0, SessionPrintEventBytes: 2, SessionEventTimestamp: 50},
+	}
+
+	out, err := ExportAsciicast(sessionEvents, stream)
+	c.Assert(err, check.IsNil)
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	c.Assert(lines, check.HasLen, 2)
+	c.Assert(lines[0], check.Equals, `{"version":2,"width":100,"height":30}`)
+	c.Assert(lines[1], check.Equals, `[0.05,"o","hi"]`)
+}
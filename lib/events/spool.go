@@ -0,0 +1,165 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// SpoolingEmitterConfig configures a SpoolingEmitter.
+type SpoolingEmitterConfig struct {
+	// Emitter is the underlying audit log events are emitted to. Typically
+	// this is the node's auth client.
+	Emitter IAuditLog
+	// SpoolDir is the directory spooled events are written to while the
+	// emitter is unreachable.
+	SpoolDir string
+	// MaxSpoolFiles caps the number of events kept on disk so an extended
+	// outage cannot fill the node's disk. Once the cap is hit, new events
+	// are dropped (the oldest spooled events are kept, since they are
+	// closest to being successfully replayed).
+	MaxSpoolFiles int
+}
+
+// CheckAndSetDefaults checks and sets default values.
+func (cfg *SpoolingEmitterConfig) CheckAndSetDefaults() error {
+	if cfg.Emitter == nil {
+		return trace.BadParameter("missing parameter Emitter")
+	}
+	if cfg.SpoolDir == "" {
+		return trace.BadParameter("missing parameter SpoolDir")
+	}
+	if cfg.MaxSpoolFiles == 0 {
+		cfg.MaxSpoolFiles = 10000
+	}
+	return nil
+}
+
+// SpoolingEmitter wraps an IAuditLog and, when EmitAuditEvent fails (most
+// commonly because the auth server is unreachable), writes the event to a
+// bounded on-disk spool instead of dropping it or blocking the session
+// that triggered it. ReplaySpool should be called periodically (e.g. from
+// a reconnect handler) to flush spooled events back to the real emitter.
+//
+// Every spooled event carries the unique ID assigned to it by
+// UpdateEventFields, so a replay that races with the node recovering
+// connectivity on its own cannot double-emit: the auth server's audit log
+// is keyed by that same ID.
+type SpoolingEmitter struct {
+	IAuditLog
+	cfg SpoolingEmitterConfig
+
+	mu sync.Mutex
+}
+
+// NewSpoolingEmitter returns a new SpoolingEmitter.
+func NewSpoolingEmitter(cfg SpoolingEmitterConfig) (*SpoolingEmitter, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := os.MkdirAll(cfg.SpoolDir, 0700); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return &SpoolingEmitter{
+		IAuditLog: cfg.Emitter,
+		cfg:       cfg,
+	}, nil
+}
+
+// EmitAuditEvent emits event to the underlying emitter. If that fails, the
+// event is written to the on-disk spool instead of being dropped.
+func (s *SpoolingEmitter) EmitAuditEvent(event Event, fields EventFields) error {
+	err := s.IAuditLog.EmitAuditEvent(event, fields)
+	if err == nil {
+		return nil
+	}
+	if spoolErr := s.spool(event, fields); spoolErr != nil {
+		log.WithError(spoolErr).Warn("Failed to spool audit event to disk, event will be lost.")
+	}
+	return trace.Wrap(err)
+}
+
+type spooledEvent struct {
+	Event  Event       `json:"event"`
+	Fields EventFields `json:"fields"`
+}
+
+// spool writes a single event to SpoolDir, unless the spool is already at
+// MaxSpoolFiles.
+func (s *SpoolingEmitter) spool(event Event, fields EventFields) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := ioutil.ReadDir(s.cfg.SpoolDir)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if len(entries) >= s.cfg.MaxSpoolFiles {
+		return trace.LimitExceeded("audit event spool is full (%v files), dropping event", s.cfg.MaxSpoolFiles)
+	}
+
+	id := fields.GetID()
+	if id == "" {
+		id = fields.GetString(EventTime)
+	}
+	data, err := json.Marshal(spooledEvent{Event: event, Fields: fields})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	path := filepath.Join(s.cfg.SpoolDir, id+".json")
+	return trace.ConvertSystemError(ioutil.WriteFile(path, data, 0600))
+}
+
+// ReplaySpool attempts to re-emit every event currently in the spool to the
+// underlying emitter, in the order they were spooled. Events that succeed
+// are removed from disk; the first failure stops the replay so that the
+// remaining (older) events are retried again on the next call.
+func (s *SpoolingEmitter) ReplaySpool() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := ioutil.ReadDir(s.cfg.SpoolDir)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(s.cfg.SpoolDir, entry.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		var se spooledEvent
+		if err := json.Unmarshal(data, &se); err != nil {
+			log.WithError(err).Warnf("Discarding corrupt spooled event %v.", entry.Name())
+			os.Remove(path)
+			continue
+		}
+		if err := s.IAuditLog.EmitAuditEvent(se.Event, se.Fields); err != nil {
+			return trace.Wrap(err)
+		}
+		os.Remove(path)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+type flakyEmitter struct {
+	MockAuditLog
+	down    bool
+	emitted int
+}
+
+func (f *flakyEmitter) EmitAuditEvent(event Event, fields EventFields) error {
+	if f.down {
+		return trace.ConnectionProblem(nil, "auth server unreachable")
+	}
+	f.emitted++
+	return f.MockAuditLog.EmitAuditEvent(event, fields)
+}
+
+type SpoolSuite struct{}
+
+var _ = check.Suite(&SpoolSuite{})
+
+func (s *SpoolSuite) TestSpoolAndReplay(c *check.C) {
+	dir, err := ioutil.TempDir("", "spool")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(dir)
+
+	emitter := &flakyEmitter{down: true}
+	spooler, err := NewSpoolingEmitter(SpoolingEmitterConfig{
+		Emitter:  emitter,
+		SpoolDir: dir,
+	})
+	c.Assert(err, check.IsNil)
+
+	err = spooler.EmitAuditEvent(Event{Name: "test"}, EventFields{EventID: "one"})
+	c.Assert(err, check.NotNil)
+
+	entries, err := ioutil.ReadDir(dir)
+	c.Assert(err, check.IsNil)
+	c.Assert(entries, check.HasLen, 1)
+
+	emitter.down = false
+	c.Assert(spooler.ReplaySpool(), check.IsNil)
+	c.Assert(emitter.emitted, check.Equals, 1)
+
+	entries, err = ioutil.ReadDir(dir)
+	c.Assert(err, check.IsNil)
+	c.Assert(entries, check.HasLen, 0)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/gravitational/trace"
+)
+
+// TypedEvent is implemented by every strongly typed audit event. Typed
+// events are the replacement for the historical EventFields bag: each
+// event kind gets its own Go struct, and consumers that want exhaustive
+// handling can switch over GetType() instead of probing map keys.
+//
+// This is the first step of the migration described in RFD 17 -- the
+// wire format is still plain JSON (matching how EventFields is persisted
+// today). Moving these to gRPC oneof messages is tracked separately and
+// will reuse the same struct layout.
+type TypedEvent interface {
+	// GetType returns the event type, e.g. "session.start".
+	GetType() string
+}
+
+// SessionStartTypedEvent is emitted when a new interactive or non-interactive
+// session is created on a node.
+type SessionStartTypedEvent struct {
+	// SessionID is the unique ID of the session.
+	SessionID string `json:"sid"`
+	// ServerID is the UUID of the node the session was started on.
+	ServerID string `json:"server_id"`
+	// Login is the OS login used for the session.
+	Login string `json:"login"`
+	// User is the Teleport user that started the session.
+	User string `json:"user"`
+}
+
+// GetType returns the event type.
+func (e *SessionStartTypedEvent) GetType() string { return SessionStartEvent }
+
+// ExecTypedEvent is emitted when a non-interactive command is executed
+// on a node (i.e. `tsh ssh host command`, not a shell).
+type ExecTypedEvent struct {
+	// SessionID is the unique ID of the session the command ran in.
+	SessionID string `json:"sid"`
+	// ServerID is the UUID of the node the command ran on.
+	ServerID string `json:"server_id"`
+	// User is the Teleport user that ran the command.
+	User string `json:"user"`
+	// Command is the full command line that was executed.
+	Command string `json:"command"`
+	// ExitCode is the process exit code.
+	ExitCode string `json:"exitCode"`
+	// Error, if set, describes why the command could not be executed.
+	Error string `json:"exitError,omitempty"`
+}
+
+// GetType returns the event type.
+func (e *ExecTypedEvent) GetType() string { return ExecEvent }
+
+// SCPTypedEvent is emitted for file transfers performed over the SCP
+// subsystem.
+type SCPTypedEvent struct {
+	// SessionID is the unique ID of the session the transfer ran in.
+	SessionID string `json:"sid"`
+	// User is the Teleport user that initiated the transfer.
+	User string `json:"user"`
+	// Path is the remote path that was read from or written to.
+	Path string `json:"path"`
+	// Action is either "upload" or "download".
+	Action string `json:"action"`
+}
+
+// GetType returns the event type.
+func (e *SCPTypedEvent) GetType() string { return SCPEvent }
+
+// AuthFailureTypedEvent is emitted whenever an authentication attempt
+// (local, SSO, or certificate-based) fails.
+type AuthFailureTypedEvent struct {
+	// User is the user that attempted to authenticate, if known.
+	User string `json:"user"`
+	// Error describes why authentication failed.
+	Error string `json:"error"`
+}
+
+// GetType returns the event type.
+func (e *AuthFailureTypedEvent) GetType() string { return UserLoginEvent }
+
+// ToEventFields converts a typed event into the legacy EventFields map
+// used by the audit log backends and the web UI. This keeps every
+// existing emitter and consumer working while typed events are rolled
+// out incrementally.
+func ToEventFields(e TypedEvent) (EventFields, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var fields EventFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	fields[EventType] = e.GetType()
+	return fields, nil
+}
+
+// FromEventFields is the compatibility shim that reconstructs a typed
+// event from an EventFields map read back from an older (untyped) audit
+// log. It returns trace.NotFound if the event type has no typed
+// counterpart yet.
+func FromEventFields(fields EventFields) (TypedEvent, error) {
+	data, err := json.Marshal(map[string]interface{}(fields))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var target TypedEvent
+	switch fields.GetType() {
+	case SessionStartEvent:
+		target = &SessionStartTypedEvent{}
+	case ExecEvent:
+		target = &ExecTypedEvent{}
+	case SCPEvent:
+		target = &SCPTypedEvent{}
+	case UserLoginEvent:
+		target = &AuthFailureTypedEvent{}
+	default:
+		return nil, trace.NotFound("no typed event registered for %q", fields.GetType())
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return target, nil
+}
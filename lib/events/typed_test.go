@@ -0,0 +1,47 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"gopkg.in/check.v1"
+)
+
+type TypedEventSuite struct{}
+
+var _ = check.Suite(&TypedEventSuite{})
+
+func (s *TypedEventSuite) TestRoundTrip(c *check.C) {
+	start := &SessionStartTypedEvent{
+		SessionID: "sid-1",
+		ServerID:  "server-1",
+		Login:     "root",
+		User:      "alice",
+	}
+
+	fields, err := ToEventFields(start)
+	c.Assert(err, check.IsNil)
+	c.Assert(fields.GetType(), check.Equals, SessionStartEvent)
+
+	typed, err := FromEventFields(fields)
+	c.Assert(err, check.IsNil)
+	c.Assert(typed, check.DeepEquals, start)
+}
+
+func (s *TypedEventSuite) TestUnknownType(c *check.C) {
+	_, err := FromEventFields(EventFields{EventType: "unknown.event"})
+	c.Assert(err, check.NotNil)
+}
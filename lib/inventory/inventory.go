@@ -0,0 +1,116 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inventory tracks the set of agents (nodes, proxies, and other
+// teleport services) that are currently checked in with this auth server.
+//
+// It is the foundation for a future persistent control stream between
+// agents and auth: today, entries are populated from the existing
+// heartbeat/keep-alive traffic agents already send, which lets the auth
+// server answer "who is connected right now, and when did we last hear
+// from them". Carrying a Hello (version, running services) and
+// server-initiated commands (request re-register, update labels) over
+// that same stream requires extending the keep-alive wire message, which
+// is out of scope here: it's generated from a .proto definition and has
+// to be changed there, with the protobuf bindings regenerated to match.
+package inventory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// Hello is the handshake an agent's control stream identifies itself
+// with: who it is, what it runs, and what version it's on.
+type Hello struct {
+	// ServerID is the unique ID of the connecting server
+	ServerID string
+	// Version is the teleport version reported by the connecting agent
+	Version string
+	// Services lists the teleport services the agent is running,
+	// e.g. "node", "proxy", "auth"
+	Services []string
+}
+
+// Handle is a snapshot of one agent's presence in the inventory
+type Handle struct {
+	Hello    Hello
+	LastSeen time.Time
+}
+
+// Controller tracks the set of agents currently checked in with this auth
+// server instance
+type Controller struct {
+	mu      sync.Mutex
+	clock   clockwork.Clock
+	handles map[string]Handle
+}
+
+// NewController returns a new, empty inventory controller
+func NewController() *Controller {
+	return &Controller{
+		clock:   clockwork.NewRealClock(),
+		handles: make(map[string]Handle),
+	}
+}
+
+// SetClock overrides the clock used to timestamp check-ins, for tests
+func (c *Controller) SetClock(clock clockwork.Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock
+}
+
+// RegisterHeartbeat records that an agent has checked in, updating its
+// last-seen time and Hello information
+func (c *Controller) RegisterHeartbeat(hello Hello) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handles[hello.ServerID] = Handle{
+		Hello:    hello,
+		LastSeen: c.clock.Now().UTC(),
+	}
+}
+
+// Unregister removes an agent from the inventory, e.g. once its heartbeat
+// stream has closed
+func (c *Controller) Unregister(serverID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.handles, serverID)
+}
+
+// GetHandle returns the current handle for a connected agent
+func (c *Controller) GetHandle(serverID string) (Handle, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	handle, ok := c.handles[serverID]
+	return handle, ok
+}
+
+// Connected returns a snapshot of all agents currently tracked as
+// checked in
+func (c *Controller) Connected() []Handle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Handle, 0, len(c.handles))
+	for _, handle := range c.handles {
+		out = append(out, handle)
+	}
+	return out
+}
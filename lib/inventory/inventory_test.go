@@ -0,0 +1,59 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type InventorySuite struct{}
+
+var _ = check.Suite(&InventorySuite{})
+
+func (s *InventorySuite) TestRegisterHeartbeat(c *check.C) {
+	clock := clockwork.NewFakeClock()
+	ctrl := NewController()
+	ctrl.SetClock(clock)
+
+	_, ok := ctrl.GetHandle("node1")
+	c.Assert(ok, check.Equals, false)
+	c.Assert(ctrl.Connected(), check.HasLen, 0)
+
+	ctrl.RegisterHeartbeat(Hello{ServerID: "node1", Services: []string{"node"}})
+	handle, ok := ctrl.GetHandle("node1")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(handle.Hello.ServerID, check.Equals, "node1")
+	c.Assert(handle.LastSeen, check.Equals, clock.Now().UTC())
+	c.Assert(ctrl.Connected(), check.HasLen, 1)
+
+	clock.Advance(time.Minute)
+	ctrl.RegisterHeartbeat(Hello{ServerID: "node1", Services: []string{"node"}})
+	handle, ok = ctrl.GetHandle("node1")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(handle.LastSeen, check.Equals, clock.Now().UTC())
+
+	ctrl.Unregister("node1")
+	_, ok = ctrl.GetHandle("node1")
+	c.Assert(ok, check.Equals, false)
+	c.Assert(ctrl.Connected(), check.HasLen, 0)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+// UpgradeSchedule is an operator-declared rollout plan for bringing
+// connected agents up to a target teleport version.
+//
+// Waves are rolled out in order: wave N is only offered once every agent
+// selected by wave N-1 has either reported the target version or dropped
+// out of the inventory. This lets an operator stage a rollout (e.g. 10%
+// of nodes, then the rest) and stop between waves if the first wave
+// regresses.
+//
+// Driving an agent through an upgrade — fetching the target version,
+// verifying its release signature, and restarting the agent process in
+// place — happens outside of this controller: it requires the agent to
+// expose a download/verify/restart capability over its control
+// connection, which in turn requires a new message on the heartbeat wire
+// format. That message is generated from a .proto definition, so adding
+// it is out of scope here; see the package doc comment in inventory.go.
+type UpgradeSchedule struct {
+	// TargetVersion is the teleport version every agent should converge on
+	TargetVersion string
+	// Waves partitions the rollout into ordered batches of agents,
+	// identified by server ID
+	Waves [][]string
+}
+
+// NextWave returns the server IDs of the next wave that still needs to be
+// offered the upgrade, or nil if the schedule is exhausted. A wave is
+// considered done once every agent in it either reports TargetVersion or
+// is no longer connected.
+func (c *Controller) NextWave(schedule UpgradeSchedule) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, wave := range schedule.Waves {
+		if !c.waveCompleteLocked(wave, schedule.TargetVersion) {
+			return wave
+		}
+	}
+	return nil
+}
+
+func (c *Controller) waveCompleteLocked(wave []string, targetVersion string) bool {
+	for _, serverID := range wave {
+		handle, ok := c.handles[serverID]
+		if !ok {
+			// agent is no longer connected, treat as done
+			continue
+		}
+		if handle.Hello.Version != targetVersion {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import "gopkg.in/check.v1"
+
+func (s *InventorySuite) TestNextWave(c *check.C) {
+	ctrl := NewController()
+	ctrl.RegisterHeartbeat(Hello{ServerID: "node1", Version: "4.0.0"})
+	ctrl.RegisterHeartbeat(Hello{ServerID: "node2", Version: "4.0.0"})
+	ctrl.RegisterHeartbeat(Hello{ServerID: "node3", Version: "4.0.0"})
+
+	schedule := UpgradeSchedule{
+		TargetVersion: "4.1.0",
+		Waves: [][]string{
+			{"node1"},
+			{"node2", "node3"},
+		},
+	}
+
+	c.Assert(ctrl.NextWave(schedule), check.DeepEquals, []string{"node1"})
+
+	// node1 upgrades, first wave is now complete, second wave is next
+	ctrl.RegisterHeartbeat(Hello{ServerID: "node1", Version: "4.1.0"})
+	c.Assert(ctrl.NextWave(schedule), check.DeepEquals, []string{"node2", "node3"})
+
+	// an agent that drops out of the inventory no longer blocks its wave
+	ctrl.Unregister("node2")
+	c.Assert(ctrl.NextWave(schedule), check.DeepEquals, []string{"node2", "node3"})
+	ctrl.RegisterHeartbeat(Hello{ServerID: "node3", Version: "4.1.0"})
+	c.Assert(ctrl.NextWave(schedule), check.IsNil)
+}
@@ -1,10 +1,12 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/client"
@@ -23,6 +25,13 @@ var log = logrus.WithFields(logrus.Fields{
 })
 
 // UpdateKubeconfig adds Teleport configuration to kubeconfig.
+//
+// The credentials are embedded directly rather than pointed at an "exec:"
+// credential plugin entry (which would let kubectl call "tsh kube
+// credentials" on demand and always get a fresh cert): the vendored
+// client-go in this tree predates exec credential plugin support and its
+// clientcmdapi.AuthInfo has no Exec field to populate. Run "tsh kube login"
+// again after relogging in to refresh the embedded certificate.
 func UpdateKubeconfig(tc *client.TeleportClient) error {
 	config, err := LoadKubeConfig()
 	if err != nil {
@@ -54,7 +63,7 @@ func UpdateKubeconfig(tc *client.TeleportClient) error {
 		ClientKeyData:         creds.Priv,
 	}
 	config.Clusters[clusterName] = &clientcmdapi.Cluster{
-		Server: clusterAddr,
+		Server:                   clusterAddr,
 		CertificateAuthorityData: certAuthorities,
 	}
 
@@ -161,3 +170,44 @@ func kubeconfigFromEnv() string {
 
 	return configpath
 }
+
+// execCredential and execCredentialStatus mirror the Kubernetes
+// client.authentication.k8s.io/v1beta1 ExecCredential API, which a
+// credential plugin prints to stdout for kubectl to consume.
+type execCredential struct {
+	Kind       string               `json:"kind"`
+	APIVersion string               `json:"apiVersion"`
+	Status     execCredentialStatus `json:"status"`
+}
+
+type execCredentialStatus struct {
+	ExpirationTimestamp   string `json:"expirationTimestamp,omitempty"`
+	ClientCertificateData string `json:"clientCertificateData"`
+	ClientKeyData         string `json:"clientKeyData"`
+}
+
+// FormatExecCredential returns the JSON document a Kubernetes exec
+// credential plugin prints to stdout. It's used by "tsh kube credentials",
+// which can be wired into a kubeconfig "exec:" entry by hand so kubectl
+// picks up a fresh Teleport certificate on every invocation instead of the
+// one UpdateKubeconfig embedded at "tsh kube login" time.
+func FormatExecCredential(tc *client.TeleportClient) ([]byte, error) {
+	key, err := tc.LocalAgent().GetKey()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	expiry, err := key.TLSCertValidBefore()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	cred := execCredential{
+		Kind:       "ExecCredential",
+		APIVersion: "client.authentication.k8s.io/v1beta1",
+		Status: execCredentialStatus{
+			ExpirationTimestamp:   expiry.Format(time.RFC3339),
+			ClientCertificateData: string(key.TLSCert),
+			ClientKeyData:         string(key.Priv),
+		},
+	}
+	return json.Marshal(cred)
+}
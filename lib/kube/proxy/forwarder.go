@@ -497,6 +497,36 @@ func (f *Forwarder) exec(ctx *authContext, w http.ResponseWriter, req *http.Requ
 			events.RemoteAddr:      req.RemoteAddr,
 			events.TerminalSize:    termParams.Serialize(),
 		})
+
+		// Register the session with the session server so it shows up
+		// alongside SSH sessions in the active sessions list, and keep it
+		// alive with periodic heartbeats for as long as the exec/attach is
+		// running, the same way an interactive SSH session does.
+		now := time.Now().UTC()
+		if err := f.Client.CreateSession(session.Session{
+			ID:             sessionID,
+			Namespace:      f.Namespace,
+			Login:          ctx.User.GetName(),
+			Created:        now,
+			LastActive:     now,
+			ServerID:       f.ServerID,
+			TerminalParams: termParams,
+		}); err != nil {
+			f.Warningf("Failed to create session tracker for %v: %v.", sessionID, err)
+		}
+		heartbeatStopC := make(chan struct{})
+		go f.heartbeatSession(sessionID, heartbeatStopC)
+		defer func() {
+			close(heartbeatStopC)
+			active := false
+			if err := f.Client.UpdateSession(session.UpdateRequest{
+				Namespace: f.Namespace,
+				ID:        sessionID,
+				Active:    &active,
+			}); err != nil {
+				f.Warningf("Failed to mark session tracker %v as inactive: %v.", sessionID, err)
+			}
+		}()
 	}
 
 	if err := f.setupForwardingHeaders(ctx, sess, req); err != nil {
@@ -651,6 +681,91 @@ func (f *Forwarder) setupForwardingHeaders(ctx *authContext, sess *clusterSessio
 	return nil
 }
 
+// heartbeatSession periodically marks sessionID as active in the session
+// server until stopC is closed, so that a long-running kubectl exec/attach
+// continues to show up in the active sessions list rather than expiring
+// after a single TTL period.
+func (f *Forwarder) heartbeatSession(sessionID session.ID, stopC chan struct{}) {
+	ticker := time.NewTicker(defaults.SessionRefreshPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			active := true
+			if err := f.Client.UpdateSession(session.UpdateRequest{
+				Namespace: f.Namespace,
+				ID:        sessionID,
+				Active:    &active,
+			}); err != nil {
+				f.Warningf("Unable to update session %v as active: %v.", sessionID, err)
+			}
+		case <-stopC:
+			return
+		}
+	}
+}
+
+// emitRequestAuditEvent emits an audit event for a Kubernetes API request
+// forwarded by catchAll. Requests that establish an exec, attach, or
+// port-forward session are audited separately, with their own dedicated
+// event types, once the session completes.
+func (f *Forwarder) emitRequestAuditEvent(ctx *authContext, req *http.Request) {
+	apiGroup, apiVersion, namespace, resource := parseResourcePath(req.URL.Path)
+	f.AuditLog.EmitAuditEvent(events.KubeRequest, events.EventFields{
+		events.EventProtocol:         events.EventProtocolKube,
+		events.EventLogin:            ctx.User.GetName(),
+		events.EventUser:             ctx.User.GetName(),
+		events.LocalAddr:             ctx.cluster.targetAddr,
+		events.RemoteAddr:            req.RemoteAddr,
+		events.KubeRequestVerb:       req.Method,
+		events.KubeRequestPath:       req.URL.Path,
+		events.KubeRequestAPIGroup:   apiGroup,
+		events.KubeRequestAPIVersion: apiVersion,
+		events.KubeRequestNamespace:  namespace,
+		events.KubeRequestResource:   resource,
+	})
+}
+
+// parseResourcePath does a best-effort extraction of the API group,
+// version, namespace and resource kind from a Kubernetes API request path,
+// for audit logging purposes. It understands the two path shapes the
+// Kubernetes API server uses:
+//
+//	/api/<version>/namespaces/<namespace>/<resource>/...
+//	/apis/<group>/<version>/namespaces/<namespace>/<resource>/...
+//
+// and their cluster-scoped (no namespace) equivalents.
+func parseResourcePath(path string) (apiGroup, apiVersion, namespace, resource string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 0 {
+		return "", "", "", ""
+	}
+	switch parts[0] {
+	case "api":
+		if len(parts) < 2 {
+			return "", "", "", ""
+		}
+		apiVersion = parts[1]
+		parts = parts[2:]
+	case "apis":
+		if len(parts) < 3 {
+			return "", "", "", ""
+		}
+		apiGroup, apiVersion = parts[1], parts[2]
+		parts = parts[3:]
+	default:
+		return "", "", "", ""
+	}
+	if len(parts) > 0 && parts[0] == "namespaces" && len(parts) > 1 {
+		namespace = parts[1]
+		parts = parts[2:]
+	}
+	if len(parts) > 0 {
+		resource = parts[0]
+	}
+	return apiGroup, apiVersion, namespace, resource
+}
+
 // catchAll forwards all HTTP requests to the target k8s API server
 func (f *Forwarder) catchAll(ctx *authContext, w http.ResponseWriter, req *http.Request) (interface{}, error) {
 	sess, err := f.getOrCreateClusterSession(*ctx)
@@ -660,6 +775,7 @@ func (f *Forwarder) catchAll(ctx *authContext, w http.ResponseWriter, req *http.
 	if err := f.setupForwardingHeaders(ctx, sess, req); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	f.emitRequestAuditEvent(ctx, req)
 	sess.forwarder.ServeHTTP(w, req)
 	return nil, nil
 }
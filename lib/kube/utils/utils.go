@@ -45,6 +45,30 @@ func GetKubeConfig(configPath string) (*rest.Config, error) {
 	return rest.InClusterConfig()
 }
 
+// GetKubeConfigForAllContexts reads configPath and returns a rest.Config for
+// every context it defines, keyed by context name. This lets a single
+// kube_service process serve every Kubernetes cluster listed in the file,
+// rather than only the current-context one that GetKubeConfig returns.
+func GetKubeConfigForAllContexts(configPath string) (map[string]*rest.Config, error) {
+	kubeconfig, err := clientcmd.LoadFromFile(configPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	configs := make(map[string]*rest.Config, len(kubeconfig.Contexts))
+	for contextName := range kubeconfig.Contexts {
+		restConfig, err := clientcmd.NewNonInteractiveClientConfig(
+			*kubeconfig, contextName, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to build client config for context %q", contextName)
+		}
+		configs[contextName] = restConfig
+	}
+	if len(configs) == 0 {
+		return nil, trace.BadParameter("kubeconfig %v defines no contexts", configPath)
+	}
+	return configs, nil
+}
+
 // EncodeClusterName encodes cluster name for SNI matching
 //
 // For example:
@@ -23,24 +23,43 @@ import (
 	"github.com/gravitational/oxy/connlimit"
 	"github.com/gravitational/oxy/utils"
 	"github.com/gravitational/trace"
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 )
 
-// ConnectionsLimiter is a network connection limiter and tracker
+var connectionsRejected = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "teleport_connection_limit_exceeded_total",
+		Help: "Number of connections rejected for exceeding a connection limit, by quota kind",
+	},
+	[]string{"kind"},
+)
+
+func init() {
+	prometheus.MustRegister(connectionsRejected)
+}
+
+// ConnectionsLimiter is a network connection limiter and tracker. It tracks
+// simultaneous connections in two independent buckets keyed by client IP
+// and by authenticated user, each with its own configurable quota.
 type ConnectionsLimiter struct {
 	*connlimit.ConnLimiter
 	*sync.Mutex
-	connections    map[string]int64
-	maxConnections int64
+	connections           map[string]int64
+	maxConnections        int64
+	userConnections       map[string]int64
+	maxConnectionsPerUser int64
 }
 
 // NewConnectionsLimiter returns new connection limiter, in case if connection
 // limits are not set, they won't be tracked
 func NewConnectionsLimiter(config LimiterConfig) (*ConnectionsLimiter, error) {
 	limiter := ConnectionsLimiter{
-		Mutex:          &sync.Mutex{},
-		maxConnections: config.MaxConnections,
-		connections:    make(map[string]int64),
+		Mutex:                 &sync.Mutex{},
+		maxConnections:        config.MaxConnections,
+		connections:           make(map[string]int64),
+		maxConnectionsPerUser: config.MaxConnectionsPerUser,
+		userConnections:       make(map[string]int64),
 	}
 
 	ipExtractor, err := utils.NewExtractor("client.ip")
@@ -62,47 +81,81 @@ func (l *ConnectionsLimiter) WrapHandle(h http.Handler) {
 	l.ConnLimiter.Wrap(h)
 }
 
-// AcquireConnection acquires connection and bumps counter
+// AcquireConnection acquires connection and bumps counter for the client IP
 func (l *ConnectionsLimiter) AcquireConnection(token string) error {
-	l.Lock()
-	defer l.Unlock()
+	if err := acquire(l.Mutex, l.connections, token, l.maxConnections); err != nil {
+		connectionsRejected.WithLabelValues("ip").Inc()
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// ReleaseConnection decrements the per-IP counter
+func (l *ConnectionsLimiter) ReleaseConnection(token string) {
+	release(l.Mutex, l.connections, token, l.maxConnections)
+}
 
-	if l.maxConnections == 0 {
+// AcquireConnectionForUser acquires a connection and bumps the counter for
+// the authenticated user, independent of how many client IPs they are
+// connecting from.
+//
+// Per-role quotas are not implemented here: by the time a connection reaches
+// this limiter (lib/sshutils.Server.HandleConnection) only the SSH username
+// from the handshake is known, not the user's resolved roles, which would
+// require threading an access point through the transport layer.
+func (l *ConnectionsLimiter) AcquireConnectionForUser(user string) error {
+	if err := acquire(l.Mutex, l.userConnections, user, l.maxConnectionsPerUser); err != nil {
+		connectionsRejected.WithLabelValues("user").Inc()
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// ReleaseConnectionForUser decrements the per-user counter
+func (l *ConnectionsLimiter) ReleaseConnectionForUser(user string) {
+	release(l.Mutex, l.userConnections, user, l.maxConnectionsPerUser)
+}
+
+// acquire bumps counts[token], enforcing max (a max of 0 means unlimited).
+func acquire(mu *sync.Mutex, counts map[string]int64, token string, max int64) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if max == 0 {
 		return nil
 	}
 
-	numberOfConnections, exists := l.connections[token]
+	numberOfConnections, exists := counts[token]
 	if !exists {
-		l.connections[token] = 1
+		counts[token] = 1
 		return nil
 	}
-	if numberOfConnections >= l.maxConnections {
+	if numberOfConnections >= max {
 		return trace.LimitExceeded(
 			"too many connections from %v: %v, max is %v",
-			token, numberOfConnections, l.maxConnections)
+			token, numberOfConnections, max)
 	}
-	l.connections[token] = numberOfConnections + 1
+	counts[token] = numberOfConnections + 1
 	return nil
 }
 
-// ReleaseConnection decrements the counter
-func (l *ConnectionsLimiter) ReleaseConnection(token string) {
-
-	l.Lock()
-	defer l.Unlock()
+// release decrements counts[token], removing it once it reaches zero.
+func release(mu *sync.Mutex, counts map[string]int64, token string, max int64) {
+	mu.Lock()
+	defer mu.Unlock()
 
-	if l.maxConnections == 0 {
+	if max == 0 {
 		return
 	}
 
-	numberOfConnections, exists := l.connections[token]
+	numberOfConnections, exists := counts[token]
 	if !exists {
 		log.Errorf("Trying to set negative number of connections")
 	} else {
 		if numberOfConnections <= 1 {
-			delete(l.connections, token)
+			delete(counts, token)
 		} else {
-			l.connections[token] = numberOfConnections - 1
+			counts[token] = numberOfConnections - 1
 		}
 	}
 }
@@ -37,8 +37,12 @@ type Limiter struct {
 type LimiterConfig struct {
 	// Rates set ups rate limits
 	Rates []Rate
-	// MaxConnections configures maximum number of connections
+	// MaxConnections configures maximum number of connections per client IP
 	MaxConnections int64
+	// MaxConnectionsPerUser configures the maximum number of simultaneous
+	// connections a single authenticated user may hold open, regardless of
+	// how many client IPs they connect from. 0 means no limit.
+	MaxConnectionsPerUser int64
 	// MaxNumberOfUsers controls maximum number of simultaneously active users
 	MaxNumberOfUsers int
 	// Clock is an optional parameter, if not set, will use system time
@@ -20,7 +20,6 @@ limitations under the License.
 // mux, _ := multiplexer.New(Config{Listener: listener})
 // mux.SSH() // returns listener getting SSH connections
 // mux.TLS() // returns listener getting TLS connections
-//
 package multiplexer
 
 import (
@@ -275,6 +274,23 @@ func detect(conn net.Conn, enableProxyProtocol bool) (*Conn, error) {
 				return nil, trace.Wrap(err)
 			}
 			// repeat the cycle to detect the protocol
+		case ProtoProxyV2:
+			if !enableProxyProtocol {
+				return nil, trace.BadParameter("proxy protocol support is disabled")
+			}
+			if proxyLine != nil {
+				return nil, trace.BadParameter("duplicate proxy line")
+			}
+			// detectProto only peeked the signature, consume it for real
+			// before ReadProxyLineV2 reads the rest of the header.
+			if _, err := reader.Discard(len(proxyV2Signature)); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			proxyLine, err = ReadProxyLineV2(reader)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			// repeat the cycle to detect the protocol
 		case ProtoTLS, ProtoSSH:
 			return &Conn{
 				protocol:  proto,
@@ -297,6 +313,8 @@ const (
 	ProtoSSH
 	// ProtoProxy is a HAProxy proxy line protocol
 	ProtoProxy
+	// ProtoProxyV2 is a HAProxy binary proxy protocol v2
+	ProtoProxyV2
 )
 
 var (
@@ -307,9 +325,11 @@ var (
 
 func detectProto(in []byte) (int, error) {
 	switch {
-	// reader peeks only 3 bytes, slice the longer proxy prefix
+	// reader peeks only 3 bytes, slice the longer proxy prefixes
 	case bytes.HasPrefix(in, proxyPrefix[:3]):
 		return ProtoProxy, nil
+	case bytes.HasPrefix(in, proxyV2Signature[:3]):
+		return ProtoProxyV2, nil
 	case bytes.HasPrefix(in, sshPrefix):
 		return ProtoSSH, nil
 	case bytes.HasPrefix(in, tlsPrefix):
@@ -19,6 +19,7 @@ package multiplexer
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -182,6 +183,65 @@ func (s *MuxSuite) TestProxy(c *check.C) {
 	c.Assert(out, check.Equals, remoteAddr.String())
 }
 
+// TestProxyV2 tests PROXY protocol version 2 support, including a trailing
+// TLV
+func (s *MuxSuite) TestProxyV2(c *check.C) {
+	ports, err := utils.GetFreeTCPPorts(1)
+	c.Assert(err, check.IsNil)
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", ports[0]))
+	c.Assert(err, check.IsNil)
+
+	mux, err := New(Config{
+		Listener:            listener,
+		EnableProxyProtocol: true,
+	})
+	c.Assert(err, check.IsNil)
+	go mux.Serve()
+	defer mux.Close()
+
+	backend1 := &httptest.Server{
+		Listener: mux.TLS(),
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, r.RemoteAddr)
+		}),
+		},
+	}
+	backend1.StartTLS()
+	defer backend1.Close()
+
+	remoteAddr := net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8000}
+	tlv := []byte{0x02, 0x00, 0x03, 'f', 'o', 'o'} // type 0x02, 3-byte value "foo"
+	addrs := []byte{127, 0, 0, 1, 127, 0, 0, 1, 0x1F, 0x40, 0x23, 0x28}
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x21, 0x11) // version 2, PROXY command; AF_INET, STREAM
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(addrs)+len(tlv)))
+	header = append(header, length...)
+	header = append(header, addrs...)
+	header = append(header, tlv...)
+
+	parsedURL, err := url.Parse(backend1.URL)
+	c.Assert(err, check.IsNil)
+
+	conn, err := net.Dial("tcp", parsedURL.Host)
+	c.Assert(err, check.IsNil)
+	defer conn.Close()
+	// send proxy protocol v2 header first before establishing TLS connection
+	_, err = conn.Write(header)
+	c.Assert(err, check.IsNil)
+
+	// upgrade connection to TLS
+	tlsConn := tls.Client(conn, clientConfig(backend1))
+	defer tlsConn.Close()
+
+	// make sure the TLS call succeeded and we got remote address
+	// correctly
+	out, err := utils.RoundtripWithConn(tlsConn)
+	c.Assert(err, check.IsNil)
+	c.Assert(out, check.Equals, remoteAddr.String())
+}
+
 // TestDisabledProxy makes sure the connection gets dropped
 // when Proxy line support protocol is turned off
 func (s *MuxSuite) TestDisabledProxy(c *check.C) {
@@ -49,6 +49,10 @@ type ProxyLine struct {
 	Protocol    string
 	Source      net.TCPAddr
 	Destination net.TCPAddr
+	// TLVs holds the type-length-value extensions attached by a PROXY
+	// protocol v2 header, if any. Always empty for a v1 line, which has
+	// no extension mechanism.
+	TLVs []TLV
 }
 
 // String returns on-the wire string representation of the proxy line
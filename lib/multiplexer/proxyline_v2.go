@@ -0,0 +1,151 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multiplexer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+
+	"github.com/gravitational/trace"
+)
+
+// proxyV2Signature is the fixed 12-byte signature that starts every PROXY
+// protocol v2 header.
+// https://www.haproxy.org/download/2.2/doc/proxy-protocol.txt
+var proxyV2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+const (
+	proxyV2CmdLocal = 0x0
+	proxyV2CmdProxy = 0x1
+
+	proxyV2FamilyUnspec = 0x0
+	proxyV2FamilyInet   = 0x1
+	proxyV2FamilyInet6  = 0x2
+	proxyV2FamilyUnix   = 0x3
+)
+
+// TLV is a type-length-value extension attached to a PROXY protocol v2
+// header, for example PP2_TYPE_AUTHORITY (the SNI/ALPN-unaware ingress's
+// view of the TLS server name) or PP2_TYPE_SSL (client certificate info).
+// Teleport does not currently interpret any TLV type, but parses and
+// exposes them so that callers inspecting a ProxyLine don't silently lose
+// data an upstream load balancer attached.
+type TLV struct {
+	Type  byte
+	Value []byte
+}
+
+// ReadProxyLineV2 reads a binary PROXY protocol v2 header from reader. The
+// 12-byte signature itself must already have been consumed by the caller
+// (detect peeks it to pick the protocol); ReadProxyLineV2 reads everything
+// that follows it.
+func ReadProxyLineV2(reader *bufio.Reader) (*ProxyLine, error) {
+	// verb/version byte, address family/protocol byte, 2 byte big endian
+	// length of everything that follows.
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, trace.Wrap(err, "failed to read proxy protocol v2 header")
+	}
+
+	version := header[0] >> 4
+	command := header[0] & 0x0F
+	if version != 0x2 {
+		return nil, trace.BadParameter("unsupported proxy protocol version %v", version)
+	}
+
+	family := header[1] >> 4
+	length := binary.BigEndian.Uint16(header[2:4])
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, trace.Wrap(err, "failed to read proxy protocol v2 body")
+	}
+
+	// LOCAL connections (health checks from the proxy itself) carry no
+	// meaningful source/destination; the caller should fall back to the
+	// real TCP connection's addresses.
+	if command == proxyV2CmdLocal {
+		return nil, nil
+	}
+	if command != proxyV2CmdProxy {
+		return nil, trace.BadParameter("unsupported proxy protocol v2 command %v", command)
+	}
+
+	var proto string
+	var addrLen int
+	switch family {
+	case proxyV2FamilyInet:
+		proto = TCP4
+		addrLen = 4 + 4 + 2 + 2
+	case proxyV2FamilyInet6:
+		proto = TCP6
+		addrLen = 16 + 16 + 2 + 2
+	case proxyV2FamilyUnspec, proxyV2FamilyUnix:
+		// UNSPEC carries no address, and AF_UNIX addresses aren't
+		// meaningful TCP peers; skip straight to the TLVs, if any.
+		return &ProxyLine{
+			Protocol: UNKNOWN,
+			TLVs:     parseTLVs(body),
+		}, nil
+	default:
+		return nil, trace.BadParameter("unsupported proxy protocol v2 address family %v", family)
+	}
+	if len(body) < addrLen {
+		return nil, trace.BadParameter("proxy protocol v2 header too short for address family")
+	}
+
+	var sourceIP, destIP net.IP
+	var sourcePort, destPort uint16
+	if family == proxyV2FamilyInet {
+		sourceIP = net.IP(body[0:4])
+		destIP = net.IP(body[4:8])
+		sourcePort = binary.BigEndian.Uint16(body[8:10])
+		destPort = binary.BigEndian.Uint16(body[10:12])
+	} else {
+		sourceIP = net.IP(body[0:16])
+		destIP = net.IP(body[16:32])
+		sourcePort = binary.BigEndian.Uint16(body[32:34])
+		destPort = binary.BigEndian.Uint16(body[34:36])
+	}
+
+	return &ProxyLine{
+		Protocol:    proto,
+		Source:      net.TCPAddr{IP: sourceIP, Port: int(sourcePort)},
+		Destination: net.TCPAddr{IP: destIP, Port: int(destPort)},
+		TLVs:        parseTLVs(body[addrLen:]),
+	}, nil
+}
+
+// parseTLVs parses the trailing type-length-value records of a PROXY
+// protocol v2 header. Malformed trailing bytes are ignored rather than
+// failing the whole header, since TLVs are informational.
+func parseTLVs(b []byte) []TLV {
+	var tlvs []TLV
+	for len(b) >= 3 {
+		typ := b[0]
+		length := binary.BigEndian.Uint16(b[1:3])
+		b = b[3:]
+		if int(length) > len(b) {
+			break
+		}
+		tlvs = append(tlvs, TLV{Type: typ, Value: b[:length]})
+		b = b[length:]
+	}
+	return tlvs
+}
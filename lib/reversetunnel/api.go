@@ -24,6 +24,7 @@ import (
 	"golang.org/x/crypto/ssh/agent"
 
 	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/trace"
 )
 
@@ -48,6 +49,17 @@ type DialParams struct {
 	// Principals are additonal principals that need to be added to the host
 	// certificate.
 	Principals []string
+
+	// TargetServer is the node being dialed, if the caller has already
+	// resolved one. It is optional; when set, its
+	// services.SessionRecordingModeLabel is consulted to override the
+	// cluster-wide session recording mode for this dial.
+	TargetServer services.Server
+
+	// Context carries the trace span (if any) started by the caller, so the
+	// dial path can attach its own spans underneath it. Defaults to
+	// context.Background() if unset.
+	Context context.Context
 }
 
 // CheckAndSetDefaults makes sure the minimal parameters are set.
@@ -58,6 +70,9 @@ func (d *DialParams) CheckAndSetDefaults() error {
 	if d.To == nil {
 		return trace.BadParameter("parameter To required")
 	}
+	if d.Context == nil {
+		d.Context = context.Background()
+	}
 
 	return nil
 }
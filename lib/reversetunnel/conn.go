@@ -97,6 +97,12 @@ type connConfig struct {
 
 	// clusterName is the name of the cluster this tunnel is associated with.
 	clusterName string
+
+	// principals is the set of valid principals from the host certificate
+	// presented when this connection was registered. It's retained so that
+	// a Dial against this connection can be re-checked under strict host
+	// checking.
+	principals []string
 }
 
 func newRemoteConn(cfg *connConfig) *remoteConn {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,8 +29,12 @@ import (
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/auth"
 	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/srv/agentless"
 	"github.com/gravitational/teleport/lib/srv/forward"
+	"github.com/gravitational/teleport/lib/tracing"
+	"github.com/gravitational/teleport/lib/utils"
 	"github.com/gravitational/teleport/lib/utils/proxy"
 
 	"github.com/gravitational/trace"
@@ -147,7 +152,7 @@ func (s *localSite) DialAuthServer() (conn net.Conn, err error) {
 
 	// try and dial to one of them, as soon as we are successful, return the net.Conn
 	for _, authServer := range authServers {
-		conn, err = net.DialTimeout("tcp", authServer.GetAddr(), defaults.DefaultDialTimeout)
+		conn, err = net.DialTimeout("tcp", authServer.GetAddr(), s.srv.Config.AuthDialTimeout)
 		if err == nil {
 			return conn, nil
 		}
@@ -157,54 +162,139 @@ func (s *localSite) DialAuthServer() (conn net.Conn, err error) {
 	return nil, trace.ConnectionProblem(err, "unable to connect to auth server")
 }
 
-func (s *localSite) Dial(params DialParams) (net.Conn, error) {
-	err := params.CheckAndSetDefaults()
+func (s *localSite) Dial(params DialParams) (conn net.Conn, err error) {
+	start := time.Now()
+	defer func() {
+		dialLatencies.Observe(time.Since(start).Seconds())
+	}()
+
+	timer := newDialPhaseTimer()
+	defer func() {
+		s.maybeEmitSlowDial(params, timer, err)
+	}()
+
+	err = params.CheckAndSetDefaults()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
+	ctx, span := tracing.StartSpan(params.Context, "localSite.Dial")
+	span.SetAttribute("to", params.To.String())
+	defer span.End()
+	params.Context = ctx
+
 	// Try and see if any of the principals match a node that is heartbeating
 	// over the tunnel. If a matching node is found, connect to it over the tunnel.
 	rconn, ok := s.findMatchingConn(params.Principals)
+	timer.mark("conn_lookup")
 	if ok {
-		return s.chanTransportConn(rconn)
+		clusterConfig, err := s.accessPoint.GetClusterConfig()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if clusterConfig.GetStrictHostCheck() && params.TargetServer != nil {
+			if err := s.checkStrictHostDial(rconn, params.TargetServer); err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+		timer.mark("cluster_config")
+		conn, err = s.chanTransportConn(params.Context, rconn)
+		timer.mark("tunnel_channel")
+		return conn, err
 	}
 
 	clusterConfig, err := s.accessPoint.GetClusterConfig()
+	timer.mark("cluster_config")
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
+	recordingMode := clusterConfig.GetSessionRecording()
+	if params.TargetServer != nil {
+		if override, ok := params.TargetServer.GetAllLabels()[services.SessionRecordingModeLabel]; ok {
+			switch override {
+			case services.RecordAtProxy, services.RecordAtNode, services.RecordOff:
+				recordingMode = override
+			default:
+				s.log.Warnf("Ignoring invalid %v label value %q on node %v.",
+					services.SessionRecordingModeLabel, override, params.TargetServer.GetName())
+			}
+		}
+	}
+
 	// if the proxy is in recording mode use the agent to dial and build a
 	// in-memory forwarding server
-	if clusterConfig.GetSessionRecording() == services.RecordAtProxy {
+	if recordingMode == services.RecordAtProxy {
 		if params.UserAgent == nil {
 			return nil, trace.BadParameter("user agent missing")
 		}
-		return s.dialWithAgent(params)
+		return s.dialWithAgent(params, timer)
 	}
 
-	return s.DialTCP(params.From, params.To)
+	conn, err = s.DialTCP(params.From, params.To)
+	timer.mark("tcp_dial")
+	return conn, err
+}
+
+// dialAgentless dials target through the agentless transport named by
+// method, using the AWS instance ID and region from target's labels.
+func (s *localSite) dialAgentless(ctx context.Context, method string, target services.Server) (net.Conn, error) {
+	labels := target.GetAllLabels()
+	transport, err := agentless.NewTransport(agentless.Config{
+		Method:     method,
+		InstanceID: labels[services.AWSInstanceIDLabel],
+		Region:     labels[services.AWSRegionLabel],
+	})
+	if err != nil {
+		return nil, trace.Wrap(err, "node %v", target.GetName())
+	}
+	conn, err := transport.Dial(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err, "node %v", target.GetName())
+	}
+	return conn, nil
 }
 
 func (s *localSite) DialTCP(from net.Addr, to net.Addr) (net.Conn, error) {
 	s.log.Debugf("Dialing from %v to %v", from, to)
 
 	dialer := proxy.DialerFromEnvironment(to.String())
-	return dialer.DialTimeout(to.Network(), to.String(), defaults.DefaultDialTimeout)
+	return dialer.DialTimeout(to.Network(), to.String(), s.srv.Config.NodeDialTimeout)
 }
 
-func (s *localSite) dialWithAgent(params DialParams) (net.Conn, error) {
+// dialWithAgent dials the target server directly and wraps the connection in
+// an in-memory forwarding server that presents a host certificate from the
+// cache and records the session. The actual SSH handshake with the target
+// happens asynchronously inside the forwarding server once it starts
+// serving, so it isn't reflected in timer; the phases below cover the part
+// of the dial that happens synchronously, here, on the hot path.
+func (s *localSite) dialWithAgent(params DialParams, timer *dialPhaseTimer) (net.Conn, error) {
+	_, span := tracing.StartSpan(params.Context, "localSite.dialWithAgent")
+	defer span.End()
+
 	s.log.Debugf("Dialing with an agent from %v to %v.", params.From, params.To)
 
 	// Get a host certificate for the forwarding node from the cache.
 	hostCertificate, err := s.certificateCache.GetHostCertificate(params.Address, params.Principals)
+	timer.mark("cert_fetch")
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	// get a net.Conn to the target server
-	targetConn, err := net.DialTimeout(params.To.Network(), params.To.String(), defaults.DefaultDialTimeout)
+	// get a net.Conn to the target server. if the target is labeled for an
+	// agentless transport, it has no direct route we can dial, so use that
+	// transport instead of a plain TCP dial.
+	var targetConn net.Conn
+	if params.TargetServer != nil {
+		if method, ok := params.TargetServer.GetAllLabels()[services.AgentlessTransportLabel]; ok {
+			targetConn, err = s.dialAgentless(params.Context, method, params.TargetServer)
+		} else {
+			targetConn, err = net.DialTimeout(params.To.Network(), params.To.String(), s.srv.Config.TunnelDialTimeout)
+		}
+	} else {
+		targetConn, err = net.DialTimeout(params.To.Network(), params.To.String(), s.srv.Config.TunnelDialTimeout)
+	}
+	timer.mark("tcp_dial")
 	if err != nil {
 		return nil, err
 	}
@@ -232,6 +322,7 @@ func (s *localSite) dialWithAgent(params DialParams) (net.Conn, error) {
 
 	// return a connection to the forwarding server
 	conn, err := remoteServer.Dial()
+	timer.mark("forward_server_setup")
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -239,6 +330,66 @@ func (s *localSite) dialWithAgent(params DialParams) (net.Conn, error) {
 	return conn, nil
 }
 
+// dialPhaseTimer breaks a dial's total duration down into the time spent in
+// each named phase, so that an unusually slow dial can be diagnosed.
+type dialPhaseTimer struct {
+	start     time.Time
+	lastMark  time.Time
+	phases    []string
+	durations []time.Duration
+}
+
+func newDialPhaseTimer() *dialPhaseTimer {
+	now := time.Now()
+	return &dialPhaseTimer{start: now, lastMark: now}
+}
+
+// mark records how long has elapsed since the previous mark (or since the
+// timer was created, for the first call) as having been spent in phase.
+func (t *dialPhaseTimer) mark(phase string) {
+	now := time.Now()
+	t.phases = append(t.phases, phase)
+	t.durations = append(t.durations, now.Sub(t.lastMark))
+	t.lastMark = now
+}
+
+func (t *dialPhaseTimer) total() time.Duration {
+	return time.Since(t.start)
+}
+
+// String renders the recorded phases as "phase=duration" pairs, in the
+// order they were marked.
+func (t *dialPhaseTimer) String() string {
+	parts := make([]string, len(t.phases))
+	for i, phase := range t.phases {
+		parts[i] = fmt.Sprintf("%v=%v", phase, t.durations[i])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maybeEmitSlowDial emits an audit event with a per-phase timing breakdown
+// when a successful dial took longer than the configured slow dial
+// threshold, to make intermittent slowness diagnosable.
+func (s *localSite) maybeEmitSlowDial(params DialParams, timer *dialPhaseTimer, dialErr error) {
+	if dialErr != nil {
+		return
+	}
+	total := timer.total()
+	if total < s.srv.Config.SlowDialThreshold {
+		return
+	}
+	s.log.Warnf("Slow dial to %v took %v: %v", params.To, total, timer)
+	if err := s.client.EmitAuditEvent(events.TunnelSlowDial, events.EventFields{
+		events.EventProtocol: events.EventProtocolSSH,
+		events.RemoteAddr:    params.To.String(),
+		events.DialDuration:  total.String(),
+		events.DialPhases:    timer.String(),
+		events.TunnelProxy:   s.srv.ID,
+	}); err != nil {
+		s.log.WithError(err).Warn("Failed to emit slow dial event.")
+	}
+}
+
 // findMatchingConn iterates over passed in principals looking for matching
 // remote connections.
 func (s *localSite) findMatchingConn(principals []string) (*remoteConn, bool) {
@@ -251,7 +402,36 @@ func (s *localSite) findMatchingConn(principals []string) (*remoteConn, bool) {
 	return nil, false
 }
 
-func (s *localSite) addConn(nodeID string, conn net.Conn, sconn ssh.Conn) (*remoteConn, error) {
+// checkStrictHostDial verifies, under strict host checking, that a matched
+// tunnel connection's registration-time principals still cover the target
+// Server's hostname, UUID and advertised address. This catches a Server
+// resource that has drifted (e.g. its address was changed) out from under
+// an already-established tunnel connection.
+func (s *localSite) checkStrictHostDial(rconn *remoteConn, target services.Server) error {
+	expected := []string{target.GetName(), target.GetHostname()}
+	if host, _, err := net.SplitHostPort(target.GetAddr()); err == nil && host != "" {
+		expected = append(expected, host)
+	}
+
+	for _, principal := range expected {
+		if principal == "" {
+			continue
+		}
+		if !utils.SliceContainsStr(rconn.principals, principal) {
+			reason := fmt.Sprintf("registered server %q does not match tunnel certificate principals", target.GetName())
+			if err := s.client.EmitAuditEvent(events.TunnelReject, events.EventFields{
+				events.TunnelRejectNode:   target.GetName(),
+				events.TunnelRejectReason: reason,
+			}); err != nil {
+				s.log.WithError(err).Warn("Failed to emit tunnel reject event.")
+			}
+			return trace.AccessDenied(reason)
+		}
+	}
+	return nil
+}
+
+func (s *localSite) addConn(nodeID string, conn net.Conn, sconn ssh.Conn, principals []string) (*remoteConn, error) {
 	s.Lock()
 	defer s.Unlock()
 
@@ -263,9 +443,19 @@ func (s *localSite) addConn(nodeID string, conn net.Conn, sconn ssh.Conn) (*remo
 		tunnelType:  string(services.NodeTunnel),
 		proxyName:   s.srv.ID,
 		clusterName: s.domainName,
+		principals:  principals,
 	})
 	s.remoteConns[nodeID] = rconn
 
+	if err := s.client.EmitAuditEvent(events.TunnelConnect, events.EventFields{
+		events.EventProtocol: events.EventProtocolSSH,
+		events.RemoteAddr:    conn.RemoteAddr().String(),
+		events.TunnelType:    string(services.NodeTunnel),
+		events.TunnelProxy:   s.srv.ID,
+	}); err != nil {
+		s.log.WithError(err).Warn("Failed to emit tunnel connect event.")
+	}
+
 	return rconn, nil
 }
 
@@ -289,6 +479,21 @@ func (s *localSite) registerHeartbeat(t time.Time) {
 	}
 }
 
+// emitTunnelDisconnect records an audit event for a reverse tunnel agent
+// connection that has been marked invalid, whether by explicit disconnect
+// or by missing its heartbeat window.
+func (s *localSite) emitTunnelDisconnect(rconn *remoteConn, reason string) {
+	if err := s.client.EmitAuditEvent(events.TunnelDisconnect, events.EventFields{
+		events.EventProtocol: events.EventProtocolSSH,
+		events.RemoteAddr:    rconn.conn.RemoteAddr().String(),
+		events.TunnelType:    string(services.NodeTunnel),
+		events.TunnelProxy:   s.srv.ID,
+		events.Reason:        reason,
+	}); err != nil {
+		s.log.WithError(err).Warn("Failed to emit tunnel disconnect event.")
+	}
+}
+
 func (s *localSite) hasValidConnections() bool {
 	s.Lock()
 	defer s.Unlock()
@@ -328,6 +533,7 @@ func (s *localSite) handleHeartbeat(rconn *remoteConn, ch ssh.Channel, reqC <-ch
 			if req == nil {
 				s.log.Infof("Cluster agent disconnected.")
 				rconn.markInvalid(trace.ConnectionProblem(nil, "agent disconnected"))
+				s.emitTunnelDisconnect(rconn, "agent disconnected")
 
 				if !s.hasValidConnections() {
 					err := s.deleteConnectionRecord(s.domainName, s.srv.ID)
@@ -356,7 +562,9 @@ func (s *localSite) handleHeartbeat(rconn *remoteConn, ch ssh.Channel, reqC <-ch
 		// Since we block on select, time.After is re-created everytime we process
 		// a request.
 		case <-time.After(defaults.ReverseTunnelOfflineThreshold):
-			rconn.markInvalid(trace.ConnectionProblem(nil, "no heartbeats for %v", defaults.ReverseTunnelOfflineThreshold))
+			reason := fmt.Sprintf("no heartbeats for %v", defaults.ReverseTunnelOfflineThreshold)
+			rconn.markInvalid(trace.ConnectionProblem(nil, reason))
+			s.emitTunnelDisconnect(rconn, reason)
 		}
 	}
 }
@@ -384,7 +592,10 @@ func (s *localSite) getConn(addr string) (*remoteConn, error) {
 	return rconn, nil
 }
 
-func (s *localSite) chanTransportConn(rconn *remoteConn) (net.Conn, error) {
+func (s *localSite) chanTransportConn(ctx context.Context, rconn *remoteConn) (net.Conn, error) {
+	_, span := tracing.StartSpan(ctx, "localSite.chanTransportConn")
+	defer span.End()
+
 	s.log.Debugf("Connecting to %v through tunnel.", rconn.conn.RemoteAddr())
 
 	conn, err := connectProxyTransport(rconn.sconn, LocalNode)
@@ -56,12 +56,19 @@ var (
 		},
 		[]string{"cluster", "state"},
 	)
+	dialLatencies = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name: "reversetunnel_dial_latency_seconds",
+			Help: "Latency for dialing a target through the reverse tunnel",
+		},
+	)
 )
 
 func init() {
 	// Metrics have to be registered to be exposed:
 	prometheus.MustRegister(remoteClustersStats)
 	prometheus.MustRegister(trustedClustersStats)
+	prometheus.MustRegister(dialLatencies)
 }
 
 // server is a "reverse tunnel server". it exposes the cluster capabilities
@@ -170,6 +177,26 @@ type Config struct {
 
 	// Component is a component used in logs
 	Component string
+
+	// SlowDialThreshold is how long a dial to a target through the reverse
+	// tunnel can take before it's logged as slow, along with a breakdown
+	// of per-phase timing. If unset, defaults.ReverseTunnelSlowDialThreshold
+	// is used.
+	SlowDialThreshold time.Duration
+
+	// AuthDialTimeout is the dial timeout used when connecting to an auth
+	// server. If unset, defaults.DefaultDialTimeout is used.
+	AuthDialTimeout time.Duration
+
+	// NodeDialTimeout is the dial timeout used when connecting directly to
+	// a node's SSH port (i.e. not through a reverse tunnel). If unset,
+	// defaults.DefaultDialTimeout is used.
+	NodeDialTimeout time.Duration
+
+	// TunnelDialTimeout is the dial timeout used when, in recording-at-proxy
+	// mode, setting up the forwarding server's connection to the target
+	// node. If unset, defaults.DefaultDialTimeout is used.
+	TunnelDialTimeout time.Duration
 }
 
 // CheckAndSetDefaults checks parameters and sets default values
@@ -208,6 +235,18 @@ func (cfg *Config) CheckAndSetDefaults() error {
 	if cfg.Component == "" {
 		cfg.Component = teleport.Component(teleport.ComponentProxy, teleport.ComponentServer)
 	}
+	if cfg.SlowDialThreshold == 0 {
+		cfg.SlowDialThreshold = defaults.ReverseTunnelSlowDialThreshold
+	}
+	if cfg.AuthDialTimeout == 0 {
+		cfg.AuthDialTimeout = defaults.DefaultDialTimeout
+	}
+	if cfg.NodeDialTimeout == 0 {
+		cfg.NodeDialTimeout = defaults.DefaultDialTimeout
+	}
+	if cfg.TunnelDialTimeout == 0 {
+		cfg.TunnelDialTimeout = defaults.DefaultDialTimeout
+	}
 	return nil
 }
 
@@ -278,6 +317,8 @@ func remoteClustersMap(rc []services.RemoteCluster) map[string]services.RemoteCl
 // disconnectClusters disconnects reverse tunnel connections from remote clusters
 // that were deleted from the the local cluster side and cleans up in memory objects.
 // In this case all local trust has been deleted, so all the tunnel connections have to be dropped.
+// Remote clusters that still exist but have been disabled are disconnected as well,
+// without touching the underlying trust relationship.
 func (s *server) disconnectClusters() error {
 	connectedRemoteClusters := s.getRemoteClusters()
 	if len(connectedRemoteClusters) == 0 {
@@ -289,13 +330,19 @@ func (s *server) disconnectClusters() error {
 	}
 	remoteMap := remoteClustersMap(remoteClusters)
 	for _, cluster := range connectedRemoteClusters {
-		if _, ok := remoteMap[cluster.GetName()]; !ok {
+		remoteCluster, ok := remoteMap[cluster.GetName()]
+		switch {
+		case !ok:
 			s.Infof("Remote cluster %q has been deleted. Disconnecting it from the proxy.", cluster.GetName())
-			s.RemoveSite(cluster.GetName())
-			err := cluster.Close()
-			if err != nil {
-				s.Debugf("Failure closing cluster %q: %v.", cluster.GetName(), err)
-			}
+		case !remoteCluster.GetEnabled():
+			s.Infof("Remote cluster %q has been disabled. Disconnecting it from the proxy.", cluster.GetName())
+		default:
+			continue
+		}
+		s.RemoveSite(cluster.GetName())
+		err := cluster.Close()
+		if err != nil {
+			s.Debugf("Failure closing cluster %q: %v.", cluster.GetName(), err)
 		}
 	}
 	return nil
@@ -580,6 +627,17 @@ func (s *server) handleNewNode(conn net.Conn, sconn *ssh.ServerConn, nch ssh.New
 }
 
 func (s *server) handleNewCluster(conn net.Conn, sshConn *ssh.ServerConn, nch ssh.NewChannel) {
+	// refuse tunnels from clusters that have been explicitly disabled,
+	// without touching the trust relationship that allowed them to connect
+	domainName := sshConn.Permissions.Extensions[extAuthority]
+	remoteCluster, err := s.localAuthClient.GetRemoteCluster(domainName)
+	if err == nil && !remoteCluster.GetEnabled() {
+		log.Warningf("Remote cluster %q is disabled, rejecting incoming tunnel.", domainName)
+		nch.Reject(ssh.ConnectionFailed, "remote cluster is disabled")
+		sshConn.Close()
+		return
+	}
+
 	// add the incoming site (cluster) to the list of active connections:
 	site, remoteConn, err := s.upsertSite(conn, sshConn)
 	if err != nil {
@@ -702,10 +760,11 @@ func (s *server) keyAuth(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permiss
 		}
 		return &ssh.Permissions{
 			Extensions: map[string]string{
-				extHost:      conn.User(),
-				extCertType:  extCertTypeHost,
-				extCertRole:  certRole,
-				extAuthority: authDomain,
+				extHost:       conn.User(),
+				extCertType:   extCertTypeHost,
+				extCertRole:   certRole,
+				extAuthority:  authDomain,
+				extPrincipals: strings.Join(cert.ValidPrincipals, ","),
 			},
 		}, nil
 	default:
@@ -747,6 +806,63 @@ func (s *server) checkHostCert(logger *log.Entry, user string, clusterName strin
 		return trace.BadParameter(err.Error())
 	}
 
+	if err := s.checkStrictHostCert(logger, user, cert); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// checkStrictHostCert verifies, when strict host checking is enabled in the
+// cluster configuration, that the principals on a node's host certificate
+// match the hostname, UUID and advertised address of that node's registered
+// Server resource. This catches a node whose host certificate was issued
+// for one identity being used to impersonate another node's tunnel
+// connection.
+//
+// A node that hasn't completed its own heartbeat registration yet has no
+// Server resource to check against; in that case the check is skipped for
+// this connection and enforced again on the node's next reconnect.
+func (s *server) checkStrictHostCert(logger *log.Entry, nodeID string, cert *ssh.Certificate) error {
+	clusterConfig, err := s.localAccessPoint.GetClusterConfig()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !clusterConfig.GetStrictHostCheck() {
+		return nil
+	}
+
+	nodes, err := s.localAccessPoint.GetNodes(defaults.Namespace, services.SkipValidation())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	var node services.Server
+	for _, n := range nodes {
+		if n.GetName() == nodeID {
+			node = n
+			break
+		}
+	}
+	if node == nil {
+		return nil
+	}
+
+	expected := []string{node.GetName(), node.GetHostname()}
+	if host, _, err := net.SplitHostPort(node.GetAddr()); err == nil && host != "" {
+		expected = append(expected, host)
+	}
+
+	for _, principal := range expected {
+		if principal == "" {
+			continue
+		}
+		if !utils.SliceContainsStr(cert.ValidPrincipals, principal) {
+			logger.Warnf("Host certificate for %q is missing expected principal %q.", nodeID, principal)
+			return trace.AccessDenied("host certificate for %q does not match registered server %q", nodeID, node.GetName())
+		}
+	}
+
 	return nil
 }
 
@@ -764,7 +880,12 @@ func (s *server) upsertNode(conn net.Conn, sconn *ssh.ServerConn) (*localSite, *
 		return nil, nil, trace.BadParameter("host id not found")
 	}
 
-	rconn, err := cluster.addConn(nodeID, conn, sconn)
+	var principals []string
+	if p := sconn.Permissions.Extensions[extPrincipals]; p != "" {
+		principals = strings.Split(p, ",")
+	}
+
+	rconn, err := cluster.addConn(nodeID, conn, sconn, principals)
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
@@ -870,9 +991,47 @@ func (s *server) GetSite(name string) (RemoteSite, error) {
 			return s.clusterPeers[i], nil
 		}
 	}
+	if hint := s.findNestedClusterHint(name); hint != "" {
+		return nil, trace.NotFound("cluster %q is not found: %q is a trusted cluster of this one, but trusted clusters are not transitive here, log into %q directly and jump from there to reach %q",
+			name, hint, hint, strings.TrimPrefix(name, hint+"."))
+	}
 	return nil, trace.NotFound("cluster %q is not found", name)
 }
 
+// findNestedClusterHint checks whether name names a cluster nested behind
+// one of the clusters this proxy already has a tunnel to (root -> leaf ->
+// leaf-of-leaf), so that GetSite can return an actionable error instead of
+// a bare "not found" for the common case of someone trying to jump through
+// more than one hop of trusted clusters at once.
+//
+// This proxy only ever has tunnels to clusters directly trusted by its own
+// cluster, so it has no way to reach, or re-sign host certificates for, a
+// cluster trusted by one of ITS leaves - that would require the leaf to
+// expose its own control plane back to the root, which is exactly what
+// tunnels are built to avoid. Matching is done against registered site
+// names rather than a naive split on ".", since cluster names can contain
+// dots themselves.
+//
+// Callers must already hold s's lock for reading.
+func (s *server) findNestedClusterHint(name string) string {
+	var longest string
+	check := func(siteName string) {
+		if siteName != name && strings.HasPrefix(name, siteName+".") && len(siteName) > len(longest) {
+			longest = siteName
+		}
+	}
+	for i := range s.remoteSites {
+		check(s.remoteSites[i].GetName())
+	}
+	for i := range s.localSites {
+		check(s.localSites[i].GetName())
+	}
+	for i := range s.clusterPeers {
+		check(s.clusterPeers[i].GetName())
+	}
+	return longest
+}
+
 func (s *server) RemoveSite(domainName string) error {
 	s.Lock()
 	defer s.Unlock()
@@ -962,4 +1121,8 @@ const (
 	extCertTypeHost = "host"
 	extCertTypeUser = "user"
 	extCertRole     = "role"
+	// extPrincipals carries the host certificate's comma-separated valid
+	// principals, retained so strict host checking can be re-verified at
+	// Dial time against whatever the Server resource looks like then.
+	extPrincipals = "principals@teleport"
 )
@@ -0,0 +1,151 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reversetunnel
+
+import (
+	"testing"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	. "gopkg.in/check.v1"
+)
+
+func TestReverseTunnel(t *testing.T) { TestingT(t) }
+
+// StrictHostSuite covers checkStrictHostCert and checkStrictHostDial, the
+// checks that gate tunnel trust on a node's registered Server resource
+// matching the principals on its host certificate (or, for an already
+// established tunnel, the principals recorded when it was accepted).
+type StrictHostSuite struct {
+	authServer *auth.TestAuthServer
+	tlsServer  *auth.TestTLSServer
+	client     *auth.Client
+}
+
+var _ = Suite(&StrictHostSuite{})
+
+func (s *StrictHostSuite) SetUpTest(c *C) {
+	var err error
+	s.authServer, err = auth.NewTestAuthServer(auth.TestAuthServerConfig{
+		ClusterName: "localhost",
+		Dir:         c.MkDir(),
+	})
+	c.Assert(err, IsNil)
+	s.tlsServer, err = s.authServer.NewTestTLSServer()
+	c.Assert(err, IsNil)
+	s.client, err = s.tlsServer.NewClient(auth.TestBuiltin(teleport.RoleAdmin))
+	c.Assert(err, IsNil)
+}
+
+func (s *StrictHostSuite) TearDownTest(c *C) {
+	c.Assert(s.client.Close(), IsNil)
+	c.Assert(s.tlsServer.Close(), IsNil)
+}
+
+func (s *StrictHostSuite) setStrictHostCheck(c *C, strict bool) {
+	clusterConfig, err := services.NewClusterConfig(services.ClusterConfigSpecV3{})
+	c.Assert(err, IsNil)
+	clusterConfig.SetStrictHostCheck(strict)
+	c.Assert(s.authServer.AuthServer.SetClusterConfig(clusterConfig), IsNil)
+}
+
+func (s *StrictHostSuite) registerNode(c *C, name, hostname, addr string) {
+	node := &services.ServerV2{
+		Kind:    services.KindNode,
+		Version: services.V2,
+		Metadata: services.Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+		Spec: services.ServerSpecV2{
+			Addr:     addr,
+			Hostname: hostname,
+		},
+	}
+	_, err := s.client.UpsertNode(node)
+	c.Assert(err, IsNil)
+}
+
+func (s *StrictHostSuite) TestCheckStrictHostCertMatch(c *C) {
+	s.setStrictHostCheck(c, true)
+	s.registerNode(c, "node1", "node1.example.com", "10.0.0.1:3022")
+
+	srv := &server{localAccessPoint: s.client}
+	cert := &ssh.Certificate{ValidPrincipals: []string{"node1", "node1.example.com", "10.0.0.1"}}
+	err := srv.checkStrictHostCert(logrus.WithField("test", "strict-host"), "node1", cert)
+	c.Assert(err, IsNil)
+}
+
+func (s *StrictHostSuite) TestCheckStrictHostCertMismatch(c *C) {
+	s.setStrictHostCheck(c, true)
+	s.registerNode(c, "node1", "node1.example.com", "10.0.0.1:3022")
+
+	srv := &server{localAccessPoint: s.client}
+	cert := &ssh.Certificate{ValidPrincipals: []string{"some-other-node"}}
+	err := srv.checkStrictHostCert(logrus.WithField("test", "strict-host"), "node1", cert)
+	c.Assert(err, NotNil)
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%#v", err))
+}
+
+func (s *StrictHostSuite) TestCheckStrictHostCertSkippedWithoutServerResource(c *C) {
+	s.setStrictHostCheck(c, true)
+	// no node registered for "node1", so there's nothing to check against
+
+	srv := &server{localAccessPoint: s.client}
+	cert := &ssh.Certificate{ValidPrincipals: []string{"whatever"}}
+	err := srv.checkStrictHostCert(logrus.WithField("test", "strict-host"), "node1", cert)
+	c.Assert(err, IsNil)
+}
+
+func (s *StrictHostSuite) TestCheckStrictHostCertDisabled(c *C) {
+	s.setStrictHostCheck(c, false)
+	s.registerNode(c, "node1", "node1.example.com", "10.0.0.1:3022")
+
+	srv := &server{localAccessPoint: s.client}
+	// would fail the principal check if strict host checking were enabled
+	cert := &ssh.Certificate{ValidPrincipals: []string{"some-other-node"}}
+	err := srv.checkStrictHostCert(logrus.WithField("test", "strict-host"), "node1", cert)
+	c.Assert(err, IsNil)
+}
+
+func (s *StrictHostSuite) TestCheckStrictHostDialMatch(c *C) {
+	site := &localSite{client: s.client, log: logrus.WithField("test", "strict-host-dial")}
+	rconn := &remoteConn{connConfig: &connConfig{principals: []string{"node1", "node1.example.com", "10.0.0.1"}}}
+	target := &services.ServerV2{
+		Metadata: services.Metadata{Name: "node1"},
+		Spec:     services.ServerSpecV2{Addr: "10.0.0.1:3022", Hostname: "node1.example.com"},
+	}
+	c.Assert(site.checkStrictHostDial(rconn, target), IsNil)
+}
+
+func (s *StrictHostSuite) TestCheckStrictHostDialMismatch(c *C) {
+	site := &localSite{client: s.client, log: logrus.WithField("test", "strict-host-dial")}
+	rconn := &remoteConn{connConfig: &connConfig{principals: []string{"some-other-node"}}}
+	target := &services.ServerV2{
+		Metadata: services.Metadata{Name: "node1"},
+		Spec:     services.ServerSpecV2{Addr: "10.0.0.1:3022", Hostname: "node1.example.com"},
+	}
+	err := site.checkStrictHostDial(rconn, target)
+	c.Assert(err, NotNil)
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%#v", err))
+}
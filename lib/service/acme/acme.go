@@ -0,0 +1,134 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package acme lets the proxy's web listener obtain and renew its public
+// TLS certificate automatically from an ACME certificate authority such as
+// Let's Encrypt, instead of requiring an operator to provision one.
+//
+// Cache stores the account key and issued certificates in the cluster
+// backend rather than on local disk, so that every proxy in a highly
+// available deployment renews from, and serves, the same certificate
+// instead of each racing the ACME rate limits independently.
+package acme
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/gravitational/teleport/lib/backend"
+
+	"github.com/gravitational/trace"
+)
+
+const cachePrefix = "acme"
+
+// Cache stores ACME account keys and certificates in the cluster backend.
+// Its method set matches golang.org/x/crypto/acme/autocert.Cache, so a
+// build that vendors that package can pass a Cache directly to
+// autocert.Manager without an adapter.
+type Cache struct {
+	backend backend.Backend
+}
+
+// NewCache returns a Cache backed by b.
+func NewCache(b backend.Backend) *Cache {
+	return &Cache{backend: b}
+}
+
+// Get returns the value stored under key, or autocert.ErrCacheMiss
+// (reported here as trace.NotFound, which a thin adapter can translate)
+// if there isn't one.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	item, err := c.backend.Get(ctx, backend.Key(cachePrefix, key))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return item.Value, nil
+}
+
+// Put stores data under key, overwriting any previous value.
+func (c *Cache) Put(ctx context.Context, key string, data []byte) error {
+	_, err := c.backend.Put(ctx, backend.Item{
+		Key:   backend.Key(cachePrefix, key),
+		Value: data,
+	})
+	return trace.Wrap(err)
+}
+
+// Delete removes key. It is not an error to delete a key that doesn't
+// exist.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	err := c.backend.Delete(ctx, backend.Key(cachePrefix, key))
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// Config configures the proxy's ACME client.
+type Config struct {
+	// Enabled turns on automatic certificate management for the proxy's
+	// web listener.
+	Enabled bool
+	// Email is the contact address given to the ACME CA, used to warn
+	// about expiring certificates and account problems.
+	Email string
+	// URI is the ACME directory URL. Defaults to Let's Encrypt's
+	// production directory if unspecified.
+	URI string
+}
+
+// CheckAndSetDefaults validates the configuration and fills in defaults.
+func (c *Config) CheckAndSetDefaults() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Email == "" {
+		return trace.BadParameter("acme: email is required")
+	}
+	if c.URI == "" {
+		c.URI = "https://acme-v02.api.letsencrypt.org/directory"
+	}
+	return nil
+}
+
+// NewTLSConfig returns a *tls.Config that obtains and renews a certificate
+// for domainNames from the ACME CA described by cfg on demand, serving it
+// via GetCertificate, and performing TLS-ALPN-01 challenge responses
+// through NextProtos/GetCertificate as the HTTP-01 challenge responder
+// handles its own requests directly against the web handler.
+//
+// This build of Teleport does not vendor an ACME client, so NewTLSConfig
+// validates its configuration and the requested domains but always
+// returns trace.NotImplemented; Cache above is real and ready to be wired
+// into golang.org/x/crypto/acme/autocert.Manager by a build that adds it.
+func NewTLSConfig(domainNames []string, cache *Cache, cfg Config) (*tls.Config, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !cfg.Enabled {
+		return nil, trace.BadParameter("acme: not enabled")
+	}
+	if len(domainNames) == 0 {
+		return nil, trace.BadParameter("acme: at least one domain name is required")
+	}
+	if cache == nil {
+		return nil, trace.BadParameter("acme: missing parameter Cache")
+	}
+	return nil, trace.NotImplemented(
+		"this build of Teleport was compiled without ACME support; " +
+			"automatic certificates require a build that vendors golang.org/x/crypto/acme/autocert")
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acme
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/backend/memory"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type CacheSuite struct{}
+
+var _ = check.Suite(&CacheSuite{})
+
+func (s *CacheSuite) TestGetPutDelete(c *check.C) {
+	ctx := context.Background()
+	bk, err := memory.New(memory.Config{})
+	c.Assert(err, check.IsNil)
+
+	cache := NewCache(bk)
+
+	_, err = cache.Get(ctx, "example.com")
+	c.Assert(trace.IsNotFound(err), check.Equals, true)
+
+	err = cache.Put(ctx, "example.com", []byte("cert bytes"))
+	c.Assert(err, check.IsNil)
+
+	data, err := cache.Get(ctx, "example.com")
+	c.Assert(err, check.IsNil)
+	c.Assert(string(data), check.Equals, "cert bytes")
+
+	err = cache.Delete(ctx, "example.com")
+	c.Assert(err, check.IsNil)
+
+	_, err = cache.Get(ctx, "example.com")
+	c.Assert(trace.IsNotFound(err), check.Equals, true)
+
+	// deleting an already-absent key is not an error
+	err = cache.Delete(ctx, "example.com")
+	c.Assert(err, check.IsNil)
+}
@@ -26,12 +26,14 @@ import (
 	"golang.org/x/crypto/ssh"
 
 	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/auth/pkcs11ca"
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/backend/legacy/dir"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/limiter"
 	"github.com/gravitational/teleport/lib/pam"
+	"github.com/gravitational/teleport/lib/service/acme"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/sshca"
 	"github.com/gravitational/teleport/lib/utils"
@@ -144,6 +146,13 @@ type Config struct {
 	// endpoint extended with additional /debug handlers
 	Debug bool
 
+	// FIPS means Teleport started in a FedRAMP/FIPS 140-2 compliant
+	// configuration. It restricts the TLS cipher suites, SSH ciphers, KEX
+	// algorithms, and MAC algorithms to those approved for FIPS 140-2 use,
+	// and refuses to start if a non-compliant algorithm was explicitly
+	// configured.
+	FIPS bool
+
 	// UploadEventsC is a channel for upload events
 	// used in tests
 	UploadEventsC chan *events.UploadEvent `json:"-"`
@@ -305,6 +314,29 @@ type ProxyConfig struct {
 
 	// Kube specifies kubernetes proxy configuration
 	Kube KubeProxyConfig
+
+	// ACME is the configuration for automatic certificate management for
+	// the proxy's web listener via an ACME CA such as Let's Encrypt.
+	ACME acme.Config
+
+	// DNSResolver, if set, is used to resolve SSH targets that can't be
+	// matched against a registered Server resource, supporting hybrid
+	// environments with unregistered legacy hosts.
+	DNSResolver *utils.DNSResolver
+
+	// AuthDialTimeout, if set, overrides the default dial timeout used when
+	// the proxy connects to an auth server.
+	AuthDialTimeout time.Duration
+
+	// NodeDialTimeout, if set, overrides the default dial timeout used when
+	// the proxy connects directly to a node's SSH port (i.e. not through a
+	// reverse tunnel).
+	NodeDialTimeout time.Duration
+
+	// TunnelDialTimeout, if set, overrides the default dial timeout used
+	// when the proxy, in recording-at-proxy mode, dials a node to set up
+	// the forwarding server that records the session.
+	TunnelDialTimeout time.Duration
 }
 
 // KubeProxyConfig specifies configuration for proxy service
@@ -376,6 +408,12 @@ type AuthConfig struct {
 	// ClusterConfig stores cluster level configuration.
 	ClusterConfig services.ClusterConfig
 
+	// KeyStore configures a PKCS#11 token to hold CA private keys on an
+	// HSM instead of generating and storing them in this process. If
+	// KeyStore.Path is empty (the default), CA keys are generated and
+	// stored as before.
+	KeyStore pkcs11ca.Config
+
 	// LicenseFile is a full path to the license file
 	LicenseFile string
 
@@ -23,14 +23,17 @@ package service
 import (
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 	"time"
 )
 
-// writeDebugInfo writes debugging information
-// about this process
-func writeDebugInfo(w io.Writer) {
+// writeDebugInfo writes debugging information about this process, followed
+// by the output of any dumpers registered with RegisterDebugDumper (for
+// example the reversetunnel connection table).
+func (process *TeleportProcess) writeDebugInfo(w io.Writer) {
 	fmt.Fprintf(w, "Runtime stats\n")
 	runtimeStats(w)
 
@@ -39,6 +42,31 @@ func writeDebugInfo(w io.Writer) {
 
 	fmt.Fprintf(w, "Goroutines\n")
 	goroutineDump(w)
+
+	process.Lock()
+	dumpers := append([]func(io.Writer){}, process.debugDumpers...)
+	process.Unlock()
+
+	for _, dump := range dumpers {
+		dump(w)
+	}
+}
+
+// dumpDebugInfo writes the process debug dump to a file under the data
+// directory, so it survives after the terminal or log tail is gone. Falls
+// back to stderr if the file cannot be created.
+func (process *TeleportProcess) dumpDebugInfo() {
+	path := filepath.Join(process.Config.DataDir, fmt.Sprintf("debug-%v.log", time.Now().Format("20060102-150405")))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		process.Warningf("Failed to create debug dump file, writing to stderr instead: %v.", err)
+		process.writeDebugInfo(os.Stderr)
+		return
+	}
+	defer f.Close()
+
+	process.writeDebugInfo(f)
+	process.Infof("Wrote diagnostic dump to %v.", path)
 }
 
 func goroutineDump(w io.Writer) {
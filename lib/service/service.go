@@ -21,6 +21,7 @@ package service
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -38,8 +39,10 @@ import (
 	"golang.org/x/crypto/ssh"
 
 	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/alpnproxy"
 	"github.com/gravitational/teleport/lib/auth"
 	"github.com/gravitational/teleport/lib/auth/native"
+	"github.com/gravitational/teleport/lib/auth/pkcs11ca"
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/backend/dynamo"
 	"github.com/gravitational/teleport/lib/backend/etcdbk"
@@ -59,6 +62,7 @@ import (
 	"github.com/gravitational/teleport/lib/limiter"
 	"github.com/gravitational/teleport/lib/multiplexer"
 	"github.com/gravitational/teleport/lib/reversetunnel"
+	"github.com/gravitational/teleport/lib/service/acme"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/srv"
@@ -234,6 +238,21 @@ type TeleportProcess struct {
 
 	// reporter is used to report some in memory stats
 	reporter *backend.Reporter
+
+	// debugDumpers are invoked by writeDebugInfo (triggered by SIGUSR1) to
+	// append component-specific diagnostics, such as the reversetunnel
+	// connection table, to the process dump.
+	debugDumpers []func(io.Writer)
+}
+
+// RegisterDebugDumper registers a function that is called, in registration
+// order, whenever the process is asked to dump its debugging state (see
+// writeDebugInfo). Used by components such as the reversetunnel server to
+// make connection tables visible without a debugger.
+func (process *TeleportProcess) RegisterDebugDumper(dump func(io.Writer)) {
+	process.Lock()
+	defer process.Unlock()
+	process.debugDumpers = append(process.debugDumpers, dump)
 }
 
 type keyPairKey struct {
@@ -582,16 +601,26 @@ func NewTeleport(cfg *Config) (*TeleportProcess, error) {
 	// Create a process wide key generator that will be shared. This is so the
 	// key generator can pre-generate keys and share these across services.
 	if cfg.Keygen == nil {
-		precomputeCount := native.PrecomputedNum
-		// in case if not auth or proxy services are enabled,
-		// there is no need to precompute any SSH keys in the pool
-		if !cfg.Auth.Enabled && !cfg.Proxy.Enabled {
-			precomputeCount = 0
-		}
-		var err error
-		cfg.Keygen, err = native.New(process.ExitContext(), native.PrecomputeKeys(precomputeCount))
-		if err != nil {
-			return nil, trace.Wrap(err)
+		// If an HSM keystore was configured, CA keys are generated and
+		// signed on the token instead of in this process.
+		if cfg.Auth.KeyStore.Path != "" {
+			var err error
+			cfg.Keygen, err = pkcs11ca.New(cfg.Auth.KeyStore)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+		} else {
+			precomputeCount := native.PrecomputedNum
+			// in case if not auth or proxy services are enabled,
+			// there is no need to precompute any SSH keys in the pool
+			if !cfg.Auth.Enabled && !cfg.Proxy.Enabled {
+				precomputeCount = 0
+			}
+			var err error
+			cfg.Keygen, err = native.New(process.ExitContext(), native.PrecomputeKeys(precomputeCount))
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
 		}
 	}
 
@@ -1003,7 +1032,7 @@ func (process *TeleportProcess) initAuthService() error {
 		return trace.Wrap(err)
 	}
 	// auth server listens on SSH and TLS, reusing the same socket
-	listener, err := process.importOrCreateListener(teleport.ComponentAuth, cfg.Auth.SSHAddr.Addr)
+	listener, err := process.importOrCreateListener(teleport.ComponentAuth, cfg.Auth.SSHAddr)
 	if err != nil {
 		log.Errorf("PID: %v Failed to bind to address %v: %v, exiting.", os.Getpid(), cfg.Auth.SSHAddr.Addr, err)
 		return trace.Wrap(err)
@@ -1402,7 +1431,7 @@ func (process *TeleportProcess) initSSH() error {
 		}
 
 		if !conn.UseTunnel {
-			listener, err := process.importOrCreateListener(teleport.ComponentNode, cfg.SSH.Addr.Addr)
+			listener, err := process.importOrCreateListener(teleport.ComponentNode, cfg.SSH.Addr)
 			if err != nil {
 				return trace.Wrap(err)
 			}
@@ -1608,6 +1637,29 @@ func (process *TeleportProcess) initDiagnosticService() error {
 		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
 		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+		mux.HandleFunc("/debug/loglevel", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost && r.Method != http.MethodPut {
+				roundtrip.ReplyJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "expected POST or PUT"})
+				return
+			}
+			var req struct {
+				Component string `json:"component"`
+				Level     string `json:"level"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				roundtrip.ReplyJSON(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+				return
+			}
+			level, err := logrus.ParseLevel(req.Level)
+			if err != nil {
+				roundtrip.ReplyJSON(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+				return
+			}
+			utils.SetComponentLogLevel(req.Component, level)
+			log.Infof("Set log level for component %q to %v.", req.Component, level)
+			roundtrip.ReplyJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+		})
 	}
 
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
@@ -1622,12 +1674,33 @@ func (process *TeleportProcess) initDiagnosticService() error {
 	// Teleport based off Events. Use this state machine to return return the
 	// status from the /readyz endpoint.
 	ps := newProcessState(process)
+
+	// requiredReadyEvents are the component-level readiness events that must
+	// have fired at least once, for the roles enabled in this process,
+	// before /readyz can report success. This ensures /readyz reflects
+	// that the backend connection, and any listeners the enabled roles
+	// depend on (e.g. the proxy's reverse tunnel listener), are actually up,
+	// rather than only that Teleport has not yet observed a degraded event.
+	var requiredReadyEvents []string
+	if process.Config.Auth.Enabled {
+		requiredReadyEvents = append(requiredReadyEvents, AuthTLSReady)
+	}
+	if process.Config.Proxy.Enabled {
+		requiredReadyEvents = append(requiredReadyEvents, ProxyReverseTunnelReady)
+	}
+	if process.Config.SSH.Enabled {
+		requiredReadyEvents = append(requiredReadyEvents, NodeSSHReady)
+	}
+
 	process.RegisterFunc("readyz.monitor", func() error {
 		// Start loop to monitor for events that are used to update Teleport state.
 		eventCh := make(chan Event, 1024)
 		process.WaitForEvent(process.ExitContext(), TeleportReadyEvent, eventCh)
 		process.WaitForEvent(process.ExitContext(), TeleportDegradedEvent, eventCh)
 		process.WaitForEvent(process.ExitContext(), TeleportOKEvent, eventCh)
+		for _, eventName := range requiredReadyEvents {
+			process.WaitForEvent(process.ExitContext(), eventName, eventCh)
+		}
 
 		for {
 			select {
@@ -1640,6 +1713,15 @@ func (process *TeleportProcess) initDiagnosticService() error {
 		}
 	})
 	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		for _, eventName := range requiredReadyEvents {
+			if !ps.readinessReported(eventName) {
+				roundtrip.ReplyJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+					"status": fmt.Sprintf("teleport is not yet ready: waiting for %v", eventName),
+				})
+				return
+			}
+		}
+
 		switch ps.GetState() {
 		// 503
 		case stateDegraded:
@@ -1659,7 +1741,7 @@ func (process *TeleportProcess) initDiagnosticService() error {
 		}
 	})
 
-	listener, err := process.importOrCreateListener(teleport.ComponentDiagnostic, process.Config.DiagnosticAddr.Addr)
+	listener, err := process.importOrCreateListener(teleport.ComponentDiagnostic, process.Config.DiagnosticAddr)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -1749,9 +1831,9 @@ func (process *TeleportProcess) getAdditionalPrincipals(role teleport.Role) ([]s
 
 // initProxy gets called if teleport runs with 'proxy' role enabled.
 // this means it will do two things:
-//    1. serve a web UI
-//    2. proxy SSH connections to nodes running with 'node' role
-//    3. take care of reverse tunnels
+//  1. serve a web UI
+//  2. proxy SSH connections to nodes running with 'node' role
+//  3. take care of reverse tunnels
 func (process *TeleportProcess) initProxy() error {
 	// if no TLS key was provided for the web UI, generate a self signed cert
 	if process.Config.Proxy.TLSKey == "" && !process.Config.Proxy.DisableTLS && !process.Config.Proxy.DisableWebService {
@@ -1791,6 +1873,7 @@ func (process *TeleportProcess) initProxy() error {
 
 type proxyListeners struct {
 	mux           *multiplexer.Mux
+	alpn          *alpnproxy.Router
 	web           net.Listener
 	reverseTunnel net.Listener
 	kube          net.Listener
@@ -1800,6 +1883,9 @@ func (l *proxyListeners) Close() {
 	if l.mux != nil {
 		l.mux.Close()
 	}
+	if l.alpn != nil {
+		l.alpn.Close()
+	}
 	if l.web != nil {
 		l.web.Close()
 	}
@@ -1818,9 +1904,14 @@ func (process *TeleportProcess) setupProxyListeners() (*proxyListeners, error) {
 	var err error
 	var listeners proxyListeners
 
-	if cfg.Proxy.Kube.Enabled {
+	// If Kube's listener is configured to share the web port, it's routed
+	// by negotiated ALPN protocol once the shared listener is multiplexed
+	// below instead of getting a dedicated listener here.
+	muxKube := cfg.Proxy.Kube.Enabled && cfg.Proxy.Kube.ListenAddr.Equals(cfg.Proxy.WebAddr)
+
+	if cfg.Proxy.Kube.Enabled && !muxKube {
 		process.Debugf("Setup Proxy: turning on Kubernetes proxy.")
-		listener, err := process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "kube"), cfg.Proxy.Kube.ListenAddr.Addr)
+		listener, err := process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "kube"), cfg.Proxy.Kube.ListenAddr)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
@@ -1833,7 +1924,7 @@ func (process *TeleportProcess) setupProxyListeners() (*proxyListeners, error) {
 		return &listeners, nil
 	case cfg.Proxy.ReverseTunnelListenAddr.Equals(cfg.Proxy.WebAddr) && !cfg.Proxy.DisableTLS:
 		process.Debugf("Setup Proxy: Reverse tunnel proxy and web proxy listen on the same port, multiplexing is on.")
-		listener, err := process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "tunnel", "web"), cfg.Proxy.WebAddr.Addr)
+		listener, err := process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "tunnel", "web"), cfg.Proxy.WebAddr)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
@@ -1851,10 +1942,22 @@ func (process *TeleportProcess) setupProxyListeners() (*proxyListeners, error) {
 		listeners.web = listeners.mux.TLS()
 		listeners.reverseTunnel = listeners.mux.SSH()
 		go listeners.mux.Serve()
+
+		if muxKube {
+			process.Debugf("Setup Proxy: Kubernetes proxy is multiplexed onto the web port by negotiated ALPN protocol.")
+			listeners.alpn, err = alpnproxy.New(alpnproxy.Config{Listener: listeners.web})
+			if err != nil {
+				listener.Close()
+				return nil, trace.Wrap(err)
+			}
+			listeners.kube = listeners.alpn.Add(alpnproxy.ProtocolKube)
+			listeners.web = listeners.alpn.Add("", alpnproxy.ProtocolHTTP, alpnproxy.ProtocolHTTP2)
+			go listeners.alpn.Serve()
+		}
 		return &listeners, nil
 	case cfg.Proxy.EnableProxyProtocol && !cfg.Proxy.DisableWebService && !cfg.Proxy.DisableTLS:
 		process.Debugf("Setup Proxy: Proxy protocol is enabled for web service, multiplexing is on.")
-		listener, err := process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "web"), cfg.Proxy.WebAddr.Addr)
+		listener, err := process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "web"), cfg.Proxy.WebAddr)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
@@ -1870,7 +1973,7 @@ func (process *TeleportProcess) setupProxyListeners() (*proxyListeners, error) {
 			return nil, trace.Wrap(err)
 		}
 		listeners.web = listeners.mux.TLS()
-		listeners.reverseTunnel, err = process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "tunnel"), cfg.Proxy.ReverseTunnelListenAddr.Addr)
+		listeners.reverseTunnel, err = process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "tunnel"), cfg.Proxy.ReverseTunnelListenAddr)
 		if err != nil {
 			listener.Close()
 			listeners.Close()
@@ -1881,14 +1984,14 @@ func (process *TeleportProcess) setupProxyListeners() (*proxyListeners, error) {
 	default:
 		process.Debugf("Proxy reverse tunnel are listening on the separate ports.")
 		if !cfg.Proxy.DisableReverseTunnel {
-			listeners.reverseTunnel, err = process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "tunnel"), cfg.Proxy.ReverseTunnelListenAddr.Addr)
+			listeners.reverseTunnel, err = process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "tunnel"), cfg.Proxy.ReverseTunnelListenAddr)
 			if err != nil {
 				listeners.Close()
 				return nil, trace.Wrap(err)
 			}
 		}
 		if !cfg.Proxy.DisableWebService {
-			listeners.web, err = process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "web"), cfg.Proxy.WebAddr.Addr)
+			listeners.web, err = process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "web"), cfg.Proxy.WebAddr)
 			if err != nil {
 				listeners.Close()
 				return nil, trace.Wrap(err)
@@ -1970,16 +2073,26 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 						Client: conn.Client,
 					},
 				},
-				KeyGen:        cfg.Keygen,
-				Ciphers:       cfg.Ciphers,
-				KEXAlgorithms: cfg.KEXAlgorithms,
-				MACAlgorithms: cfg.MACAlgorithms,
-				DataDir:       process.Config.DataDir,
-				PollingPeriod: process.Config.PollingPeriod,
+				KeyGen:            cfg.Keygen,
+				Ciphers:           cfg.Ciphers,
+				KEXAlgorithms:     cfg.KEXAlgorithms,
+				MACAlgorithms:     cfg.MACAlgorithms,
+				DataDir:           process.Config.DataDir,
+				PollingPeriod:     process.Config.PollingPeriod,
+				AuthDialTimeout:   process.Config.Proxy.AuthDialTimeout,
+				NodeDialTimeout:   process.Config.Proxy.NodeDialTimeout,
+				TunnelDialTimeout: process.Config.Proxy.TunnelDialTimeout,
 			})
 		if err != nil {
 			return trace.Wrap(err)
 		}
+		process.RegisterDebugDumper(func(w io.Writer) {
+			fmt.Fprintf(w, "Reverse tunnel connections\n")
+			for _, site := range tsrv.GetSites() {
+				fmt.Fprintf(w, "  cluster=%v status=%v tunnels=%v last-connected=%v\n",
+					site.GetName(), site.GetStatus(), site.GetTunnelsCount(), site.GetLastConnected())
+			}
+		})
 		process.RegisterCriticalFunc("proxy.reveresetunnel.server", func() error {
 			utils.Consolef(cfg.Console, teleport.ComponentProxy, "Reverse tunnel service is starting on %v.", cfg.Proxy.ReverseTunnelListenAddr.Addr)
 			log.Infof("Starting on %v using %v", cfg.Proxy.ReverseTunnelListenAddr.Addr, process.Config.CachePolicy)
@@ -2031,14 +2144,21 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 				ProxyWebAddr:  cfg.Proxy.WebAddr,
 				ProxySettings: proxySettings,
 				CipherSuites:  cfg.CipherSuites,
+				Limiter:       cfg.Proxy.Limiter,
 			})
 		if err != nil {
 			return trace.Wrap(err)
 		}
 		proxyLimiter.WrapHandle(webHandler)
 		if !process.Config.Proxy.DisableTLS {
-			log.Infof("Using TLS cert %v, key %v", cfg.Proxy.TLSCert, cfg.Proxy.TLSKey)
-			tlsConfig, err := utils.CreateTLSConfiguration(cfg.Proxy.TLSCert, cfg.Proxy.TLSKey, cfg.CipherSuites)
+			var tlsConfig *tls.Config
+			if cfg.Proxy.ACME.Enabled {
+				log.Infof("Using ACME to obtain a TLS certificate for %v", cfg.Hostname)
+				tlsConfig, err = acme.NewTLSConfig([]string{cfg.Hostname}, acme.NewCache(process.backend), cfg.Proxy.ACME)
+			} else {
+				log.Infof("Using TLS cert %v, key %v", cfg.Proxy.TLSCert, cfg.Proxy.TLSKey)
+				tlsConfig, err = utils.CreateTLSConfiguration(cfg.Proxy.TLSCert, cfg.Proxy.TLSKey, cfg.CipherSuites)
+			}
 			if err != nil {
 				return trace.Wrap(err)
 			}
@@ -2063,7 +2183,7 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 	}
 
 	// Register SSH proxy server - SSH jumphost proxy server
-	listener, err := process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "ssh"), cfg.Proxy.SSHAddr.Addr)
+	listener, err := process.importOrCreateListener(teleport.Component(teleport.ComponentProxy, "ssh"), cfg.Proxy.SSHAddr)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -2083,6 +2203,7 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 		regular.SetMACAlgorithms(cfg.MACAlgorithms),
 		regular.SetNamespace(defaults.Namespace),
 		regular.SetRotationGetter(process.getRotation),
+		regular.SetDNSResolver(cfg.Proxy.DNSResolver),
 	)
 	if err != nil {
 		return trace.Wrap(err)
@@ -2221,50 +2342,58 @@ func warnOnErr(err error) {
 	}
 }
 
-// initAuthStorage initializes the storage backend for the auth service.
-func (process *TeleportProcess) initAuthStorage() (bk backend.Backend, err error) {
-	bc := &process.Config.Auth.StorageConfig
-	process.Debugf("Using %v backend.", bc.Type)
-	switch bc.Type {
+// NewBackend constructs the storage backend described by storageConfig.
+// It is exported so that tools that need direct backend access without
+// starting a full Teleport process, such as `tctl backend`, can open the
+// same backend a Teleport process configured with storageConfig would.
+func NewBackend(storageConfig backend.Config) (backend.Backend, error) {
+	switch storageConfig.Type {
 	case lite.GetName():
-		bk, err = lite.New(context.TODO(), bc.Params)
+		return lite.New(context.TODO(), storageConfig.Params)
 		// legacy bolt backend, import all data into SQLite and return
 		// SQLite data
 	case boltbk.GetName():
-		litebk, err := lite.New(context.TODO(), bc.Params)
+		litebk, err := lite.New(context.TODO(), storageConfig.Params)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
 		err = legacy.Import(context.TODO(), litebk, func() (legacy.Exporter, error) {
-			return boltbk.New(legacy.Params(bc.Params))
+			return boltbk.New(legacy.Params(storageConfig.Params))
 		})
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
-		bk = litebk
+		return litebk, nil
 		// legacy filesystem backend, import all data into SQLite and return
 		// SQLite data
 	case dir.GetName():
-		litebk, err := lite.New(context.TODO(), bc.Params)
+		litebk, err := lite.New(context.TODO(), storageConfig.Params)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
 		err = legacy.Import(context.TODO(), litebk, func() (legacy.Exporter, error) {
-			return dir.New(legacy.Params(bc.Params))
+			return dir.New(legacy.Params(storageConfig.Params))
 		})
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
-		bk = litebk
+		return litebk, nil
 	// DynamoDB backend:
 	case dynamo.GetName():
-		bk, err = dynamo.New(context.TODO(), bc.Params)
+		return dynamo.New(context.TODO(), storageConfig.Params)
 	// etcd backend:
 	case etcdbk.GetName():
-		bk, err = etcdbk.New(context.TODO(), bc.Params)
+		return etcdbk.New(context.TODO(), storageConfig.Params)
 	default:
-		err = trace.BadParameter("unsupported secrets storage type: %q", bc.Type)
+		return nil, trace.BadParameter("unsupported secrets storage type: %q", storageConfig.Type)
 	}
+}
+
+// initAuthStorage initializes the storage backend for the auth service.
+func (process *TeleportProcess) initAuthStorage() (bk backend.Backend, err error) {
+	bc := &process.Config.Auth.StorageConfig
+	process.Debugf("Using %v backend.", bc.Type)
+	bk, err = NewBackend(*bc)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -96,8 +97,8 @@ func (process *TeleportProcess) WaitForSignals(ctx context.Context) error {
 				// That was not quite enough. With pipelines diagnostics could come from any of several programs running simultaneously.
 				// Diagnostics needed to identify themselves.
 				// - Doug McIllroy, "A Research UNIX Reader: Annotated Excerpts from the Programmer’s Manual, 1971-1986"
-				process.Infof("Got signal %q, logging diagostic info to stderr.", signal)
-				writeDebugInfo(os.Stderr)
+				process.Infof("Got signal %q, dumping diagnostic info.", signal)
+				process.dumpDebugInfo()
 			case syscall.SIGUSR2:
 				log.Infof("Got signal %q, forking a new process.", signal)
 				if err := process.forkChild(); err != nil {
@@ -113,7 +114,18 @@ func (process *TeleportProcess) WaitForSignals(ctx context.Context) error {
 				}
 				process.Infof("Successfully started new process, shutting down gracefully.")
 				go process.printShutdownStatus(doneContext)
-				process.Shutdown(ctx)
+				// The new process has already taken over the listening sockets and is
+				// accepting new reverse tunnel agents and sessions, so this process must
+				// not wait on draining connections forever: bound the shutdown the same
+				// way the in-process reload path does, so a handful of stuck connections
+				// can't keep the old process (and its resources) around indefinitely.
+				shutdownTimeout := process.Config.ShutdownTimeout
+				if shutdownTimeout == 0 {
+					shutdownTimeout = defaults.DefaultGracefulShutdownTimeout
+				}
+				timeoutCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+				process.Shutdown(timeoutCtx)
+				cancel()
 				log.Infof("All services stopped, exiting.")
 				return nil
 			case syscall.SIGCHLD:
@@ -198,19 +210,19 @@ func (process *TeleportProcess) closeImportedDescriptors(prefix string) error {
 	return trace.NewAggregate(errors...)
 }
 
-// importOrCreateListener imports listener passed by the parent process (happens during live reload)
-// or creates a new listener if there was no listener registered
-func (process *TeleportProcess) importOrCreateListener(listenerType, address string) (net.Listener, error) {
-	l, err := process.importListener(listenerType, address)
+// importOrCreateListener imports listener passed by the parent process (happens during live reload
+// or systemd socket activation) or creates a new listener if there was no listener registered
+func (process *TeleportProcess) importOrCreateListener(listenerType string, addr utils.NetAddr) (net.Listener, error) {
+	l, err := process.importListener(listenerType, addr)
 	if err == nil {
-		process.Infof("Using file descriptor %v %v passed by the parent process.", listenerType, address)
+		process.Infof("Using file descriptor %v %v passed by the parent process.", listenerType, addr.Addr)
 		return l, nil
 	}
 	if !trace.IsNotFound(err) {
 		return nil, trace.Wrap(err)
 	}
-	process.Infof("Service %v is creating new listener on %v.", listenerType, address)
-	return process.createListener(listenerType, address)
+	process.Infof("Service %v is creating new listener on %v.", listenerType, addr.Addr)
+	return process.createListener(listenerType, addr)
 }
 
 func (process *TeleportProcess) importSignalPipe() (*os.File, error) {
@@ -229,36 +241,45 @@ func (process *TeleportProcess) importSignalPipe() (*os.File, error) {
 }
 
 // importListener imports listener passed by the parent process, if no listener is found
-// returns NotFound, otherwise removes the file from the list
-func (process *TeleportProcess) importListener(listenerType, address string) (net.Listener, error) {
+// returns NotFound, otherwise removes the file from the list. Descriptors handed over by
+// systemd (see importSystemdFileDescriptors) carry no address, since systemd has already
+// bound them to whatever address their socket unit specifies, so those are matched by
+// type alone.
+func (process *TeleportProcess) importListener(listenerType string, addr utils.NetAddr) (net.Listener, error) {
 	process.Lock()
 	defer process.Unlock()
 
 	for i := range process.importedDescriptors {
 		d := process.importedDescriptors[i]
-		if d.Type == listenerType && d.Address == address {
+		if d.Type == listenerType && (d.Address == addr.Addr || d.Address == "") {
 			l, err := d.ToListener()
 			if err != nil {
 				return nil, trace.Wrap(err)
 			}
 			process.importedDescriptors = append(process.importedDescriptors[:i], process.importedDescriptors[i+1:]...)
-			process.registeredListeners = append(process.registeredListeners, RegisteredListener{Type: listenerType, Address: address, Listener: l})
+			process.registeredListeners = append(process.registeredListeners, RegisteredListener{Type: listenerType, Address: addr.Addr, Listener: l})
 			return l, nil
 		}
 	}
 
-	return nil, trace.NotFound("no file descriptor for type %v and address %v has been imported", listenerType, address)
+	return nil, trace.NotFound("no file descriptor for type %v and address %v has been imported", listenerType, addr.Addr)
 }
 
-// createListener creates listener and adds to a list of tracked listeners
-func (process *TeleportProcess) createListener(listenerType, address string) (net.Listener, error) {
-	listener, err := net.Listen("tcp", address)
+// createListener creates listener and adds to a list of tracked listeners. addr.AddrNetwork
+// selects the socket type, e.g. "tcp" or "unix" for a Unix domain socket; it defaults to "tcp"
+// when unset.
+func (process *TeleportProcess) createListener(listenerType string, addr utils.NetAddr) (net.Listener, error) {
+	network := addr.AddrNetwork
+	if network == "" {
+		network = "tcp"
+	}
+	listener, err := net.Listen(network, addr.Addr)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 	process.Lock()
 	defer process.Unlock()
-	r := RegisteredListener{Type: listenerType, Address: address, Listener: listener}
+	r := RegisteredListener{Type: listenerType, Address: addr.Addr, Listener: listener}
 	process.registeredListeners = append(process.registeredListeners, r)
 	return listener, nil
 }
@@ -278,24 +299,73 @@ func (process *TeleportProcess) ExportFileDescriptors() ([]FileDescriptor, error
 	return out, nil
 }
 
-// importFileDescriptors imports file descriptors from environment if there are any
+// importFileDescriptors imports file descriptors passed by a parent teleport process
+// (live reload) as well as any sockets handed over by systemd socket activation.
 func importFileDescriptors() ([]FileDescriptor, error) {
-	// These files may be passed in by the parent process
+	var out []FileDescriptor
+
+	// These files may be passed in by a parent teleport process during live reload.
 	filesString := os.Getenv(teleportFilesEnvVar)
-	if filesString == "" {
-		return nil, nil
+	if filesString != "" {
+		files, err := filesFromString(filesString)
+		if err != nil {
+			return nil, trace.BadParameter("child process has failed to read files, error %q", err)
+		}
+		if len(files) != 0 {
+			log.Infof("Child has been passed files: %v", files)
+		}
+		out = append(out, files...)
 	}
 
-	files, err := filesFromString(filesString)
+	systemdFiles, err := importSystemdFileDescriptors()
 	if err != nil {
-		return nil, trace.BadParameter("child process has failed to read files, error %q", err)
+		return nil, trace.Wrap(err)
 	}
+	out = append(out, systemdFiles...)
+
+	return out, nil
+}
 
-	if len(files) != 0 {
-		log.Infof("Child has been passed files: %v", files)
+// importSystemdFileDescriptors imports sockets passed by systemd socket activation
+// (man 3 sd_listen_fds). systemd passes activated sockets starting at file descriptor
+// 3, and names them in LISTEN_FDNAMES (set via FileDescriptorName= in the socket unit)
+// in the same order; a name is expected to match one of teleport's listener types, e.g.
+// "proxy:web" or "auth". Unnamed sockets are skipped since there would be no way to
+// match them to a listener.
+func importSystemdFileDescriptors() ([]FileDescriptor, error) {
+	pid, err := strconv.Atoi(os.Getenv(systemdPIDEnvVar))
+	if err != nil || pid != os.Getpid() {
+		// LISTEN_PID is not set, not a number, or meant for a different process;
+		// either way there's nothing for us to import.
+		return nil, nil
 	}
 
-	return files, nil
+	count, err := strconv.Atoi(os.Getenv(systemdFDsEnvVar))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	names := strings.Split(os.Getenv(systemdFDNamesEnvVar), ":")
+
+	var out []FileDescriptor
+	for i := 0; i < count; i++ {
+		var name string
+		if i < len(names) {
+			name = names[i]
+		}
+		if name == "" {
+			log.Warningf("Ignoring unnamed systemd socket fd %v, set FileDescriptorName= in its socket unit.", systemdFDStart+i)
+			continue
+		}
+		file := os.NewFile(uintptr(systemdFDStart+i), name)
+		out = append(out, FileDescriptor{File: file, Type: name})
+	}
+
+	if len(out) != 0 {
+		log.Infof("Imported %v socket(s) from systemd.", len(out))
+	}
+
+	return out, nil
 }
 
 // RegisteredListener is a listener registered
@@ -359,6 +429,18 @@ func filesToString(files []FileDescriptor) (string, error) {
 
 const teleportFilesEnvVar = "TELEPORT_OS_FILES"
 
+const (
+	// systemdPIDEnvVar, systemdFDsEnvVar and systemdFDNamesEnvVar are the
+	// environment variables systemd sets on a socket-activated process, per
+	// the sd_listen_fds(3) protocol.
+	systemdPIDEnvVar     = "LISTEN_PID"
+	systemdFDsEnvVar     = "LISTEN_FDS"
+	systemdFDNamesEnvVar = "LISTEN_FDNAMES"
+	// systemdFDStart is the first file descriptor number systemd-activated
+	// sockets are passed on.
+	systemdFDStart = 3
+)
+
 func execPath() (string, error) {
 	name, err := exec.LookPath(os.Args[0])
 	if err != nil {
@@ -17,6 +17,7 @@ limitations under the License.
 package service
 
 import (
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -40,6 +41,9 @@ type processState struct {
 	process      *TeleportProcess
 	recoveryTime time.Time
 	currentState int64
+
+	mu       sync.Mutex
+	reported map[string]bool
 }
 
 // newProcessState returns a new FSM that tracks the state of the Teleport process.
@@ -48,6 +52,7 @@ func newProcessState(process *TeleportProcess) *processState {
 		process:      process,
 		recoveryTime: process.Clock.Now(),
 		currentState: stateOK,
+		reported:     make(map[string]bool),
 	}
 }
 
@@ -79,6 +84,13 @@ func (f *processState) Process(event Event) {
 				f.process.Infof("Teleport has recovered from a degraded state.")
 			}
 		}
+	// Any other event is treated as a one-shot component readiness signal
+	// (for example AuthTLSReady or ProxyReverseTunnelReady) and is simply
+	// recorded so readinessReported can report on it.
+	default:
+		f.mu.Lock()
+		f.reported[event.Name] = true
+		f.mu.Unlock()
 	}
 }
 
@@ -86,3 +98,11 @@ func (f *processState) Process(event Event) {
 func (f *processState) GetState() int64 {
 	return atomic.LoadInt64(&f.currentState)
 }
+
+// readinessReported returns true once the named component readiness event
+// has been observed at least once.
+func (f *processState) readinessReported(eventName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reported[eventName]
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// AccessWindow restricts access to a range of days and hours, evaluated in a
+// fixed timezone, so that access can be limited to business hours or similar
+// windows. It is the building block for time-based access windows on roles;
+// see the package doc comment on this file for the current integration
+// status.
+type AccessWindow struct {
+	// Weekdays is the set of days of the week access is permitted. An empty
+	// set means every day is permitted.
+	Weekdays []time.Weekday
+	// StartHour is the first hour of the day, in the window's Location, that
+	// access is permitted, inclusive.
+	StartHour int
+	// EndHour is the last hour of the day, in the window's Location, that
+	// access is permitted, exclusive.
+	EndHour int
+	// Location is the IANA timezone name the window is evaluated in, for
+	// example "America/New_York". An empty value means UTC.
+	Location string
+}
+
+// CheckAndSetDefaults validates the access window and fills in defaults.
+func (w *AccessWindow) CheckAndSetDefaults() error {
+	if w.Location == "" {
+		w.Location = "UTC"
+	}
+	if _, err := time.LoadLocation(w.Location); err != nil {
+		return trace.BadParameter("access window has invalid location %q: %v", w.Location, err)
+	}
+	if w.StartHour < 0 || w.StartHour > 24 {
+		return trace.BadParameter("access window start hour %v is out of range", w.StartHour)
+	}
+	if w.EndHour < 0 || w.EndHour > 24 {
+		return trace.BadParameter("access window end hour %v is out of range", w.EndHour)
+	}
+	if w.StartHour >= w.EndHour {
+		return trace.BadParameter("access window start hour %v must be before end hour %v", w.StartHour, w.EndHour)
+	}
+	return nil
+}
+
+// Contains returns true if t falls within the access window.
+func (w *AccessWindow) Contains(t time.Time) bool {
+	loc, err := time.LoadLocation(w.Location)
+	if err != nil {
+		// CheckAndSetDefaults should have caught this already; fail closed.
+		return false
+	}
+	local := t.In(loc)
+
+	if len(w.Weekdays) > 0 {
+		var dayAllowed bool
+		for _, day := range w.Weekdays {
+			if local.Weekday() == day {
+				dayAllowed = true
+				break
+			}
+		}
+		if !dayAllowed {
+			return false
+		}
+	}
+
+	hour := local.Hour()
+	return hour >= w.StartHour && hour < w.EndHour
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type AccessWindowSuite struct{}
+
+var _ = Suite(&AccessWindowSuite{})
+
+func (s *AccessWindowSuite) TestCheckAndSetDefaults(c *C) {
+	w := AccessWindow{StartHour: 9, EndHour: 17}
+	c.Assert(w.CheckAndSetDefaults(), IsNil)
+	c.Assert(w.Location, Equals, "UTC")
+
+	bad := AccessWindow{StartHour: 17, EndHour: 9}
+	c.Assert(bad.CheckAndSetDefaults(), NotNil)
+
+	badLoc := AccessWindow{StartHour: 9, EndHour: 17, Location: "Not/A/Zone"}
+	c.Assert(badLoc.CheckAndSetDefaults(), NotNil)
+}
+
+func (s *AccessWindowSuite) TestContains(c *C) {
+	w := AccessWindow{
+		Weekdays:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		StartHour: 9,
+		EndHour:   17,
+		Location:  "UTC",
+	}
+	c.Assert(w.CheckAndSetDefaults(), IsNil)
+
+	// Tuesday at 10:00 UTC, within business hours.
+	c.Assert(w.Contains(time.Date(2019, time.March, 5, 10, 0, 0, 0, time.UTC)), Equals, true)
+
+	// Tuesday at 18:00 UTC, after hours.
+	c.Assert(w.Contains(time.Date(2019, time.March, 5, 18, 0, 0, 0, time.UTC)), Equals, false)
+
+	// Saturday at 10:00 UTC, outside allowed weekdays.
+	c.Assert(w.Contains(time.Date(2019, time.March, 9, 10, 0, 0, 0, time.UTC)), Equals, false)
+}
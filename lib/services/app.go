@@ -0,0 +1,104 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"github.com/gravitational/teleport"
+
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// App represents a named internal web application registered with an
+// app_service. Like KubeCluster, it is a process-local value derived from
+// configuration rather than a dynamic, backend-persisted resource: making
+// apps discoverable across every proxy would require extending Presence
+// with UpsertAppServer/GetAppServers, which is not implemented here.
+type App struct {
+	// Name is the name of the application, as it appears to users (for
+	// example, in the application launcher).
+	Name string
+	// URI is the internal address the app_service proxies requests to.
+	URI string
+	// PublicAddr is the DNS name clients use to reach the application
+	// through the proxy.
+	PublicAddr string
+	// StaticLabels is a map of static labels associated with this
+	// application, matched against a role's app_labels to decide access.
+	StaticLabels map[string]string
+	// Protocol is the protocol the app speaks. The zero value, "", means
+	// HTTPS: the app_service terminates TLS and reverse-proxies individual
+	// HTTP requests to URI. "tcp" means URI is forwarded as an opaque byte
+	// stream instead, for apps like internal Redis or RDP gateways that
+	// don't speak HTTP.
+	Protocol string
+}
+
+// AppProtocolTCP identifies a plain TCP application, forwarded as an
+// opaque byte stream rather than reverse-proxied request by request.
+const AppProtocolTCP = "tcp"
+
+// IsTCP returns true if this app should be forwarded as a raw TCP stream
+// instead of being reverse-proxied as HTTPS.
+func (a App) IsTCP() bool {
+	return a.Protocol == AppProtocolTCP
+}
+
+// GetAllLabels returns all labels associated with this application.
+func (a App) GetAllLabels() map[string]string {
+	return a.StaticLabels
+}
+
+// CheckAccessToApp checks if a role set has access to a given application.
+// Access is granted if the application's labels satisfy at least one role's
+// allow rule and no role's deny rule. There is no dedicated app_labels role
+// field yet (adding one means extending the generated RoleConditions proto
+// message), so this reuses node_labels, the same way CheckAccessToKubeCluster
+// reuses it for Kubernetes clusters.
+func (set RoleSet) CheckAccessToApp(app App) error {
+	for _, role := range set {
+		matchLabels, labelsMessage, err := MatchLabels(role.GetNodeLabels(Deny), app.GetAllLabels())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if matchLabels {
+			log.WithFields(log.Fields{
+				trace.Component: teleport.ComponentRBAC,
+			}).Debugf("Access to app %v denied, deny rule in %v matched; match(label=%v)",
+				app.Name, role.GetName(), labelsMessage)
+			return trace.AccessDenied("access to app denied")
+		}
+	}
+
+	var errs []error
+	for _, role := range set {
+		matchLabels, labelsMessage, err := MatchLabels(role.GetNodeLabels(Allow), app.GetAllLabels())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if matchLabels {
+			return nil
+		}
+		errs = append(errs, trace.AccessDenied("role=%v, match(label=%v)", role.GetName(), labelsMessage))
+	}
+
+	log.WithFields(log.Fields{
+		trace.Component: teleport.ComponentRBAC,
+	}).Debugf("Access to app %v denied, no allow rule matched; %v", app.Name, errs)
+	return trace.AccessDenied("access to app denied")
+}
@@ -204,6 +204,12 @@ func (c *AuthPreferenceV2) CheckAndSetDefaults() error {
 	// make sure second factor makes sense
 	switch c.Spec.SecondFactor {
 	case teleport.OFF, teleport.OTP, teleport.U2F:
+	case teleport.WebAuthn:
+		// lib/auth/webauthn is a scaffold only: this Teleport version has no
+		// vendored WebAuthn relying-party library or libfido2 bindings for
+		// tsh, so reject the value explicitly rather than accept a second
+		// factor type nothing actually implements.
+		return trace.BadParameter("second factor type %q is not yet implemented in this Teleport version", c.Spec.SecondFactor)
 	default:
 		return trace.BadParameter("second factor type %q not supported", c.Spec.SecondFactor)
 	}
@@ -0,0 +1,43 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"github.com/gravitational/teleport"
+
+	. "gopkg.in/check.v1"
+)
+
+type AuthenticationSuite struct{}
+
+var _ = Suite(&AuthenticationSuite{})
+
+func (s *AuthenticationSuite) TestSecondFactorValidation(c *C) {
+	for _, sf := range []string{teleport.OFF, teleport.OTP, teleport.U2F} {
+		pref := AuthPreferenceV2{Spec: AuthPreferenceSpecV2{SecondFactor: sf}}
+		c.Assert(pref.CheckAndSetDefaults(), IsNil)
+	}
+
+	// WebAuthn is a recognized value, but lib/auth/webauthn has no working
+	// implementation yet, so it must be rejected rather than silently
+	// accepted as if it were supported.
+	pref := AuthPreferenceV2{Spec: AuthPreferenceSpecV2{SecondFactor: teleport.WebAuthn}}
+	c.Assert(pref.CheckAndSetDefaults(), NotNil)
+
+	pref = AuthPreferenceV2{Spec: AuthPreferenceSpecV2{SecondFactor: "not-a-real-value"}}
+	c.Assert(pref.CheckAndSetDefaults(), NotNil)
+}
@@ -162,7 +162,10 @@ type CertAuthority interface {
 	GetClusterName() string
 	// GetCheckingKeys returns public keys to check signature
 	GetCheckingKeys() [][]byte
-	// GetSigning keys returns signing keys
+	// GetSigning keys returns signing keys. For a software-backed CA each
+	// entry is a raw PEM private key; for an HSM-backed CA (see
+	// lib/auth/pkcs11ca) each entry is instead an opaque key identifier
+	// that the configured sshca.Authority resolves against the device.
 	GetSigningKeys() [][]byte
 	// CombinedMapping is used to specify combined mapping from legacy property Roles
 	// and new property RoleMap
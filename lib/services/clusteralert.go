@@ -0,0 +1,177 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// AlertSeverity indicates how urgently a cluster alert should be surfaced
+// to a user
+type AlertSeverity string
+
+const (
+	// AlertSeverityLow is an informational alert that does not require action
+	AlertSeverityLow AlertSeverity = "low"
+	// AlertSeverityMedium is an alert that should be addressed soon
+	AlertSeverityMedium AlertSeverity = "medium"
+	// AlertSeverityHigh is an alert that should be addressed immediately
+	AlertSeverityHigh AlertSeverity = "high"
+)
+
+// ClusterAlert represents an operational condition surfaced by the auth
+// server to cluster users, e.g. a CA rotation in progress, a certificate
+// nearing expiry, or a connected agent running an incompatible version.
+type ClusterAlert interface {
+	// Resource provides common resource properties
+	Resource
+	// GetSeverity returns the severity of the alert
+	GetSeverity() AlertSeverity
+	// GetMessage returns the human-readable alert message
+	GetMessage() string
+
+	// CheckAndSetDefaults checks and sets default values
+	CheckAndSetDefaults() error
+}
+
+// NewClusterAlert creates a new ClusterAlert resource
+func NewClusterAlert(name string, severity AlertSeverity, message string) (ClusterAlert, error) {
+	alert := &ClusterAlertV1{
+		Kind:    KindClusterAlert,
+		Version: V1,
+		Metadata: Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+		Spec: ClusterAlertSpecV1{
+			Severity: severity,
+			Message:  message,
+		},
+	}
+	if err := alert.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return alert, nil
+}
+
+// ClusterAlertV1 implements ClusterAlert
+type ClusterAlertV1 struct {
+	// Kind is a resource kind - always cluster_alert
+	Kind string `json:"kind"`
+	// SubKind is a resource sub kind
+	SubKind string `json:"sub_kind,omitempty"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Metadata is metadata about the resource
+	Metadata Metadata `json:"metadata"`
+	// Spec is the alert specification
+	Spec ClusterAlertSpecV1 `json:"spec"`
+}
+
+// ClusterAlertSpecV1 is the specification of a ClusterAlert
+type ClusterAlertSpecV1 struct {
+	// Severity is how urgently the alert should be surfaced
+	Severity AlertSeverity `json:"severity"`
+	// Message is the human-readable alert message
+	Message string `json:"message"`
+}
+
+// GetVersion returns resource version
+func (c *ClusterAlertV1) GetVersion() string {
+	return c.Version
+}
+
+// GetKind returns resource kind
+func (c *ClusterAlertV1) GetKind() string {
+	return c.Kind
+}
+
+// GetSubKind returns resource sub kind
+func (c *ClusterAlertV1) GetSubKind() string {
+	return c.SubKind
+}
+
+// SetSubKind sets resource subkind
+func (c *ClusterAlertV1) SetSubKind(s string) {
+	c.SubKind = s
+}
+
+// GetResourceID returns resource ID
+func (c *ClusterAlertV1) GetResourceID() int64 {
+	return c.Metadata.ID
+}
+
+// SetResourceID sets resource ID
+func (c *ClusterAlertV1) SetResourceID(id int64) {
+	c.Metadata.ID = id
+}
+
+// GetMetadata returns object metadata
+func (c *ClusterAlertV1) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+// GetName returns the name of the alert
+func (c *ClusterAlertV1) GetName() string {
+	return c.Metadata.Name
+}
+
+// SetName sets the name of the alert
+func (c *ClusterAlertV1) SetName(name string) {
+	c.Metadata.Name = name
+}
+
+// SetExpiry sets expiry time for the object
+func (c *ClusterAlertV1) SetExpiry(expires time.Time) {
+	c.Metadata.SetExpiry(expires)
+}
+
+// Expiry returns object expiry setting
+func (c *ClusterAlertV1) Expiry() time.Time {
+	return c.Metadata.Expiry()
+}
+
+// SetTTL sets Expires header using the provided clock
+func (c *ClusterAlertV1) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	c.Metadata.SetTTL(clock, ttl)
+}
+
+// GetSeverity returns the severity of the alert
+func (c *ClusterAlertV1) GetSeverity() AlertSeverity {
+	return c.Spec.Severity
+}
+
+// GetMessage returns the human-readable alert message
+func (c *ClusterAlertV1) GetMessage() string {
+	return c.Spec.Message
+}
+
+// CheckAndSetDefaults checks and sets default values
+func (c *ClusterAlertV1) CheckAndSetDefaults() error {
+	if c.Spec.Severity == "" {
+		c.Spec.Severity = AlertSeverityLow
+	}
+	if c.Spec.Message == "" {
+		return trace.BadParameter("cluster alert message is required")
+	}
+	return c.Metadata.CheckAndSetDefaults()
+}
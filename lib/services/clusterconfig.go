@@ -89,10 +89,33 @@ type ClusterConfig interface {
 	// the server disconnects the client.
 	SetKeepAliveCountMax(c int64)
 
+	// GetStrictHostCheck returns the strict host certificate checking setting.
+	GetStrictHostCheck() bool
+
+	// SetStrictHostCheck sets the strict host certificate checking setting.
+	SetStrictHostCheck(bool)
+
+	// GetAmbiguousHostResolution returns how a dial that matches more than
+	// one registered Server resource by hostname is resolved.
+	GetAmbiguousHostResolution() string
+
+	// SetAmbiguousHostResolution sets how a dial that matches more than one
+	// registered Server resource by hostname is resolved.
+	SetAmbiguousHostResolution(string)
+
 	// Copy creates a copy of the resource and returns it.
 	Copy() ClusterConfig
 }
 
+const (
+	// AmbiguousHostResolutionError rejects a dial that matches more than one
+	// registered Server resource by hostname. This is the default.
+	AmbiguousHostResolutionError = "error"
+	// AmbiguousHostResolutionMostRecent picks the server that heartbeated
+	// most recently out of all servers that match a dial by hostname.
+	AmbiguousHostResolutionMostRecent = "most_recent"
+)
+
 // NewClusterConfig is a convenience wrapper to create a ClusterConfig resource.
 func NewClusterConfig(spec ClusterConfigSpecV3) (ClusterConfig, error) {
 	cc := ClusterConfigV3{
@@ -150,16 +173,60 @@ func AuditConfigFromObject(in interface{}) (*AuditConfig, error) {
 
 const (
 	// RecordAtNode is the default. Sessions are recorded at Teleport nodes.
+	// Each node spills the session recording to local disk as it happens
+	// and uploads it to the auth server's session storage asynchronously,
+	// in the background, decoupled from the lifetime of the session itself.
 	RecordAtNode string = "node"
 
 	// RecordAtProxy enables the recording proxy which intercepts and records
 	// all sessions.
 	RecordAtProxy string = "proxy"
 
-	// RecordOff is used to disable session recording completely.
+	// RecordOff is used to disable session recording completely. Audit
+	// events (session.start, exec, session.end, and the like) are still
+	// emitted and stored in the audit log; only the session's terminal
+	// output, which is what would otherwise be replayed with `tsh play`,
+	// is not recorded.
 	RecordOff string = "off"
 )
 
+// SessionRecordingModeLabel is a node label that, when set to one of
+// RecordAtNode, RecordAtProxy or RecordOff, overrides the cluster-wide
+// session recording mode for sessions to that node. This lets operators
+// dial in recording behavior per-host, for example enabling RecordAtProxy
+// for agentless hosts that can't record locally, or RecordOff for CI
+// runners that would otherwise flood the session storage with throwaway
+// output. A missing or unrecognized value falls back to the cluster's
+// configured mode.
+const SessionRecordingModeLabel = "teleport.internal/session-recording-mode"
+
+// AgentlessTransportLabel is a node label that, when set to one of
+// AgentlessTransportSSM or AgentlessTransportEC2InstanceConnect, tells the
+// proxy to reach that node through AWS SSM Session Manager or EC2 Instance
+// Connect instead of a direct TCP dial or a reverse tunnel. This is for
+// nodes that run no Teleport agent and have no inbound SSH route the proxy
+// can otherwise use. A missing or unrecognized value leaves the normal
+// direct-dial/tunnel resolution unchanged.
+const AgentlessTransportLabel = "teleport.internal/agentless-transport"
+
+const (
+	// AgentlessTransportSSM selects AWS SSM Session Manager as the dial
+	// transport for a node labeled with AgentlessTransportLabel.
+	AgentlessTransportSSM = "ssm"
+
+	// AgentlessTransportEC2InstanceConnect selects AWS EC2 Instance Connect
+	// as the dial transport for a node labeled with AgentlessTransportLabel.
+	AgentlessTransportEC2InstanceConnect = "ec2-instance-connect"
+)
+
+// AWSInstanceIDLabel and AWSRegionLabel identify the EC2 instance and
+// region an agentless node's AgentlessTransportLabel transport should
+// target; both are required alongside AgentlessTransportLabel.
+const (
+	AWSInstanceIDLabel = "teleport.internal/aws-instance-id"
+	AWSRegionLabel     = "teleport.internal/aws-region"
+)
+
 const (
 	// HostKeyCheckYes is the default. The proxy will check the host key of the
 	// target node it connects to.
@@ -290,6 +357,31 @@ func (c *ClusterConfigV3) SetDisconnectExpiredCert(b bool) {
 	c.Spec.DisconnectExpiredCert = NewBool(b)
 }
 
+// GetStrictHostCheck returns the strict host certificate checking setting.
+func (c *ClusterConfigV3) GetStrictHostCheck() bool {
+	return c.Spec.StrictHostCheck.Value()
+}
+
+// SetStrictHostCheck sets the strict host certificate checking setting.
+func (c *ClusterConfigV3) SetStrictHostCheck(b bool) {
+	c.Spec.StrictHostCheck = NewBool(b)
+}
+
+// GetAmbiguousHostResolution returns how a dial that matches more than one
+// registered Server resource by hostname is resolved.
+func (c *ClusterConfigV3) GetAmbiguousHostResolution() string {
+	if c.Spec.AmbiguousHostResolution == "" {
+		return AmbiguousHostResolutionError
+	}
+	return c.Spec.AmbiguousHostResolution
+}
+
+// SetAmbiguousHostResolution sets how a dial that matches more than one
+// registered Server resource by hostname is resolved.
+func (c *ClusterConfigV3) SetAmbiguousHostResolution(value string) {
+	c.Spec.AmbiguousHostResolution = value
+}
+
 // GetKeepAliveInterval gets the keep-alive interval.
 func (c *ClusterConfigV3) GetKeepAliveInterval() time.Duration {
 	return c.Spec.KeepAliveInterval.Duration()
@@ -350,6 +442,15 @@ func (c *ClusterConfigV3) CheckAndSetDefaults() error {
 		c.Spec.KeepAliveCountMax = int64(defaults.KeepAliveCountMax)
 	}
 
+	if c.Spec.AmbiguousHostResolution == "" {
+		c.Spec.AmbiguousHostResolution = AmbiguousHostResolutionError
+	}
+	all = []string{AmbiguousHostResolutionError, AmbiguousHostResolutionMostRecent}
+	ok = utils.SliceContainsStr(all, c.Spec.AmbiguousHostResolution)
+	if !ok {
+		return trace.BadParameter("ambiguous_host_resolution must be one of: %v", strings.Join(all, ","))
+	}
+
 	return nil
 }
 
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"github.com/gravitational/teleport"
+
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DatabaseProtocolPostgres identifies a PostgreSQL database, proxied by
+// speaking enough of the PostgreSQL wire protocol to extract audit fields
+// from the client's startup message.
+const DatabaseProtocolPostgres = "postgres"
+
+// DatabaseProtocolMySQL identifies a MySQL or MariaDB database, proxied by
+// speaking enough of the MySQL wire protocol to extract audit fields from
+// the client's handshake response.
+const DatabaseProtocolMySQL = "mysql"
+
+// DatabaseProtocolMongoDB identifies a MongoDB database, proxied by
+// speaking enough of the MongoDB wire protocol to extract the command
+// name and target database out of every message a client sends.
+const DatabaseProtocolMongoDB = "mongodb"
+
+// Database represents a named database registered with a db_service. Like
+// KubeCluster and App, it is a process-local value derived from
+// configuration rather than a dynamic, backend-persisted resource: making
+// databases discoverable across every db_service instance would require
+// extending Presence with UpsertDatabaseServer/GetDatabaseServers, which is
+// not implemented here.
+type Database struct {
+	// Name is the name of the database, as it appears to users (for
+	// example, in "tsh db ls").
+	Name string
+	// Protocol is the wire protocol the database speaks, e.g.
+	// DatabaseProtocolPostgres.
+	Protocol string
+	// URI is the address of the database server the db_service connects
+	// to, e.g. "postgres.internal:5432".
+	URI string
+	// StaticLabels is a map of static labels associated with this
+	// database, matched against a role's db_labels to decide access.
+	StaticLabels map[string]string
+}
+
+// GetAllLabels returns all labels associated with this database.
+func (d Database) GetAllLabels() map[string]string {
+	return d.StaticLabels
+}
+
+// CheckAccessToDatabase checks if a role set has access to a given
+// database. Access is granted if the database's labels satisfy at least
+// one role's allow rule and no role's deny rule. There is no dedicated
+// db_labels role field yet (adding one means extending the generated
+// RoleConditions proto message), so this reuses node_labels, the same way
+// CheckAccessToKubeCluster and CheckAccessToApp do for their resources.
+func (set RoleSet) CheckAccessToDatabase(db Database) error {
+	for _, role := range set {
+		matchLabels, labelsMessage, err := MatchLabels(role.GetNodeLabels(Deny), db.GetAllLabels())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if matchLabels {
+			log.WithFields(log.Fields{
+				trace.Component: teleport.ComponentRBAC,
+			}).Debugf("Access to database %v denied, deny rule in %v matched; match(label=%v)",
+				db.Name, role.GetName(), labelsMessage)
+			return trace.AccessDenied("access to database denied")
+		}
+	}
+
+	var errs []error
+	for _, role := range set {
+		matchLabels, labelsMessage, err := MatchLabels(role.GetNodeLabels(Allow), db.GetAllLabels())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if matchLabels {
+			return nil
+		}
+		errs = append(errs, trace.AccessDenied("role=%v, match(label=%v)", role.GetName(), labelsMessage))
+	}
+
+	log.WithFields(log.Fields{
+		trace.Component: teleport.ComponentRBAC,
+	}).Debugf("Access to database %v denied, no allow rule matched; %v", db.Name, errs)
+	return trace.AccessDenied("access to database denied")
+}
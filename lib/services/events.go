@@ -36,6 +36,12 @@ type Watch struct {
 
 	// MetricComponent is used for reporting
 	MetricComponent string
+
+	// StartAfterEventID is an optional resume token, see
+	// backend.Watch.StartAfterEventID. Implementations that cannot honor
+	// it (e.g. a watcher backed by a remote gRPC stream) are free to
+	// ignore it and always send OpInit.
+	StartAfterEventID int64
 }
 
 // WatchKind specifies resource kind to watch
@@ -121,6 +121,14 @@ type Identity interface {
 	// DeleteWebSession deletes web session from the storage
 	DeleteWebSession(user, sid string) error
 
+	// GetWebSessions returns all web sessions for a user, for example to
+	// list the devices a user is currently signed in on
+	GetWebSessions(user string) ([]WebSession, error)
+
+	// DeleteAllWebSessions deletes all web sessions for a user, used to log
+	// a user out of every device at once
+	DeleteAllWebSessions(user string) error
+
 	// UpsertPassword upserts new password and OTP token
 	UpsertPassword(user string, password []byte) error
 
@@ -0,0 +1,84 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"github.com/gravitational/teleport"
+
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// KubeCluster represents a named Kubernetes cluster (in practice, one
+// kubeconfig context) served by a kube proxy or kube_service. Unlike Server,
+// it is not yet a dynamic, backend-persisted resource: it is derived from
+// local kubeconfig contents by the process serving it and only lives for the
+// lifetime of that process. Cluster-wide discovery across many agents would
+// require extending Presence with UpsertKubeService/GetKubeServices, which
+// is not implemented here.
+type KubeCluster struct {
+	// Name is the name of the Kubernetes cluster, as it appears to users
+	// (for example, in "tsh kube login").
+	Name string
+	// StaticLabels is a map of static labels associated with this cluster,
+	// matched against a role's node_labels to decide access, the same way
+	// static labels are matched for SSH nodes.
+	StaticLabels map[string]string
+}
+
+// GetAllLabels returns all labels associated with this Kubernetes cluster.
+func (k KubeCluster) GetAllLabels() map[string]string {
+	return k.StaticLabels
+}
+
+// CheckAccessToKubeCluster checks if a role set has access to a given
+// Kubernetes cluster. Access is granted if the cluster's labels satisfy at
+// least one role's allow rule and no role's deny rule, using the same
+// node_labels matching SSH node access uses.
+func (set RoleSet) CheckAccessToKubeCluster(kubeCluster KubeCluster) error {
+	for _, role := range set {
+		matchLabels, labelsMessage, err := MatchLabels(role.GetNodeLabels(Deny), kubeCluster.GetAllLabels())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if matchLabels {
+			log.WithFields(log.Fields{
+				trace.Component: teleport.ComponentRBAC,
+			}).Debugf("Access to kubernetes cluster %v denied, deny rule in %v matched; match(label=%v)",
+				kubeCluster.Name, role.GetName(), labelsMessage)
+			return trace.AccessDenied("access to kubernetes cluster denied")
+		}
+	}
+
+	var errs []error
+	for _, role := range set {
+		matchLabels, labelsMessage, err := MatchLabels(role.GetNodeLabels(Allow), kubeCluster.GetAllLabels())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if matchLabels {
+			return nil
+		}
+		errs = append(errs, trace.AccessDenied("role=%v, match(label=%v)", role.GetName(), labelsMessage))
+	}
+
+	log.WithFields(log.Fields{
+		trace.Component: teleport.ComponentRBAC,
+	}).Debugf("Access to kubernetes cluster %v denied, no allow rule matched; %v", kubeCluster.Name, errs)
+	return trace.AccessDenied("access to kubernetes cluster denied")
+}
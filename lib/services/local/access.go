@@ -18,6 +18,7 @@ package local
 
 import (
 	"context"
+	"encoding/json"
 	"sort"
 
 	"github.com/gravitational/teleport/lib/backend"
@@ -60,6 +61,28 @@ func (s *AccessService) GetRoles() ([]services.Role, error) {
 	return out, nil
 }
 
+// GetRoleVersions returns the schema version each stored role actually has
+// in the backend, keyed by role name, without up-converting any of them.
+// GetRoles always returns fully up-converted RoleV3s regardless of what's on
+// disk, so callers that need to tell a role that's due for migration from
+// one that's already current (e.g. migrateRoleVersions) can't use it for
+// that and must go through this instead.
+func (s *AccessService) GetRoleVersions() (map[string]string, error) {
+	result, err := s.GetRange(context.TODO(), backend.Key(rolesPrefix), backend.RangeEnd(backend.Key(rolesPrefix)), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	versions := make(map[string]string, len(result.Items))
+	for _, item := range result.Items {
+		var h services.ResourceHeader
+		if err := json.Unmarshal(item.Value, &h); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		versions[h.Metadata.Name] = h.Version
+	}
+	return versions, nil
+}
+
 // CreateRole creates a role on the backend.
 func (s *AccessService) CreateRole(role services.Role) error {
 	value, err := services.GetRoleMarshaler().MarshalRole(role)
@@ -89,10 +89,11 @@ func (e *EventsService) NewWatcher(ctx context.Context, watch services.Watch) (s
 	// sort so that longer prefixes get first
 	sort.Slice(parsers, func(i, j int) bool { return len(parsers[i].prefix()) > len(parsers[j].prefix()) })
 	w, err := e.backend.NewWatcher(ctx, backend.Watch{
-		Name:            watch.Name,
-		Prefixes:        prefixes,
-		QueueSize:       watch.QueueSize,
-		MetricComponent: watch.MetricComponent,
+		Name:              watch.Name,
+		Prefixes:          prefixes,
+		QueueSize:         watch.QueueSize,
+		MetricComponent:   watch.MetricComponent,
+		StartAfterEventID: watch.StartAfterEventID,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -225,6 +226,7 @@ func (p *certAuthorityParser) parse(event backend.Event) (services.Resource, err
 			Metadata: services.Metadata{
 				Name:      name,
 				Namespace: defaults.Namespace,
+				ID:        event.Item.ID,
 			},
 		}, nil
 	case backend.OpPut:
@@ -599,6 +601,7 @@ func (p *tunnelConnectionParser) parse(event backend.Event) (services.Resource,
 			Metadata: services.Metadata{
 				Name:      name,
 				Namespace: defaults.Namespace,
+				ID:        event.Item.ID,
 			},
 		}, nil
 	case backend.OpPut:
@@ -676,14 +679,16 @@ func resourceHeader(event backend.Event, kind, version string, offset int) (serv
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return &services.ResourceHeader{
+	h := &services.ResourceHeader{
 		Kind:    kind,
 		Version: version,
 		Metadata: services.Metadata{
 			Name:      string(name),
 			Namespace: defaults.Namespace,
 		},
-	}, nil
+	}
+	h.SetResourceID(event.Item.ID)
+	return h, nil
 }
 
 // base returns last element delimited by separator, index is
@@ -314,8 +314,36 @@ func (s *PresenceService) UpsertAuthServer(server services.Server) error {
 
 // UpsertProxy registers proxy server presence, permanently if ttl is 0 or
 // for the specified duration with second resolution if it's >= 1 second
-func (s *PresenceService) UpsertProxy(server services.Server) error {
-	return s.upsertServer(proxiesPrefix, server)
+func (s *PresenceService) UpsertProxy(server services.Server) (*services.KeepAlive, error) {
+	value, err := services.GetServerMarshaler().MarshalServer(server)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	lease, err := s.Put(context.TODO(), backend.Item{
+		Key:     backend.Key(proxiesPrefix, server.GetName()),
+		Value:   value,
+		Expires: server.Expiry(),
+		ID:      server.GetResourceID(),
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if server.Expiry().IsZero() {
+		return &services.KeepAlive{}, nil
+	}
+	return &services.KeepAlive{LeaseID: lease.ID, ServerName: server.GetName()}, nil
+}
+
+// KeepAliveProxy extends the TTL of a proxy's presence entry
+func (s *PresenceService) KeepAliveProxy(ctx context.Context, h services.KeepAlive) error {
+	if h.IsEmpty() {
+		return trace.BadParameter("no lease ID or server name is specified")
+	}
+	err := s.KeepAlive(ctx, backend.Lease{
+		ID:  h.LeaseID,
+		Key: backend.Key(proxiesPrefix, h.ServerName),
+	}, h.Expires)
+	return trace.Wrap(err)
 }
 
 // GetProxies returns a list of registered proxies
@@ -594,6 +622,27 @@ func (s *PresenceService) CreateRemoteCluster(rc services.RemoteCluster) error {
 	return nil
 }
 
+// UpsertRemoteCluster creates or updates remote cluster
+func (s *PresenceService) UpsertRemoteCluster(rc services.RemoteCluster) error {
+	if err := rc.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := json.Marshal(rc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = s.Put(context.TODO(), backend.Item{
+		Key:     backend.Key(remoteClustersPrefix, rc.GetName()),
+		Value:   value,
+		Expires: rc.Expiry(),
+		ID:      rc.GetResourceID(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
 // GetRemoteClusters returns a list of remote clusters
 func (s *PresenceService) GetRemoteClusters(opts ...services.MarshalOption) ([]services.RemoteCluster, error) {
 	startKey := backend.Key(remoteClustersPrefix)
@@ -645,12 +694,96 @@ func (s *PresenceService) DeleteAllRemoteClusters() error {
 	return trace.Wrap(err)
 }
 
+// CreatePendingOperation creates a pending operation
+func (s *PresenceService) CreatePendingOperation(op services.PendingOperation) error {
+	value, err := json.Marshal(op)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	item := backend.Item{
+		Key:     backend.Key(pendingOperationsPrefix, op.GetName()),
+		Value:   value,
+		Expires: op.Expiry(),
+	}
+	_, err = s.Create(context.TODO(), item)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// UpsertPendingOperation creates or updates a pending operation
+func (s *PresenceService) UpsertPendingOperation(op services.PendingOperation) error {
+	if err := op.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := json.Marshal(op)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = s.Put(context.TODO(), backend.Item{
+		Key:     backend.Key(pendingOperationsPrefix, op.GetName()),
+		Value:   value,
+		Expires: op.Expiry(),
+		ID:      op.GetResourceID(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetPendingOperations returns a list of pending operations
+func (s *PresenceService) GetPendingOperations(opts ...services.MarshalOption) ([]services.PendingOperation, error) {
+	startKey := backend.Key(pendingOperationsPrefix)
+	result, err := s.GetRange(context.TODO(), startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	ops := make([]services.PendingOperation, len(result.Items))
+	for i, item := range result.Items {
+		op, err := services.UnmarshalPendingOperation(item.Value,
+			services.AddOptions(opts, services.WithResourceID(item.ID), services.WithExpires(item.Expires))...)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		ops[i] = op
+	}
+	return ops, nil
+}
+
+// GetPendingOperation returns a pending operation by name
+func (s *PresenceService) GetPendingOperation(name string) (services.PendingOperation, error) {
+	if name == "" {
+		return nil, trace.BadParameter("missing parameter name")
+	}
+	item, err := s.Get(context.TODO(), backend.Key(pendingOperationsPrefix, name))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("pending operation %q is not found", name)
+		}
+		return nil, trace.Wrap(err)
+	}
+	return services.UnmarshalPendingOperation(item.Value,
+		services.WithResourceID(item.ID), services.WithExpires(item.Expires))
+}
+
+// DeletePendingOperation deletes a pending operation by name
+func (s *PresenceService) DeletePendingOperation(name string) error {
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	return s.Delete(context.TODO(), backend.Key(pendingOperationsPrefix, name))
+}
+
 const (
 	localClusterPrefix      = "localCluster"
 	reverseTunnelsPrefix    = "reverseTunnels"
 	tunnelConnectionsPrefix = "tunnelConnections"
 	trustedClustersPrefix   = "trustedclusters"
 	remoteClustersPrefix    = "remoteClusters"
+	pendingOperationsPrefix = "pendingOperations"
 	nodesPrefix             = "nodes"
 	namespacesPrefix        = "namespaces"
 	authServersPrefix       = "authservers"
@@ -30,29 +30,68 @@ func (s *CA) DeleteAllCertAuthorities(caType services.CertAuthType) error {
 
 // CreateCertAuthority updates or inserts a new certificate authority
 func (s *CA) CreateCertAuthority(ca services.CertAuthority) error {
-	if err := ca.Check(); err != nil {
+	item, err := caItem(ca)
+	if err != nil {
 		return trace.Wrap(err)
 	}
-	value, err := services.GetCertAuthorityMarshaler().MarshalCertAuthority(ca)
+	_, err = s.Create(context.TODO(), *item)
 	if err != nil {
+		if trace.IsAlreadyExists(err) {
+			return trace.AlreadyExists("cluster %q already exists", ca.GetName())
+		}
 		return trace.Wrap(err)
 	}
-	item := backend.Item{
-		Key:     backend.Key(authoritiesPrefix, string(ca.GetType()), ca.GetName()),
-		Value:   value,
-		Expires: ca.Expiry(),
-	}
+	return nil
+}
 
-	_, err = s.Create(context.TODO(), item)
-	if err != nil {
+// CreateCertAuthorities creates multiple certificate authorities in a
+// single backend transaction if the backend supports it, so a crash
+// partway through leaves either none or all of the authorities in place.
+// Falls back to creating them one at a time if the backend does not
+// implement backend.Batch.
+func (s *CA) CreateCertAuthorities(cas ...services.CertAuthority) error {
+	items := make([]backend.Item, len(cas))
+	for i, ca := range cas {
+		item, err := caItem(ca)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		items[i] = *item
+	}
+	batch, ok := s.Backend.(backend.Batch)
+	if !ok {
+		for _, ca := range cas {
+			if err := s.CreateCertAuthority(ca); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	}
+	if err := batch.CreateRange(context.TODO(), items); err != nil {
 		if trace.IsAlreadyExists(err) {
-			return trace.AlreadyExists("cluster %q already exists", ca.GetName())
+			return trace.AlreadyExists("one of the certificate authorities already exists")
 		}
 		return trace.Wrap(err)
 	}
 	return nil
 }
 
+// caItem builds the backend.Item used to store ca
+func caItem(ca services.CertAuthority) (*backend.Item, error) {
+	if err := ca.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	value, err := services.GetCertAuthorityMarshaler().MarshalCertAuthority(ca)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &backend.Item{
+		Key:     backend.Key(authoritiesPrefix, string(ca.GetType()), ca.GetName()),
+		Value:   value,
+		Expires: ca.Expiry(),
+	}, nil
+}
+
 // UpsertCertAuthority updates or inserts a new certificate authority
 func (s *CA) UpsertCertAuthority(ca services.CertAuthority) error {
 	if err := ca.Check(); err != nil {
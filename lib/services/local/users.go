@@ -473,6 +473,39 @@ func (s *IdentityService) DeleteWebSession(user, sid string) error {
 	return trace.Wrap(err)
 }
 
+// GetWebSessions returns all web sessions for a user, for example to list
+// the devices a user is currently signed in on
+func (s *IdentityService) GetWebSessions(user string) ([]services.WebSession, error) {
+	if user == "" {
+		return nil, trace.BadParameter("missing username")
+	}
+	startKey := backend.Key(webPrefix, usersPrefix, user, sessionsPrefix)
+	result, err := s.GetRange(context.TODO(), startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]services.WebSession, len(result.Items))
+	for i, item := range result.Items {
+		session, err := services.GetWebSessionMarshaler().UnmarshalWebSession(item.Value)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out[i] = session
+	}
+	return out, nil
+}
+
+// DeleteAllWebSessions deletes all web sessions for a user, used to log a
+// user out of every device at once
+func (s *IdentityService) DeleteAllWebSessions(user string) error {
+	if user == "" {
+		return trace.BadParameter("missing username")
+	}
+	startKey := backend.Key(webPrefix, usersPrefix, user, sessionsPrefix)
+	err := s.DeleteRange(context.TODO(), startKey, backend.RangeEnd(startKey))
+	return trace.Wrap(err)
+}
+
 // UpsertPassword upserts new password hash into a backend.
 func (s *IdentityService) UpsertPassword(user string, password []byte) error {
 	if user == "" {
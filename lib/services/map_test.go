@@ -186,6 +186,14 @@ func (s *RoleMapSuite) TestRoleMap(c *check.C) {
 				{Remote: "^(remote-(.*))$", Local: []string{"$1", "$2"}},
 			},
 		},
+		{
+			name:   "single capture group rule maps an entire family of remote roles",
+			remote: []string{"admins-eng", "admins-sales"},
+			local:  []string{"leaf-eng", "leaf-sales"},
+			roleMap: RoleMap{
+				{Remote: "^admins-(.*)$", Local: []string{"leaf-$1"}},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
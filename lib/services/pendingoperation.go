@@ -0,0 +1,329 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+const (
+	// PendingOperationDeleteRole requests deletion of a role.
+	PendingOperationDeleteRole = "delete_role"
+	// PendingOperationDeleteTrustedCluster requests removal of a trusted
+	// cluster.
+	PendingOperationDeleteTrustedCluster = "delete_trusted_cluster"
+	// PendingOperationRotateCertAuthority requests a certificate authority
+	// rotation.
+	PendingOperationRotateCertAuthority = "rotate_ca"
+)
+
+const (
+	// PendingOperationPending means the operation has not yet received the
+	// second approval it needs to run.
+	PendingOperationPending = "pending"
+	// PendingOperationApproved means the operation was approved and applied.
+	PendingOperationApproved = "approved"
+	// PendingOperationDenied means the operation was denied and will never
+	// be applied.
+	PendingOperationDenied = "denied"
+)
+
+// pendingOperationActions is the set of actions the two-person rule is
+// enforced for. See PendingOperation for details.
+var pendingOperationActions = map[string]bool{
+	PendingOperationDeleteRole:           true,
+	PendingOperationDeleteTrustedCluster: true,
+	PendingOperationRotateCertAuthority:  true,
+}
+
+// PendingOperation represents a destructive administrative action, such as
+// deleting a role or rotating a certificate authority, that has been
+// requested by one administrator but is withheld from the auth server until
+// a second administrator approves it (the "two-person rule"). tctl creates a
+// PendingOperation in place of performing the action directly, and applies
+// the action once ApprovedBy is set by a different administrator than
+// RequestedBy.
+type PendingOperation interface {
+	// Resource provides common resource properties
+	Resource
+
+	// GetAction returns the kind of operation being requested, one of the
+	// PendingOperationXXX constants.
+	GetAction() string
+
+	// GetTarget returns the name of the resource the operation applies to,
+	// for example a role or trusted cluster name. Empty for operations that
+	// do not target a single named resource.
+	GetTarget() string
+
+	// GetParams returns any extra, action-specific parameters serialized as
+	// JSON, for example a certificate authority rotation request.
+	GetParams() string
+
+	// GetRequestedBy returns the username that created the operation.
+	GetRequestedBy() string
+
+	// GetStatus returns the current status of the operation, one of the
+	// PendingOperationXXX status constants.
+	GetStatus() string
+	// SetStatus sets the status of the operation.
+	SetStatus(string)
+
+	// GetApprovedBy returns the username that approved or denied the
+	// operation, or an empty string if it is still pending.
+	GetApprovedBy() string
+	// SetApprovedBy records the username that approved or denied the
+	// operation.
+	SetApprovedBy(string)
+
+	// CheckAndSetDefaults checks and sets default values
+	CheckAndSetDefaults() error
+}
+
+// NewPendingOperation creates a new PendingOperation resource requesting the
+// given action against the given target on behalf of requestedBy.
+func NewPendingOperation(name, action, target, params, requestedBy string) (PendingOperation, error) {
+	op := &PendingOperationV3{
+		Kind:    KindPendingOperation,
+		Version: V3,
+		Metadata: Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+		Spec: PendingOperationSpecV3{
+			Action:      action,
+			Target:      target,
+			Params:      params,
+			RequestedBy: requestedBy,
+			Status:      PendingOperationPending,
+		},
+	}
+	if err := op.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return op, nil
+}
+
+// PendingOperationV3 implements PendingOperation.
+type PendingOperationV3 struct {
+	// Kind is a resource kind - always pending_operation.
+	Kind string `json:"kind"`
+
+	// SubKind is a resource sub kind.
+	SubKind string `json:"sub_kind,omitempty"`
+
+	// Version is a resource version.
+	Version string `json:"version"`
+
+	// Metadata is metadata about the resource.
+	Metadata Metadata `json:"metadata"`
+
+	// Spec is the pending operation spec.
+	Spec PendingOperationSpecV3 `json:"spec"`
+}
+
+// PendingOperationSpecV3 is the pending operation spec.
+type PendingOperationSpecV3 struct {
+	// Action is the kind of operation being requested.
+	Action string `json:"action"`
+	// Target is the name of the resource the operation applies to.
+	Target string `json:"target,omitempty"`
+	// Params holds extra, action-specific parameters serialized as JSON.
+	Params string `json:"params,omitempty"`
+	// RequestedBy is the username that created the operation.
+	RequestedBy string `json:"requested_by"`
+	// ApprovedBy is the username that approved or denied the operation.
+	ApprovedBy string `json:"approved_by,omitempty"`
+	// Status is the current status of the operation.
+	Status string `json:"status"`
+}
+
+// GetVersion returns resource version
+func (p *PendingOperationV3) GetVersion() string {
+	return p.Version
+}
+
+// GetKind returns resource kind
+func (p *PendingOperationV3) GetKind() string {
+	return p.Kind
+}
+
+// GetSubKind returns resource sub kind
+func (p *PendingOperationV3) GetSubKind() string {
+	return p.SubKind
+}
+
+// SetSubKind sets resource subkind
+func (p *PendingOperationV3) SetSubKind(s string) {
+	p.SubKind = s
+}
+
+// GetResourceID returns resource ID
+func (p *PendingOperationV3) GetResourceID() int64 {
+	return p.Metadata.ID
+}
+
+// SetResourceID sets resource ID
+func (p *PendingOperationV3) SetResourceID(id int64) {
+	p.Metadata.ID = id
+}
+
+// GetMetadata returns object metadata
+func (p *PendingOperationV3) GetMetadata() Metadata {
+	return p.Metadata
+}
+
+// GetName returns the name of the PendingOperation.
+func (p *PendingOperationV3) GetName() string {
+	return p.Metadata.Name
+}
+
+// SetName sets the name of the PendingOperation.
+func (p *PendingOperationV3) SetName(e string) {
+	p.Metadata.Name = e
+}
+
+// SetExpiry sets expiry time for the object
+func (p *PendingOperationV3) SetExpiry(expires time.Time) {
+	p.Metadata.SetExpiry(expires)
+}
+
+// Expiry returns object expiry setting
+func (p *PendingOperationV3) Expiry() time.Time {
+	return p.Metadata.Expiry()
+}
+
+// SetTTL sets Expires header using realtime clock
+func (p *PendingOperationV3) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	p.Metadata.SetTTL(clock, ttl)
+}
+
+// GetAction returns the kind of operation being requested.
+func (p *PendingOperationV3) GetAction() string {
+	return p.Spec.Action
+}
+
+// GetTarget returns the name of the resource the operation applies to.
+func (p *PendingOperationV3) GetTarget() string {
+	return p.Spec.Target
+}
+
+// GetParams returns any extra, action-specific parameters.
+func (p *PendingOperationV3) GetParams() string {
+	return p.Spec.Params
+}
+
+// GetRequestedBy returns the username that created the operation.
+func (p *PendingOperationV3) GetRequestedBy() string {
+	return p.Spec.RequestedBy
+}
+
+// GetStatus returns the current status of the operation.
+func (p *PendingOperationV3) GetStatus() string {
+	return p.Spec.Status
+}
+
+// SetStatus sets the status of the operation.
+func (p *PendingOperationV3) SetStatus(status string) {
+	p.Spec.Status = status
+}
+
+// GetApprovedBy returns the username that approved or denied the operation.
+func (p *PendingOperationV3) GetApprovedBy() string {
+	return p.Spec.ApprovedBy
+}
+
+// SetApprovedBy records the username that approved or denied the operation.
+func (p *PendingOperationV3) SetApprovedBy(username string) {
+	p.Spec.ApprovedBy = username
+}
+
+// CheckAndSetDefaults checks and sets default values
+func (p *PendingOperationV3) CheckAndSetDefaults() error {
+	if err := p.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if !pendingOperationActions[p.Spec.Action] {
+		return trace.BadParameter("unsupported pending operation action %q", p.Spec.Action)
+	}
+	if p.Spec.RequestedBy == "" {
+		return trace.BadParameter("missing parameter RequestedBy")
+	}
+	if p.Spec.Status == "" {
+		p.Spec.Status = PendingOperationPending
+	}
+	return nil
+}
+
+// String represents a human readable version of the pending operation.
+func (p *PendingOperationV3) String() string {
+	return fmt.Sprintf("PendingOperation(name=%v, action=%v, target=%v, requested_by=%v, status=%v)",
+		p.Metadata.Name, p.Spec.Action, p.Spec.Target, p.Spec.RequestedBy, p.Spec.Status)
+}
+
+// UnmarshalPendingOperation unmarshals a pending operation from JSON or YAML.
+func UnmarshalPendingOperation(bytes []byte, opts ...MarshalOption) (PendingOperation, error) {
+	cfg, err := collectOptions(opts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(bytes) == 0 {
+		return nil, trace.BadParameter("missing resource data")
+	}
+	var op PendingOperationV3
+	if err := utils.FastUnmarshal(bytes, &op); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := op.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if cfg.ID != 0 {
+		op.SetResourceID(cfg.ID)
+	}
+	if !cfg.Expires.IsZero() {
+		op.SetExpiry(cfg.Expires)
+	}
+	return &op, nil
+}
+
+// MarshalPendingOperation marshals a pending operation to JSON.
+func MarshalPendingOperation(p PendingOperation, opts ...MarshalOption) ([]byte, error) {
+	cfg, err := collectOptions(opts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch resource := p.(type) {
+	case *PendingOperationV3:
+		if !cfg.PreserveResourceID {
+			// avoid modifying the original object
+			// to prevent unexpected data races
+			copy := *resource
+			copy.SetResourceID(0)
+			resource = &copy
+		}
+		return utils.FastMarshal(resource)
+	default:
+		return nil, trace.BadParameter("unrecognized resource version %T", p)
+	}
+}
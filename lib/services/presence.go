@@ -61,8 +61,14 @@ type Presence interface {
 	UpsertAuthServer(server Server) error
 
 	// UpsertProxy registers proxy server presence, permanently if ttl is 0 or
-	// for the specified duration with second resolution if it's >= 1 second
-	UpsertProxy(server Server) error
+	// for the specified duration with second resolution if it's >= 1 second.
+	// It returns a KeepAlive that can be used to cheaply extend the proxy's
+	// TTL instead of re-upserting the full Server resource on every heartbeat.
+	UpsertProxy(server Server) (*KeepAlive, error)
+
+	// KeepAliveProxy extends the TTL of a proxy's presence entry without
+	// rewriting its full Server resource
+	KeepAliveProxy(ctx context.Context, h KeepAlive) error
 
 	// GetProxies returns a list of registered proxies
 	GetProxies() ([]Server, error)
@@ -136,6 +142,10 @@ type Presence interface {
 	// CreateRemoteCluster creates a remote cluster
 	CreateRemoteCluster(RemoteCluster) error
 
+	// UpsertRemoteCluster creates or updates a remote cluster, e.g. to
+	// toggle its enabled state
+	UpsertRemoteCluster(RemoteCluster) error
+
 	// GetRemoteClusters returns a list of remote clusters
 	GetRemoteClusters(opts ...MarshalOption) ([]RemoteCluster, error)
 
@@ -147,6 +157,23 @@ type Presence interface {
 
 	// DeleteAllRemoteClusters deletes all remote clusters
 	DeleteAllRemoteClusters() error
+
+	// CreatePendingOperation creates a pending operation awaiting a second
+	// administrator's approval under the two-person rule.
+	CreatePendingOperation(PendingOperation) error
+
+	// UpsertPendingOperation creates or updates a pending operation, e.g. to
+	// record its approval or denial.
+	UpsertPendingOperation(PendingOperation) error
+
+	// GetPendingOperations returns a list of pending operations
+	GetPendingOperations(opts ...MarshalOption) ([]PendingOperation, error)
+
+	// GetPendingOperation returns a pending operation by name
+	GetPendingOperation(name string) (PendingOperation, error)
+
+	// DeletePendingOperation deletes a pending operation by name
+	DeletePendingOperation(name string) error
 }
 
 // NewNamespace returns new namespace
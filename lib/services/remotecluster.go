@@ -42,6 +42,12 @@ type RemoteCluster interface {
 	// SetLastHeartbeat sets last heartbeat of the cluster
 	SetLastHeartbeat(t time.Time)
 
+	// GetEnabled returns whether the remote cluster is enabled or disabled.
+	GetEnabled() bool
+	// SetEnabled enables (accepts new tunnels, keeps existing ones) or
+	// disables (drops existing tunnels, refuses new ones) the remote cluster.
+	SetEnabled(bool)
+
 	// CheckAndSetDefaults checks and sets default values
 	CheckAndSetDefaults() error
 }
@@ -55,6 +61,7 @@ func NewRemoteCluster(name string) (RemoteCluster, error) {
 			Name:      name,
 			Namespace: defaults.Namespace,
 		},
+		Enabled: true,
 	}, nil
 }
 
@@ -72,6 +79,12 @@ type RemoteClusterV3 struct {
 	// Metadata is metadata about the resource.
 	Metadata Metadata `json:"metadata"`
 
+	// Enabled is a bool that indicates if the RemoteCluster is enabled or
+	// disabled. Disabling a remote cluster drops its existing reverse
+	// tunnels and refuses new ones, without deleting the trust relationship
+	// that created it.
+	Enabled bool `json:"enabled"`
+
 	// Sstatus is read only status of the remote cluster
 	Status RemoteClusterStatusV3 `json:"status"`
 }
@@ -139,6 +152,17 @@ func (c *RemoteClusterV3) SetConnectionStatus(status string) {
 	c.Status.Connection = status
 }
 
+// GetEnabled returns whether the remote cluster is enabled or disabled.
+func (c *RemoteClusterV3) GetEnabled() bool {
+	return c.Enabled
+}
+
+// SetEnabled enables (accepts new tunnels, keeps existing ones) or disables
+// (drops existing tunnels, refuses new ones) the remote cluster.
+func (c *RemoteClusterV3) SetEnabled(e bool) {
+	c.Enabled = e
+}
+
 // GetMetadata returns object metadata
 func (c *RemoteClusterV3) GetMetadata() Metadata {
 	return c.Metadata
@@ -171,7 +195,7 @@ func (c *RemoteClusterV3) SetName(e string) {
 
 // String represents a human readable version of remote cluster settings.
 func (r *RemoteClusterV3) String() string {
-	return fmt.Sprintf("RemoteCluster(%v, %v)", r.Metadata.Name, r.Status.Connection)
+	return fmt.Sprintf("RemoteCluster(%v, enabled=%v, %v)", r.Metadata.Name, r.Enabled, r.Status.Connection)
 }
 
 // RemoteClusterSchemaTemplate is a template JSON Schema for V3 style objects
@@ -183,6 +207,7 @@ const RemoteClusterV3SchemaTemplate = `{
     "kind": {"type": "string"},
     "version": {"type": "string", "default": "v3"},
     "metadata": %v,
+    "enabled": {"type": "boolean"},
     "status": %v
   }
 }`
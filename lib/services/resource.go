@@ -159,6 +159,10 @@ const (
 	// to proxy
 	KindRemoteCluster = "remote_cluster"
 
+	// KindPendingOperation is a destructive operation awaiting a second
+	// administrator's approval under the two-person rule.
+	KindPendingOperation = "pending_operation"
+
 	// KindInviteToken is a local user invite token
 	KindInviteToken = "invite_token"
 
@@ -168,6 +172,11 @@ const (
 	// KindState is local on disk process state
 	KindState = "state"
 
+	// KindClusterAlert is a cluster alert resource, used to surface
+	// operational conditions (CA rotation pending, certificates nearing
+	// expiry, incompatible agent versions) to cluster users
+	KindClusterAlert = "cluster_alert"
+
 	// V3 is the third version of resources.
 	V3 = "v3"
 
@@ -240,6 +249,11 @@ type MarshalConfig struct {
 
 	// Expires is an optional expiry time
 	Expires time.Time
+
+	// Labels, if set, restricts GetNodes to servers matching all of the
+	// given labels, so the filtering happens on the auth server instead of
+	// shipping the full inventory to the caller.
+	Labels map[string]string
 }
 
 // GetVersion returns explicitly provided version or sets latest as default
@@ -306,6 +320,14 @@ func SkipValidation() MarshalOption {
 	}
 }
 
+// WithLabels restricts GetNodes to servers matching all of the given labels.
+func WithLabels(labels map[string]string) MarshalOption {
+	return func(c *MarshalConfig) error {
+		c.Labels = labels
+		return nil
+	}
+}
+
 // marshalerMutex is a mutex for resource marshalers/unmarshalers
 var marshalerMutex sync.RWMutex
 
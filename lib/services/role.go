@@ -57,6 +57,7 @@ var DefaultImplicitRules = []Rule{
 	NewRule(KindCertAuthority, ReadNoSecrets()),
 	NewRule(KindClusterAuthPreference, RO()),
 	NewRule(KindClusterName, RO()),
+	NewRule(KindClusterAlert, RO()),
 	NewRule(KindSSHSession, RO()),
 }
 
@@ -849,7 +850,6 @@ type RuleSet map[string][]Rule
 // Specifying order solves the problem on having multiple rules, e.g. one wildcard
 // rule can override more specific rules with 'where' sections that can have
 // 'actions' lists with side effects that will not be triggered otherwise.
-//
 func (set RuleSet) Match(whereParser predicate.Parser, actionsParser predicate.Parser, resource string, verb string) (bool, error) {
 	// empty set matches nothing
 	if len(set) == 0 {
@@ -1357,12 +1357,22 @@ func MatchNamespace(selectors []string, namespace string) (bool, string) {
 	return false, fmt.Sprintf("no match, role selectors %v, server namespace: %v", selectors, namespace)
 }
 
+// containsGlobPattern returns true if login is a glob-style pattern (e.g.
+// "ubuntu-*") rather than a concrete, literal login.
+func containsGlobPattern(login string) bool {
+	return strings.Contains(login, Wildcard)
+}
+
 // MatchLogin returns true if attempted login matches any of the logins.
+// Selectors may be literal logins or glob-style patterns (e.g. "ubuntu-*"),
+// matched the same way MatchLabels matches label values.
 func MatchLogin(selectors []string, login string) (bool, string) {
-	for _, l := range selectors {
-		if l == login {
-			return true, "matched"
-		}
+	result, err := utils.SliceMatchesRegex(login, selectors)
+	if err != nil {
+		return false, fmt.Sprintf("no match, error matching role selectors %v, login: %v: %v", selectors, login, err)
+	}
+	if result {
+		return true, "matched"
 	}
 	return false, fmt.Sprintf("no match, role selectors %v, login: %v", selectors, login)
 }
@@ -1499,6 +1509,13 @@ func (set RoleSet) CheckKubeGroups(ttl time.Duration) ([]string, error) {
 
 // CheckLoginDuration checks if role set can login up to given duration and
 // returns a combined list of allowed logins.
+//
+// Logins that are glob-style patterns (e.g. "ubuntu-*") are not included:
+// certificates are issued for a concrete requested username, and an SSH
+// certificate's principals must contain that literal username for the
+// handshake to succeed, so a pattern cannot be embedded here. Patterns are
+// only meaningful to MatchLogin, which checks a concrete requested login
+// against the role's selectors at connection time.
 func (set RoleSet) CheckLoginDuration(ttl time.Duration) ([]string, error) {
 	logins := make(map[string]bool)
 	var matchedTTL bool
@@ -1508,6 +1525,9 @@ func (set RoleSet) CheckLoginDuration(ttl time.Duration) ([]string, error) {
 			matchedTTL = true
 
 			for _, login := range role.GetLogins(Allow) {
+				if containsGlobPattern(login) {
+					continue
+				}
 				logins[login] = true
 			}
 		}
@@ -1582,6 +1602,121 @@ func (set RoleSet) CheckAccessToServer(login string, s Server) error {
 	return trace.AccessDenied("access to server denied")
 }
 
+// RoleMatch describes whether a single role's allow or deny rules matched a
+// node and login, and why, as computed by ExplainAccessToServer.
+type RoleMatch struct {
+	// RoleName is the name of the role this match is for.
+	RoleName string `json:"role_name"`
+	// Namespace is true if the role's namespace selectors matched.
+	Namespace bool `json:"namespace"`
+	// NamespaceMessage explains the namespace match result.
+	NamespaceMessage string `json:"namespace_message"`
+	// Labels is true if the role's node label selectors matched.
+	Labels bool `json:"labels"`
+	// LabelsMessage explains the label match result.
+	LabelsMessage string `json:"labels_message"`
+	// Login is true if the role's login selectors matched.
+	Login bool `json:"login"`
+	// LoginMessage explains the login match result.
+	LoginMessage string `json:"login_message"`
+}
+
+// matched returns true if this role's allow (or deny) conditions are
+// satisfied, following the same combination rules as CheckAccessToServer:
+// deny rules trigger on namespace plus either a label or login match, while
+// allow rules require namespace, label, and login to all match.
+func (m RoleMatch) matched(requireLogin bool) bool {
+	if requireLogin {
+		return m.Namespace && m.Labels && m.Login
+	}
+	return m.Namespace && (m.Labels || m.Login)
+}
+
+// AccessExplanation is the structured result of ExplainAccessToServer,
+// describing which role's allow or deny rules decided the outcome of an
+// access check and why, so operators can debug RBAC without trial-and-error
+// logins.
+type AccessExplanation struct {
+	// Allowed is true if access would be granted.
+	Allowed bool `json:"allowed"`
+	// Deny lists, for every role with at least one deny rule, whether that
+	// rule matched the node and login.
+	Deny []RoleMatch `json:"deny"`
+	// Allow lists, for every role, whether its allow rules matched the node
+	// and login.
+	Allow []RoleMatch `json:"allow"`
+}
+
+// ExplainAccessToServer evaluates the same conditions as CheckAccessToServer,
+// but instead of returning only the final error, it returns a structured
+// breakdown of how each role's allow and deny rules matched the node and
+// login, so callers can explain why access was granted or denied.
+func (set RoleSet) ExplainAccessToServer(login string, s Server) (*AccessExplanation, error) {
+	explanation := &AccessExplanation{}
+
+	for _, role := range set {
+		matchNamespace, namespaceMessage := MatchNamespace(role.GetNamespaces(Deny), s.GetNamespace())
+		matchLabels, labelsMessage, err := MatchLabels(role.GetNodeLabels(Deny), s.GetAllLabels())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		matchLogin, loginMessage := MatchLogin(role.GetLogins(Deny), login)
+
+		match := RoleMatch{
+			RoleName:         role.GetName(),
+			Namespace:        matchNamespace,
+			NamespaceMessage: namespaceMessage,
+			Labels:           matchLabels,
+			LabelsMessage:    labelsMessage,
+			Login:            matchLogin,
+			LoginMessage:     loginMessage,
+		}
+		explanation.Deny = append(explanation.Deny, match)
+		if match.matched(false) {
+			return explanation, nil
+		}
+	}
+
+	for _, role := range set {
+		matchNamespace, namespaceMessage := MatchNamespace(role.GetNamespaces(Allow), s.GetNamespace())
+		matchLabels, labelsMessage, err := MatchLabels(role.GetNodeLabels(Allow), s.GetAllLabels())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		matchLogin, loginMessage := MatchLogin(role.GetLogins(Allow), login)
+
+		match := RoleMatch{
+			RoleName:         role.GetName(),
+			Namespace:        matchNamespace,
+			NamespaceMessage: namespaceMessage,
+			Labels:           matchLabels,
+			LabelsMessage:    labelsMessage,
+			Login:            matchLogin,
+			LoginMessage:     loginMessage,
+		}
+		explanation.Allow = append(explanation.Allow, match)
+		if match.matched(true) {
+			explanation.Allowed = true
+		}
+	}
+
+	return explanation, nil
+}
+
+// SessionEnvironment returns the combined set of environment variables
+// injected into the session environment by all roles in the set. If more
+// than one role sets the same variable, the value from the role that
+// appears later in the set wins.
+func (set RoleSet) SessionEnvironment() map[string]string {
+	env := make(map[string]string)
+	for _, role := range set {
+		for k, v := range role.GetOptions().Environment {
+			env[k] = v
+		}
+	}
+	return env
+}
+
 // CanForwardAgents returns true if role set allows forwarding agents.
 func (set RoleSet) CanForwardAgents() bool {
 	for _, role := range set {
@@ -2174,6 +2309,31 @@ func GetRoleSchema(version string, extensionSchema string) string {
 	return fmt.Sprintf(V2SchemaTemplate, MetadataSchema, schema, schemaDefinitions)
 }
 
+// roleUnmarshalFunc decodes a role stored at a specific schema version,
+// applying defaults and validation, and returns it up-converted to the
+// current RoleV3 representation.
+type roleUnmarshalFunc func(data []byte, cfg *MarshalConfig) (*RoleV3, error)
+
+// roleUnmarshalers maps a role resource version to the function that knows
+// how to decode it and up-convert it to the current version. New schema
+// versions (e.g. a future V4) register themselves here with
+// RegisterRoleVersion instead of requiring changes to UnmarshalRole.
+var roleUnmarshalers = map[string]roleUnmarshalFunc{
+	V2: unmarshalRoleV2,
+	V3: unmarshalRoleV3,
+}
+
+// RegisterRoleVersion makes UnmarshalRole aware of how to decode and
+// up-convert roles stored at the given version. It's the extension point
+// for evolving the role schema (adding a V4, etc.) without modifying role
+// parsing itself; every version's unmarshal function is responsible for
+// returning a current, fully up-converted RoleV3.
+func RegisterRoleVersion(version string, fn roleUnmarshalFunc) {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	roleUnmarshalers[version] = fn
+}
+
 // UnmarshalRole unmarshals role from JSON, sets defaults, and checks schema.
 func UnmarshalRole(data []byte, opts ...MarshalOption) (*RoleV3, error) {
 	var h ResourceHeader
@@ -2187,46 +2347,55 @@ func UnmarshalRole(data []byte, opts ...MarshalOption) (*RoleV3, error) {
 		return nil, trace.Wrap(err)
 	}
 
-	switch h.Version {
-	case V2:
-		var role RoleV2
-		if err := utils.UnmarshalWithSchema(GetRoleSchema(V2, ""), &role, data); err != nil {
-			return nil, trace.BadParameter(err.Error())
-		}
+	marshalerMutex.Lock()
+	unmarshal, ok := roleUnmarshalers[h.Version]
+	marshalerMutex.Unlock()
+	if !ok {
+		return nil, trace.BadParameter("role version %q is not supported", h.Version)
+	}
+	return unmarshal(data, cfg)
+}
 
-		if err := role.CheckAndSetDefaults(); err != nil {
-			return nil, trace.Wrap(err)
-		}
+// unmarshalRoleV2 decodes a V2 role and up-converts it to V3.
+func unmarshalRoleV2(data []byte, cfg *MarshalConfig) (*RoleV3, error) {
+	var role RoleV2
+	if err := utils.UnmarshalWithSchema(GetRoleSchema(V2, ""), &role, data); err != nil {
+		return nil, trace.BadParameter(err.Error())
+	}
 
-		roleV3 := role.V3()
-		roleV3.SetResourceID(cfg.ID)
-		return roleV3, nil
-	case V3:
-		var role RoleV3
-		if cfg.SkipValidation {
-			if err := utils.FastUnmarshal(data, &role); err != nil {
-				return nil, trace.BadParameter(err.Error())
-			}
-		} else {
-			if err := utils.UnmarshalWithSchema(GetRoleSchema(V3, ""), &role, data); err != nil {
-				return nil, trace.BadParameter(err.Error())
-			}
-		}
+	if err := role.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
 
-		if err := role.CheckAndSetDefaults(); err != nil {
-			return nil, trace.Wrap(err)
-		}
+	roleV3 := role.V3()
+	roleV3.SetResourceID(cfg.ID)
+	return roleV3, nil
+}
 
-		if cfg.ID != 0 {
-			role.SetResourceID(cfg.ID)
+// unmarshalRoleV3 decodes a V3 role, which is the current version.
+func unmarshalRoleV3(data []byte, cfg *MarshalConfig) (*RoleV3, error) {
+	var role RoleV3
+	if cfg.SkipValidation {
+		if err := utils.FastUnmarshal(data, &role); err != nil {
+			return nil, trace.BadParameter(err.Error())
 		}
-		if !cfg.Expires.IsZero() {
-			role.SetExpiry(cfg.Expires)
+	} else {
+		if err := utils.UnmarshalWithSchema(GetRoleSchema(V3, ""), &role, data); err != nil {
+			return nil, trace.BadParameter(err.Error())
 		}
-		return &role, nil
 	}
 
-	return nil, trace.BadParameter("role version %q is not supported", h.Version)
+	if err := role.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if cfg.ID != 0 {
+		role.SetResourceID(cfg.ID)
+	}
+	if !cfg.Expires.IsZero() {
+		role.SetExpiry(cfg.Expires)
+	}
+	return &role, nil
 }
 
 var roleMarshaler RoleMarshaler = &TeleportRoleMarshaler{}
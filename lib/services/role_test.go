@@ -705,6 +705,59 @@ func (s *RoleSuite) TestCheckAccess(c *C) {
 	}
 }
 
+func (s *RoleSuite) TestExplainAccessToServer(c *C) {
+	server := &ServerV2{
+		Metadata: Metadata{
+			Name:      "a",
+			Namespace: defaults.Namespace,
+			Labels:    map[string]string{"role": "worker"},
+		},
+	}
+
+	allowRole := &RoleV3{
+		Metadata: Metadata{Name: "allow-worker", Namespace: defaults.Namespace},
+		Spec: RoleSpecV3{
+			Allow: RoleConditions{
+				Namespaces: []string{defaults.Namespace},
+				Logins:     []string{"admin"},
+				NodeLabels: Labels{"role": []string{"worker"}},
+			},
+		},
+	}
+	set := NewRoleSet(allowRole)
+
+	explanation, err := set.ExplainAccessToServer("admin", server)
+	c.Assert(err, IsNil)
+	c.Assert(explanation.Allowed, Equals, true)
+	c.Assert(explanation.Allow, HasLen, 2)
+	c.Assert(explanation.Allow[0].RoleName, Equals, "allow-worker")
+	c.Assert(explanation.Allow[0].Labels, Equals, true)
+	c.Assert(explanation.Allow[0].Login, Equals, true)
+
+	explanation, err = set.ExplainAccessToServer("root", server)
+	c.Assert(err, IsNil)
+	c.Assert(explanation.Allowed, Equals, false)
+	c.Assert(explanation.Allow[0].Login, Equals, false)
+
+	denyRole := &RoleV3{
+		Metadata: Metadata{Name: "deny-worker", Namespace: defaults.Namespace},
+		Spec: RoleSpecV3{
+			Deny: RoleConditions{
+				Namespaces: []string{defaults.Namespace},
+				NodeLabels: Labels{"role": []string{"worker"}},
+			},
+		},
+	}
+	set = NewRoleSet(allowRole, denyRole)
+
+	explanation, err = set.ExplainAccessToServer("admin", server)
+	c.Assert(err, IsNil)
+	c.Assert(explanation.Allowed, Equals, false)
+	c.Assert(explanation.Deny, HasLen, 2)
+	c.Assert(explanation.Deny[1].RoleName, Equals, "deny-worker")
+	c.Assert(explanation.Deny[1].Labels, Equals, true)
+}
+
 // testContext overrides context and captures log writes in action
 type testContext struct {
 	Context
@@ -1318,6 +1371,21 @@ func (s *RoleSuite) TestApplyTraits(c *C) {
 	}
 }
 
+func (s *RoleSuite) TestRegisterRoleVersion(c *C) {
+	RegisterRoleVersion("v9000", func(data []byte, cfg *MarshalConfig) (*RoleV3, error) {
+		return &RoleV3{
+			Kind:     KindRole,
+			Version:  V3,
+			Metadata: Metadata{Name: "from-v9000", Namespace: defaults.Namespace},
+		}, nil
+	})
+
+	role, err := UnmarshalRole([]byte(`{"kind": "role", "version": "v9000", "metadata": {"name": "name1"}}`))
+	c.Assert(err, IsNil)
+	c.Assert(role.GetName(), Equals, "from-v9000")
+	c.Assert(role.GetVersion(), Equals, V3)
+}
+
 func (s *RoleSuite) TestCheckAndSetDefaults(c *C) {
 	var tests = []struct {
 		inLogins []string
@@ -1375,22 +1443,21 @@ func (s *RoleSuite) TestCheckAndSetDefaults(c *C) {
 //
 // To run benchmark:
 //
-//    go test -bench=.
+//	go test -bench=.
 //
 // To run benchmark and obtain CPU and memory profiling:
 //
-//    go test -bench=. -cpuprofile=cpu.prof -memprofile=mem.prof
+//	go test -bench=. -cpuprofile=cpu.prof -memprofile=mem.prof
 //
 // To use the command line tool to read the profile:
 //
-//   go tool pprof cpu.prof
-//   go tool pprof cpu.prof
+//	go tool pprof cpu.prof
+//	go tool pprof cpu.prof
 //
 // To generate a graph:
 //
-//   go tool pprof --pdf cpu.prof > cpu.pdf
-//   go tool pprof --pdf mem.prof > mem.pdf
-//
+//	go tool pprof --pdf cpu.prof > cpu.pdf
+//	go tool pprof --pdf mem.prof > mem.pdf
 func BenchmarkCheckAccessToServer(b *testing.B) {
 	servers := make([]*ServerV2, 0, 4000)
 
@@ -348,6 +348,46 @@ func CompareServers(a, b Server) int {
 	return Equal
 }
 
+// FindServerByID returns the server in servers whose name (UUID) matches
+// id, or nil if there is no such server. Unlike hostname, a server's name
+// is assigned once and is unique, so this never returns more than one
+// match.
+func FindServerByID(servers []Server, id string) Server {
+	for _, server := range servers {
+		if server.GetName() == id {
+			return server
+		}
+	}
+	return nil
+}
+
+// FindServersByHostname returns every server in servers whose hostname
+// matches hostname. Multiple nodes can register the same hostname, so
+// callers that need a single server back must decide how to disambiguate
+// between the results.
+func FindServersByHostname(servers []Server, hostname string) []Server {
+	var matches []Server
+	for _, server := range servers {
+		if server.GetHostname() == hostname {
+			matches = append(matches, server)
+		}
+	}
+	return matches
+}
+
+// MostRecentServer returns the server in servers with the furthest-out
+// expiry, i.e. the one that heartbeated most recently. Returns nil if
+// servers is empty.
+func MostRecentServer(servers []Server) Server {
+	var mostRecent Server
+	for _, server := range servers {
+		if mostRecent == nil || server.Expiry().After(mostRecent.Expiry()) {
+			mostRecent = server
+		}
+	}
+	return mostRecent
+}
+
 // CmdLabelMapsEqual compares two maps with command labels,
 // returns true if label sets are equal
 func CmdLabelMapsEqual(a, b map[string]CommandLabel) bool {
@@ -60,6 +60,12 @@ type WebSession interface {
 	GetBearerTokenExpiryTime() time.Time
 	// GetExpiryTime - absolute time when web session expires
 	GetExpiryTime() time.Time
+	// GetLoginTime is the time this session was created, used to identify
+	// and order a user's sessions for listing and for bounding how far a
+	// renewal can slide the session's expiry forward
+	GetLoginTime() time.Time
+	// SetLoginTime sets the time this session was created
+	SetLoginTime(time.Time)
 	// V1 returns V1 version of the resource
 	V1() *WebSessionV1
 	// V2 returns V2 version of the resource
@@ -112,6 +118,8 @@ type WebSessionSpecV2 struct {
 	BearerTokenExpires time.Time `json:"bearer_token_expires"`
 	// Expires - absolute time when session expires
 	Expires time.Time `json:"expires"`
+	// LoginTime is the time this session was created
+	LoginTime time.Time `json:"login_time,omitempty"`
 }
 
 // GetMetadata returns metadata
@@ -210,6 +218,16 @@ func (ws *WebSessionV2) GetExpiryTime() time.Time {
 	return ws.Spec.Expires
 }
 
+// GetLoginTime is the time this session was created
+func (ws *WebSessionV2) GetLoginTime() time.Time {
+	return ws.Spec.LoginTime
+}
+
+// SetLoginTime sets the time this session was created
+func (ws *WebSessionV2) SetLoginTime(t time.Time) {
+	ws.Spec.LoginTime = t
+}
+
 // V2 returns V2 version of the resource
 func (ws *WebSessionV2) V2() *WebSessionV2 {
 	return ws
@@ -238,7 +256,8 @@ const WebSessionSpecV2Schema = `{
     "tls_cert": {"type": "string"},
     "bearer_token": {"type": "string"},
     "bearer_token_expires": {"type": "string"},
-    "expires": {"type": "string"}%v
+    "expires": {"type": "string"},
+    "login_time": {"type": "string"}%v
   }
 }`
 
@@ -355,6 +374,16 @@ func (ws *WebSessionV1) SetBearerTokenExpiryTime(tm time.Time) {
 	ws.Expires = tm
 }
 
+// GetLoginTime is the time this session was created. WebSessionV1 predates
+// login time tracking, so this is always zero.
+func (ws *WebSessionV1) GetLoginTime() time.Time {
+	return time.Time{}
+}
+
+// SetLoginTime is a no-op on WebSessionV1, which predates login time
+// tracking.
+func (ws *WebSessionV1) SetLoginTime(t time.Time) {}
+
 var webSessionMarshaler WebSessionMarshaler = &TeleportWebSessionMarshaler{}
 
 // SetWebSessionMarshaler sets global user marshaler
@@ -341,7 +341,8 @@ func (s *ServicesTestSuite) ServerCRUD(c *check.C) {
 	c.Assert(len(out), check.Equals, 0)
 
 	proxy := NewServer(services.KindProxy, "proxy1", "127.0.0.1:2023", defaults.Namespace)
-	c.Assert(s.PresenceS.UpsertProxy(proxy), check.IsNil)
+	_, err = s.PresenceS.UpsertProxy(proxy)
+	c.Assert(err, check.IsNil)
 
 	out, err = s.PresenceS.GetProxies()
 	c.Assert(err, check.IsNil)
@@ -1215,7 +1216,7 @@ func (s *ServicesTestSuite) Events(c *check.C) {
 			crud: func() services.Resource {
 				srv := NewServer(services.KindProxy, "srv1", "127.0.0.1:2022", defaults.Namespace)
 
-				err := s.PresenceS.UpsertProxy(srv)
+				_, err := s.PresenceS.UpsertProxy(srv)
 				c.Assert(err, check.IsNil)
 
 				out, err := s.PresenceS.GetProxies()
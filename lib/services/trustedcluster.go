@@ -124,7 +124,16 @@ type TrustedClusterSpecV2 struct {
 	RoleMap RoleMap `json:"role_map,omitempty"`
 }
 
-// RoleMap is a list of mappings
+// RoleMap is a list of mappings. Each entry's 'remote' pattern is matched
+// against every role of the remote user: a plain string (optionally
+// containing '*' glob wildcards) is treated as a literal match, while a
+// pattern wrapped in '^...$' is compiled as a regular expression, allowing
+// capture groups to be referenced from 'local' via $1, $2, etc. For example,
+// to avoid enumerating every role pair in a large organization, remote role
+// "admins-eng" can be mapped to local role "leaf-eng" with a single entry:
+//
+//   - remote: "^admins-(.*)$"
+//     local: ["leaf-$1"]
 type RoleMap []RoleMapping
 
 // Equals checks if the two role maps are equal.
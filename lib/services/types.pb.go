@@ -1028,10 +1028,20 @@ type ClusterConfigSpecV3 struct {
 	KeepAliveInterval Duration `protobuf:"varint,7,opt,name=KeepAliveInterval,proto3,casttype=Duration" json:"keep_alive_interval"`
 	// KeepAliveCountMax is the number of keep-alive messages that can be missed before
 	// the server disconnects the connection to the client.
-	KeepAliveCountMax    int64    `protobuf:"varint,8,opt,name=KeepAliveCountMax,proto3" json:"keep_alive_count_max"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	KeepAliveCountMax int64 `protobuf:"varint,8,opt,name=KeepAliveCountMax,proto3" json:"keep_alive_count_max"`
+	// StrictHostCheck requires the principals in a node's host certificate to
+	// match that node's registered Server resource (hostname, UUID, and
+	// advertised address) on every reverse tunnel registration and Dial,
+	// rejecting the connection if they don't.
+	StrictHostCheck Bool `json:"strict_host_check,omitempty"`
+	// AmbiguousHostResolution controls what happens when an SSH dial target
+	// matches more than one registered Server resource by hostname: "error"
+	// (the default) rejects the dial, "most_recent" picks the server that
+	// heartbeated most recently.
+	AmbiguousHostResolution string   `json:"ambiguous_host_resolution,omitempty"`
+	XXX_NoUnkeyedLiteral    struct{} `json:"-"`
+	XXX_unrecognized        []byte   `json:"-"`
+	XXX_sizecache           int32    `json:"-"`
 }
 
 func (m *ClusterConfigSpecV3) Reset()         { *m = ClusterConfigSpecV3{} }
@@ -1364,10 +1374,13 @@ type RoleOptions struct {
 	// duration.
 	ClientIdleTimeout Duration `protobuf:"varint,5,opt,name=ClientIdleTimeout,proto3,casttype=Duration" json:"client_idle_timeout,omitempty"`
 	// DisconnectExpiredCert sets disconnect clients on expired certificates.
-	DisconnectExpiredCert Bool     `protobuf:"varint,6,opt,name=DisconnectExpiredCert,proto3,casttype=Bool" json:"disconnect_expired_cert,omitempty"`
-	XXX_NoUnkeyedLiteral  struct{} `json:"-"`
-	XXX_unrecognized      []byte   `json:"-"`
-	XXX_sizecache         int32    `json:"-"`
+	DisconnectExpiredCert Bool `protobuf:"varint,6,opt,name=DisconnectExpiredCert,proto3,casttype=Bool" json:"disconnect_expired_cert,omitempty"`
+	// Environment is a map of extra environment variables injected into the
+	// session environment by lib/srv before a shell or exec request runs.
+	Environment          map[string]string `json:"environment,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *RoleOptions) Reset()         { *m = RoleOptions{} }
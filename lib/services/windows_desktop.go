@@ -0,0 +1,88 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"github.com/gravitational/teleport"
+
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WindowsDesktop represents a named Windows host registered with a
+// windows_desktop_service. Like KubeCluster, App and Database, it is a
+// process-local value derived from configuration rather than a dynamic,
+// backend-persisted resource: making desktops discoverable across every
+// windows_desktop_service instance would require extending Presence with
+// UpsertWindowsDesktop/GetWindowsDesktops, which is not implemented here.
+type WindowsDesktop struct {
+	// Name is the name of the desktop, as it appears to users.
+	Name string
+	// Addr is the address of the Windows host's RDP listener, e.g.
+	// "desktop.internal:3389".
+	Addr string
+	// StaticLabels is a map of static labels associated with this
+	// desktop, matched against a role's windows_desktop_labels to decide
+	// access.
+	StaticLabels map[string]string
+}
+
+// GetAllLabels returns all labels associated with this desktop.
+func (d WindowsDesktop) GetAllLabels() map[string]string {
+	return d.StaticLabels
+}
+
+// CheckAccessToWindowsDesktop checks if a role set has access to a given
+// Windows desktop. Access is granted if the desktop's labels satisfy at
+// least one role's allow rule and no role's deny rule. There is no
+// dedicated windows_desktop_labels role field yet (adding one means
+// extending the generated RoleConditions proto message), so this reuses
+// node_labels, the same way CheckAccessToKubeCluster, CheckAccessToApp and
+// CheckAccessToDatabase do for their resources.
+func (set RoleSet) CheckAccessToWindowsDesktop(desktop WindowsDesktop) error {
+	for _, role := range set {
+		matchLabels, labelsMessage, err := MatchLabels(role.GetNodeLabels(Deny), desktop.GetAllLabels())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if matchLabels {
+			log.WithFields(log.Fields{
+				trace.Component: teleport.ComponentRBAC,
+			}).Debugf("Access to Windows desktop %v denied, deny rule in %v matched; match(label=%v)",
+				desktop.Name, role.GetName(), labelsMessage)
+			return trace.AccessDenied("access to windows desktop denied")
+		}
+	}
+
+	var errs []error
+	for _, role := range set {
+		matchLabels, labelsMessage, err := MatchLabels(role.GetNodeLabels(Allow), desktop.GetAllLabels())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if matchLabels {
+			return nil
+		}
+		errs = append(errs, trace.AccessDenied("role=%v, match(label=%v)", role.GetName(), labelsMessage))
+	}
+
+	log.WithFields(log.Fields{
+		trace.Component: teleport.ComponentRBAC,
+	}).Debugf("Access to Windows desktop %v denied, no allow rule matched; %v", desktop.Name, errs)
+	return trace.AccessDenied("access to windows desktop denied")
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/sshutils"
+)
+
+// auditingAgent wraps an agent.Agent and emits an audit event, including the
+// target key's fingerprint, every time the wrapped agent is asked to sign a
+// request. It's used to audit use of a forwarded SSH agent, which otherwise
+// signs requests invisibly on the user's behalf.
+type auditingAgent struct {
+	agent.Agent
+
+	auditLog events.IAuditLog
+	login    string
+	user     string
+}
+
+// NewAuditingAgent wraps the forwarded agent on ctx so that every signing
+// request it serves is recorded in the audit log, along with the fingerprint
+// of the key used.
+func NewAuditingAgent(a agent.Agent, auditLog events.IAuditLog, ctx *ServerContext) agent.Agent {
+	return &auditingAgent{
+		Agent:    a,
+		auditLog: auditLog,
+		login:    ctx.Identity.Login,
+		user:     ctx.Identity.TeleportUser,
+	}
+}
+
+// Sign has the wrapped agent sign the data, emitting an audit event
+// recording the fingerprint of the key used before returning the result.
+func (a *auditingAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	a.auditLog.EmitAuditEvent(events.AgentForwardSign, events.EventFields{
+		events.AgentForwardKeyFingerprint: sshutils.Fingerprint(key),
+		events.EventLogin:                 a.login,
+		events.EventUser:                  a.user,
+	})
+	return a.Agent.Sign(key, data)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/sshutils"
+
+	"gopkg.in/check.v1"
+)
+
+// recordingAuditLog wraps events.MockAuditLog and records the last event
+// emitted through it, so tests can assert on event fields.
+type recordingAuditLog struct {
+	*events.MockAuditLog
+
+	lastEvent  events.Event
+	lastFields events.EventFields
+}
+
+func (r *recordingAuditLog) EmitAuditEvent(event events.Event, fields events.EventFields) error {
+	r.lastEvent = event
+	r.lastFields = fields
+	return nil
+}
+
+// fakeSigningAgent is a stub agent.Agent whose Sign method just records that
+// it was called.
+type fakeSigningAgent struct {
+	agent.Agent
+
+	signCalled bool
+}
+
+func (f *fakeSigningAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	f.signCalled = true
+	return &ssh.Signature{Format: "stub"}, nil
+}
+
+// TestAuditingAgentSign verifies that wrapping an agent.Agent with
+// NewAuditingAgent emits an audit event (with the signing key's fingerprint)
+// for every Sign call, while still delegating to the wrapped agent.
+func (s *KeepAliveSuite) TestAuditingAgentSign(c *check.C) {
+	inner := &fakeSigningAgent{}
+	auditLog := &recordingAuditLog{MockAuditLog: events.NewMockAuditLog(1)}
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, check.IsNil)
+	pub, err := ssh.NewPublicKey(&priv.PublicKey)
+	c.Assert(err, check.IsNil)
+
+	ctx := &ServerContext{Identity: IdentityContext{Login: "bob", TeleportUser: "bob@example.com"}}
+	a := NewAuditingAgent(inner, auditLog, ctx)
+
+	sig, err := a.Sign(pub, []byte("data"))
+	c.Assert(err, check.IsNil)
+	c.Assert(sig.Format, check.Equals, "stub")
+	c.Assert(inner.signCalled, check.Equals, true)
+
+	c.Assert(auditLog.lastEvent, check.DeepEquals, events.AgentForwardSign)
+	c.Assert(auditLog.lastFields[events.EventLogin], check.Equals, ctx.Identity.Login)
+	c.Assert(auditLog.lastFields[events.EventUser], check.Equals, ctx.Identity.TeleportUser)
+	c.Assert(auditLog.lastFields[events.AgentForwardKeyFingerprint], check.Equals, sshutils.Fingerprint(pub))
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package agentless implements alternative dial transports for nodes that
+// run no Teleport agent and have no inbound route a proxy can reach by a
+// direct TCP dial or a reverse tunnel: AWS SSM Session Manager and AWS EC2
+// Instance Connect. A node opts in by setting
+// services.AgentlessTransportLabel (plus services.AWSInstanceIDLabel and
+// services.AWSRegionLabel) to select one of these as a last-resort fallback.
+//
+// Neither transport is actually implemented here. A real SSM transport
+// needs aws-sdk-go/service/ssm's StartSession/TerminateSession APIs wired
+// to the SSM data channel's websocket framing, and a real EC2 Instance
+// Connect transport needs aws-sdk-go/service/ec2instanceconnect's
+// SendSSHPublicKey pushed ahead of an otherwise-ordinary TCP dial; neither
+// AWS SDK service package is vendored in this tree, and there's no network
+// access here to vendor them. What's provided is the seam: a Transport
+// interface that returns a plain net.Conn, so that once a real
+// implementation is plugged in, callers such as
+// reversetunnel.localSite.dialWithAgent can use it as a drop-in replacement
+// for net.DialTimeout, with the recording forwarding server running on top
+// exactly as it does for a direct dial today.
+package agentless
+
+import (
+	"context"
+	"net"
+
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// Transport dials a target identified by a Config through AWS SSM Session
+// Manager or EC2 Instance Connect.
+type Transport interface {
+	// Dial returns a net.Conn carrying the raw SSH byte stream to the
+	// transport's target instance, as if it had been reached by a direct
+	// TCP dial.
+	Dial(ctx context.Context) (net.Conn, error)
+}
+
+// Config specifies the AWS instance a Transport connects to.
+type Config struct {
+	// Method selects the transport mechanism: one of
+	// services.AgentlessTransportSSM or
+	// services.AgentlessTransportEC2InstanceConnect.
+	Method string
+	// InstanceID is the target EC2 instance's ID, e.g. "i-0123456789abcdef0".
+	InstanceID string
+	// Region is the AWS region the instance runs in.
+	Region string
+}
+
+// CheckAndSetDefaults validates the configuration.
+func (c *Config) CheckAndSetDefaults() error {
+	switch c.Method {
+	case services.AgentlessTransportSSM, services.AgentlessTransportEC2InstanceConnect:
+	case "":
+		return trace.BadParameter("agentless transport method is required")
+	default:
+		return trace.BadParameter("unsupported agentless transport method %q", c.Method)
+	}
+	if c.InstanceID == "" {
+		return trace.BadParameter("agentless transport requires an instance ID")
+	}
+	if c.Region == "" {
+		return trace.BadParameter("agentless transport requires a region")
+	}
+	return nil
+}
+
+// NewTransport returns the Transport selected by cfg.Method.
+func NewTransport(cfg Config) (Transport, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch cfg.Method {
+	case services.AgentlessTransportSSM:
+		return &ssmTransport{cfg}, nil
+	case services.AgentlessTransportEC2InstanceConnect:
+		return &ec2InstanceConnectTransport{cfg}, nil
+	default:
+		// Unreachable: CheckAndSetDefaults already rejected any other value.
+		return nil, trace.BadParameter("unsupported agentless transport method %q", cfg.Method)
+	}
+}
+
+// ssmTransport dials its target by starting an AWS SSM Session Manager
+// session against it and piping the SSH byte stream over the resulting
+// data channel.
+type ssmTransport struct {
+	cfg Config
+}
+
+func (t *ssmTransport) Dial(ctx context.Context) (net.Conn, error) {
+	// A real implementation calls aws-sdk-go/service/ssm's StartSession for
+	// t.cfg.InstanceID in t.cfg.Region, opens the websocket data channel
+	// named in the response, and wraps it in a net.Conn that frames reads
+	// and writes per the SSM data channel protocol. That SDK package isn't
+	// vendored here; see the package doc comment.
+	return nil, trace.NotImplemented(
+		"SSM dial transport for instance %v in %v is not implemented: aws-sdk-go/service/ssm is not vendored in this build",
+		t.cfg.InstanceID, t.cfg.Region)
+}
+
+// ec2InstanceConnectTransport dials its target by pushing a short-lived SSH
+// public key to it via AWS EC2 Instance Connect and then doing an ordinary
+// TCP dial.
+type ec2InstanceConnectTransport struct {
+	cfg Config
+}
+
+func (t *ec2InstanceConnectTransport) Dial(ctx context.Context) (net.Conn, error) {
+	// A real implementation calls
+	// aws-sdk-go/service/ec2instanceconnect's SendSSHPublicKey for
+	// t.cfg.InstanceID in t.cfg.Region with a freshly generated key, then
+	// dials the instance's SSH port with net.DialTimeout using that key.
+	// That SDK package isn't vendored here; see the package doc comment.
+	return nil, trace.NotImplemented(
+		"EC2 Instance Connect dial transport for instance %v in %v is not implemented: aws-sdk-go/service/ec2instanceconnect is not vendored in this build",
+		t.cfg.InstanceID, t.cfg.Region)
+}
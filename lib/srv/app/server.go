@@ -0,0 +1,213 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package app implements an app_service: a reverse-tunnel agent that
+// registers internal web applications and lets the proxy route
+// authenticated HTTPS requests to them.
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// Config specifies configuration for an app proxy server.
+type Config struct {
+	// Apps is the list of applications this server proxies requests to,
+	// keyed by the PublicAddr clients use to reach them.
+	Apps []services.App
+	// Auth authenticates users from the mutually-authenticated TLS
+	// connection the proxy establishes over the reverse tunnel.
+	Auth auth.Authorizer
+	// Middleware resolves the Teleport identity from a connection's client
+	// certificate, the same way it does for the kubernetes and web proxy
+	// listeners.
+	Middleware *auth.AuthMiddleware
+}
+
+// CheckAndSetDefaults makes sure the configuration is valid.
+func (c *Config) CheckAndSetDefaults() error {
+	if c.Auth == nil {
+		return trace.BadParameter("missing parameter Auth")
+	}
+	if c.Middleware == nil {
+		return trace.BadParameter("missing parameter Middleware")
+	}
+	for _, app := range c.Apps {
+		if app.Name == "" || app.URI == "" || app.PublicAddr == "" {
+			return trace.BadParameter("app %+v is missing name, uri or public_addr", app)
+		}
+	}
+	return nil
+}
+
+// Server authenticates requests for registered applications and forwards
+// them to the application's internal address, either request by request
+// over HTTPS (ServeHTTP) or as an opaque byte stream for plain TCP apps
+// such as internal Redis or RDP gateways (HandleTCPConn).
+//
+// Registration is static, from Config.Apps, rather than heartbeated over
+// the reverse tunnel: discovering apps registered with other app_service
+// instances needs new Presence RPCs the same way multi-agent Kubernetes
+// cluster discovery does, see services.KubeCluster. Forwarded requests also
+// don't carry an identity JWT yet, since there is no JWT signing CA in this
+// tree to issue and verify one from; RBAC is still enforced per request via
+// CheckAccessToApp below.
+//
+// Dialing into a TCP app from tsh is not wired up either: it needs an SNI-
+// routing TLS listener on the proxy's web port (mirroring how the main
+// proxy listener already dispatches by SNI for Kubernetes, via
+// EncodeClusterName) that terminates the tunnel hop and calls HandleTCPConn,
+// plus a "tsh proxy app" local listener analogous to onProxyCommandSOCKS.
+type Server struct {
+	*log.Entry
+	Config
+
+	// appsByPublicAddr indexes Apps by the public address clients use to
+	// reach them, for fast lookup by request Host.
+	appsByPublicAddr map[string]services.App
+	// proxiesByPublicAddr holds a reverse proxy per app, so each app gets
+	// its own connection pool and error handling.
+	proxiesByPublicAddr map[string]*httputil.ReverseProxy
+}
+
+// New creates a new app proxy server.
+func New(cfg Config) (*Server, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	s := &Server{
+		Entry: log.WithFields(log.Fields{
+			trace.Component: teleport.Component(teleport.ComponentApp),
+		}),
+		Config:              cfg,
+		appsByPublicAddr:    make(map[string]services.App, len(cfg.Apps)),
+		proxiesByPublicAddr: make(map[string]*httputil.ReverseProxy, len(cfg.Apps)),
+	}
+	for _, app := range cfg.Apps {
+		s.appsByPublicAddr[app.PublicAddr] = app
+		if app.IsTCP() {
+			continue
+		}
+		targetURL, err := url.Parse(app.URI)
+		if err != nil {
+			return nil, trace.Wrap(err, "invalid URI for app %q", app.Name)
+		}
+		s.proxiesByPublicAddr[app.PublicAddr] = httputil.NewSingleHostReverseProxy(targetURL)
+	}
+	return s, nil
+}
+
+// ServeHTTP authenticates the request, checks RBAC against the matched
+// app's labels, and forwards the request to the app's internal address.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	app, proxy, err := s.resolveApp(r.Host)
+	if err != nil {
+		s.Warn(err.Error())
+		http.Error(w, "application not found", http.StatusNotFound)
+		return
+	}
+	if err := s.authorize(r, app); err != nil {
+		s.Warn(err.Error())
+		http.Error(w, "access denied", http.StatusForbidden)
+		return
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// HandleTCPConn authenticates a raw TCP application connection and, once
+// authorized, forwards it byte-for-byte to the target app's URI until
+// either side closes. conn must be a *tls.Conn whose handshake has
+// already completed: the app to forward to is selected by its TLS SNI
+// server name, the same way EncodeClusterName/SNI routing already
+// selects a Kubernetes cluster for the main proxy listener.
+func (s *Server) HandleTCPConn(conn net.Conn) error {
+	defer conn.Close()
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return trace.BadParameter("expected a TLS connection, got %T", conn)
+	}
+	serverName := tlsConn.ConnectionState().ServerName
+	app, ok := s.appsByPublicAddr[serverName]
+	if !ok || !app.IsTCP() {
+		return trace.NotFound("no TCP application registered for %q", serverName)
+	}
+	state := tlsConn.ConnectionState()
+	r := &http.Request{TLS: &state}
+	if err := s.authorize(r, app); err != nil {
+		return trace.Wrap(err)
+	}
+	upstream, err := net.Dial("tcp", app.URI)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer upstream.Close()
+
+	errCh := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(upstream, tlsConn)
+		errCh <- err
+	}()
+	go func() {
+		_, err := io.Copy(tlsConn, upstream)
+		errCh <- err
+	}()
+	err = <-errCh
+	if err != nil && err != io.EOF {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// resolveApp returns the app and reverse proxy registered for host.
+func (s *Server) resolveApp(host string) (services.App, *httputil.ReverseProxy, error) {
+	app, ok := s.appsByPublicAddr[host]
+	if !ok {
+		return services.App{}, nil, trace.NotFound("no application registered for %q", host)
+	}
+	return app, s.proxiesByPublicAddr[host], nil
+}
+
+// authorize checks that the request's Teleport identity is allowed to
+// access app, based on the app's labels and the user's roles.
+func (s *Server) authorize(r *http.Request, app services.App) error {
+	user, err := s.Middleware.GetUser(r)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ctx := context.WithValue(context.Background(), auth.ContextUser, user)
+	authContext, err := s.Auth.Authorize(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	checker, ok := authContext.Checker.(services.RoleSet)
+	if !ok {
+		return trace.AccessDenied("access denied: unsupported checker type %T", authContext.Checker)
+	}
+	return checker.CheckAccessToApp(app)
+}
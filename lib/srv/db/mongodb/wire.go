@@ -0,0 +1,214 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mongodb implements just enough of the MongoDB wire protocol for
+// db_service to extract the command name and target database out of each
+// message a client sends, for audit logging, before forwarding it on to
+// the target database unmodified. Unlike lib/srv/db/postgres and
+// lib/srv/db/mysql, which sniff a single preamble message and then treat
+// the connection as opaque, MongoDB has no such preamble: every message a
+// client sends is itself a command, so this package is used to audit the
+// whole client-to-server direction of the connection, not just its start.
+package mongodb
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/gravitational/trace"
+)
+
+// opMsg is the OP_MSG opcode, the only message format MongoDB clients and
+// servers have spoken to each other since MongoDB 3.6; older opcodes
+// (OP_QUERY, OP_GET_MORE, ...) are not parsed by this package.
+const opMsg = 2013
+
+// maxMessageSize caps how much of a single message this parser will
+// buffer, to bound memory use for a malformed or hostile client. It
+// matches MongoDB's own maxMessageSizeBytes default.
+const maxMessageSize = 48 * 1024 * 1024
+
+// sectionKindBody identifies an OP_MSG section that holds a single BSON
+// document: the command itself. sectionKindDocumentSequence (kind 1)
+// holds bulk-write document sequences and is skipped, not parsed, since
+// it never carries the command name or "$db".
+const sectionKindBody = 0
+
+// ReadMessage reads one MongoDB wire protocol message (a 16-byte header
+// followed by its body) from r and returns the exact bytes read,
+// including the header, so the caller can forward them on unmodified.
+func ReadMessage(r io.Reader) ([]byte, error) {
+	var header [16]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	length := int(binary.LittleEndian.Uint32(header[:4]))
+	if length < 16 || length > maxMessageSize {
+		return nil, trace.BadParameter("invalid MongoDB message length %v", length)
+	}
+	body := make([]byte, length-16)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return append(header[:], body...), nil
+}
+
+// ParseCommand extracts the command name and target database ("$db") out
+// of a raw message previously returned by ReadMessage. It returns
+// ok=false, rather than an error, for anything it can't or doesn't need
+// to parse: a non-OP_MSG message, a document-sequence-only message, or a
+// command document in a shape this minimal BSON reader doesn't handle.
+// Audit logging is best-effort; it must never be the reason a connection
+// is dropped.
+func ParseCommand(raw []byte) (command, db string, ok bool) {
+	if len(raw) < 21 {
+		return "", "", false
+	}
+	opCode := int32(binary.LittleEndian.Uint32(raw[12:16]))
+	if opCode != opMsg {
+		return "", "", false
+	}
+	pos := 20 // header(16) + flagBits(4)
+	for pos < len(raw) {
+		kind := raw[pos]
+		pos++
+		if kind != sectionKindBody {
+			// Document sequences and any future section kind are not
+			// parsed; bail out rather than guess their length.
+			return command, db, command != ""
+		}
+		if pos+4 > len(raw) {
+			return command, db, command != ""
+		}
+		docLen := int(binary.LittleEndian.Uint32(raw[pos : pos+4]))
+		if docLen < 5 || pos+docLen > len(raw) {
+			return command, db, command != ""
+		}
+		doc := raw[pos : pos+docLen]
+		c, d := parseCommandDocument(doc)
+		if command == "" {
+			command = c
+		}
+		if d != "" {
+			db = d
+		}
+		pos += docLen
+	}
+	return command, db, command != ""
+}
+
+// parseCommandDocument walks the top-level elements of a BSON document,
+// treating the first element's key as the command name and looking for a
+// string-valued "$db" element, skipping every other element without
+// decoding its value.
+func parseCommandDocument(doc []byte) (command, db string) {
+	if len(doc) < 5 {
+		return "", ""
+	}
+	pos := 4 // skip the document's own length prefix
+	first := true
+	for pos < len(doc) {
+		elemType := doc[pos]
+		pos++
+		if elemType == 0x00 {
+			break
+		}
+		key, next, err := readCString(doc, pos)
+		if err != nil {
+			return command, db
+		}
+		pos = next
+		if first {
+			command = key
+			first = false
+		}
+		if key == "$db" && elemType == 0x02 {
+			if value, _, err := readBSONString(doc, pos); err == nil {
+				db = value
+			}
+		}
+		next, err = skipBSONValue(doc, pos, elemType)
+		if err != nil {
+			return command, db
+		}
+		pos = next
+	}
+	return command, db
+}
+
+func readCString(b []byte, start int) (value string, next int, err error) {
+	for i := start; i < len(b); i++ {
+		if b[i] == 0 {
+			return string(b[start:i]), i + 1, nil
+		}
+	}
+	return "", 0, trace.BadParameter("BSON document missing expected null terminator")
+}
+
+func readBSONString(b []byte, start int) (value string, next int, err error) {
+	if start+4 > len(b) {
+		return "", 0, trace.BadParameter("BSON string truncated")
+	}
+	length := int(binary.LittleEndian.Uint32(b[start : start+4]))
+	end := start + 4 + length
+	if length < 1 || end > len(b) {
+		return "", 0, trace.BadParameter("BSON string truncated")
+	}
+	// length includes the trailing nul byte.
+	return string(b[start+4 : end-1]), end, nil
+}
+
+// skipBSONValue advances past the value of a BSON element of the given
+// type, returning the position of the next element. Only the types
+// common in MongoDB command documents are handled; an unrecognized type
+// is reported as an error so the caller stops parsing rather than
+// misinterpreting the rest of the document.
+func skipBSONValue(b []byte, pos int, elemType byte) (int, error) {
+	switch elemType {
+	case 0x01, 0x09, 0x11, 0x12: // double, UTC datetime, timestamp, int64
+		pos += 8
+	case 0x02, 0x0D, 0x0E: // string, javascript, symbol
+		if pos+4 > len(b) {
+			return 0, trace.BadParameter("BSON value truncated")
+		}
+		pos += 4 + int(binary.LittleEndian.Uint32(b[pos:pos+4]))
+	case 0x03, 0x04: // document, array
+		if pos+4 > len(b) {
+			return 0, trace.BadParameter("BSON value truncated")
+		}
+		pos += int(binary.LittleEndian.Uint32(b[pos : pos+4]))
+	case 0x05: // binary
+		if pos+4 > len(b) {
+			return 0, trace.BadParameter("BSON value truncated")
+		}
+		pos += 4 + 1 + int(binary.LittleEndian.Uint32(b[pos:pos+4]))
+	case 0x07: // objectid
+		pos += 12
+	case 0x08: // boolean
+		pos += 1
+	case 0x0A, 0xFF, 0x7F: // null, minkey, maxkey
+	case 0x10: // int32
+		pos += 4
+	case 0x13: // decimal128
+		pos += 16
+	default:
+		return 0, trace.BadParameter("unsupported BSON element type %#x", elemType)
+	}
+	if pos > len(b) {
+		return 0, trace.BadParameter("BSON value truncated")
+	}
+	return pos, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+func TestMongoDB(t *testing.T) { check.TestingT(t) }
+
+type WireSuite struct{}
+
+var _ = check.Suite(&WireSuite{})
+
+// bsonString appends a BSON string element (type 0x02) named key with
+// value to doc.
+func bsonString(doc []byte, key, value string) []byte {
+	doc = append(doc, 0x02)
+	doc = append(doc, key...)
+	doc = append(doc, 0)
+	valueBytes := append([]byte(value), 0)
+	length := make([]byte, 4)
+	binary.LittleEndian.PutUint32(length, uint32(len(valueBytes)))
+	doc = append(doc, length...)
+	doc = append(doc, valueBytes...)
+	return doc
+}
+
+// buildCommandDocument builds a minimal BSON document of the shape
+// {<command>: 1, $db: "<db>"}.
+func buildCommandDocument(command, db string) []byte {
+	var body []byte
+	body = append(body, 0x10) // int32
+	body = append(body, command...)
+	body = append(body, 0)
+	body = append(body, 1, 0, 0, 0)
+	body = bsonString(body, "$db", db)
+	body = append(body, 0) // terminator
+
+	doc := make([]byte, 4)
+	binary.LittleEndian.PutUint32(doc, uint32(len(body)+4))
+	return append(doc, body...)
+}
+
+// buildOpMsg wraps a single-section OP_MSG message around doc.
+func buildOpMsg(doc []byte) []byte {
+	var body []byte
+	body = append(body, 0, 0, 0, 0)      // flagBits
+	body = append(body, sectionKindBody) // kind 0
+	body = append(body, doc...)
+
+	header := make([]byte, 16)
+	binary.LittleEndian.PutUint32(header[0:4], uint32(len(body)+16))
+	binary.LittleEndian.PutUint32(header[12:16], opMsg)
+	return append(header, body...)
+}
+
+func (s *WireSuite) TestReadMessageAndParseCommand(c *check.C) {
+	msg := buildOpMsg(buildCommandDocument("find", "test"))
+	raw, err := ReadMessage(bytes.NewReader(msg))
+	c.Assert(err, check.IsNil)
+	c.Assert(raw, check.DeepEquals, msg)
+
+	command, db, ok := ParseCommand(raw)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(command, check.Equals, "find")
+	c.Assert(db, check.Equals, "test")
+}
+
+func (s *WireSuite) TestParseCommandNotOpMsg(c *check.C) {
+	header := make([]byte, 21)
+	binary.LittleEndian.PutUint32(header[0:4], 21)
+	binary.LittleEndian.PutUint32(header[12:16], 1) // OP_REPLY
+	_, _, ok := ParseCommand(header)
+	c.Assert(ok, check.Equals, false)
+}
+
+func (s *WireSuite) TestReadMessageTooShort(c *check.C) {
+	_, err := ReadMessage(bytes.NewReader([]byte{1, 2, 3}))
+	c.Assert(err, check.NotNil)
+}
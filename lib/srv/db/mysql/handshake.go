@@ -0,0 +1,143 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mysql implements just enough of the MySQL/MariaDB wire protocol
+// for db_service to extract audit fields from a client's handshake
+// response before handing the raw byte stream off to the target database,
+// the same way lib/srv/db/postgres does for PostgreSQL's startup message.
+package mysql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/gravitational/trace"
+)
+
+// clientConnectWithDB is set in the handshake response's capability flags
+// when the client requested a default database at connect time.
+const clientConnectWithDB = 0x00000008
+
+// clientSecureConnection is set when the client's auth-response is
+// prefixed with a 1-byte length, the scheme every client since MySQL 4.1
+// uses. The legacy, null-terminated auth-response format predates this
+// and is not supported here.
+const clientSecureConnection = 0x00008000
+
+// maxPacketSize caps how much of a single packet this parser will buffer,
+// to bound memory use for a malformed or hostile client.
+const maxPacketSize = 1 << 15
+
+// HandshakeResponse holds the fields of a MySQL client's handshake
+// response packet that are relevant for audit logging.
+type HandshakeResponse struct {
+	// User is the username the client authenticated as.
+	User string
+	// Database is the default database the client requested, if any.
+	Database string
+	// Raw is the exact bytes the handshake response was read from, so the
+	// caller can replay them to the real MySQL server, which still
+	// expects to receive them.
+	Raw []byte
+}
+
+// ReadPacket reads one MySQL protocol packet from r and returns the exact
+// bytes read, including its header. It's used to relay the server's
+// initial handshake packet to the client verbatim, without needing to
+// parse it: only the client's handshake response carries audit fields.
+func ReadPacket(r io.Reader) ([]byte, error) {
+	_, raw, err := readPacket(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return raw, nil
+}
+
+// readPacket reads one MySQL protocol packet (a 3-byte little-endian
+// length, a 1-byte sequence id, and length bytes of payload) from r.
+func readPacket(r io.Reader) (payload []byte, raw []byte, err error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	if length > maxPacketSize {
+		return nil, nil, trace.BadParameter("MySQL packet too large: %v bytes", length)
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return payload, append(header[:], payload...), nil
+}
+
+// ReadHandshakeResponse reads a MySQL handshake response packet from r,
+// returning the audit-relevant fields along with the raw bytes that were
+// read. The caller is responsible for writing HandshakeResponse.Raw to the
+// real server before proxying the rest of the connection as an opaque
+// stream.
+func ReadHandshakeResponse(r io.Reader) (*HandshakeResponse, error) {
+	payload, raw, err := readPacket(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(payload) < 32 {
+		return nil, trace.BadParameter("MySQL handshake response packet too short: %v bytes", len(payload))
+	}
+	capabilities := binary.LittleEndian.Uint32(payload[:4])
+	// Skip client_flag(4) + max_packet_size(4) + character_set(1) + reserved(23).
+	pos := 32
+
+	user, pos, err := readNullTerminatedString(payload, pos)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if capabilities&clientSecureConnection != 0 {
+		if pos >= len(payload) {
+			return nil, trace.BadParameter("MySQL handshake response truncated before auth-response length")
+		}
+		authLen := int(payload[pos])
+		pos++
+		pos += authLen
+	}
+	if pos > len(payload) {
+		return nil, trace.BadParameter("MySQL handshake response truncated in auth-response")
+	}
+
+	var database string
+	if capabilities&clientConnectWithDB != 0 {
+		database, _, err = readNullTerminatedString(payload, pos)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	return &HandshakeResponse{
+		User:     user,
+		Database: database,
+		Raw:      raw,
+	}, nil
+}
+
+func readNullTerminatedString(b []byte, start int) (value string, next int, err error) {
+	end := bytes.IndexByte(b[start:], 0)
+	if end < 0 {
+		return "", 0, trace.BadParameter("MySQL packet missing expected null terminator")
+	}
+	return string(b[start : start+end]), start + end + 1, nil
+}
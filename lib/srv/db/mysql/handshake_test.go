@@ -0,0 +1,83 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+func TestMySQL(t *testing.T) { check.TestingT(t) }
+
+type HandshakeSuite struct{}
+
+var _ = check.Suite(&HandshakeSuite{})
+
+func buildHandshakeResponse(user, database string, withDB bool) []byte {
+	var payload []byte
+
+	var capabilities uint32 = clientSecureConnection
+	if withDB {
+		capabilities |= clientConnectWithDB
+	}
+	capBytes := make([]byte, 4)
+	capBytes[0] = byte(capabilities)
+	capBytes[1] = byte(capabilities >> 8)
+	capBytes[2] = byte(capabilities >> 16)
+	capBytes[3] = byte(capabilities >> 24)
+	payload = append(payload, capBytes...)
+	payload = append(payload, make([]byte, 28)...) // max_packet_size + charset + reserved
+
+	payload = append(payload, user...)
+	payload = append(payload, 0)
+
+	payload = append(payload, 0) // zero-length auth response
+
+	if withDB {
+		payload = append(payload, database...)
+		payload = append(payload, 0)
+	}
+
+	length := len(payload)
+	header := []byte{byte(length), byte(length >> 8), byte(length >> 16), 0}
+	return append(header, payload...)
+}
+
+func (s *HandshakeSuite) TestReadHandshakeResponse(c *check.C) {
+	packet := buildHandshakeResponse("alice", "billing", true)
+	got, err := ReadHandshakeResponse(bytes.NewReader(packet))
+	c.Assert(err, check.IsNil)
+	c.Assert(got.User, check.Equals, "alice")
+	c.Assert(got.Database, check.Equals, "billing")
+	c.Assert(got.Raw, check.DeepEquals, packet)
+}
+
+func (s *HandshakeSuite) TestReadHandshakeResponseNoDB(c *check.C) {
+	packet := buildHandshakeResponse("bob", "", false)
+	got, err := ReadHandshakeResponse(bytes.NewReader(packet))
+	c.Assert(err, check.IsNil)
+	c.Assert(got.User, check.Equals, "bob")
+	c.Assert(got.Database, check.Equals, "")
+}
+
+func (s *HandshakeSuite) TestReadHandshakeResponseTooShort(c *check.C) {
+	header := []byte{4, 0, 0, 0}
+	_, err := ReadHandshakeResponse(bytes.NewReader(append(header, []byte{1, 2, 3, 4}...)))
+	c.Assert(err, check.NotNil)
+}
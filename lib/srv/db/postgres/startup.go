@@ -0,0 +1,104 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package postgres implements just enough of the PostgreSQL wire protocol
+// for db_service to extract audit fields from a client connection before
+// handing the raw byte stream off to the target database. It deliberately
+// does not implement the rest of the protocol (authentication messages,
+// query parsing, SASL) since db_service proxies the connection as an
+// opaque stream once the startup message has been read.
+package postgres
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/gravitational/trace"
+)
+
+// protocolVersion3 is the startup message protocol version every
+// PostgreSQL server and client has spoken since version 7.4.
+const protocolVersion3 = 196608
+
+// maxStartupMessageSize caps how much of the startup message this parser
+// will buffer, to bound memory use for a malformed or hostile client.
+const maxStartupMessageSize = 1 << 15
+
+// StartupMessage holds the fields of a PostgreSQL client's startup message
+// that are relevant for audit logging.
+type StartupMessage struct {
+	// User is the "user" startup parameter.
+	User string
+	// Database is the "database" startup parameter.
+	Database string
+	// Raw is the exact bytes the startup message was read from, so the
+	// caller can replay them to the real PostgreSQL server, which still
+	// expects to receive them.
+	Raw []byte
+}
+
+// ReadStartupMessage reads and parses a PostgreSQL startup message from r,
+// returning the audit-relevant parameters along with the raw bytes that
+// were read. The caller is responsible for writing StartupMessage.Raw to
+// the real server before proxying the rest of the connection as an opaque
+// stream: ReadStartupMessage only parses the message, it does not forward
+// it anywhere.
+func ReadStartupMessage(r io.Reader) (*StartupMessage, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 8 || length > maxStartupMessageSize {
+		return nil, trace.BadParameter("invalid PostgreSQL startup message length %v", length)
+	}
+	version := binary.BigEndian.Uint32(header[4:8])
+	if version != protocolVersion3 {
+		return nil, trace.BadParameter("unsupported PostgreSQL protocol version %v", version)
+	}
+	body := make([]byte, length-8)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	params := make(map[string]string)
+	var key string
+	start := 0
+	keyExpected := true
+	for i, b := range body {
+		if b != 0 {
+			continue
+		}
+		value := string(body[start:i])
+		start = i + 1
+		if value == "" && keyExpected {
+			// Trailing zero byte that terminates the parameter list.
+			break
+		}
+		if keyExpected {
+			key = value
+		} else {
+			params[key] = value
+		}
+		keyExpected = !keyExpected
+	}
+
+	return &StartupMessage{
+		User:     params["user"],
+		Database: params["database"],
+		Raw:      append(header[:], body...),
+	}, nil
+}
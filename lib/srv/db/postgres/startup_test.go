@@ -0,0 +1,67 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+func TestPostgres(t *testing.T) { check.TestingT(t) }
+
+type StartupSuite struct{}
+
+var _ = check.Suite(&StartupSuite{})
+
+func buildStartupMessage(params map[string]string) []byte {
+	var body []byte
+	for k, v := range params {
+		body = append(body, k...)
+		body = append(body, 0)
+		body = append(body, v...)
+		body = append(body, 0)
+	}
+	body = append(body, 0)
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[:4], uint32(len(body)+8))
+	binary.BigEndian.PutUint32(header[4:8], protocolVersion3)
+	return append(header, body...)
+}
+
+func (s *StartupSuite) TestReadStartupMessage(c *check.C) {
+	msg := buildStartupMessage(map[string]string{
+		"user":     "alice",
+		"database": "billing",
+	})
+	got, err := ReadStartupMessage(bytes.NewReader(msg))
+	c.Assert(err, check.IsNil)
+	c.Assert(got.User, check.Equals, "alice")
+	c.Assert(got.Database, check.Equals, "billing")
+	c.Assert(got.Raw, check.DeepEquals, msg)
+}
+
+func (s *StartupSuite) TestReadStartupMessageBadVersion(c *check.C) {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[:4], 8)
+	binary.BigEndian.PutUint32(header[4:8], 12345)
+	_, err := ReadStartupMessage(bytes.NewReader(header))
+	c.Assert(err, check.NotNil)
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package db implements a db_service: a reverse-tunnel agent that
+// authenticates database clients with Teleport-issued certificates, checks
+// per-database RBAC, and forwards the connection to the target database.
+package db
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/srv/db/mongodb"
+	"github.com/gravitational/teleport/lib/srv/db/mysql"
+	"github.com/gravitational/teleport/lib/srv/db/postgres"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// Config specifies configuration for a db_service server.
+type Config struct {
+	// Databases is the list of databases this server proxies connections
+	// to, keyed by the name clients select with (e.g. via TLS SNI).
+	Databases []services.Database
+	// Auth authenticates users from the mutually-authenticated TLS
+	// connection the proxy establishes over the reverse tunnel.
+	Auth auth.Authorizer
+	// Middleware resolves the Teleport identity from a connection's client
+	// certificate, the same way it does for the kubernetes and app proxy
+	// listeners.
+	Middleware *auth.AuthMiddleware
+	// AuditLog emits db.session.start/end events. Optional, primarily so
+	// tests can omit it.
+	AuditLog events.IAuditLog
+}
+
+// CheckAndSetDefaults makes sure the configuration is valid.
+func (c *Config) CheckAndSetDefaults() error {
+	if c.Auth == nil {
+		return trace.BadParameter("missing parameter Auth")
+	}
+	if c.Middleware == nil {
+		return trace.BadParameter("missing parameter Middleware")
+	}
+	for _, db := range c.Databases {
+		if db.Name == "" || db.URI == "" {
+			return trace.BadParameter("database %+v is missing name or uri", db)
+		}
+	}
+	return nil
+}
+
+// Server authenticates database connections and forwards them to the
+// target database as an opaque byte stream.
+//
+// Registration is static, from Config.Databases, rather than heartbeated
+// over the reverse tunnel, and connections to the database itself are
+// made over plain TCP using whatever trust the network already provides:
+// IAM auth tokens and mutual TLS to the backend database are not
+// implemented here. For PostgreSQL and MySQL databases the server sniffs
+// the protocol-specific preamble (the client's startup message for
+// PostgreSQL, the client's handshake response for MySQL) to extract the
+// database user for audit logging before treating the rest of the
+// connection as opaque. MongoDB has no such preamble, so instead every
+// command a client sends for the lifetime of the connection is parsed
+// and audited as it's forwarded; SCRAM/x509 authentication to the
+// backend and per-database/collection access restrictions beyond the
+// whole-Database RBAC check above are not implemented.
+type Server struct {
+	*log.Entry
+	Config
+
+	databasesByName map[string]services.Database
+}
+
+// New creates a new db_service server.
+func New(cfg Config) (*Server, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	s := &Server{
+		Entry: log.WithFields(log.Fields{
+			trace.Component: teleport.Component(teleport.ComponentDatabase),
+		}),
+		Config:          cfg,
+		databasesByName: make(map[string]services.Database, len(cfg.Databases)),
+	}
+	for _, db := range cfg.Databases {
+		s.databasesByName[db.Name] = db
+	}
+	return s, nil
+}
+
+// HandleConn authenticates a database client connection and, once
+// authorized, forwards it to the target database. conn must be a
+// *tls.Conn whose handshake has already completed: the database to
+// forward to is selected by its TLS SNI server name, the same way
+// app.Server.HandleTCPConn selects a TCP application.
+func (s *Server) HandleConn(ctx context.Context, conn net.Conn) error {
+	defer conn.Close()
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return trace.BadParameter("expected a TLS connection, got %T", conn)
+	}
+	state := tlsConn.ConnectionState()
+	serverName := state.ServerName
+	db, ok := s.databasesByName[serverName]
+	if !ok {
+		return trace.NotFound("no database registered for %q", serverName)
+	}
+
+	r := &http.Request{TLS: &state}
+	user, err := s.Middleware.GetUser(r)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	authCtx := context.WithValue(ctx, auth.ContextUser, user)
+	authContext, err := s.Auth.Authorize(authCtx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	checker, ok := authContext.Checker.(services.RoleSet)
+	if !ok {
+		return trace.AccessDenied("access denied: unsupported checker type %T", authContext.Checker)
+	}
+	if err := checker.CheckAccessToDatabase(db); err != nil {
+		return trace.Wrap(err)
+	}
+
+	upstream, err := net.Dial("tcp", db.URI)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer upstream.Close()
+
+	dbUser, err := s.sniffPreamble(tlsConn, upstream, db.Protocol)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	s.emitSessionEvent(events.DatabaseSessionStart, authContext.User.GetName(), db, dbUser)
+	defer s.emitSessionEvent(events.DatabaseSessionEnd, authContext.User.GetName(), db, dbUser)
+
+	errCh := make(chan error, 2)
+	go func() {
+		var err error
+		if db.Protocol == services.DatabaseProtocolMongoDB {
+			err = s.proxyMongoCommands(tlsConn, upstream, authContext.User.GetName(), db)
+		} else {
+			_, err = io.Copy(upstream, tlsConn)
+		}
+		errCh <- err
+	}()
+	go func() {
+		_, err := io.Copy(tlsConn, upstream)
+		errCh <- err
+	}()
+	if err := <-errCh; err != nil && err != io.EOF {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// sniffPreamble reads and relays whatever protocol-specific preamble
+// precedes ordinary traffic for protocol, returning the database user it
+// found for audit logging, or "" for protocols without one. Every byte it
+// reads from either side is written back out to the other, so the
+// preamble still reaches whichever end normally expects it; after it
+// returns, the connection is proxied as an opaque stream.
+func (s *Server) sniffPreamble(client, upstream net.Conn, protocol string) (dbUser string, err error) {
+	switch protocol {
+	case services.DatabaseProtocolPostgres:
+		// The PostgreSQL client speaks first.
+		startup, err := postgres.ReadStartupMessage(client)
+		if err != nil {
+			return "", trace.Wrap(err)
+		}
+		if _, err := upstream.Write(startup.Raw); err != nil {
+			return "", trace.Wrap(err)
+		}
+		return startup.User, nil
+	case services.DatabaseProtocolMySQL:
+		// The MySQL server speaks first: relay its initial handshake
+		// packet to the client before reading the client's response.
+		greeting, err := mysql.ReadPacket(upstream)
+		if err != nil {
+			return "", trace.Wrap(err)
+		}
+		if _, err := client.Write(greeting); err != nil {
+			return "", trace.Wrap(err)
+		}
+		response, err := mysql.ReadHandshakeResponse(client)
+		if err != nil {
+			return "", trace.Wrap(err)
+		}
+		if _, err := upstream.Write(response.Raw); err != nil {
+			return "", trace.Wrap(err)
+		}
+		return response.User, nil
+	case services.DatabaseProtocolMongoDB:
+		// MongoDB has no separate preamble message to sniff: every
+		// message a client sends, including authentication, is itself a
+		// command. Those are audited per-command by proxyMongoCommands
+		// as the connection is forwarded, not here.
+		return "", nil
+	default:
+		return "", nil
+	}
+}
+
+// proxyMongoCommands forwards client to upstream, emitting a
+// db.session.query audit event for each command it can parse out of the
+// MongoDB wire protocol messages in between. A message it can't parse is
+// still forwarded; audit logging is best-effort and never blocks the
+// connection.
+func (s *Server) proxyMongoCommands(client io.Reader, upstream io.Writer, user string, db services.Database) error {
+	for {
+		raw, err := mongodb.ReadMessage(client)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		if _, err := upstream.Write(raw); err != nil {
+			return trace.Wrap(err)
+		}
+		if command, _, ok := mongodb.ParseCommand(raw); ok {
+			s.emitQueryEvent(user, db, command)
+		}
+	}
+}
+
+func (s *Server) emitQueryEvent(user string, db services.Database, query string) {
+	if s.AuditLog == nil {
+		return
+	}
+	if err := s.AuditLog.EmitAuditEvent(events.DatabaseSessionQuery, events.EventFields{
+		events.EventUser:        user,
+		events.DatabaseName:     db.Name,
+		events.DatabaseProtocol: db.Protocol,
+		events.DatabaseQuery:    query,
+	}); err != nil {
+		s.Warningf("Failed to emit %v audit event: %v.", events.DatabaseSessionQuery.Name, err)
+	}
+}
+
+func (s *Server) emitSessionEvent(event events.Event, user string, db services.Database, dbUser string) {
+	if s.AuditLog == nil {
+		return
+	}
+	fields := events.EventFields{
+		events.EventUser:        user,
+		events.DatabaseName:     db.Name,
+		events.DatabaseProtocol: db.Protocol,
+	}
+	if dbUser != "" {
+		fields[events.DatabaseUser] = dbUser
+	}
+	if err := s.AuditLog.EmitAuditEvent(event, fields); err != nil {
+		s.Warningf("Failed to emit %v audit event: %v.", event.Name, err)
+	}
+}
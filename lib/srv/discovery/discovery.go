@@ -0,0 +1,230 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package discovery implements a discovery service that periodically
+// scans AWS EC2 for instances matching configured tag filters and
+// enrolls each new match into the cluster.
+//
+// What "enrolls" means here is deliberately narrow. A real discovery
+// service needs two pieces of AWS-specific machinery that this tree
+// doesn't vendor: the aws-sdk-go/service/ec2 client to list instances
+// (EC2Client below is the seam a real implementation would satisfy) and
+// either aws-sdk-go/service/ssm, to run the Teleport install script on a
+// matched instance, or an IAM join method, which verifies an incoming
+// join request by checking the caller's AWS identity via STS
+// GetCallerIdentity rather than a shared secret. Neither SSM invocation
+// nor IAM-based verification is implemented here. What is implemented,
+// and works end-to-end against the existing auth server, is the polling
+// loop, tag-filter matching, and generating a short-lived node join
+// token via the same AuthServer.GenerateToken RPC "tctl tokens add"
+// uses, so that a real SSM or IAM integration only needs to be plugged
+// in to deliver that token (or prove identity in its place) to the
+// instance.
+package discovery
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// EC2Instance describes an EC2 instance discovered by EC2Client.
+type EC2Instance struct {
+	// InstanceID is the instance's unique EC2 instance ID, e.g.
+	// "i-0123456789abcdef0".
+	InstanceID string
+	// Tags is the instance's EC2 tags.
+	Tags map[string]string
+}
+
+// EC2Client lists EC2 instances visible to the discovery service's AWS
+// credentials. A real implementation wraps
+// aws-sdk-go/service/ec2.EC2.DescribeInstances; that SDK package isn't
+// vendored in this tree, so no such implementation is provided here.
+type EC2Client interface {
+	DescribeInstances(ctx context.Context) ([]EC2Instance, error)
+}
+
+// Matcher selects EC2 instances to auto-enroll by their tags. An
+// instance matches if, for every key in Tags, one of its values is
+// present among the instance's tag values for that key; a single "*"
+// value matches any tag value for that key, including an absent tag.
+type Matcher struct {
+	Tags map[string][]string
+}
+
+// Matches reports whether instance satisfies every tag filter in m.
+func (m Matcher) Matches(instance EC2Instance) bool {
+	for key, values := range m.Tags {
+		actual, hasTag := instance.Tags[key]
+		if !matchesAny(values, actual, hasTag) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(values []string, actual string, hasTag bool) bool {
+	for _, value := range values {
+		if value == "*" {
+			return true
+		}
+		if hasTag && value == actual {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenGenerator generates a node join token. *auth.AuthServer
+// implements this.
+type TokenGenerator interface {
+	GenerateToken(req auth.GenerateTokenRequest) (string, error)
+}
+
+// Config specifies configuration for the discovery service.
+type Config struct {
+	// EC2Client lists candidate EC2 instances.
+	EC2Client EC2Client
+	// Matchers decide which EC2 instances to enroll; an instance is
+	// enrolled if any Matcher matches it.
+	Matchers []Matcher
+	// Tokens generates the join token handed to newly discovered
+	// instances.
+	Tokens TokenGenerator
+	// PollInterval is how often EC2Client is polled for instances.
+	PollInterval time.Duration
+	// TokenTTL is how long a generated join token remains valid.
+	TokenTTL time.Duration
+}
+
+// CheckAndSetDefaults makes sure the configuration is valid.
+func (c *Config) CheckAndSetDefaults() error {
+	if c.EC2Client == nil {
+		return trace.BadParameter("missing parameter EC2Client")
+	}
+	if c.Tokens == nil {
+		return trace.BadParameter("missing parameter Tokens")
+	}
+	if len(c.Matchers) == 0 {
+		return trace.BadParameter("missing parameter Matchers")
+	}
+	if c.PollInterval == 0 {
+		c.PollInterval = defaults.HeartbeatCheckPeriod
+	}
+	if c.TokenTTL == 0 {
+		c.TokenTTL = defaults.ProvisioningTokenTTL
+	}
+	return nil
+}
+
+// Server periodically scans EC2 for instances matching Config.Matchers
+// and generates a join token for each one discovered for the first
+// time.
+type Server struct {
+	*log.Entry
+	Config
+
+	// enrolled tracks instance IDs a token has already been generated
+	// for, so a still-joining instance isn't handed a fresh token (and
+	// logged about) on every poll.
+	enrolled map[string]bool
+}
+
+// New creates a new discovery service.
+func New(cfg Config) (*Server, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Server{
+		Entry: log.WithFields(log.Fields{
+			trace.Component: teleport.ComponentDiscovery,
+		}),
+		Config:   cfg,
+		enrolled: make(map[string]bool),
+	}, nil
+}
+
+// Run polls for EC2 instances matching Config.Matchers until ctx is
+// canceled, enrolling each new match exactly once.
+func (s *Server) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.poll(ctx); err != nil {
+			s.Warningf("EC2 discovery poll failed: %v.", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) poll(ctx context.Context) error {
+	instances, err := s.EC2Client.DescribeInstances(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, instance := range instances {
+		if s.enrolled[instance.InstanceID] {
+			continue
+		}
+		if !s.matches(instance) {
+			continue
+		}
+		if err := s.enroll(instance); err != nil {
+			s.Warningf("Failed to enroll EC2 instance %v: %v.", instance.InstanceID, err)
+			continue
+		}
+		s.enrolled[instance.InstanceID] = true
+	}
+	return nil
+}
+
+func (s *Server) matches(instance EC2Instance) bool {
+	for _, matcher := range s.Matchers {
+		if matcher.Matches(instance) {
+			return true
+		}
+	}
+	return false
+}
+
+// enroll generates a join token for instance. Delivering that token to
+// the instance, by running the Teleport installer over SSM or by some
+// other means, is not implemented: this logs the token as a stand-in
+// for that delivery step.
+func (s *Server) enroll(instance EC2Instance) error {
+	token, err := s.Tokens.GenerateToken(auth.GenerateTokenRequest{
+		Roles: teleport.Roles{teleport.RoleNode},
+		TTL:   s.TokenTTL,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	s.Infof("Discovered EC2 instance %v, generated join token %v (expires in %v). "+
+		"Delivering it to the instance, e.g. via SSM RunCommand, is not automated.",
+		instance.InstanceID, token, s.TokenTTL)
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+func TestDiscovery(t *testing.T) { check.TestingT(t) }
+
+type MatcherSuite struct{}
+
+var _ = check.Suite(&MatcherSuite{})
+
+func (s *MatcherSuite) TestMatches(c *check.C) {
+	matcher := Matcher{Tags: map[string][]string{
+		"teleport.dev/discover": {"true"},
+		"env":                   {"prod", "staging"},
+	}}
+
+	c.Assert(matcher.Matches(EC2Instance{Tags: map[string]string{
+		"teleport.dev/discover": "true",
+		"env":                   "prod",
+	}}), check.Equals, true)
+
+	c.Assert(matcher.Matches(EC2Instance{Tags: map[string]string{
+		"teleport.dev/discover": "true",
+		"env":                   "dev",
+	}}), check.Equals, false)
+
+	c.Assert(matcher.Matches(EC2Instance{Tags: map[string]string{
+		"env": "prod",
+	}}), check.Equals, false)
+}
+
+func (s *MatcherSuite) TestMatchesWildcard(c *check.C) {
+	matcher := Matcher{Tags: map[string][]string{"env": {"*"}}}
+	c.Assert(matcher.Matches(EC2Instance{Tags: map[string]string{"env": "anything"}}), check.Equals, true)
+	c.Assert(matcher.Matches(EC2Instance{Tags: map[string]string{}}), check.Equals, true)
+}
@@ -18,6 +18,7 @@ package srv
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"net"
@@ -32,6 +33,7 @@ import (
 	"golang.org/x/crypto/ssh"
 
 	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/shell"
@@ -114,6 +116,33 @@ type localExec struct {
 
 	// Ctx holds the *ServerContext.
 	Ctx *ServerContext
+
+	// stdout and stderr capture up to defaults.ExecOutputCaptureSize bytes of
+	// the command's output, for inclusion in the exec audit event.
+	stdout *capturingWriter
+	stderr *capturingWriter
+}
+
+// capturingWriter is an io.Writer that retains up to limit bytes written to
+// it, discarding the rest, so a command's output can be sampled for the
+// audit log without buffering an unbounded amount of it in memory.
+type capturingWriter struct {
+	limit int
+	buf   bytes.Buffer
+}
+
+func newCapturingWriter(limit int) *capturingWriter {
+	return &capturingWriter{limit: limit}
+}
+
+func (w *capturingWriter) Write(p []byte) (int, error) {
+	if room := w.limit - w.buf.Len(); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+		w.buf.Write(p[:room])
+	}
+	return len(p), nil
 }
 
 // GetCommand returns the command string.
@@ -143,9 +172,12 @@ func (e *localExec) Start(channel ssh.Channel) (*ExecResult, error) {
 		return nil, trace.Wrap(err)
 	}
 
-	// hook up stdout/err the channel so the user can interact with the command
-	e.Cmd.Stderr = channel.Stderr()
-	e.Cmd.Stdout = channel
+	// hook up stdout/err the channel so the user can interact with the command,
+	// while also sampling a size-capped copy of each stream for the audit event.
+	e.stdout = newCapturingWriter(defaults.ExecOutputCaptureSize)
+	e.stderr = newCapturingWriter(defaults.ExecOutputCaptureSize)
+	e.Cmd.Stderr = io.MultiWriter(channel.Stderr(), e.stderr)
+	e.Cmd.Stdout = io.MultiWriter(channel, e.stdout)
 	inputWriter, err := e.Cmd.StdinPipe()
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -162,7 +194,7 @@ func (e *localExec) Start(channel ssh.Channel) (*ExecResult, error) {
 		execResult, err := collectLocalStatus(e.Cmd, trace.ConvertSystemError(err))
 
 		// emit the result of execution to the audit log
-		emitExecAuditEvent(e.Ctx, e.GetCommand(), execResult, err)
+		emitExecAuditEvent(e.Ctx, e.GetCommand(), execResult, err, e.stdout.buf.Bytes(), e.stderr.buf.Bytes())
 
 		return execResult, trace.Wrap(err)
 	}
@@ -181,8 +213,9 @@ func (e *localExec) Wait() (*ExecResult, error) {
 	// successfully exited or if it exited in failure
 	execResult, err := collectLocalStatus(e.Cmd, e.Cmd.Wait())
 
-	// emit the result of execution to the audit log
-	emitExecAuditEvent(e.Ctx, e.GetCommand(), execResult, err)
+	// emit the result of execution to the audit log, including the captured
+	// output samples
+	emitExecAuditEvent(e.Ctx, e.GetCommand(), execResult, err, e.stdout.buf.Bytes(), e.stderr.buf.Bytes())
 
 	return execResult, trace.Wrap(err)
 }
@@ -308,6 +341,12 @@ func prepareCommand(ctx *ServerContext) (*exec.Cmd, error) {
 		teleport.SSHTeleportHostUUID + "=" + ctx.srv.ID(),
 		teleport.SSHTeleportClusterName + "=" + clusterName.GetClusterName(),
 	}
+	// apply any environment variables configured on the user's roles, e.g.
+	// HTTP_PROXY or other team-specific settings.
+	for n, v := range ctx.Identity.RoleSet.SessionEnvironment() {
+		c.Env = append(c.Env, fmt.Sprintf("%s=%s", n, v))
+	}
+
 	c.Dir = osUser.HomeDir
 	c.SysProcAttr = &syscall.SysProcAttr{}
 
@@ -450,8 +489,11 @@ func (r *remoteExec) Wait() (*ExecResult, error) {
 	// successfully exited or if it exited in failure
 	execResult, err := r.collectRemoteStatus(r.session.Wait())
 
-	// emit the result of execution to the audit log
-	emitExecAuditEvent(r.ctx, r.command, execResult, err)
+	// emit the result of execution to the audit log. Output is not captured
+	// here: a *remoteExec streams to a forwarding proxy's remote session,
+	// which is recorded in full separately, so sampling it again into the
+	// exec event would be redundant.
+	emitExecAuditEvent(r.ctx, r.command, execResult, err, nil, nil)
 
 	return execResult, trace.Wrap(err)
 }
@@ -480,7 +522,10 @@ func (r *remoteExec) collectRemoteStatus(err error) (*ExecResult, error) {
 	}, err
 }
 
-func emitExecAuditEvent(ctx *ServerContext, cmd string, status *ExecResult, execErr error) {
+// emitExecAuditEvent emits the result of an exec request to the audit log.
+// stdout/stderr, if non-empty, hold up to defaults.ExecOutputCaptureSize
+// bytes of the command's output.
+func emitExecAuditEvent(ctx *ServerContext, cmd string, status *ExecResult, execErr error, stdout, stderr []byte) {
 	// Report the result of this exec event to the audit logger.
 	auditLog := ctx.srv.GetAuditLog()
 	if auditLog == nil {
@@ -498,6 +543,12 @@ func emitExecAuditEvent(ctx *ServerContext, cmd string, status *ExecResult, exec
 		events.RemoteAddr:     ctx.Conn.RemoteAddr().String(),
 		events.EventNamespace: ctx.srv.GetNamespace(),
 	}
+	if len(stdout) > 0 {
+		fields[events.ExecEventStdout] = string(stdout)
+	}
+	if len(stderr) > 0 {
+		fields[events.ExecEventStderr] = string(stderr)
+	}
 	if execErr != nil {
 		fields[events.ExecEventError] = execErr.Error()
 		if status != nil {
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forward
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// nodeConnPoolTTL bounds how long an idle SSH connection to a node is kept
+// around for reuse. It's intentionally short: long enough to absorb a burst
+// of short-lived sessions (for example a handful of `tsh ssh host cmd` runs
+// in quick succession) without keeping connections to nodes that are no
+// longer in active use.
+const nodeConnPoolTTL = 15 * time.Second
+
+// pooledClient is a reference-counted SSH client connection to a remote
+// node shared by multiple forwarding sessions.
+type pooledClient struct {
+	client   *ssh.Client
+	refCount int
+	expires  time.Time
+}
+
+// nodeConnPool caches established SSH client connections to nodes so that
+// repeated dials to the same node (as the same system login) within a short
+// window reuse the existing connection, multiplexing sessions as channels
+// over it, instead of paying for a fresh TCP dial and SSH handshake each
+// time.
+type nodeConnPool struct {
+	mu    sync.Mutex
+	conns map[string]*pooledClient
+}
+
+// nodeConnPoolInstance is shared by every forwarding Server in the process,
+// since the connections it caches belong to the remote node, not to any one
+// Server instance.
+var nodeConnPoolInstance = &nodeConnPool{
+	conns: make(map[string]*pooledClient),
+}
+
+// get returns a cached, still-healthy SSH client for key, bumping its
+// reference count. The second return value is false if no usable connection
+// is cached, in which case the caller is expected to dial a new one and
+// register it with put.
+func (p *nodeConnPool) get(key string) (*ssh.Client, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pc, ok := p.conns[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(pc.expires) {
+		delete(p.conns, key)
+		if pc.refCount == 0 {
+			pc.client.Close()
+		}
+		return nil, false
+	}
+
+	// Probe the connection before handing it out: the remote node may have
+	// closed it without the pool noticing yet.
+	if _, _, err := pc.client.SendRequest("keepalive@openssh.com", false, nil); err != nil {
+		delete(p.conns, key)
+		pc.client.Close()
+		return nil, false
+	}
+
+	pc.refCount++
+	return pc.client, true
+}
+
+// put registers a freshly dialed SSH client under key so later get calls
+// within nodeConnPoolTTL can reuse it. The caller's reference is accounted
+// for, so a matching release is still required once the caller is done.
+func (p *nodeConnPool) put(key string, client *ssh.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.conns[key] = &pooledClient{
+		client:   client,
+		refCount: 1,
+		expires:  time.Now().Add(nodeConnPoolTTL),
+	}
+}
+
+// release drops the caller's reference to the pooled connection for key. If
+// the entry has since expired and no one else is using it, the underlying
+// client is closed.
+func (p *nodeConnPool) release(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pc, ok := p.conns[key]
+	if !ok {
+		return
+	}
+	pc.refCount--
+	if pc.refCount <= 0 && time.Now().After(pc.expires) {
+		delete(p.conns, key)
+		pc.client.Close()
+	}
+}
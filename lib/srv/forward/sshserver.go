@@ -51,19 +51,19 @@ import (
 //
 // To create a forwarding server and serve a single SSH connection on it:
 //
-//   serverConfig := forward.ServerConfig{
-//      ...
-//   }
-//   remoteServer, err := forward.New(serverConfig)
-//   if err != nil {
-//   	return nil, trace.Wrap(err)
-//   }
-//   go remoteServer.Serve()
+//	serverConfig := forward.ServerConfig{
+//	   ...
+//	}
+//	remoteServer, err := forward.New(serverConfig)
+//	if err != nil {
+//		return nil, trace.Wrap(err)
+//	}
+//	go remoteServer.Serve()
 //
-//   conn, err := remoteServer.Dial()
-//   if err != nil {
-//   	return nil, trace.Wrap(err)
-//   }
+//	conn, err := remoteServer.Dial()
+//	if err != nil {
+//		return nil, trace.Wrap(err)
+//	}
 type Server struct {
 	log *logrus.Entry
 
@@ -87,6 +87,12 @@ type Server struct {
 	// forwarding, subsystems.
 	remoteClient *ssh.Client
 
+	// poolKey identifies the nodeConnPool entry remoteClient was obtained
+	// from, if any. When set, remoteClient (and the TCP connection it rides
+	// on) is shared with other forwarding sessions and must not be closed
+	// directly; see Close.
+	poolKey string
+
 	// identityContext holds identity information about the user that has
 	// authenticated on sconn (like system login, Teleport username, roles).
 	identityContext srv.IdentityContext
@@ -438,8 +444,16 @@ func (s *Server) Close() error {
 		s.sconn,
 		s.clientConn,
 		s.serverConn,
-		s.targetConn,
-		s.remoteClient,
+	}
+
+	// If the connection to the remote node came from the pool, it's shared
+	// with other forwarding sessions: release our reference instead of
+	// closing it out from under them. It's only actually closed once the
+	// pool has no other references and the entry has expired.
+	if s.poolKey != "" {
+		nodeConnPoolInstance.release(s.poolKey)
+	} else {
+		conns = append(conns, s.targetConn, s.remoteClient)
 	}
 
 	var errs []error
@@ -470,6 +484,20 @@ func (s *Server) newRemoteClient(systemLogin string) (*ssh.Client, error) {
 	if s.userAgent == nil {
 		return nil, trace.AccessDenied("agent must be forwarded to proxy")
 	}
+
+	dstAddr := s.targetConn.RemoteAddr().String()
+	poolKey := systemLogin + "@" + dstAddr
+
+	// If a recently established connection to this node as this system
+	// login is still around, reuse it as an additional channel instead of
+	// dialing and re-authenticating from scratch.
+	if client, ok := nodeConnPoolInstance.get(poolKey); ok {
+		s.log.Debugf("Reusing pooled SSH connection to %v@%v.", systemLogin, dstAddr)
+		s.targetConn.Close()
+		s.poolKey = poolKey
+		return client, nil
+	}
+
 	authMethod := ssh.PublicKeysCallback(s.userAgent.Signers)
 
 	clientConfig := &ssh.ClientConfig{
@@ -487,12 +515,14 @@ func (s *Server) newRemoteClient(systemLogin string) (*ssh.Client, error) {
 	clientConfig.KeyExchanges = s.kexAlgorithms
 	clientConfig.MACs = s.macAlgorithms
 
-	dstAddr := s.targetConn.RemoteAddr().String()
 	client, err := proxy.NewClientConnWithDeadline(s.targetConn, dstAddr, clientConfig)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
+	nodeConnPoolInstance.put(poolKey, client)
+	s.poolKey = poolKey
+
 	return client, nil
 }
 
@@ -761,8 +791,10 @@ func (s *Server) handleAgentForward(ch ssh.Channel, req *ssh.Request, ctx *srv.S
 		return trace.Wrap(err)
 	}
 
-	// Route authentication requests to the agent that was forwarded to the proxy.
-	err = agent.ForwardToAgent(ctx.RemoteClient, s.userAgent)
+	// Route authentication requests to the agent that was forwarded to the
+	// proxy, wrapping it so every signing request it serves on behalf of the
+	// target node is recorded in the audit log.
+	err = agent.ForwardToAgent(ctx.RemoteClient, srv.NewAuditingAgent(s.userAgent, s.GetAuditLog(), ctx))
 	if err != nil {
 		return trace.Wrap(err)
 	}
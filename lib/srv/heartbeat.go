@@ -103,7 +103,7 @@ const (
 	// updates that support keep alives
 	HeartbeatModeNode HeartbeatMode = iota
 	// HeartbeatModeProxy sets heartbeat to proxy
-	// that does not support keep alives
+	// updates that support keep alives
 	HeartbeatModeProxy HeartbeatMode = iota
 	// HeartbeatModeAuth sets heartbeat to auth
 	// that does not support keep alives
@@ -348,11 +348,11 @@ func (h *Heartbeat) announce() error {
 	case HeartbeatStateInit, HeartbeatStateKeepAliveWait, HeartbeatStateAnnounceWait:
 		return nil
 	case HeartbeatStateAnnounce:
-		// proxies and auth servers don't support keep alive logic yet,
-		// so keep state at announce forever for proxies
+		// auth servers don't support keep alive logic yet,
+		// so keep state at announce forever for auth servers
 		switch h.Mode {
 		case HeartbeatModeProxy:
-			err := h.Announcer.UpsertProxy(h.current)
+			keepAlive, err := h.Announcer.UpsertProxy(h.current)
 			if err != nil {
 				// try next announce using keep alive period,
 				// that happens more frequently
@@ -362,7 +362,15 @@ func (h *Heartbeat) announce() error {
 			}
 			h.nextAnnounce = h.Clock.Now().UTC().Add(h.AnnouncePeriod)
 			h.notifySend()
-			h.setState(HeartbeatStateAnnounceWait)
+			// a zero-value keep alive means the proxy was upserted permanently
+			// (TTL of 0) and does not need lightweight TTL extensions
+			if keepAlive.IsEmpty() {
+				h.setState(HeartbeatStateAnnounceWait)
+				return nil
+			}
+			h.nextKeepAlive = h.Clock.Now().UTC().Add(h.KeepAlivePeriod)
+			h.keepAlive = keepAlive
+			h.setState(HeartbeatStateKeepAliveWait)
 			return nil
 		case HeartbeatModeAuth:
 			err := h.Announcer.UpsertAuthServer(h.current)
@@ -395,6 +403,16 @@ func (h *Heartbeat) announce() error {
 	case HeartbeatStateKeepAlive:
 		keepAlive := *h.keepAlive
 		keepAlive.Expires = h.Clock.Now().UTC().Add(h.ServerTTL)
+		if h.Mode == HeartbeatModeProxy {
+			if err := h.Announcer.KeepAliveProxy(h.cancelCtx, keepAlive); err != nil {
+				h.reset(HeartbeatStateInit)
+				return trace.Wrap(err)
+			}
+			h.notifySend()
+			h.nextKeepAlive = h.Clock.Now().UTC().Add(h.KeepAlivePeriod)
+			h.setState(HeartbeatStateKeepAliveWait)
+			return nil
+		}
 		timeout := time.NewTimer(h.KeepAlivePeriod)
 		defer timeout.Stop()
 		select {
@@ -43,21 +43,32 @@ func (s *HeartbeatSuite) SetUpSuite(c *check.C) {
 	utils.InitLoggerForTests(testing.Verbose())
 }
 
-// TestHeartbeatAnnounce tests announce cycles used for proxies and auth servers
+// TestHeartbeatAnnounce tests announce cycles used for auth servers, which
+// don't yet support lightweight keep alives and re-announce on every cycle
 func (s *HeartbeatSuite) TestHeartbeatAnnounce(c *check.C) {
-	s.heartbeatAnnounce(c, HeartbeatModeProxy, services.KindProxy)
 	s.heartbeatAnnounce(c, HeartbeatModeAuth, services.KindAuthServer)
 }
 
 // TestHeartbeatKeepAlive tests keep alive cycle used for nodes
 func (s *HeartbeatSuite) TestHeartbeatKeepAlive(c *check.C) {
+	s.heartbeatKeepAlive(c, HeartbeatModeNode, services.KindNode)
+}
+
+// TestHeartbeatProxyKeepAlive tests that proxies, like nodes, split presence
+// into a full announce plus lightweight keep alives instead of re-upserting
+// their full Server resource on every cycle
+func (s *HeartbeatSuite) TestHeartbeatProxyKeepAlive(c *check.C) {
+	s.heartbeatKeepAlive(c, HeartbeatModeProxy, services.KindProxy)
+}
+
+func (s *HeartbeatSuite) heartbeatKeepAlive(c *check.C, mode HeartbeatMode, kind string) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
 	clock := clockwork.NewFakeClock()
 	announcer := newFakeAnnouncer(ctx)
 
 	srv := &services.ServerV2{
-		Kind:    services.KindNode,
+		Kind:    kind,
 		Version: services.V2,
 		Metadata: services.Metadata{
 			Namespace: defaults.Namespace,
@@ -70,7 +81,7 @@ func (s *HeartbeatSuite) TestHeartbeatKeepAlive(c *check.C) {
 	}
 	hb, err := NewHeartbeat(HeartbeatConfig{
 		Context:         ctx,
-		Mode:            HeartbeatModeNode,
+		Mode:            mode,
 		Component:       "test",
 		Announcer:       announcer,
 		CheckPeriod:     time.Second,
@@ -116,7 +127,7 @@ func (s *HeartbeatSuite) TestHeartbeatKeepAlive(c *check.C) {
 
 	// update server info, system should switch to announce state
 	srv = &services.ServerV2{
-		Kind:    services.KindNode,
+		Kind:    kind,
 		Version: services.V2,
 		Metadata: services.Metadata{
 			Namespace: defaults.Namespace,
@@ -273,9 +284,20 @@ func (f *fakeAnnouncer) UpsertNode(s services.Server) (*services.KeepAlive, erro
 	return &services.KeepAlive{}, nil
 }
 
-func (f *fakeAnnouncer) UpsertProxy(s services.Server) error {
+func (f *fakeAnnouncer) UpsertProxy(s services.Server) (*services.KeepAlive, error) {
 	f.upsertCalls[HeartbeatModeProxy] += 1
-	return f.err
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &services.KeepAlive{LeaseID: 1, ServerName: s.GetName()}, nil
+}
+
+func (f *fakeAnnouncer) KeepAliveProxy(ctx context.Context, h services.KeepAlive) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.keepAlivesC <- h
+	return nil
 }
 
 func (f *fakeAnnouncer) UpsertAuthServer(s services.Server) error {
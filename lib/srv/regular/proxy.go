@@ -61,10 +61,11 @@ type proxySubsys struct {
 // proxy subsystem
 //
 // proxy subsystem name can take the following forms:
-//  "proxy:host:22"          - standard SSH request to connect to  host:22 on the 1st cluster
-//  "proxy:@clustername"        - Teleport request to connect to an auth server for cluster with name 'clustername'
-//  "proxy:host:22@clustername" - Teleport request to connect to host:22 on cluster 'clustername'
-//  "proxy:host:22@namespace@clustername"
+//
+//	"proxy:host:22"          - standard SSH request to connect to  host:22 on the 1st cluster
+//	"proxy:@clustername"        - Teleport request to connect to an auth server for cluster with name 'clustername'
+//	"proxy:host:22@clustername" - Teleport request to connect to host:22 on cluster 'clustername'
+//	"proxy:host:22@namespace@clustername"
 func parseProxySubsys(request string, srv *Server, ctx *srv.ServerContext) (*proxySubsys, error) {
 	log.Debugf("parse_proxy_subsys(%q)", request)
 	var (
@@ -112,7 +113,7 @@ func parseProxySubsys(request string, srv *Server, ctx *srv.ServerContext) (*pro
 	if clusterName != "" && srv.proxyTun != nil {
 		_, err := srv.proxyTun.GetSite(clusterName)
 		if err != nil {
-			return nil, trace.BadParameter("invalid format for proxy request: unknown cluster %q in %q", clusterName, request)
+			return nil, trace.BadParameter("invalid format for proxy request: unknown cluster %q in %q: %v", clusterName, request, err)
 		}
 	}
 
@@ -267,9 +268,19 @@ func (t *proxySubsys) proxyToHost(
 	ips, _ := net.LookupHost(t.host)
 	t.log.Debugf("proxy connecting to host=%v port=%v, exact port=%v", t.host, t.port, specifiedPort)
 
-	// enumerate and try to find a server with self-registered with a matching name/IP:
+	// enumerate and try to find a server self-registered with a matching
+	// UUID, IP, or hostname. A UUID match is always unambiguous (UUIDs are
+	// unique), so it takes priority over IP/hostname matches, which can
+	// collide when multiple nodes share a hostname.
 	var server services.Server
+	var matches []services.Server
 	for i := range servers {
+		if t.host == servers[i].GetName() {
+			server = servers[i]
+			matches = nil
+			break
+		}
+
 		ip, port, err := net.SplitHostPort(servers[i].GetAddr())
 		if err != nil {
 			t.log.Error(err)
@@ -278,8 +289,30 @@ func (t *proxySubsys) proxyToHost(
 
 		if t.host == ip || t.host == servers[i].GetHostname() || utils.SliceContainsStr(ips, ip) {
 			if !specifiedPort || t.port == port {
-				server = servers[i]
-				break
+				matches = append(matches, servers[i])
+			}
+		}
+	}
+	if server == nil {
+		switch len(matches) {
+		case 0:
+		case 1:
+			server = matches[0]
+		default:
+			clusterConfig, err := t.srv.authService.GetClusterConfig()
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			switch clusterConfig.GetAmbiguousHostResolution() {
+			case services.AmbiguousHostResolutionMostRecent:
+				server = services.MostRecentServer(matches)
+				t.log.Debugf("host %q matched %v nodes, picking most recently heartbeated: %v", t.host, len(matches), server.GetName())
+			default:
+				ids := make([]string, len(matches))
+				for i, m := range matches {
+					ids[i] = fmt.Sprintf("%v (%v)", m.GetName(), m.GetAddr())
+				}
+				return trace.BadParameter("host %q is ambiguous and matched multiple nodes, use the node UUID to disambiguate: %v", t.host, strings.Join(ids, ", "))
 			}
 		}
 	}
@@ -305,8 +338,17 @@ func (t *proxySubsys) proxyToHost(
 		if !specifiedPort {
 			t.port = strconv.Itoa(defaults.SSHServerListenPort)
 		}
-		serverAddr = net.JoinHostPort(t.host, t.port)
-		t.log.Warnf("server lookup failed: using default=%v", serverAddr)
+		if t.srv.dnsResolver != nil {
+			resolved, err := t.srv.dnsResolver.Resolve(t.host)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			t.log.Debugf("Resolved unregistered host %q to %v via configured search domains.", t.host, resolved)
+			serverAddr = net.JoinHostPort(resolved, t.port)
+		} else {
+			serverAddr = net.JoinHostPort(t.host, t.port)
+			t.log.Warnf("server lookup failed: using default=%v", serverAddr)
+		}
 	}
 
 	// Pass the agent along to the site. If the proxy is in recording mode, this
@@ -318,11 +360,12 @@ func (t *proxySubsys) proxyToHost(
 		Addr:        serverAddr,
 	}
 	conn, err := site.Dial(reversetunnel.DialParams{
-		From:       remoteAddr,
-		To:         toAddr,
-		UserAgent:  t.agent,
-		Address:    t.host,
-		Principals: principals,
+		From:         remoteAddr,
+		To:           toAddr,
+		UserAgent:    t.agent,
+		Address:      t.host,
+		Principals:   principals,
+		TargetServer: server,
 	})
 	if err != nil {
 		return trace.Wrap(err)
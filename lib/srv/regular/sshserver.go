@@ -38,6 +38,7 @@ import (
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/cloud"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/limiter"
@@ -83,6 +84,7 @@ type Server struct {
 	labels      map[string]string                //static server labels
 	cmdLabels   map[string]services.CommandLabel //dymanic server labels
 	labelsMutex *sync.Mutex
+	cloudLabels []*cloud.LabelImporter // labels imported from the cloud provider's instance metadata service, if configured
 
 	proxyMode bool
 	proxyTun  reversetunnel.Server
@@ -144,6 +146,10 @@ type Server struct {
 	// useTunnel is used to inform other components that this server is
 	// requesting connections to it come over a reverse tunnel.
 	useTunnel bool
+
+	// dnsResolver, if set, is used in proxy mode to resolve SSH targets
+	// that can't be matched against a registered Server resource.
+	dnsResolver *utils.DNSResolver
 }
 
 // GetClock returns server clock implementation
@@ -184,7 +190,21 @@ func (s *Server) GetPAM() (*pam.Config, error) {
 }
 
 // isAuditedAtProxy returns true if sessions are being recorded at the proxy
-// and this is a Teleport node.
+// and this is a Teleport node. This is how a node and the proxy it tunnels
+// through agree on who records a given session: if both sides read the
+// same session_recording setting the same way, exactly one of them records
+// it. A node and proxy can briefly disagree right after session_recording
+// is changed, since each caches cluster config independently, but that
+// window is bounded by the access point's cache TTL on both sides.
+//
+// A node can also disagree with its proxy for longer than that if it has a
+// services.SessionRecordingModeLabel override and the proxy's localSite.Dial
+// honors it (see the matching switch there) while this method ignored it:
+// that would make the node think session_recording is e.g. "proxy" and
+// discard its own session/audit log, while the proxy sees the node's
+// override as "node" and never takes over recording, so nobody records the
+// session. Apply the same label override here that localSite.Dial applies,
+// so both sides are reading the same effective mode.
 func (s *Server) isAuditedAtProxy() bool {
 	// always be safe, better to double record than not record at all
 	clusterConfig, err := s.GetAccessPoint().GetClusterConfig()
@@ -192,7 +212,18 @@ func (s *Server) isAuditedAtProxy() bool {
 		return false
 	}
 
-	isRecordAtProxy := clusterConfig.GetSessionRecording() == services.RecordAtProxy
+	recordingMode := clusterConfig.GetSessionRecording()
+	if override, ok := s.allLabels()[services.SessionRecordingModeLabel]; ok {
+		switch override {
+		case services.RecordAtProxy, services.RecordAtNode, services.RecordOff:
+			recordingMode = override
+		default:
+			log.Warningf("Ignoring invalid %v label value %q.",
+				services.SessionRecordingModeLabel, override)
+		}
+	}
+
+	isRecordAtProxy := recordingMode == services.RecordAtProxy
 	isTeleportNode := s.Component() == teleport.ComponentNode
 
 	if isRecordAtProxy && isTeleportNode {
@@ -237,6 +268,7 @@ func (s *Server) Start() error {
 	if len(s.getCommandLabels()) > 0 {
 		s.updateLabels()
 	}
+	s.startCloudLabels()
 	go s.heartbeat.Run()
 
 	// If the server requested connections to it arrive over a reverse tunnel,
@@ -252,6 +284,7 @@ func (s *Server) Serve(l net.Listener) error {
 	if len(s.getCommandLabels()) > 0 {
 		s.updateLabels()
 	}
+	s.startCloudLabels()
 	go s.heartbeat.Run()
 	return s.srv.Serve(l)
 }
@@ -328,6 +361,15 @@ func SetLabels(labels map[string]string,
 	}
 }
 
+// SetCloudLabels sets the importers that refresh this server's dynamic
+// labels from its cloud provider's instance metadata service.
+func SetCloudLabels(importers ...*cloud.LabelImporter) ServerOption {
+	return func(s *Server) error {
+		s.cloudLabels = importers
+		return nil
+	}
+}
+
 // SetLimiter sets rate and connection limiter for this server
 func SetLimiter(limiter *limiter.Limiter) ServerOption {
 	return func(s *Server) error {
@@ -402,6 +444,15 @@ func SetUseTunnel(useTunnel bool) ServerOption {
 	}
 }
 
+// SetDNSResolver sets the fallback resolver used, in proxy mode, for SSH
+// targets that can't be matched against a registered Server resource.
+func SetDNSResolver(resolver *utils.DNSResolver) ServerOption {
+	return func(s *Server) error {
+		s.dnsResolver = resolver
+		return nil
+	}
+}
+
 // New returns an unstarted server
 func New(addr utils.NetAddr,
 	hostname string,
@@ -600,7 +651,7 @@ func (s *Server) GetInfo() services.Server {
 		Metadata: services.Metadata{
 			Name:      s.ID(),
 			Namespace: s.getNamespace(),
-			Labels:    s.labels,
+			Labels:    s.allLabels(),
 		},
 		Spec: services.ServerSpecV2{
 			CmdLabels: services.LabelsToV2(s.getCommandLabels()),
@@ -627,6 +678,41 @@ func (s *Server) getServerInfo() (services.Server, error) {
 	return server, nil
 }
 
+// allLabels merges the server's configured static labels with whatever
+// its cloud label importers have most recently fetched.
+func (s *Server) allLabels() map[string]string {
+	cloudLabels := s.getCloudLabels()
+	if len(cloudLabels) == 0 {
+		return s.labels
+	}
+	out := make(map[string]string, len(s.labels)+len(cloudLabels))
+	for k, v := range s.labels {
+		out[k] = v
+	}
+	for k, v := range cloudLabels {
+		out[k] = v
+	}
+	return out
+}
+
+func (s *Server) startCloudLabels() {
+	for _, importer := range s.cloudLabels {
+		go importer.Start(s.ctx)
+	}
+}
+
+// getCloudLabels returns the most recently imported labels from every
+// configured cloud label importer, merged into a single map.
+func (s *Server) getCloudLabels() map[string]string {
+	out := make(map[string]string)
+	for _, importer := range s.cloudLabels {
+		for k, v := range importer.Get() {
+			out[k] = v
+		}
+	}
+	return out
+}
+
 func (s *Server) updateLabels() {
 	for name, label := range s.getCommandLabels() {
 		go s.periodicUpdateLabel(name, label.Clone())
@@ -1089,8 +1175,9 @@ func (s *Server) handleAgentForwardNode(req *ssh.Request, ctx *srv.ServerContext
 		return trace.Wrap(err)
 	}
 
-	// save the agent in the context so it can be used later
-	ctx.SetAgent(agent.NewClient(authChannel), authChannel)
+	// save the agent in the context so it can be used later, wrapping it so
+	// every signing request it serves is recorded in the audit log
+	ctx.SetAgent(srv.NewAuditingAgent(agent.NewClient(authChannel), s.GetAuditLog(), ctx), authChannel)
 
 	// serve an agent on a unix socket on this node
 	err = s.serveAgent(ctx)
@@ -1127,8 +1214,9 @@ func (s *Server) handleAgentForwardProxy(req *ssh.Request, ctx *srv.ServerContex
 
 	// Save the agent so it can be used when making a proxy subsystem request
 	// later. It will also be used when building a remote connection to the
-	// target node.
-	ctx.SetAgent(agent.NewClient(authChannel), authChannel)
+	// target node. Wrap it so every signing request it serves is recorded in
+	// the audit log.
+	ctx.SetAgent(srv.NewAuditingAgent(agent.NewClient(authChannel), s.GetAuditLog(), ctx), authChannel)
 
 	return nil
 }
@@ -1069,6 +1069,49 @@ func (s *SrvSuite) TestGlobalRequestRecordingProxy(c *C) {
 	c.Assert(response, Equals, true)
 }
 
+// TestIsAuditedAtProxyLabelOverride checks that isAuditedAtProxy honors a
+// SessionRecordingModeLabel override on the node itself the same way
+// localSite.Dial honors it on the proxy side, so the two sides of a tunnel
+// don't disagree about who records a session that has the label set.
+func (s *SrvSuite) TestIsAuditedAtProxyLabelOverride(c *C) {
+	clusterConfig, err := services.NewClusterConfig(services.ClusterConfigSpecV3{
+		SessionRecording: services.RecordAtNode,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(s.server.Auth().SetClusterConfig(clusterConfig), IsNil)
+
+	// cluster-wide mode is "node" and there's no override, so the node
+	// should not think the proxy is recording for it.
+	c.Assert(s.srv.isAuditedAtProxy(), Equals, false)
+
+	// a "proxy" override on the node itself should make the node defer to
+	// the proxy, matching what localSite.Dial would do for the same label.
+	s.srv.labels[services.SessionRecordingModeLabel] = services.RecordAtProxy
+	c.Assert(s.srv.isAuditedAtProxy(), Equals, true)
+
+	// an invalid override value is ignored, falling back to the cluster
+	// setting rather than silently picking a mode nobody asked for.
+	s.srv.labels[services.SessionRecordingModeLabel] = "not-a-real-mode"
+	c.Assert(s.srv.isAuditedAtProxy(), Equals, false)
+
+	delete(s.srv.labels, services.SessionRecordingModeLabel)
+
+	clusterConfig, err = services.NewClusterConfig(services.ClusterConfigSpecV3{
+		SessionRecording: services.RecordAtProxy,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(s.server.Auth().SetClusterConfig(clusterConfig), IsNil)
+
+	// cluster-wide mode is "proxy" with no override: the node should defer
+	// to the proxy.
+	c.Assert(s.srv.isAuditedAtProxy(), Equals, true)
+
+	// a "node" override on the node itself should make the node keep
+	// recording locally even though the cluster default is "proxy".
+	s.srv.labels[services.SessionRecordingModeLabel] = services.RecordAtNode
+	c.Assert(s.srv.isAuditedAtProxy(), Equals, false)
+}
+
 // upack holds all ssh signing artefacts needed for signing and checking user keys
 type upack struct {
 	// key is a raw private user key
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -607,16 +608,26 @@ func (s *session) start(ch ssh.Channel, ctx *ServerContext) error {
 
 	params := s.term.GetTerminalParams()
 
+	// List the names (but not values, to avoid leaking secrets into the
+	// audit log) of any environment variables injected by the user's roles.
+	roleEnv := ctx.Identity.RoleSet.SessionEnvironment()
+	envNames := make([]string, 0, len(roleEnv))
+	for n := range roleEnv {
+		envNames = append(envNames, n)
+	}
+	sort.Strings(envNames)
+
 	// emit "new session created" event:
 	s.recorder.GetAuditLog().EmitAuditEvent(events.SessionStart, events.EventFields{
-		events.EventNamespace:  ctx.srv.GetNamespace(),
-		events.SessionEventID:  string(s.id),
-		events.SessionServerID: ctx.srv.ID(),
-		events.EventLogin:      ctx.Identity.Login,
-		events.EventUser:       ctx.Identity.TeleportUser,
-		events.LocalAddr:       ctx.Conn.LocalAddr().String(),
-		events.RemoteAddr:      ctx.Conn.RemoteAddr().String(),
-		events.TerminalSize:    params.Serialize(),
+		events.EventNamespace:     ctx.srv.GetNamespace(),
+		events.SessionEventID:     string(s.id),
+		events.SessionServerID:    ctx.srv.ID(),
+		events.EventLogin:         ctx.Identity.Login,
+		events.EventUser:          ctx.Identity.TeleportUser,
+		events.LocalAddr:          ctx.Conn.LocalAddr().String(),
+		events.RemoteAddr:         ctx.Conn.RemoteAddr().String(),
+		events.TerminalSize:       params.Serialize(),
+		events.SessionEnvironment: envNames,
 	})
 
 	// Start a heartbeat that marks this session as active with current members
@@ -806,6 +817,28 @@ func (s *session) heartbeat(ctx *ServerContext) {
 			if err != nil {
 				s.log.Warnf("Unable to update session %v as active: %v", s.id, err)
 			}
+
+			// Check whether the session was terminated from the backend (for
+			// example, an admin terminating it through the web API). If so,
+			// close it, which disconnects all parties.
+			sess, err := sessionServer.GetSession(s.getNamespace(), s.id)
+			if err != nil {
+				s.log.Warnf("Unable to fetch session %v: %v", s.id, err)
+				continue
+			}
+			if !sess.Active {
+				s.log.Infof("Session %v was terminated, closing.", s.id)
+				s.recorder.GetAuditLog().EmitAuditEvent(events.ClientDisconnect, events.EventFields{
+					events.EventType:      events.ClientDisconnectEvent,
+					events.SessionEventID: string(s.id),
+					events.EventNamespace: s.getNamespace(),
+					events.Reason:         "session terminated",
+				})
+				if err := s.Close(); err != nil {
+					s.log.Warnf("Unable to close session %v: %v", s.id, err)
+				}
+				return
+			}
 		case <-s.closeC:
 			return
 		}
@@ -852,6 +885,12 @@ func (s *session) addParty(p *party) error {
 
 	s.log.Infof("New party %v joined session: %v", p.String(), s.id)
 
+	// Observers only receive session output; their input must never reach
+	// the PTY, so skip spawning the goroutine that pumps it there.
+	if p.readOnly {
+		return nil
+	}
+
 	// This goroutine keeps pumping party's input into the session.
 	go func() {
 		defer s.term.AddParty(-1)
@@ -962,9 +1001,18 @@ type party struct {
 	termSizeC  chan []byte
 	lastActive time.Time
 	closeOnce  sync.Once
+
+	// readOnly is true if this party joined in observer mode: it receives
+	// session output but its input is never forwarded to the session.
+	readOnly bool
 }
 
 func newParty(s *session, ch ssh.Channel, ctx *ServerContext) *party {
+	readOnly := false
+	if mode, found := ctx.GetEnv(sshutils.SessionJoinModeEnvVar); found {
+		readOnly = mode == sshutils.SessionJoinModeObserver
+	}
+
 	return &party{
 		log: logrus.WithFields(logrus.Fields{
 			trace.Component: teleport.Component(teleport.ComponentSession, ctx.srv.Component()),
@@ -980,6 +1028,7 @@ func newParty(s *session, ch ssh.Channel, ctx *ServerContext) *party {
 		sconn:     ctx.Conn,
 		termSizeC: make(chan []byte, 5),
 		closeC:    make(chan bool),
+		readOnly:  readOnly,
 	}
 }
 
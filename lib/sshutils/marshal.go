@@ -28,7 +28,7 @@ import (
 // base64-encoded key, comment.
 // For example:
 //
-//    cert-authority AAA... type=user&clustername=cluster-a
+//	cert-authority AAA... type=user&clustername=cluster-a
 //
 // URL encoding is used to pass the CA type and cluster name into the comment field.
 func MarshalAuthorizedKeysFormat(clusterName string, keyBytes []byte) (string, error) {
@@ -45,7 +45,7 @@ func MarshalAuthorizedKeysFormat(clusterName string, keyBytes []byte) (string, e
 // authorized_hosts format, a space-separated list of: marker, hosts, key, and comment.
 // For example:
 //
-//    @cert-authority *.cluster-a ssh-rsa AAA... type=host
+//	@cert-authority *.cluster-a ssh-rsa AAA... type=host
 //
 // URL encoding is used to pass the CA type and allowed logins into the comment field.
 func MarshalAuthorizedHostsFormat(clusterName string, keyBytes []byte, logins []string) (string, error) {
@@ -57,3 +57,20 @@ func MarshalAuthorizedHostsFormat(clusterName string, keyBytes []byte, logins []
 	return fmt.Sprintf("@cert-authority *.%s %s %s",
 		clusterName, strings.TrimSpace(string(keyBytes)), comment.Encode()), nil
 }
+
+// MarshalTrustedUserCAKeysFormat returns the certificate authority public key exported as a
+// single line that can be placed in a file referenced by sshd_config's TrustedUserCAKeys
+// directive. Unlike authorized_keys, that format takes bare public keys with no
+// "cert-authority" marker and no option list. For example:
+//
+//	AAA... type=user&clustername=cluster-a
+//
+// URL encoding is used to pass the CA type and cluster name into the comment field.
+func MarshalTrustedUserCAKeysFormat(clusterName string, keyBytes []byte) (string, error) {
+	comment := url.Values{
+		"type":        []string{"user"},
+		"clustername": []string{clusterName},
+	}
+
+	return fmt.Sprintf("%s %s", strings.TrimSpace(string(keyBytes)), comment.Encode()), nil
+}
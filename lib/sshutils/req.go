@@ -136,6 +136,17 @@ type SubsystemReq struct {
 // SessionEnvVar is environment variable for SSH session
 const SessionEnvVar = "TELEPORT_SESSION"
 
+// SessionJoinModeEnvVar is the environment variable used to request a
+// particular mode when joining an existing SSH session. The only mode
+// currently recognized is SessionJoinModeObserver, which joins the session
+// as a read-only observer: the party's input is never forwarded to the
+// session, but it still receives the session's output.
+const SessionJoinModeEnvVar = "TELEPORT_SESSION_JOIN_MODE"
+
+// SessionJoinModeObserver requests read-only observer access when joining
+// an existing SSH session. See SessionJoinModeEnvVar.
+const SessionJoinModeObserver = "observer"
+
 const (
 	// ExecRequest is a request to run a command.
 	ExecRequest = "exec"
@@ -24,7 +24,9 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
+	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/httplib"
 
@@ -84,6 +86,9 @@ func CreateHTTPUpload(req HTTPTransferRequest) (Command, error) {
 	if err != nil {
 		return nil, trace.BadParameter("failed to parse Content-Length header: %q", contentLength)
 	}
+	if fileSize > defaults.MaxFileTransferSize {
+		return nil, trace.BadParameter("file size %v exceeds the %v limit", fileSize, defaults.MaxFileTransferSize)
+	}
 
 	fs := &httpFileSystem{
 		reader:   req.HTTPRequest.Body,
@@ -156,6 +161,12 @@ func (l *httpFileSystem) SetChmod(path string, mode int) error {
 	return nil
 }
 
+// Chtimes sets a file's access and modification times. It does nothing, as
+// there's no underlying file to update while streaming an HTTP transfer.
+func (l *httpFileSystem) Chtimes(path string, atime, mtime time.Time) error {
+	return nil
+}
+
 // MkDir creates a directory. This method is not implemented as creating directories
 // is not supported during HTTP downloads.
 func (l *httpFileSystem) MkDir(path string, mode int) error {
@@ -180,6 +191,10 @@ func (l *httpFileSystem) OpenFile(filePath string) (io.ReadCloser, error) {
 // CreateFile sets proper HTTP headers and returns HTTP writer to stream incoming
 // file content
 func (l *httpFileSystem) CreateFile(filePath string, length uint64) (io.WriteCloser, error) {
+	if length > uint64(defaults.MaxFileTransferSize) {
+		return nil, trace.BadParameter("file size %v exceeds the %v limit", length, defaults.MaxFileTransferSize)
+	}
+
 	_, filename := filepath.Split(filePath)
 	contentLength := strconv.FormatUint(length, 10)
 	header := l.writer.Header()
@@ -243,6 +258,12 @@ func (l *httpFileInfo) GetModePerm() os.FileMode {
 	return httpUploadFileMode
 }
 
+// GetModTime returns file modification time. HTTP transfers don't carry
+// one, so the zero value is returned.
+func (l *httpFileInfo) GetModTime() time.Time {
+	return time.Time{}
+}
+
 type nopWriteCloser struct {
 	io.Writer
 }
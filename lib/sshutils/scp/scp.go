@@ -31,6 +31,8 @@ import (
 	"github.com/gravitational/teleport/lib/utils"
 	"github.com/gravitational/trace"
 
+	"github.com/dustin/go-humanize"
+	"github.com/juju/ratelimit"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -61,6 +63,9 @@ type Flags struct {
 	LocalAddr string
 	// DirectoryMode indicates that a directory is being sent.
 	DirectoryMode bool
+	// PreserveAttrs indicates that access and modification times, as well
+	// as permissions, should be preserved on copied files (the "-p" flag).
+	PreserveAttrs bool
 }
 
 // Config describes Command configuration settings
@@ -81,6 +86,9 @@ type Config struct {
 	// RunOnServer is low level API flag that indicates that
 	// this command will be run on the server
 	RunOnServer bool
+	// BandwidthLimit caps the transfer rate in bytes per second. Zero (the
+	// default) means no limit.
+	BandwidthLimit int64
 }
 
 // Command is an API that describes command operations
@@ -106,6 +114,8 @@ type FileSystem interface {
 	CreateFile(filePath string, length uint64) (io.WriteCloser, error)
 	// SetChmod sets file permissions
 	SetChmod(path string, mode int) error
+	// Chtimes sets a file's access and modification times
+	Chtimes(path string, atime, mtime time.Time) error
 }
 
 // FileInfo is an API that describes methods that provide file information
@@ -122,6 +132,8 @@ type FileInfo interface {
 	GetModePerm() os.FileMode
 	// GetSize returns file size
 	GetSize() int64
+	// GetModTime returns a file's modification time
+	GetModTime() time.Time
 }
 
 // CreateDownloadCommand configures and returns a command used
@@ -174,6 +186,10 @@ func CreateCommand(cfg Config) (Command, error) {
 		Config: cfg,
 	}
 
+	if cfg.BandwidthLimit > 0 {
+		cmd.bucket = ratelimit.NewBucketWithRate(float64(cfg.BandwidthLimit), cfg.BandwidthLimit)
+	}
+
 	cmd.log = log.WithFields(log.Fields{
 		trace.Component: "SCP",
 		trace.ComponentFields: log.Fields{
@@ -194,6 +210,80 @@ func CreateCommand(cfg Config) (Command, error) {
 type command struct {
 	Config
 	log *log.Entry
+	// bucket throttles transfer of file contents to Config.BandwidthLimit
+	// bytes per second. It's nil when no limit was configured.
+	bucket *ratelimit.Bucket
+}
+
+// limitReader wraps r so reads from it are throttled to the configured
+// bandwidth limit, if any.
+func (cmd *command) limitReader(r io.Reader) io.Reader {
+	if cmd.bucket == nil {
+		return r
+	}
+	return ratelimit.Reader(r, cmd.bucket)
+}
+
+// limitWriter wraps w so writes to it are throttled to the configured
+// bandwidth limit, if any.
+func (cmd *command) limitWriter(w io.Writer) io.Writer {
+	if cmd.bucket == nil {
+		return w
+	}
+	return ratelimit.Writer(w, cmd.bucket)
+}
+
+// progressInterval is how often a progressMeter refreshes its displayed
+// line while a transfer is in flight.
+const progressInterval = 200 * time.Millisecond
+
+// progressMeter tallies bytes moving through a transfer and prints a
+// "bytes transferred / ETA" line to Config.ProgressWriter. It implements
+// io.Writer so it can be plugged into an io.MultiWriter (uploads) or used
+// as the destination of an io.TeeReader (downloads) without disturbing the
+// actual data flow.
+type progressMeter struct {
+	w         io.Writer
+	name      string
+	total     int64
+	done      int64
+	start     time.Time
+	lastPrint time.Time
+}
+
+func newProgressMeter(w io.Writer, name string, total int64) *progressMeter {
+	return &progressMeter{w: w, name: name, total: total, start: time.Now()}
+}
+
+// Write only tallies the number of bytes seen; it never errors.
+func (p *progressMeter) Write(buf []byte) (int, error) {
+	p.done += int64(len(buf))
+	if now := time.Now(); now.Sub(p.lastPrint) >= progressInterval {
+		p.lastPrint = now
+		p.print()
+	}
+	return len(buf), nil
+}
+
+// Finish prints a final, newline-terminated progress line.
+func (p *progressMeter) Finish() {
+	p.print()
+	fmt.Fprint(p.w, "\n")
+}
+
+func (p *progressMeter) print() {
+	var eta time.Duration
+	if elapsed := time.Since(p.start); elapsed > 0 && p.done < p.total {
+		if rate := float64(p.done) / elapsed.Seconds(); rate > 0 {
+			eta = time.Duration(float64(p.total-p.done)/rate) * time.Second
+		}
+	}
+	pct := 100.0
+	if p.total > 0 {
+		pct = float64(p.done) / float64(p.total) * 100
+	}
+	fmt.Fprintf(p.w, "\r%-40s %10s/%-10s %5.1f%% ETA %8s",
+		utils.EscapeControl(p.name), humanize.Bytes(uint64(p.done)), humanize.Bytes(uint64(p.total)), pct, eta.Round(time.Second))
 }
 
 // Execute() implements SSH file copy (SCP). It is called on both tsh (client)
@@ -236,6 +326,9 @@ func (cmd *command) GetRemoteShellCmd() (string, error) {
 	if cmd.Flags.DirectoryMode {
 		shellCmd += " -d"
 	}
+	if cmd.Flags.PreserveAttrs {
+		shellCmd += " -p"
+	}
 	shellCmd += (" " + cmd.RemoteLocation)
 
 	return shellCmd, nil
@@ -278,6 +371,12 @@ func (cmd *command) serveSource(ch io.ReadWriter) error {
 }
 
 func (cmd *command) sendDir(r *reader, ch io.ReadWriter, fileInfo FileInfo) error {
+	if cmd.Flags.PreserveAttrs {
+		if err := cmd.sendMtime(r, ch, fileInfo.GetModTime()); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	out := fmt.Sprintf("D%04o 0 %s\n", fileInfo.GetModePerm(), fileInfo.GetName())
 	cmd.log.Debugf("sendDir: %v", out)
 	_, err := io.WriteString(ch, out)
@@ -323,14 +422,14 @@ func (cmd *command) sendFile(r *reader, ch io.ReadWriter, fileInfo FileInfo) err
 
 	defer reader.Close()
 
-	out := fmt.Sprintf("C%04o %d %s\n", fileInfo.GetModePerm(), fileInfo.GetSize(), fileInfo.GetName())
-
-	// report progress:
-	if cmd.ProgressWriter != nil {
-		statusMessage := fmt.Sprintf("-> %s (%d)\n", fileInfo.GetPath(), fileInfo.GetSize())
-		defer fmt.Fprintf(cmd.ProgressWriter, utils.EscapeControl(statusMessage))
+	if cmd.Flags.PreserveAttrs {
+		if err := cmd.sendMtime(r, ch, fileInfo.GetModTime()); err != nil {
+			return trace.Wrap(err)
+		}
 	}
 
+	out := fmt.Sprintf("C%04o %d %s\n", fileInfo.GetModePerm(), fileInfo.GetSize(), fileInfo.GetName())
+
 	_, err = io.WriteString(ch, out)
 	if err != nil {
 		return trace.Wrap(err)
@@ -340,7 +439,16 @@ func (cmd *command) sendFile(r *reader, ch io.ReadWriter, fileInfo FileInfo) err
 		return trace.Wrap(err)
 	}
 
-	n, err := io.Copy(ch, reader)
+	var dst io.Writer = cmd.limitWriter(ch)
+
+	// report progress:
+	if cmd.ProgressWriter != nil {
+		meter := newProgressMeter(cmd.ProgressWriter, fileInfo.GetPath(), fileInfo.GetSize())
+		defer meter.Finish()
+		dst = io.MultiWriter(dst, meter)
+	}
+
+	n, err := io.Copy(dst, reader)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -354,6 +462,17 @@ func (cmd *command) sendFile(r *reader, ch io.ReadWriter, fileInfo FileInfo) err
 	return trace.Wrap(r.read())
 }
 
+// sendMtime sends the scp protocol's "T" control line carrying a file's
+// modification and access times (used by the -p/PreserveAttrs flag). Access
+// time isn't tracked separately, so the modification time is sent for both.
+func (cmd *command) sendMtime(r *reader, ch io.ReadWriter, mtime time.Time) error {
+	out := fmt.Sprintf("T%d 0 %d 0\n", mtime.Unix(), mtime.Unix())
+	if _, err := io.WriteString(ch, out); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(r.read())
+}
+
 // serveSink executes file uploading, when a remote server sends file(s)
 // via scp
 func (cmd *command) serveSink(ch io.ReadWriter) error {
@@ -439,10 +558,13 @@ func (cmd *command) processCommand(ch io.ReadWriter, st *state, b byte, line str
 	case 'E':
 		return st.pop()
 	case 'T':
-		_, err := parseMtime(line)
+		m, err := parseMtime(line)
 		if err != nil {
 			return trace.Wrap(err)
 		}
+		// the mtime line always precedes the C/D line it applies to.
+		st.pendingMtime = m
+		return nil
 	}
 	return trace.Errorf("got unrecognized command: %v", string(b))
 }
@@ -458,24 +580,30 @@ func (cmd *command) receiveFile(st *state, fc newFileCmd, ch io.ReadWriter) erro
 		path = st.makePath(path, fc.Name)
 	}
 
+	mtime := st.pendingMtime
+	st.pendingMtime = nil
+
 	writer, err := cmd.FileSystem.CreateFile(path, fc.Length)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	// report progress:
-	if cmd.ProgressWriter != nil {
-		statusMessage := fmt.Sprintf("<- %s (%d)\n", path, fc.Length)
-		defer fmt.Fprintf(cmd.ProgressWriter, utils.EscapeControl(statusMessage))
-	}
-
 	defer writer.Close()
 
 	if err = sendOK(ch); err != nil {
 		return trace.Wrap(err)
 	}
 
-	n, err := io.CopyN(writer, ch, int64(fc.Length))
+	src := cmd.limitReader(ch)
+
+	// report progress:
+	if cmd.ProgressWriter != nil {
+		meter := newProgressMeter(cmd.ProgressWriter, path, int64(fc.Length))
+		defer meter.Finish()
+		src = io.TeeReader(src, meter)
+	}
+
+	n, err := io.CopyN(writer, src, int64(fc.Length))
 	if err != nil {
 		cmd.log.Error(err)
 		return trace.Wrap(err)
@@ -489,6 +617,12 @@ func (cmd *command) receiveFile(st *state, fc newFileCmd, ch io.ReadWriter) erro
 		return trace.Wrap(err)
 	}
 
+	if cmd.Flags.PreserveAttrs && mtime != nil {
+		if err := cmd.FileSystem.Chtimes(path, mtime.Atime, mtime.Mtime); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	cmd.log.Debugf("file %v(%v) copied to %v", fc.Name, fc.Length, path)
 	return nil
 }
@@ -496,6 +630,9 @@ func (cmd *command) receiveFile(st *state, fc newFileCmd, ch io.ReadWriter) erro
 func (cmd *command) receiveDir(st *state, fc newFileCmd, ch io.ReadWriter) error {
 	targetDir := cmd.Flags.Target[0]
 
+	mtime := st.pendingMtime
+	st.pendingMtime = nil
+
 	// copying into an existing directory? append to it:
 	if cmd.FileSystem.IsDir(targetDir) {
 		targetDir = st.makePath(targetDir, fc.Name)
@@ -507,6 +644,12 @@ func (cmd *command) receiveDir(st *state, fc newFileCmd, ch io.ReadWriter) error
 		return trace.Wrap(err)
 	}
 
+	if cmd.Flags.PreserveAttrs && mtime != nil {
+		if err := cmd.FileSystem.Chtimes(targetDir, mtime.Atime, mtime.Mtime); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	return nil
 }
 
@@ -597,6 +740,9 @@ func sendOK(ch io.ReadWriter) error {
 type state struct {
 	path     []string
 	finished bool
+	// pendingMtime holds the mtime/atime sent by a preceding "T" control
+	// line, to be applied to the next file or directory that's created.
+	pendingMtime *mtimeCmd
 }
 
 func (st *state) push(dir string) {
@@ -406,6 +406,13 @@ func (s *Server) HandleConnection(conn net.Conn) {
 		conn.Close()
 		return
 	}
+	if err := s.limiter.AcquireConnectionForUser(user); err != nil {
+		log.Errorf(err.Error())
+		sconn.Close()
+		conn.Close()
+		return
+	}
+	defer s.limiter.ReleaseConnectionForUser(user)
 	// Connection successfully initiated
 	s.Debugf("Incoming connection %v -> %v vesion: %v.",
 		sconn.RemoteAddr(), sconn.LocalAddr(), string(sconn.ClientVersion()))
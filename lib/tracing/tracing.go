@@ -0,0 +1,96 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing provides minimal context-propagated spans for the
+// proxy -> reversetunnel -> forward -> node dial path, so that a slow
+// connection setup can be broken down into per-hop timings.
+//
+// This is intentionally not a full OpenTelemetry integration: the
+// vendor tree does not currently carry the OTel SDK. The API below
+// (StartSpan/Span.End, context propagation, attributes) mirrors the
+// shape of an OTel tracer closely enough that swapping this package's
+// internals for go.opentelemetry.io/otel later should not require
+// touching any call site.
+package tracing
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+// Span represents a single named operation with a start and end time and
+// a set of key/value attributes, e.g. "localSite.Dial" with
+// attribute "target=node-1".
+type Span struct {
+	name       string
+	start      time.Time
+	parent     *Span
+	attributes map[string]interface{}
+}
+
+// StartSpan starts a new span named "name", parented to any span already
+// present in ctx, and returns a context carrying the new span alongside
+// the span itself so the caller can add attributes and must call End().
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	span := &Span{
+		name:   name,
+		start:  time.Now(),
+		parent: SpanFromContext(ctx),
+	}
+	return context.WithValue(ctx, contextKey{}, span), span
+}
+
+// SpanFromContext returns the span stored in ctx, or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(contextKey{}).(*Span)
+	return span
+}
+
+// SetAttribute records a key/value pair on the span, to be included when
+// it is exported.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	if s.attributes == nil {
+		s.attributes = make(map[string]interface{})
+	}
+	s.attributes[key] = value
+}
+
+// End finishes the span and exports it. The current exporter simply logs
+// the span at debug level; it is the seam a future OTLP exporter hooks
+// into.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	fields := log.Fields{
+		"span":     s.name,
+		"duration": time.Since(s.start),
+	}
+	if s.parent != nil {
+		fields["parent"] = s.parent.name
+	}
+	for k, v := range s.attributes {
+		fields[k] = v
+	}
+	log.WithFields(fields).Debug("span finished")
+}
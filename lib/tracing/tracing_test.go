@@ -0,0 +1,53 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+func TestTracing(t *testing.T) { check.TestingT(t) }
+
+type TracingSuite struct{}
+
+var _ = check.Suite(&TracingSuite{})
+
+func (s *TracingSuite) TestSpanParentPropagation(c *check.C) {
+	c.Assert(SpanFromContext(context.Background()), check.IsNil)
+
+	ctx, parent := StartSpan(context.Background(), "parent")
+	c.Assert(SpanFromContext(ctx), check.Equals, parent)
+
+	ctx, child := StartSpan(ctx, "child")
+	c.Assert(child.parent, check.Equals, parent)
+	c.Assert(SpanFromContext(ctx), check.Equals, child)
+
+	child.SetAttribute("key", "value")
+	c.Assert(child.attributes["key"], check.Equals, "value")
+
+	child.End()
+	parent.End()
+}
+
+func (s *TracingSuite) TestNilSpanIsSafe(c *check.C) {
+	var span *Span
+	span.SetAttribute("key", "value")
+	span.End()
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package usagereporter aggregates high-level usage counts (active users,
+// protocols used, session hours) for capacity planning. It never stores or
+// reports raw identifiers: usernames are one-way hashed before they're
+// counted, and what leaves the process is a small set of totals, not a
+// list of who did what.
+//
+// Reporting is opt-in: a Reporter created with enabled=false (the default
+// unless an operator explicitly turns it on) discards every Record call,
+// so the aggregates always reflect what was actually reported, never a
+// guess at what would have been.
+package usagereporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Report is a point-in-time snapshot of aggregated usage counts
+type Report struct {
+	// StartTime is when counts in this report started accumulating
+	StartTime time.Time `json:"start_time"`
+	// EndTime is when this report was generated
+	EndTime time.Time `json:"end_time"`
+	// ActiveUsers is the number of distinct users seen in the period
+	ActiveUsers int `json:"active_users"`
+	// ProtocolSessions counts sessions started, keyed by protocol
+	// (e.g. "ssh", "kube", "db")
+	ProtocolSessions map[string]int `json:"protocol_sessions"`
+	// SessionHours is the total session duration recorded, in hours
+	SessionHours float64 `json:"session_hours"`
+}
+
+// Reporter aggregates usage counts in memory for later reporting.
+type Reporter struct {
+	mu sync.Mutex
+
+	// enabled is whether this reporter records anything at all. Set once
+	// at construction time from the cluster's opt-in/opt-out setting.
+	enabled bool
+
+	startTime        time.Time
+	activeUsers      map[string]struct{}
+	protocolSessions map[string]int
+	sessionDuration  time.Duration
+
+	clock func() time.Time
+}
+
+// New returns a Reporter. If enabled is false, every Record call is a
+// no-op and Report always returns zero counts.
+func New(enabled bool) *Reporter {
+	return &Reporter{
+		enabled:          enabled,
+		startTime:        time.Now().UTC(),
+		activeUsers:      make(map[string]struct{}),
+		protocolSessions: make(map[string]int),
+		clock:            func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Enabled returns whether this reporter is actively recording usage
+func (r *Reporter) Enabled() bool {
+	return r.enabled
+}
+
+// RecordActiveUser records that a user was active in the current period.
+// The username is hashed before being stored; the raw value is never
+// retained.
+func (r *Reporter) RecordActiveUser(username string) {
+	if !r.enabled {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activeUsers[hashIdentifier(username)] = struct{}{}
+}
+
+// RecordSession records that a session using the given protocol ran for
+// the given duration.
+func (r *Reporter) RecordSession(protocol string, duration time.Duration) {
+	if !r.enabled {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.protocolSessions[protocol]++
+	r.sessionDuration += duration
+}
+
+// Report returns a snapshot of the counts aggregated so far.
+func (r *Reporter) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	protocolSessions := make(map[string]int, len(r.protocolSessions))
+	for protocol, count := range r.protocolSessions {
+		protocolSessions[protocol] = count
+	}
+
+	return Report{
+		StartTime:        r.startTime,
+		EndTime:          r.clock(),
+		ActiveUsers:      len(r.activeUsers),
+		ProtocolSessions: protocolSessions,
+		SessionHours:     r.sessionDuration.Hours(),
+	}
+}
+
+// Reset clears all aggregated counts and starts a new reporting period.
+func (r *Reporter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.startTime = r.clock()
+	r.activeUsers = make(map[string]struct{})
+	r.protocolSessions = make(map[string]int)
+	r.sessionDuration = 0
+}
+
+// MarshalReport returns the current report as indented JSON, suitable for
+// writing to a report file for capacity planning.
+func (r *Reporter) MarshalReport() ([]byte, error) {
+	return json.MarshalIndent(r.Report(), "", "  ")
+}
+
+func hashIdentifier(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usagereporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type UsageReporterSuite struct{}
+
+var _ = check.Suite(&UsageReporterSuite{})
+
+func (s *UsageReporterSuite) TestAggregation(c *check.C) {
+	r := New(true)
+	r.RecordActiveUser("alice")
+	r.RecordActiveUser("bob")
+	r.RecordActiveUser("alice")
+	r.RecordSession("ssh", 30*time.Minute)
+	r.RecordSession("ssh", 30*time.Minute)
+	r.RecordSession("kube", time.Hour)
+
+	report := r.Report()
+	c.Assert(report.ActiveUsers, check.Equals, 2)
+	c.Assert(report.ProtocolSessions["ssh"], check.Equals, 2)
+	c.Assert(report.ProtocolSessions["kube"], check.Equals, 1)
+	c.Assert(report.SessionHours, check.Equals, 2.0)
+
+	data, err := r.MarshalReport()
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(string(data), "alice"), check.Equals, false)
+	c.Assert(strings.Contains(string(data), "bob"), check.Equals, false)
+
+	r.Reset()
+	report = r.Report()
+	c.Assert(report.ActiveUsers, check.Equals, 0)
+	c.Assert(report.ProtocolSessions, check.HasLen, 0)
+}
+
+func (s *UsageReporterSuite) TestDisabled(c *check.C) {
+	r := New(false)
+	c.Assert(r.Enabled(), check.Equals, false)
+	r.RecordActiveUser("alice")
+	r.RecordSession("ssh", time.Hour)
+
+	report := r.Report()
+	c.Assert(report.ActiveUsers, check.Equals, 0)
+	c.Assert(report.SessionHours, check.Equals, 0.0)
+}
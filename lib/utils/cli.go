@@ -44,25 +44,26 @@ const (
 func InitLogger(purpose LoggingPurpose, level log.Level, verbose ...bool) {
 	log.StandardLogger().SetHooks(make(log.LevelHooks))
 	log.SetLevel(level)
+	SetComponentLogLevel("", level)
 
 	switch purpose {
 	case LoggingForCLI:
 		// If debug logging was asked for on the CLI, then write logs to stderr.
 		// Otherwise discard all logs.
 		if level == log.DebugLevel {
-			log.SetFormatter(&trace.TextFormatter{
+			log.SetFormatter(NewComponentLevelFilter(&trace.TextFormatter{
 				DisableTimestamp: true,
 				EnableColors:     trace.IsTerminal(os.Stderr),
-			})
+			}))
 			log.SetOutput(os.Stderr)
 		} else {
 			log.SetOutput(ioutil.Discard)
 		}
 	case LoggingForDaemon:
-		log.SetFormatter(&trace.TextFormatter{
+		log.SetFormatter(NewComponentLevelFilter(&trace.TextFormatter{
 			DisableTimestamp: true,
 			EnableColors:     trace.IsTerminal(os.Stderr),
-		})
+		}))
 		log.SetOutput(os.Stderr)
 	case LoggingForTests:
 		log.SetFormatter(&trace.TextFormatter{
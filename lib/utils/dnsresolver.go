@@ -0,0 +1,94 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// DNSResolver resolves a requested SSH target that couldn't be matched
+// against a registered Server resource, by trying it against a list of
+// configured search domains and restricting the result to a list of
+// routable CIDR allowlists. It's meant for hybrid environments with legacy
+// hosts that aren't running a Teleport agent.
+type DNSResolver struct {
+	// SearchDomains is a list of DNS domains to try appending to the
+	// requested host, in order, in addition to the host as given.
+	SearchDomains []string
+	// AllowedCIDRs restricts resolved addresses to the given CIDR ranges.
+	// If empty, any resolved address is allowed.
+	AllowedCIDRs []string
+
+	nets []*net.IPNet
+}
+
+// CheckAndSetDefaults validates and parses AllowedCIDRs.
+func (r *DNSResolver) CheckAndSetDefaults() error {
+	for _, cidr := range r.AllowedCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return trace.BadParameter("invalid allowed CIDR %q: %v", cidr, err)
+		}
+		r.nets = append(r.nets, ipNet)
+	}
+	return nil
+}
+
+// Resolve tries host, then host qualified with each configured search
+// domain in turn, returning the first resolved address that falls within
+// AllowedCIDRs (or the first resolved address at all, if AllowedCIDRs is
+// empty).
+func (r *DNSResolver) Resolve(host string) (string, error) {
+	candidates := make([]string, 0, len(r.SearchDomains)+1)
+	candidates = append(candidates, host)
+	for _, domain := range r.SearchDomains {
+		candidates = append(candidates, host+"."+strings.TrimPrefix(domain, "."))
+	}
+
+	for _, candidate := range candidates {
+		ips, err := net.LookupHost(candidate)
+		if err != nil {
+			continue
+		}
+		for _, ip := range ips {
+			if r.allowed(ip) {
+				return ip, nil
+			}
+		}
+	}
+
+	return "", trace.NotFound("could not resolve %q to an allowed address", host)
+}
+
+func (r *DNSResolver) allowed(ip string) bool {
+	if len(r.nets) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range r.nets {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
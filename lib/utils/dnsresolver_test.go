@@ -0,0 +1,50 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"gopkg.in/check.v1"
+)
+
+type DNSResolverSuite struct{}
+
+var _ = check.Suite(&DNSResolverSuite{})
+
+// TestAllowedCIDRs makes sure resolved addresses are filtered against the
+// configured CIDR allowlist.
+func (s *DNSResolverSuite) TestAllowedCIDRs(c *check.C) {
+	r := &DNSResolver{
+		AllowedCIDRs: []string{"10.0.0.0/8", "not-a-cidr"},
+	}
+	c.Assert(r.CheckAndSetDefaults(), check.NotNil)
+
+	r = &DNSResolver{
+		AllowedCIDRs: []string{"10.0.0.0/8"},
+	}
+	c.Assert(r.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(r.allowed("10.1.2.3"), check.Equals, true)
+	c.Assert(r.allowed("192.168.1.1"), check.Equals, false)
+	c.Assert(r.allowed("not-an-ip"), check.Equals, false)
+}
+
+// TestNoAllowedCIDRs makes sure any address is allowed when no allowlist is
+// configured.
+func (s *DNSResolverSuite) TestNoAllowedCIDRs(c *check.C) {
+	r := &DNSResolver{}
+	c.Assert(r.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(r.allowed("192.168.1.1"), check.Equals, true)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"sync"
+
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// componentLevels holds per-component log level overrides, e.g.
+// "reversetunnel" -> log.DebugLevel while the rest of the process logs at
+// log.InfoLevel. Guarded by componentLevelsMu since it can be mutated at
+// runtime (see SetComponentLogLevel).
+var (
+	componentLevelsMu sync.RWMutex
+	componentLevels   = make(map[string]log.Level)
+	defaultLevel      = log.InfoLevel
+)
+
+// SetComponentLogLevel overrides the log level used for entries tagged with
+// the given component (see trace.Component), independently of the global
+// level set by InitLogger. Pass an empty component to change the default
+// level applied to entries with no override.
+//
+// The global logrus level is left at its most verbose configured value so
+// that entries from components with a more verbose override are not
+// dropped before reaching the formatter; filtering happens there instead.
+func SetComponentLogLevel(component string, level log.Level) {
+	componentLevelsMu.Lock()
+	defer componentLevelsMu.Unlock()
+	if component == "" {
+		defaultLevel = level
+	} else {
+		componentLevels[component] = level
+	}
+	if level > log.GetLevel() {
+		log.SetLevel(level)
+	}
+}
+
+// GetComponentLogLevel returns the level currently in effect for component,
+// and whether it was explicitly overridden (as opposed to using the
+// default level).
+func GetComponentLogLevel(component string) (log.Level, bool) {
+	componentLevelsMu.RLock()
+	defer componentLevelsMu.RUnlock()
+	level, ok := componentLevels[component]
+	if !ok {
+		return defaultLevel, false
+	}
+	return level, true
+}
+
+// componentLevelFilter wraps a log.Formatter and drops entries that are
+// more verbose than the level configured for their component, allowing
+// per-component verbosity on top of a single global logrus level.
+type componentLevelFilter struct {
+	log.Formatter
+}
+
+// NewComponentLevelFilter wraps formatter so that entries are filtered
+// according to the per-component levels set with SetComponentLogLevel.
+func NewComponentLevelFilter(formatter log.Formatter) log.Formatter {
+	return &componentLevelFilter{Formatter: formatter}
+}
+
+// Format implements log.Formatter. It returns no bytes (and no error) for
+// entries that should be suppressed for their component's level.
+func (f *componentLevelFilter) Format(e *log.Entry) ([]byte, error) {
+	component, _ := e.Data[trace.Component].(string)
+	level, _ := GetComponentLogLevel(component)
+	if e.Level > level {
+		return nil, nil
+	}
+	return f.Formatter.Format(e)
+}
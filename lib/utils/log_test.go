@@ -0,0 +1,58 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+
+	"github.com/gravitational/trace"
+
+	"gopkg.in/check.v1"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type LogSuite struct {
+}
+
+var _ = check.Suite(&LogSuite{})
+
+func (s *LogSuite) TestComponentLevelFilter(c *check.C) {
+	SetComponentLogLevel("", log.InfoLevel)
+	SetComponentLogLevel("reversetunnel", log.DebugLevel)
+
+	level, overridden := GetComponentLogLevel("reversetunnel")
+	c.Assert(level, check.Equals, log.DebugLevel)
+	c.Assert(overridden, check.Equals, true)
+
+	level, overridden = GetComponentLogLevel("auth")
+	c.Assert(level, check.Equals, log.InfoLevel)
+	c.Assert(overridden, check.Equals, false)
+
+	filter := NewComponentLevelFilter(&log.TextFormatter{DisableTimestamp: true})
+
+	debugEntry := &log.Entry{Level: log.DebugLevel, Data: log.Fields{trace.Component: "auth"}}
+	out, err := filter.Format(debugEntry)
+	c.Assert(err, check.IsNil)
+	c.Assert(out, check.HasLen, 0)
+
+	debugEntry.Data[trace.Component] = "reversetunnel"
+	out, err = filter.Format(debugEntry)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(out) > 0, check.Equals, true)
+	c.Assert(bytes.Contains(out, []byte("level=debug")), check.Equals, true)
+}
@@ -5,7 +5,7 @@ Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
 You may obtain a copy of the License at
 
-    http://www.apache.org/licenses/LICENSE-2.0
+	http://www.apache.org/licenses/LICENSE-2.0
 
 Unless required by applicable law or agreed to in writing, software
 distributed under the License is distributed on an "AS IS" BASIS,
@@ -18,6 +18,8 @@ package proxy
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"net"
 	"net/http"
 	"net/url"
@@ -85,7 +87,7 @@ func (d directDial) DialTimeout(network, address string, timeout time.Duration)
 }
 
 type proxyDial struct {
-	proxyHost string
+	proxyURL *url.URL
 }
 
 // DialTimeout acts like Dial but takes a timeout.
@@ -97,14 +99,14 @@ func (d proxyDial) DialTimeout(network, address string, timeout time.Duration) (
 		defer cancel()
 		ctx = timeoutCtx
 	}
-	return dialProxy(ctx, d.proxyHost, address)
+	return dialProxy(ctx, d.proxyURL, address)
 }
 
 // Dial first connects to a proxy, then uses the connection to establish a new
 // SSH connection.
 func (d proxyDial) Dial(network string, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
 	// Build a proxy connection first.
-	pconn, err := dialProxy(context.Background(), d.proxyHost, addr)
+	pconn, err := dialProxy(context.Background(), d.proxyURL, addr)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -128,33 +130,51 @@ func (d proxyDial) Dial(network string, addr string, config *ssh.ClientConfig) (
 // server directly.
 func DialerFromEnvironment(addr string) Dialer {
 	// Try and get proxy addr from the environment.
-	proxyAddr := getProxyAddress(addr)
+	proxyURL := getProxyAddress(addr)
 
 	// If no proxy settings are in environment return regular ssh dialer,
 	// otherwise return a proxy dialer.
-	if proxyAddr == "" {
+	if proxyURL == nil {
 		log.Debugf("No proxy set in environment, returning direct dialer.")
 		return directDial{}
 	}
-	log.Debugf("Found proxy %q in environment, returning proxy dialer.", proxyAddr)
-	return proxyDial{proxyHost: proxyAddr}
+	log.Debugf("Found proxy %q in environment, returning proxy dialer.", proxyURL.Host)
+	return proxyDial{proxyURL: proxyURL}
 }
 
-func dialProxy(ctx context.Context, proxyAddr string, addr string) (net.Conn, error) {
-
+func dialProxy(ctx context.Context, proxyURL *url.URL, addr string) (net.Conn, error) {
 	var d net.Dialer
-	conn, err := d.DialContext(ctx, "tcp", proxyAddr)
+	conn, err := d.DialContext(ctx, "tcp", proxyURL.Host)
 	if err != nil {
-		log.Warnf("Unable to dial to proxy: %v: %v.", proxyAddr, err)
+		log.Warnf("Unable to dial to proxy: %v: %v.", proxyURL.Host, err)
 		return nil, trace.ConvertSystemError(err)
 	}
 
+	// If the proxy was reached over plain TCP, promote the connection to TLS
+	// before issuing CONNECT so traffic between us and the proxy itself is
+	// encrypted (e.g. https_proxy=https://proxy:443).
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			log.Warnf("Unable to negotiate TLS with proxy: %v: %v.", proxyURL.Host, err)
+			return nil, trace.Wrap(err)
+		}
+		conn = tlsConn
+	}
+
 	connectReq := &http.Request{
 		Method: http.MethodConnect,
 		URL:    &url.URL{Opaque: addr},
 		Host:   addr,
 		Header: make(http.Header),
 	}
+	if proxyURL.User != nil {
+		username := proxyURL.User.Username()
+		password, _ := proxyURL.User.Password()
+		creds := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		connectReq.Header.Set("Proxy-Authorization", "Basic "+creds)
+	}
 	err = connectReq.Write(conn)
 	if err != nil {
 		log.Warnf("Unable to write to proxy: %v.", err)
@@ -187,7 +207,7 @@ func dialProxy(ctx context.Context, proxyAddr string, addr string) (net.Conn, er
 	}, nil
 }
 
-func getProxyAddress(addr string) string {
+func getProxyAddress(addr string) *url.URL {
 	envs := []string{
 		teleport.HTTPSProxy,
 		strings.ToLower(teleport.HTTPSProxy),
@@ -200,35 +220,36 @@ func getProxyAddress(addr string) string {
 		if envAddr == "" {
 			continue
 		}
-		proxyAddr, err := parse(envAddr)
+		proxyURL, err := parse(envAddr)
 		if err != nil {
 			log.Debugf("Unable to parse environment variable %q: %q.", v, envAddr)
 			continue
 		}
-		log.Debugf("Successfully parsed environment variable %q: %q to %q.", v, envAddr, proxyAddr)
+		log.Debugf("Successfully parsed environment variable %q: %q to %q.", v, envAddr, proxyURL.Host)
 		if !useProxy(addr) {
 			log.Debugf("Matched NO_PROXY override for %q: %q, going to ignore proxy variable.", v, envAddr)
-			return ""
+			return nil
 		}
-		return proxyAddr
+		return proxyURL
 	}
 
 	log.Debugf("No valid environment variables found.")
-	return ""
+	return nil
 }
 
-// parse will extract the host:port of the proxy to dial to. If the
-// value is not prefixed by "http", then it will prepend "http" and try.
-func parse(addr string) (string, error) {
+// parse will extract the scheme, optional userinfo (for authenticated
+// proxies), and host:port of the proxy to dial to. If the value is not
+// prefixed by "http", then it will prepend "http" and try.
+func parse(addr string) (*url.URL, error) {
 	proxyurl, err := url.Parse(addr)
 	if err != nil || !strings.HasPrefix(proxyurl.Scheme, "http") {
 		proxyurl, err = url.Parse("http://" + addr)
 		if err != nil {
-			return "", trace.Wrap(err)
+			return nil, trace.Wrap(err)
 		}
 	}
 
-	return proxyurl.Host, nil
+	return proxyurl, nil
 }
 
 // bufferedConn is used when part of the data on a connection has already been
@@ -18,6 +18,7 @@ package utils
 
 import (
 	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/gravitational/trace"
@@ -49,6 +50,10 @@ type LinearConfig struct {
 	// Max is a maximum value of the progression,
 	// can't be 0
 	Max time.Duration
+	// Jitter is an optional function that randomizes the computed
+	// duration, used to avoid clients retrying in lockstep. See
+	// NewHalfJitter.
+	Jitter Jitter
 }
 
 // CheckAndSetDefaults checks and sets defaults
@@ -98,12 +103,15 @@ func (r *Linear) Inc() {
 func (r *Linear) Duration() time.Duration {
 	a := r.First + time.Duration(r.attempt)*r.Step
 	if a < 0 {
-		return 0
+		a = 0
 	}
-	if a <= r.Max {
-		return a
+	if a > r.Max {
+		a = r.Max
 	}
-	return r.Max
+	if r.Jitter != nil {
+		return r.Jitter(a)
+	}
+	return a
 }
 
 // After returns channel that fires with timeout
@@ -120,3 +128,20 @@ func (r *Linear) After() <-chan time.Time {
 func (r *Linear) String() string {
 	return fmt.Sprintf("Linear(attempt=%v, duration=%v)", r.attempt, r.Duration())
 }
+
+// Jitter is a function which takes a duration and returns a randomized
+// version of it, used to spread out retries from multiple clients that
+// would otherwise back off in lockstep.
+type Jitter func(time.Duration) time.Duration
+
+// NewHalfJitter returns a Jitter that returns a random duration in
+// [d/2, d), the "half jitter" approach recommended for retrying throttled
+// requests: https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+func NewHalfJitter() Jitter {
+	return func(d time.Duration) time.Duration {
+		if d <= 0 {
+			return 0
+		}
+		return d/2 + time.Duration(rand.Int63n(int64(d/2+1)))
+	}
+}
@@ -125,7 +125,7 @@ func GenerateSelfSignedCert(hostNames []string) (*TLSCredentials, error) {
 		NotAfter:              notAfter,
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
 		BasicConstraintsValid: true,
-		IsCA: true,
+		IsCA:                  true,
 	}
 
 	// collect IP addresses localhost resolves to and add them to the cert. template:
@@ -217,3 +217,19 @@ func DefaultCipherSuites() []uint16 {
 		tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
 	}
 }
+
+// FIPSCipherSuites returns the list of cipher suites Teleport is restricted
+// to when started in FIPS 140-2 mode. Only AES-GCM suites are FIPS 140-2
+// approved; Chacha20-Poly1305 is excluded.
+func FIPSCipherSuites() []uint16 {
+	return []uint16{
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+
+		tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	}
+}
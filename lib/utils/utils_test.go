@@ -56,6 +56,17 @@ func (s *UtilsSuite) TestLinear(c *check.C) {
 	c.Assert(r.Duration(), check.Equals, time.Duration(0))
 }
 
+// TestHalfJitter tests that the half-jitter helper stays within bounds
+func (s *UtilsSuite) TestHalfJitter(c *check.C) {
+	jitter := NewHalfJitter()
+	c.Assert(jitter(0), check.Equals, time.Duration(0))
+	for i := 0; i < 50; i++ {
+		d := jitter(10 * time.Second)
+		c.Assert(d >= 5*time.Second, check.Equals, true)
+		c.Assert(d < 10*time.Second, check.Equals, true)
+	}
+}
+
 func (s *UtilsSuite) TestHostUUID(c *check.C) {
 	// call twice, get same result
 	dir := c.MkDir()
@@ -17,6 +17,7 @@ limitations under the License.
 package utils
 
 import (
+	"bytes"
 	"io"
 )
 
@@ -47,3 +48,46 @@ func (w *BroadcastWriter) Write(p []byte) (n int, err error) {
 	}
 	return len(p), nil
 }
+
+// NewPrefixWriter returns a writer that prepends prefix to every line
+// written to it before forwarding it to w. It's used to tag the output of
+// commands fanned out to multiple hosts.
+func NewPrefixWriter(w io.Writer, prefix string) *PrefixWriter {
+	return &PrefixWriter{
+		w:      w,
+		prefix: []byte(prefix),
+	}
+}
+
+// PrefixWriter prepends a fixed prefix to every line it writes
+type PrefixWriter struct {
+	w       io.Writer
+	prefix  []byte
+	partial bool
+}
+
+// Write splits p into lines and writes each of them to the underlying
+// writer with the prefix prepended. The returned count is always len(p) on
+// success, so callers relying on io.Copy semantics aren't confused by the
+// extra prefix bytes written underneath.
+func (w *PrefixWriter) Write(p []byte) (int, error) {
+	total := len(p)
+	for len(p) > 0 {
+		if !w.partial {
+			if _, err := w.w.Write(w.prefix); err != nil {
+				return 0, err
+			}
+		}
+		idx := bytes.IndexByte(p, '\n')
+		line := p
+		if idx >= 0 {
+			line = p[:idx+1]
+		}
+		if _, err := w.w.Write(line); err != nil {
+			return 0, err
+		}
+		w.partial = idx < 0
+		p = p[len(line):]
+	}
+	return total, nil
+}
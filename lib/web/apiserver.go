@@ -42,6 +42,7 @@ import (
 	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/httplib"
 	"github.com/gravitational/teleport/lib/httplib/csrf"
+	"github.com/gravitational/teleport/lib/limiter"
 	"github.com/gravitational/teleport/lib/reversetunnel"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/session"
@@ -69,6 +70,12 @@ type Handler struct {
 	sites                   *ttlmap.TtlMap
 	sessionStreamPollPeriod time.Duration
 	clock                   clockwork.Clock
+	// userLimiter rate limits authenticated API requests per-user, on top
+	// of the per-IP limiting the proxy already applies to the handler as a
+	// whole (see lib/service's proxyLimiter.WrapHandle). This catches a
+	// single compromised or misbehaving account spread across many source
+	// IPs, which a per-IP limiter alone cannot.
+	userLimiter *limiter.Limiter
 }
 
 // HandlerOption is a functional argument - an option that can be passed
@@ -109,6 +116,12 @@ type Config struct {
 
 	// ProxySettings is a settings communicated to proxy
 	ProxySettings client.ProxySettings
+
+	// Limiter configures the per-user rate limit applied to authenticated
+	// API requests. If unset, NewHandler falls back to limiter's defaults
+	// (effectively unlimited), matching the behavior before this field
+	// existed.
+	Limiter limiter.LimiterConfig
 }
 
 type RewritingHandler struct {
@@ -132,9 +145,15 @@ func NewHandler(cfg Config, opts ...HandlerOption) (*RewritingHandler, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	userLimiter, err := limiter.NewLimiter(cfg.Limiter)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	h := &Handler{
-		cfg:  cfg,
-		auth: lauth,
+		cfg:         cfg,
+		auth:        lauth,
+		userLimiter: userLimiter,
 	}
 
 	for _, o := range opts {
@@ -158,6 +177,8 @@ func NewHandler(cfg Config, opts ...HandlerOption) (*RewritingHandler, error) {
 	h.POST("/webapi/sessions", httplib.WithCSRFProtection(h.createSession))
 	h.DELETE("/webapi/sessions", h.WithAuth(h.deleteSession))
 	h.POST("/webapi/sessions/renew", h.WithAuth(h.renewSession))
+	h.GET("/webapi/sessions/devices", h.WithAuth(h.getSessionDevices))
+	h.DELETE("/webapi/sessions/devices", h.WithAuth(h.logoutAllDevices))
 
 	// Users
 	h.GET("/webapi/users/invites/:token", httplib.MakeHandler(h.renderUserInvite))
@@ -178,15 +199,20 @@ func NewHandler(cfg Config, opts ...HandlerOption) (*RewritingHandler, error) {
 	// get nodes
 	h.GET("/webapi/sites/:site/namespaces/:namespace/nodes", h.WithClusterAuth(h.siteNodesGet))
 
+	// get nodes across the root cluster and all of its leaf clusters
+	h.GET("/webapi/namespaces/:namespace/nodes", h.WithAuth(h.allSiteNodesGet))
+
 	// active sessions handlers
-	h.GET("/webapi/sites/:site/namespaces/:namespace/connect", h.WithClusterAuth(h.siteNodeConnect))       // connect to an active session (via websocket)
-	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions", h.WithClusterAuth(h.siteSessionsGet))      // get active list of sessions
-	h.POST("/webapi/sites/:site/namespaces/:namespace/sessions", h.WithClusterAuth(h.siteSessionGenerate)) // create active session metadata
-	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions/:sid", h.WithClusterAuth(h.siteSessionGet))  // get active session metadata
+	h.GET("/webapi/sites/:site/namespaces/:namespace/connect", h.WithClusterAuth(h.siteNodeConnect))               // connect to an active session (via websocket)
+	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions", h.WithClusterAuth(h.siteSessionsGet))              // get active list of sessions
+	h.POST("/webapi/sites/:site/namespaces/:namespace/sessions", h.WithClusterAuth(h.siteSessionGenerate))         // create active session metadata
+	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions/:sid", h.WithClusterAuth(h.siteSessionGet))          // get active session metadata
+	h.DELETE("/webapi/sites/:site/namespaces/:namespace/sessions/:sid", h.WithClusterAuth(h.siteSessionTerminate)) // terminate an active session
 
 	// recorded sessions handlers
 	h.GET("/webapi/sites/:site/events", h.WithClusterAuth(h.clusterSearchSessionEvents))                               // get recorded list of sessions (from events)
 	h.GET("/webapi/sites/:site/events/search", h.WithClusterAuth(h.clusterSearchEvents))                               // search site events
+	h.GET("/webapi/sites/:site/events/summary", h.WithClusterAuth(h.clusterEventsSummary))                             // aggregate site events for the audit explorer
 	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions/:sid/events", h.WithClusterAuth(h.siteSessionEventsGet)) // get recorded session's timing information (from events)
 	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions/:sid/stream", h.siteSessionStreamGet)                    // get recorded session's bytes (from events)
 
@@ -343,7 +369,6 @@ func (h *Handler) getUserStatus(w http.ResponseWriter, r *http.Request, _ httpro
 // getUserContext returns user context
 //
 // GET /webapi/user/context
-//
 func (h *Handler) getUserContext(w http.ResponseWriter, r *http.Request, _ httprouter.Params, c *SessionContext) (interface{}, error) {
 	clt, err := c.GetClient()
 	if err != nil {
@@ -1022,10 +1047,9 @@ func NewSessionResponse(ctx *SessionContext) (*CreateSessionResponse, error) {
 //
 // {"user": "alex", "pass": "abc123", "second_factor_token": "token", "second_factor_type": "totp"}
 //
-// Response
+// # Response
 //
 // {"type": "bearer", "token": "bearer token", "user": {"name": "alex", "allowed_logins": ["admin", "bob"]}, "expires_in": 20}
-//
 func (h *Handler) createSession(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var req *createSessionReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
@@ -1073,7 +1097,6 @@ func (h *Handler) createSession(w http.ResponseWriter, r *http.Request, p httpro
 // Response:
 //
 // {"message": "ok"}
-//
 func (h *Handler) deleteSession(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx *SessionContext) (interface{}, error) {
 	err := h.logout(w, ctx)
 	if err != nil {
@@ -1100,11 +1123,9 @@ func (h *Handler) logout(w http.ResponseWriter, ctx *SessionContext) error {
 //
 // POST /v1/webapi/sessions/renew
 //
-// Response
+// # Response
 //
 // {"type": "bearer", "token": "bearer token", "user": {"name": "alex", "allowed_logins": ["admin", "bob"]}, "expires_in": 20}
-//
-//
 func (h *Handler) renewSession(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx *SessionContext) (interface{}, error) {
 	newSess, err := ctx.ExtendWebSession()
 	if err != nil {
@@ -1123,6 +1144,65 @@ func (h *Handler) renewSession(w http.ResponseWriter, r *http.Request, _ httprou
 	return NewSessionResponse(newContext)
 }
 
+// webSessionDevice describes one of the caller's active web sessions, as
+// shown in the account settings "devices" list.
+type webSessionDevice struct {
+	// ID is the session ID. The caller's own session is listed among the
+	// others so the UI can highlight "this device".
+	ID string `json:"id"`
+	// LoginTime is when the session was created.
+	LoginTime time.Time `json:"login_time"`
+	// ExpiryTime is the absolute time the session stops being renewable.
+	ExpiryTime time.Time `json:"expiry_time"`
+}
+
+// getSessionDevices lists the caller's active web sessions, one per
+// signed-in device.
+//
+// GET /webapi/sessions/devices
+func (h *Handler) getSessionDevices(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx *SessionContext) (interface{}, error) {
+	clt, err := ctx.GetClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sessions, err := clt.GetWebSessions(ctx.GetUser())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]webSessionDevice, len(sessions))
+	for i, sess := range sessions {
+		out[i] = webSessionDevice{
+			ID:         sess.GetName(),
+			LoginTime:  sess.GetLoginTime(),
+			ExpiryTime: sess.GetExpiryTime(),
+		}
+	}
+	return out, nil
+}
+
+// logoutAllDevices logs the caller out of every device at once by revoking
+// all of their web sessions and bearer tokens, including the one used to
+// make this request.
+//
+// DELETE /webapi/sessions/devices
+func (h *Handler) logoutAllDevices(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx *SessionContext) (interface{}, error) {
+	clt, err := ctx.GetClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	user := ctx.GetUser()
+	// Invalidate and clear the caller's own session first: it deletes this
+	// one session by ID, which would otherwise fail with not-found if run
+	// after DeleteAllWebSessions has already swept it up below.
+	if err := h.logout(w, ctx); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := clt.DeleteAllWebSessions(user); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return ok(), nil
+}
+
 type renderUserInviteResponse struct {
 	InviteToken string `json:"invite_token"`
 	User        string `json:"user"`
@@ -1136,8 +1216,6 @@ type renderUserInviteResponse struct {
 // Response:
 //
 // {"invite_token": "token", "user": "alex", qr: "base64-encoded-qr-code image"}
-//
-//
 func (h *Handler) renderUserInvite(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	token := p[0].Value
 	user, qrCodeBytes, err := h.auth.GetUserInviteInfo(token)
@@ -1159,7 +1237,6 @@ func (h *Handler) renderUserInvite(w http.ResponseWriter, r *http.Request, p htt
 // Response:
 //
 // {"version":"U2F_V2","challenge":"randombase64string","appId":"https://mycorp.com:3080"}
-//
 func (h *Handler) u2fRegisterRequest(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	token := p[0].Value
 	u2fRegisterRequest, err := h.auth.GetUserInviteU2FRegisterRequest(token)
@@ -1179,7 +1256,6 @@ func (h *Handler) u2fRegisterRequest(w http.ResponseWriter, r *http.Request, p h
 // Successful response:
 //
 // {"version":"U2F_V2","challenge":"randombase64string","keyHandle":"longbase64string","appId":"https://mycorp.com:3080"}
-//
 func (h *Handler) u2fSignRequest(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var req *client.U2fSignRequestReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
@@ -1208,7 +1284,6 @@ type u2fSignResponseReq struct {
 // Successful response:
 //
 // {"type": "bearer", "token": "bearer token", "user": {"name": "alex", "allowed_logins": ["admin", "bob"]}, "expires_in": 20}
-//
 func (h *Handler) createSessionWithU2FSignResponse(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var req *u2fSignResponseReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
@@ -1306,7 +1381,6 @@ func (h *Handler) createNewU2FUser(w http.ResponseWriter, r *http.Request, p htt
 // Successful response:
 //
 // {"sites": {"name": "localhost", "last_connected": "RFC3339 time", "status": "active"}}
-//
 func (h *Handler) getClusters(w http.ResponseWriter, r *http.Request, p httprouter.Params, c *SessionContext) (interface{}, error) {
 	resource, err := h.cfg.ProxyClient.GetClusterName()
 	if err != nil {
@@ -1323,7 +1397,8 @@ type getSiteNamespacesResponse struct {
 	Namespaces []services.Namespace `json:"namespaces"`
 }
 
-/* getSiteNamespaces returns a list of namespaces for a given site
+/*
+	getSiteNamespaces returns a list of namespaces for a given site
 
 GET /v1/webapi/namespaces/:namespace/sites/:site/nodes
 
@@ -1371,6 +1446,56 @@ func (h *Handler) siteNodesGet(w http.ResponseWriter, r *http.Request, p httprou
 	return makeResponse(uiServers)
 }
 
+// allSiteNodesGet returns nodes from the root cluster and all of its leaf
+// (trusted) clusters, each tagged with the cluster it belongs to.
+//
+// GET /v1/webapi/namespaces/:namespace/nodes
+//
+// Successful response:
+//
+// {"items": [{...ui.Server...}, ...]}
+func (h *Handler) allSiteNodesGet(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext) (interface{}, error) {
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+
+	sites := h.cfg.Proxy.GetSites()
+
+	type siteResult struct {
+		uiServers []ui.Server
+		err       error
+	}
+	resultsCh := make(chan siteResult, len(sites))
+	for _, site := range sites {
+		site := site
+		go func() {
+			clt, err := ctx.GetUserClient(site)
+			if err != nil {
+				resultsCh <- siteResult{err: err}
+				return
+			}
+			servers, err := clt.GetNodes(namespace, services.SkipValidation())
+			if err != nil {
+				resultsCh <- siteResult{err: err}
+				return
+			}
+			resultsCh <- siteResult{uiServers: ui.MakeServers(site.GetName(), servers)}
+		}()
+	}
+
+	var uiServers []ui.Server
+	for i := 0; i < len(sites); i++ {
+		res := <-resultsCh
+		if res.err != nil {
+			log.Warningf("Failed to list nodes for a cluster: %v.", res.err)
+			continue
+		}
+		uiServers = append(uiServers, res.uiServers...)
+	}
+	return makeResponse(uiServers)
+}
+
 // siteNodeConnect connect to the site node
 //
 // GET /v1/webapi/sites/:site/namespaces/:namespace/connect?access_token=bearer_token&params=<urlencoded json-structure>
@@ -1380,10 +1505,9 @@ func (h *Handler) siteNodesGet(w http.ResponseWriter, r *http.Request, p httprou
 //
 // {"server_id": "uuid", "login": "admin", "term": {"h": 120, "w": 100}, "sid": "123"}
 //
-// Session id can be empty
+// # Session id can be empty
 //
 // Successful response is a websocket stream that allows read write to the server
-//
 func (h *Handler) siteNodeConnect(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -1450,7 +1574,6 @@ type siteSessionGenerateResponse struct {
 // Response body:
 //
 // {"session": {"id": "session-id", "terminal_params": {"w": 100, "h": 100}, "login": "centos"}}
-//
 func (h *Handler) siteSessionGenerate(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	namespace := p.ByName("namespace")
 	if !services.IsValidNamespace(namespace) {
@@ -1510,7 +1633,6 @@ func (h *Handler) siteSessionsGet(w http.ResponseWriter, r *http.Request, p http
 // Response body:
 //
 // {"session": {"id": "sid", "terminal_params": {"w": 100, "h": 100}, "parties": [], "login": "bob"}}
-//
 func (h *Handler) siteSessionGet(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	sessionID, err := session.ParseID(p.ByName("sid"))
 	if err != nil {
@@ -1535,6 +1657,40 @@ func (h *Handler) siteSessionGet(w http.ResponseWriter, r *http.Request, p httpr
 	return *sess, nil
 }
 
+// siteSessionTerminate forces an active session to end, disconnecting all
+// of its parties. Authorization is enforced by the cluster auth server: the
+// caller's roles must grant update access to the ssh_session resource.
+//
+// DELETE /v1/webapi/sites/:site/namespaces/:namespace/sessions/:sid
+func (h *Handler) siteSessionTerminate(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
+	sessionID, err := session.ParseID(p.ByName("sid"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+
+	clt, err := ctx.GetUserClient(site)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	active := false
+	err = clt.UpdateSession(session.UpdateRequest{
+		Namespace: namespace,
+		ID:        *sessionID,
+		Active:    &active,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return ok(), nil
+}
+
 const maxStreamBytes = 5 * 1024 * 1024
 
 // clusterSearchSessionEvents allows to search for session events on a cluster
@@ -1542,12 +1698,12 @@ const maxStreamBytes = 5 * 1024 * 1024
 // GET /v1/webapi/sites/:site/events
 //
 // Query parameters:
-//   "from"  : date range from, encoded as RFC3339
-//   "to"    : date range to, encoded as RFC3339
-//   ...     : the rest of the query string is passed to the search back-end as-is,
-//             the default backend performs exact search: ?key=value means "event
-//             with a field 'key' with value 'value'
 //
+//	"from"  : date range from, encoded as RFC3339
+//	"to"    : date range to, encoded as RFC3339
+//	...     : the rest of the query string is passed to the search back-end as-is,
+//	          the default backend performs exact search: ?key=value means "event
+//	          with a field 'key' with value 'value'
 func (h *Handler) clusterSearchSessionEvents(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	query := r.URL.Query()
 
@@ -1589,12 +1745,12 @@ func (h *Handler) clusterSearchSessionEvents(w http.ResponseWriter, r *http.Requ
 // GET /v1/webapi/sites/:site/events/search
 //
 // Query parameters:
-//   "from"   : date range from, encoded as RFC3339
-//   "to"     : date range to, encoded as RFC3339
-//   "include": optional semicolon-separated list of event names to return e.g.
-//              include=session.start;session.end, all are returned if empty
-//   "limit"  : optional maximum number of events to return
 //
+//	"from"   : date range from, encoded as RFC3339
+//	"to"     : date range to, encoded as RFC3339
+//	"include": optional semicolon-separated list of event names to return e.g.
+//	           include=session.start;session.end, all are returned if empty
+//	"limit"  : optional maximum number of events to return
 func (h *Handler) clusterSearchEvents(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	values := r.URL.Query()
 	from, err := queryTime(values, "from", time.Now().UTC().AddDate(0, -1, 0))
@@ -1665,8 +1821,9 @@ type siteSessionStreamGetResponse struct {
 // GET /v1/webapi/sites/:site/namespaces/:namespace/sessions/:sid/stream?query
 //
 // Query parameters:
-//   "offset"   : bytes from the beginning
-//   "bytes"    : number of bytes to read (it won't return more than 512Kb)
+//
+//	"offset"   : bytes from the beginning
+//	"bytes"    : number of bytes to read (it won't return more than 512Kb)
 //
 // Unlike other request handlers, this one does not return JSON.
 // It returns the binary stream unencoded, directly in the respose body,
@@ -1774,13 +1931,13 @@ type eventsListGetResponse struct {
 // GET /v1/webapi/sites/:site/namespaces/:namespace/sessions/:sid/events?after=N
 //
 // Query:
-//    "after" : cursor value of an event to return "newer than" events
-//              good for repeated polling
+//
+//	"after" : cursor value of an event to return "newer than" events
+//	          good for repeated polling
 //
 // Response body (each event is an arbitrary JSON structure)
 //
 // {"events": [{...}, {...}, ...}
-//
 func (h *Handler) siteSessionEventsGet(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	logger := log.WithFields(log.Fields{
 		trace.Component: teleport.ComponentWeb,
@@ -1839,10 +1996,9 @@ func (h *Handler) hostCredentials(w http.ResponseWriter, r *http.Request, p http
 //
 // { "user": "bob", "password": "pass", "otp_token": "tok", "pub_key": "key to sign", "ttl": 1000000000 }
 //
-// Success response
+// # Success response
 //
 // { "cert": "base64 encoded signed cert", "host_signers": [{"domain_name": "example.com", "checking_keys": ["base64 encoded public signing key"]}] }
-//
 func (h *Handler) createSSHCert(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var req *client.CreateSSHCertReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
@@ -1883,10 +2039,9 @@ func (h *Handler) createSSHCert(w http.ResponseWriter, r *http.Request, p httpro
 //
 // { "user": "bob", "password": "pass", "u2f_sign_response": { "signatureData": "signatureinbase64", "clientData": "verylongbase64string", "challenge": "randombase64string" }, "pub_key": "key to sign", "ttl": 1000000000 }
 //
-// Success response
+// # Success response
 //
 // { "cert": "base64 encoded signed cert", "host_signers": [{"domain_name": "example.com", "checking_keys": ["base64 encoded public signing key"]}] }
-//
 func (h *Handler) createSSHCertWithU2FSignResponse(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var req *client.CreateSSHCertWithU2FReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
@@ -1906,16 +2061,16 @@ func (h *Handler) createSSHCertWithU2FSignResponse(w http.ResponseWriter, r *htt
 //
 // * Request body:
 //
-// {
-//     "token": "foo",
-//     "certificate_authorities": ["AQ==", "Ag=="]
-// }
+//	{
+//	    "token": "foo",
+//	    "certificate_authorities": ["AQ==", "Ag=="]
+//	}
 //
 // * Response:
 //
-// {
-//     "certificate_authorities": ["AQ==", "Ag=="]
-// }
+//	{
+//	    "certificate_authorities": ["AQ==", "Ag=="]
+//	}
 func (h *Handler) validateTrustedCluster(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var validateRequestRaw auth.ValidateTrustedClusterRequestRaw
 	if err := httplib.ReadJSON(r, &validateRequestRaw); err != nil {
@@ -2026,6 +2181,10 @@ func (h *Handler) AuthenticateRequest(w http.ResponseWriter, r *http.Request, ch
 		ClearSession(w)
 		return nil, trace.AccessDenied("need auth")
 	}
+	if err := h.userLimiter.RegisterRequest(d.User); err != nil {
+		logger.Warningf("rate limit exceeded for user %v", d.User)
+		return nil, trace.LimitExceeded("rate limit exceeded")
+	}
 	if checkBearerToken {
 		creds, err := roundtrip.ParseAuthHeaders(r)
 		if err != nil {
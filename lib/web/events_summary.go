@@ -0,0 +1,216 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package web
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/reversetunnel"
+
+	"github.com/gravitational/trace"
+	"github.com/julienschmidt/httprouter"
+)
+
+// eventCount is the number of events seen for a single group-by key (an
+// event type, a user, or a day), used by clusterEventsSummary.
+type eventCount struct {
+	// Key is the group-by value: an event type, username, or "YYYY-MM-DD".
+	Key string `json:"key"`
+	// Count is the number of events seen for Key.
+	Count int `json:"count"`
+}
+
+// topUser is a user's total session time within the requested window, used
+// by clusterEventsSummary to answer "who spent the most time in
+// sessions". SessionID is included so the web UI can link directly to that
+// session's playback.
+type topUser struct {
+	// User is the Teleport user.
+	User string `json:"user"`
+	// DurationSeconds is the user's total session duration, in seconds.
+	DurationSeconds float64 `json:"duration_seconds"`
+	// SessionID is the longest session contributing to this total, for the
+	// web UI to use as a playback drill-down link.
+	SessionID string `json:"sid"`
+}
+
+type clusterEventsSummaryResponse struct {
+	// Counts are event counts grouped by the requested "group_by" dimension,
+	// sorted by Count descending.
+	Counts []eventCount `json:"counts"`
+	// TopUsers are the users with the most total session time in the
+	// requested window, sorted by DurationSeconds descending. Omitted
+	// unless the "top_users" query parameter is set.
+	TopUsers []topUser `json:"top_users,omitempty"`
+}
+
+// clusterEventsSummary computes audit log aggregates server-side so the
+// browser never has to download and tally raw events itself.
+//
+// GET /v1/webapi/sites/:site/events/summary
+//
+// Query parameters:
+//   "from"      : date range from, encoded as RFC3339
+//   "to"        : date range to, encoded as RFC3339
+//   "group_by"  : one of "type" (default), "user", or "day"
+//   "limit"     : maximum number of events scanned to build the summary
+//   "top_users" : if set to a positive integer N, also return the top N
+//                 users by total session time in the window
+//
+func (h *Handler) clusterEventsSummary(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
+	values := r.URL.Query()
+	from, err := queryTime(values, "from", time.Now().UTC().AddDate(0, -1, 0))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	to, err := queryTime(values, "to", time.Now().UTC())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	limit, err := queryLimit(values, "limit", defaults.EventsIterationLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	topUsersN, err := queryLimit(values, "top_users", 0)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	groupBy := values.Get("group_by")
+	if groupBy == "" {
+		groupBy = "type"
+	}
+	if groupBy != "type" && groupBy != "user" && groupBy != "day" {
+		return nil, trace.BadParameter("group_by must be one of type, user, day")
+	}
+
+	clt, err := ctx.GetUserClient(site)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	fields, err := clt.SearchEvents(from, to, "", limit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	resp := clusterEventsSummaryResponse{
+		Counts: countEventsBy(fields, groupBy),
+	}
+	if topUsersN > 0 {
+		resp.TopUsers = topUsersBySessionTime(fields, topUsersN)
+	}
+	return resp, nil
+}
+
+// countEventsBy tallies fields by the requested group-by dimension and
+// returns the counts sorted by count descending.
+func countEventsBy(fields []events.EventFields, groupBy string) []eventCount {
+	counts := make(map[string]int)
+	for _, f := range fields {
+		var key string
+		switch groupBy {
+		case "user":
+			key = f.GetString(events.EventUser)
+		case "day":
+			key = f.GetTimestamp().Format("2006-01-02")
+		default:
+			key = f.GetType()
+		}
+		if key == "" {
+			continue
+		}
+		counts[key]++
+	}
+
+	result := make([]eventCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, eventCount{Key: key, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// topUsersBySessionTime pairs up session.start/session.end events by
+// session ID to compute each session's duration, sums those durations per
+// user, and returns the top n users by total duration.
+func topUsersBySessionTime(fields []events.EventFields, n int) []topUser {
+	type session struct {
+		user  string
+		start time.Time
+	}
+	sessions := make(map[string]session)
+	durations := make(map[string]float64)
+	longestSession := make(map[string]string)
+	longestDuration := make(map[string]float64)
+
+	for _, f := range fields {
+		sid := f.GetString(events.SessionEventID)
+		if sid == "" {
+			continue
+		}
+		switch f.GetType() {
+		case events.SessionStartEvent:
+			sessions[sid] = session{
+				user:  f.GetString(events.EventUser),
+				start: f.GetTimestamp(),
+			}
+		case events.SessionEndEvent:
+			started, ok := sessions[sid]
+			if !ok {
+				continue
+			}
+			user := started.user
+			if user == "" {
+				user = f.GetString(events.EventUser)
+			}
+			duration := f.GetTimestamp().Sub(started.start).Seconds()
+			if duration < 0 {
+				continue
+			}
+			durations[user] += duration
+			if duration > longestDuration[user] {
+				longestDuration[user] = duration
+				longestSession[user] = sid
+			}
+			delete(sessions, sid)
+		}
+	}
+
+	result := make([]topUser, 0, len(durations))
+	for user, duration := range durations {
+		result = append(result, topUser{
+			User:            user,
+			DurationSeconds: duration,
+			SessionID:       longestSession[user],
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DurationSeconds > result[j].DurationSeconds
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
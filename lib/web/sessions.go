@@ -31,6 +31,7 @@ import (
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/client"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/reversetunnel"
@@ -368,9 +369,92 @@ func newSessionCache(proxyClient auth.ClientI, servers []utils.NetAddr, cipherSu
 	}
 	// periodically close expired and unused sessions
 	go cache.expireSessions()
+	// invalidate cached sessions as soon as a role changes, rather than
+	// waiting for a web session's TTL to expire, so a revoked permission
+	// takes effect immediately instead of on the next auth round-trip
+	go cache.watchRoleChanges()
 	return cache, nil
 }
 
+// watchRoleChanges invalidates every cached session context whenever a role
+// is created, updated, or deleted, since a SessionContext's client carries
+// its user's roles baked into its certificate and has no cheap way to tell
+// whether a particular role change affects it. This is coarser than
+// invalidating only the affected users, but it's correct, and roles change
+// far less often than the web API requests this cache exists to avoid
+// re-authenticating.
+//
+// Locks are not watched here: this version of Teleport has no Lock
+// resource kind to watch for.
+//
+// A transient disconnect (the watcher's Done channel firing, or NewWatcher
+// itself failing) reconnects with a jittered backoff, the same pattern
+// cache.Cache.update uses for its own watch loop, rather than giving up for
+// good and leaving sessions invalidated by TTL alone until the next proxy
+// restart.
+func (s *sessionCache) watchRoleChanges() {
+	retry, err := utils.NewLinear(utils.LinearConfig{
+		Step:   defaults.HighResPollingPeriod / 10,
+		Max:    defaults.HighResPollingPeriod,
+		Jitter: utils.NewHalfJitter(),
+	})
+	if err != nil {
+		log.Warningf("[WEB] failed to create role change watcher retry, sessions will only be invalidated by TTL: %v.", err)
+		return
+	}
+	for {
+		if err := s.fetchAndWatchRoleChanges(); err != nil {
+			log.Warningf("[WEB] role change watcher failed, retrying: %v.", err)
+		}
+		select {
+		case <-s.closer.C:
+			return
+		case <-retry.After():
+			retry.Inc()
+		}
+	}
+}
+
+// fetchAndWatchRoleChanges runs a single watch attempt, returning once the
+// watcher is closed or the session cache itself is closing.
+func (s *sessionCache) fetchAndWatchRoleChanges() error {
+	watcher, err := s.proxyClient.NewWatcher(context.TODO(), services.Watch{
+		Kinds: []services.WatchKind{{Kind: services.KindRole}},
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer watcher.Close()
+	for {
+		select {
+		case <-s.closer.C:
+			return nil
+		case <-watcher.Done():
+			return trace.Wrap(watcher.Error())
+		case event := <-watcher.Events():
+			if event.Type == backend.OpInit {
+				continue
+			}
+			log.Debugf("[WEB] invalidating cached sessions, role %v changed.", event.Resource.GetName())
+			s.invalidateAllSessions()
+		}
+	}
+}
+
+// invalidateAllSessions closes and removes every cached session context so
+// the next request for each one re-authenticates and picks up fresh roles.
+func (s *sessionCache) invalidateAllSessions() {
+	s.Lock()
+	defer s.Unlock()
+	for {
+		key, val, ok := s.contexts.Pop()
+		if !ok {
+			return
+		}
+		closeContext(key, val)
+	}
+}
+
 // sessionCache handles web session authentication,
 // and holds in memory contexts associated with each session
 type sessionCache struct {
@@ -71,6 +71,10 @@ type TerminalRequest struct {
 
 	// InteractiveCommand is a command to execut.e
 	InteractiveCommand []string `json:"-"`
+
+	// Observer, if true, joins the session as a read-only observer: the
+	// terminal receives session output but its input is never forwarded.
+	Observer bool `json:"observer"`
 }
 
 // AuthProvider is a subset of the full Auth API.
@@ -260,6 +264,9 @@ func (t *TerminalHandler) makeClient(ws *websocket.Conn) (*client.TeleportClient
 	clientConfig.Host = t.hostName
 	clientConfig.HostPort = t.hostPort
 	clientConfig.Env = map[string]string{sshutils.SessionEnvVar: string(t.params.SessionID)}
+	if t.params.Observer {
+		clientConfig.Env[sshutils.SessionJoinModeEnvVar] = sshutils.SessionJoinModeObserver
+	}
 	clientConfig.ClientAddr = ws.Request().RemoteAddr
 
 	if len(t.params.InteractiveCommand) > 0 {
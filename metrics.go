@@ -37,8 +37,15 @@ const (
 	// MetricRemoteClusters measures connected remote clusters
 	MetricRemoteClusters = "remote_clusters"
 
+	// MetricTrustedClusters measures reverse tunnels per cluster, broken
+	// down by connection state
+	MetricTrustedClusters = "trusted_clusters"
+
 	// TagCluster is a metric tag for a cluster
 	TagCluster = "cluster"
+
+	// TagState is a metric tag for a reverse tunnel's connection state
+	TagState = "state"
 )
 
 const (
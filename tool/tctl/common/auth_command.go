@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/gravitational/kingpin"
 	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
 )
 
 // AuthCommand implements `tctl auth` group of commands
@@ -38,15 +40,21 @@ type AuthCommand struct {
 	compatVersion              string
 	compatibility              string
 
-	rotateGracePeriod time.Duration
-	rotateType        string
-	rotateManualMode  bool
-	rotateTargetPhase string
+	rotateGracePeriod     time.Duration
+	rotateType            string
+	rotateManualMode      bool
+	rotateTargetPhase     string
+	rotateRequestApproval bool
+
+	explainUser  string
+	explainLogin string
+	explainNode  string
 
 	authGenerate *kingpin.CmdClause
 	authExport   *kingpin.CmdClause
 	authSign     *kingpin.CmdClause
 	authRotate   *kingpin.CmdClause
+	authExplain  *kingpin.CmdClause
 }
 
 // Initialize allows TokenCommand to plug itself into the CLI parser
@@ -59,7 +67,7 @@ func (a *AuthCommand) Initialize(app *kingpin.Application, config *service.Confi
 	a.authExport.Flag("keys", "if set, will print private keys").BoolVar(&a.exportPrivateKeys)
 	a.authExport.Flag("fingerprint", "filter authority by fingerprint").StringVar(&a.exportAuthorityFingerprint)
 	a.authExport.Flag("compat", "export cerfiticates compatible with specific version of Teleport").StringVar(&a.compatVersion)
-	a.authExport.Flag("type", "certificate type: 'user', 'host' or 'tls'").StringVar(&a.authType)
+	a.authExport.Flag("type", "certificate type: 'user', 'host', 'tls', 'known_hosts' or 'trusted_user_ca_keys'").StringVar(&a.authType)
 
 	a.authGenerate = auth.Command("gen", "Generate a new SSH keypair").Hidden()
 	a.authGenerate.Flag("pub-key", "path to the public key").Required().StringVar(&a.genPubPath)
@@ -78,6 +86,12 @@ func (a *AuthCommand) Initialize(app *kingpin.Application, config *service.Confi
 	a.authRotate.Flag("manual", "Activate manual rotation , set rotation phases manually").BoolVar(&a.rotateManualMode)
 	a.authRotate.Flag("type", "Certificate authority to rotate, rotates both host and user CA by default").StringVar(&a.rotateType)
 	a.authRotate.Flag("phase", fmt.Sprintf("Target rotation phase to set, used in manual rotation, one of: %v", strings.Join(services.RotatePhases, ", "))).StringVar(&a.rotateTargetPhase)
+	a.authRotate.Flag("request-approval", "Create a pending operation requiring a second administrator's approval (two-person rule) instead of rotating immediately.").BoolVar(&a.rotateRequestApproval)
+
+	a.authExplain = auth.Command("explain", "Explain whether a user can access a node and why")
+	a.authExplain.Flag("user", "Teleport user name").Required().StringVar(&a.explainUser)
+	a.authExplain.Flag("login", "OS login to check access for").Required().StringVar(&a.explainLogin)
+	a.authExplain.Flag("node", "Hostname or UUID of the target node").Required().StringVar(&a.explainNode)
 }
 
 // TryRun takes the CLI command as an argument (like "auth gen") and executes it
@@ -92,6 +106,8 @@ func (a *AuthCommand) TryRun(cmd string, client auth.ClientI) (match bool, err e
 		err = a.GenerateAndSignKeys(client)
 	case a.authRotate.FullCommand():
 		err = a.RotateCertAuthority(client)
+	case a.authExplain.FullCommand():
+		err = a.ExplainAccess(client)
 	default:
 		return false, nil
 	}
@@ -127,10 +143,21 @@ func (a *AuthCommand) ExportAuthorities(client auth.ClientI) error {
 		return nil
 	}
 
-	// if no --type flag is given, export all types
-	if a.authType == "" {
+	// "known_hosts" and "trusted_user_ca_keys" aren't real CertAuthTypes:
+	// they're export modes that bundle the host (or user) CA of every
+	// cluster the auth server knows about, local or trusted, into a
+	// single file meant for a plain (non-Teleport) OpenSSH fleet.
+	exportAllClusters := a.authType == "known_hosts" || a.authType == "trusted_user_ca_keys"
+
+	switch a.authType {
+	case "":
+		// if no --type flag is given, export all types
 		typesToExport = []services.CertAuthType{services.HostCA, services.UserCA}
-	} else {
+	case "known_hosts":
+		typesToExport = []services.CertAuthType{services.HostCA}
+	case "trusted_user_ca_keys":
+		typesToExport = []services.CertAuthType{services.UserCA}
+	default:
 		authType := services.CertAuthType(a.authType)
 		if err := authType.Check(); err != nil {
 			return trace.Wrap(err)
@@ -142,8 +169,10 @@ func (a *AuthCommand) ExportAuthorities(client auth.ClientI) error {
 		return trace.Wrap(err)
 	}
 
-	// fetch authorities via auth API (and only take local CAs, ignoring
-	// trusted ones)
+	// fetch authorities via auth API. Normally only local CAs are
+	// exported, ignoring trusted ones, but the known_hosts/
+	// trusted_user_ca_keys bundles are meant to cover every cluster a
+	// plain sshd fleet might see, so they keep trusted CAs too.
 	var authorities []services.CertAuthority
 	for _, at := range typesToExport {
 		cas, err := client.GetCertAuthorities(at, a.exportPrivateKeys)
@@ -151,7 +180,7 @@ func (a *AuthCommand) ExportAuthorities(client auth.ClientI) error {
 			return trace.Wrap(err)
 		}
 		for _, ca := range cas {
-			if ca.GetClusterName() == localAuthName {
+			if exportAllClusters || ca.GetClusterName() == localAuthName {
 				authorities = append(authorities, ca)
 			}
 		}
@@ -195,10 +224,12 @@ func (a *AuthCommand) ExportAuthorities(client auth.ClientI) error {
 
 				// export certificate authority in user or host ca format
 				var castr string
-				switch ca.GetType() {
-				case services.UserCA:
+				switch {
+				case a.authType == "trusted_user_ca_keys":
+					castr, err = trustedUserCAKeysFormat(ca, keyBytes)
+				case ca.GetType() == services.UserCA:
 					castr, err = userCAFormat(ca, keyBytes)
-				case services.HostCA:
+				case ca.GetType() == services.HostCA:
 					castr, err = hostCAFormat(ca, keyBytes, client)
 				default:
 					return trace.BadParameter("unknown user type: %q", ca.GetType())
@@ -264,6 +295,24 @@ func (a *AuthCommand) RotateCertAuthority(client auth.ClientI) error {
 	} else {
 		req.Mode = services.RotationModeAuto
 	}
+
+	if a.rotateRequestApproval {
+		params, err := json.Marshal(req)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		op, err := services.NewPendingOperation(uuid.New(), services.PendingOperationRotateCertAuthority,
+			string(req.Type), string(params), currentOSUsername())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := client.CreatePendingOperation(op); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Printf("pending operation %q created: certificate authority rotation awaits approval from a second administrator\n", op.GetName())
+		return nil
+	}
+
 	if err := client.RotateCertAuthority(req); err != nil {
 		return err
 	}
@@ -276,6 +325,58 @@ func (a *AuthCommand) RotateCertAuthority(client auth.ClientI) error {
 	return nil
 }
 
+// ExplainAccess evaluates the given user's role set against the given node
+// and login and prints a structured breakdown of which role's allow or deny
+// rules decided the outcome, so operators can debug RBAC without
+// trial-and-error logins.
+func (a *AuthCommand) ExplainAccess(clusterApi auth.ClientI) error {
+	user, err := clusterApi.GetUser(a.explainUser)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roles, err := services.FetchRoles(user.GetRoles(), clusterApi, user.GetTraits())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	nodes, err := clusterApi.GetNodes(defaults.Namespace)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var node services.Server
+	for _, n := range nodes {
+		if n.GetName() == a.explainNode || n.GetHostname() == a.explainNode {
+			node = n
+			break
+		}
+	}
+	if node == nil {
+		return trace.NotFound("node %q not found", a.explainNode)
+	}
+
+	explanation, err := roles.ExplainAccessToServer(a.explainLogin, node)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if explanation.Allowed {
+		fmt.Printf("ALLOWED: %v can access %v as %v\n\n", a.explainUser, node.GetHostname(), a.explainLogin)
+	} else {
+		fmt.Printf("DENIED: %v cannot access %v as %v\n\n", a.explainUser, node.GetHostname(), a.explainLogin)
+	}
+
+	fmt.Println("deny rules checked:")
+	for _, m := range explanation.Deny {
+		fmt.Printf("  role=%v namespace(%v) labels(%v) login(%v)\n", m.RoleName, m.NamespaceMessage, m.LabelsMessage, m.LoginMessage)
+	}
+	fmt.Println("\nallow rules checked:")
+	for _, m := range explanation.Allow {
+		fmt.Printf("  role=%v namespace(%v) labels(%v) login(%v)\n", m.RoleName, m.NamespaceMessage, m.LabelsMessage, m.LoginMessage)
+	}
+
+	return nil
+}
+
 func (a *AuthCommand) generateHostKeys(clusterApi auth.ClientI) error {
 	// only format=openssh is supported
 	if a.outputFormat != client.IdentityFormatOpenSSH {
@@ -364,19 +465,28 @@ func (a *AuthCommand) generateUserKeys(clusterApi auth.ClientI) error {
 // base64-encoded key, comment.
 // For example:
 //
-//    cert-authority AAA... type=user&clustername=cluster-a
+//	cert-authority AAA... type=user&clustername=cluster-a
 //
 // URL encoding is used to pass the CA type and cluster name into the comment field.
 func userCAFormat(ca services.CertAuthority, keyBytes []byte) (string, error) {
 	return sshutils.MarshalAuthorizedKeysFormat(ca.GetClusterName(), keyBytes)
 }
 
+// trustedUserCAKeysFormat returns the certificate authority public key exported as a single
+// line suitable for a file referenced by sshd_config's TrustedUserCAKeys directive, which lets
+// a plain (non-Teleport) sshd trust Teleport-issued user certificates directly. Unlike
+// userCAFormat, the key is not prefixed with a "cert-authority" marker, since
+// TrustedUserCAKeys takes bare public keys.
+func trustedUserCAKeysFormat(ca services.CertAuthority, keyBytes []byte) (string, error) {
+	return sshutils.MarshalTrustedUserCAKeysFormat(ca.GetClusterName(), keyBytes)
+}
+
 // hostCAFormat returns the certificate authority public key exported as a single line
 // that can be placed in ~/.ssh/authorized_hosts. The format adheres to the man sshd (8)
 // authorized_hosts format, a space-separated list of: marker, hosts, key, and comment.
 // For example:
 //
-//    @cert-authority *.cluster-a ssh-rsa AAA... type=host
+//	@cert-authority *.cluster-a ssh-rsa AAA... type=host
 //
 // URL encoding is used to pass the CA type and allowed logins into the comment field.
 func hostCAFormat(ca services.CertAuthority, keyBytes []byte, client auth.ClientI) (string, error) {
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/service"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/trace"
+)
+
+// BackendCommand implements the `tctl backend` family of commands, which
+// read items out of, and load items into, the storage backend configured
+// in the local teleport.yaml directly, bypassing the auth API. Running
+// `backend export` against a server using one backend driver, followed by
+// `backend import` against a server configured with a different one (dir,
+// etcd, DynamoDB, ...), migrates a cluster's state between backends
+// without hand-written scripts.
+//
+// Both subcommands operate on the node they run on, which must be an
+// auth server, same as every other tctl command.
+type BackendCommand struct {
+	config *service.Config
+
+	backendExport *kingpin.CmdClause
+	backendImport *kingpin.CmdClause
+
+	// archivePath is the file the archive is written to or read from,
+	// "-" meaning stdout/stdin
+	archivePath string
+}
+
+// Initialize allows BackendCommand to plug itself into the CLI parser
+func (c *BackendCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	bk := app.Command("backend", "Export or import the cluster's storage backend")
+
+	c.backendExport = bk.Command("export", "Write every item in the storage backend to a portable archive")
+	c.backendExport.Flag("to", "File to write the archive to").Default("-").StringVar(&c.archivePath)
+
+	c.backendImport = bk.Command("import", "Load items from an archive produced by 'backend export'")
+	c.backendImport.Flag("from", "File to read the archive from").Default("-").StringVar(&c.archivePath)
+}
+
+// TryRun takes the CLI command as an argument (like "backend export") and executes it.
+func (c *BackendCommand) TryRun(cmd string, client auth.ClientI) (match bool, err error) {
+	switch cmd {
+	case c.backendExport.FullCommand():
+		err = c.Export()
+	case c.backendImport.FullCommand():
+		err = c.Import()
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Export writes every item in the configured storage backend to c.archivePath.
+func (c *BackendCommand) Export() error {
+	bk, err := service.NewBackend(c.config.Auth.StorageConfig)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer bk.Close()
+
+	out := os.Stdout
+	if c.archivePath != "-" {
+		f, err := os.Create(c.archivePath)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	total, err := backend.Export(context.Background(), bk, bufio.NewWriter(out))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %v items.\n", total)
+	return nil
+}
+
+// Import reads an archive produced by Export from c.archivePath and
+// writes every item in it into the configured storage backend.
+func (c *BackendCommand) Import() error {
+	bk, err := service.NewBackend(c.config.Auth.StorageConfig)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer bk.Close()
+
+	in := os.Stdin
+	if c.archivePath != "-" {
+		f, err := os.Open(c.archivePath)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	total, err := backend.Import(context.Background(), bk, bufio.NewReader(in))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Fprintf(os.Stderr, "Imported %v items.\n", total)
+	return nil
+}
@@ -513,10 +513,15 @@ type remoteClusterCollection struct {
 }
 
 func (c *remoteClusterCollection) writeText(w io.Writer) error {
-	t := asciitable.MakeTable([]string{"Name", "Status", "Last Heartbeat"})
+	t := asciitable.MakeTable([]string{"Name", "Enabled", "Status", "Last Heartbeat"})
 	for _, cluster := range c.remoteClusters {
 		lastHeartbeat := cluster.GetLastHeartbeat()
-		t.AddRow([]string{cluster.GetName(), cluster.GetConnectionStatus(), formatLastHeartbeat(lastHeartbeat)})
+		t.AddRow([]string{
+			cluster.GetName(),
+			strconv.FormatBool(cluster.GetEnabled()),
+			cluster.GetConnectionStatus(),
+			formatLastHeartbeat(lastHeartbeat),
+		})
 	}
 	_, err := t.AsBuffer().WriteTo(w)
 	return trace.Wrap(err)
@@ -548,3 +553,65 @@ func (c *remoteClusterCollection) toMarshal() interface{} {
 func (c *remoteClusterCollection) writeYAML(w io.Writer) error {
 	return utils.WriteYAML(w, c.toMarshal())
 }
+
+type tunnelConnectionCollection struct {
+	tunnelConnections []services.TunnelConnection
+}
+
+func (c *tunnelConnectionCollection) writeText(w io.Writer) error {
+	t := asciitable.MakeTable([]string{"Name", "Cluster Name", "Proxy Name", "Last Heartbeat"})
+	for _, conn := range c.tunnelConnections {
+		t.AddRow([]string{
+			conn.GetName(),
+			conn.GetClusterName(),
+			conn.GetProxyName(),
+			formatLastHeartbeat(conn.GetLastHeartbeat()),
+		})
+	}
+	_, err := t.AsBuffer().WriteTo(w)
+	return trace.Wrap(err)
+}
+
+func (c *tunnelConnectionCollection) writeJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(c.toMarshal(), "", "    ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = w.Write(data)
+	return trace.Wrap(err)
+}
+
+func (c *tunnelConnectionCollection) toMarshal() interface{} {
+	if len(c.tunnelConnections) == 1 {
+		return c.tunnelConnections[0]
+	}
+	return c.tunnelConnections
+}
+
+func (c *tunnelConnectionCollection) writeYAML(w io.Writer) error {
+	return utils.WriteYAML(w, c.toMarshal())
+}
+
+type clusterConfigCollection struct {
+	clusterConfig services.ClusterConfig
+}
+
+func (c *clusterConfigCollection) writeText(w io.Writer) error {
+	t := asciitable.MakeTable([]string{"Session Recording", "Cluster ID"})
+	t.AddRow([]string{c.clusterConfig.GetSessionRecording(), c.clusterConfig.GetClusterID()})
+	_, err := t.AsBuffer().WriteTo(w)
+	return trace.Wrap(err)
+}
+
+func (c *clusterConfigCollection) writeJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(c.clusterConfig, "", "    ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = w.Write(data)
+	return trace.Wrap(err)
+}
+
+func (c *clusterConfigCollection) writeYAML(w io.Writer) error {
+	return utils.WriteYAML(w, c.clusterConfig)
+}
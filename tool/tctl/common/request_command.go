@@ -0,0 +1,111 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/gravitational/teleport/lib/asciitable"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/service"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/trace"
+)
+
+// RequestCommand implements the `tctl request` family of commands, used to
+// review pending operations created by `tctl rm --request-approval` or
+// `tctl auth rotate --request-approval` under the two-person rule.
+type RequestCommand struct {
+	config *service.Config
+
+	name string
+
+	// CLI clauses (subcommands)
+	requestList    *kingpin.CmdClause
+	requestApprove *kingpin.CmdClause
+	requestDeny    *kingpin.CmdClause
+}
+
+// Initialize allows RequestCommand to plug itself into the CLI parser.
+func (c *RequestCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	request := app.Command("request", "Review pending operations awaiting a second administrator's approval")
+
+	c.requestList = request.Command("ls", "List pending operations")
+
+	c.requestApprove = request.Command("approve", "Approve a pending operation and apply it")
+	c.requestApprove.Arg("name", "Name of the pending operation").Required().StringVar(&c.name)
+
+	c.requestDeny = request.Command("deny", "Deny a pending operation; it will never be applied")
+	c.requestDeny.Arg("name", "Name of the pending operation").Required().StringVar(&c.name)
+}
+
+// TryRun takes the CLI command as an argument and executes it.
+func (c *RequestCommand) TryRun(cmd string, client auth.ClientI) (match bool, err error) {
+	switch cmd {
+	case c.requestList.FullCommand():
+		err = c.List(client)
+	case c.requestApprove.FullCommand():
+		err = c.Approve(client)
+	case c.requestDeny.FullCommand():
+		err = c.Deny(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// List is called to execute "tctl request ls".
+func (c *RequestCommand) List(client auth.ClientI) error {
+	ops, err := client.GetPendingOperations()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(ops) == 0 {
+		fmt.Println("No pending operations found.")
+		return nil
+	}
+
+	table := asciitable.MakeTable([]string{"Name", "Action", "Target", "Requested By", "Status", "Approved By"})
+	for _, op := range ops {
+		table.AddRow([]string{
+			op.GetName(), op.GetAction(), op.GetTarget(), op.GetRequestedBy(), op.GetStatus(), op.GetApprovedBy(),
+		})
+	}
+	fmt.Print(table.AsBuffer().String())
+	return nil
+}
+
+// Approve is called to execute "tctl request approve".
+func (c *RequestCommand) Approve(client auth.ClientI) error {
+	if err := client.ApprovePendingOperation(c.name); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("pending operation %q has been approved and applied\n", c.name)
+	return nil
+}
+
+// Deny is called to execute "tctl request deny".
+func (c *RequestCommand) Deny(client auth.ClientI) error {
+	if err := client.DenyPendingOperation(c.name); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("pending operation %q has been denied\n", c.name)
+	return nil
+}
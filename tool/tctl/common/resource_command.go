@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	osuser "os/user"
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/auth"
@@ -30,21 +31,31 @@ import (
 
 	"github.com/gravitational/kingpin"
 	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
 	kyaml "k8s.io/apimachinery/pkg/util/yaml"
 )
 
+// twoPersonRuleActions maps the resource kinds that `tctl rm --request-approval`
+// can gate behind the two-person rule to the pending operation action that
+// will be applied once a second administrator approves it.
+var twoPersonRuleActions = map[string]string{
+	services.KindRole:           services.PendingOperationDeleteRole,
+	services.KindTrustedCluster: services.PendingOperationDeleteTrustedCluster,
+}
+
 type ResourceCreateHandler func(auth.ClientI, services.UnknownResource) error
 type ResourceKind string
 
 // ResourceCommand implements `tctl get/create/list` commands for manipulating
 // Teleport resources
 type ResourceCommand struct {
-	config      *service.Config
-	ref         services.Ref
-	format      string
-	namespace   string
-	withSecrets bool
-	force       bool
+	config          *service.Config
+	ref             services.Ref
+	format          string
+	namespace       string
+	withSecrets     bool
+	force           bool
+	requestApproval bool
 
 	// filename is the name of the resource, used for 'create'
 	filename string
@@ -74,6 +85,9 @@ func (g *ResourceCommand) Initialize(app *kingpin.Application, config *service.C
 		services.KindTrustedCluster:  g.createTrustedCluster,
 		services.KindGithubConnector: g.createGithubConnector,
 		services.KindCertAuthority:   g.createCertAuthority,
+		services.KindRole:            g.createRole,
+		services.KindClusterConfig:   g.createClusterConfig,
+		services.KindRemoteCluster:   g.createRemoteCluster,
 	}
 	g.config = config
 
@@ -83,6 +97,7 @@ func (g *ResourceCommand) Initialize(app *kingpin.Application, config *service.C
 
 	g.deleteCmd = app.Command("rm", "Delete a resource").Alias("del")
 	g.deleteCmd.Arg("resource", "Resource to delete").SetValue(&g.ref)
+	g.deleteCmd.Flag("request-approval", "Create a pending operation requiring a second administrator's approval (two-person rule) instead of deleting immediately. Supported for roles and trusted clusters.").BoolVar(&g.requestApproval)
 
 	g.getCmd = app.Command("get", "Print a YAML declaration of various Teleport resources")
 	g.getCmd.Arg("resource", "Resource spec: 'type/[name]'").SetValue(&g.ref)
@@ -265,12 +280,80 @@ func (u *ResourceCommand) createUser(client auth.ClientI, raw services.UnknownRe
 	return nil
 }
 
+// createRole implements `tctl create role.yaml` command
+func (u *ResourceCommand) createRole(client auth.ClientI, raw services.UnknownResource) error {
+	role, err := services.GetRoleMarshaler().UnmarshalRole(raw.Raw)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roleName := role.GetName()
+	_, err = client.GetRole(roleName)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	exists := (err == nil)
+	if u.force == false && exists {
+		return trace.AlreadyExists("role %q already exists", roleName)
+	}
+	if err := client.UpsertRole(role); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("role %q has been %s\n", roleName, UpsertVerb(exists, u.force))
+	return nil
+}
+
+// createClusterConfig implements `tctl create cluster_config.yaml` command
+func (u *ResourceCommand) createClusterConfig(client auth.ClientI, raw services.UnknownResource) error {
+	newConfig, err := services.GetClusterConfigMarshaler().Unmarshal(raw.Raw)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := client.SetClusterConfig(newConfig); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("cluster configuration has been updated\n")
+	return nil
+}
+
+// createRemoteCluster implements `tctl create remote_cluster.yaml` command,
+// used to toggle a remote cluster's enabled state without touching the
+// trust relationship that created it
+func (u *ResourceCommand) createRemoteCluster(client auth.ClientI, raw services.UnknownResource) error {
+	rc, err := services.UnmarshalRemoteCluster(raw.Raw)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	exists := true
+	if _, err := client.GetRemoteCluster(rc.GetName()); err != nil {
+		if !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		exists = false
+	}
+	if u.force == false && exists {
+		return trace.AlreadyExists("remote cluster %q already exists", rc.GetName())
+	}
+	if err := client.UpsertRemoteCluster(rc); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("remote cluster %q has been %s\n", rc.GetName(), UpsertVerb(exists, u.force))
+	return nil
+}
+
 // Delete deletes resource by name
 func (d *ResourceCommand) Delete(client auth.ClientI) (err error) {
 	if d.ref.Kind == "" || d.ref.Name == "" {
 		return trace.BadParameter("provide a full resource name to delete, for example:\n$ tctl rm cluster/east\n")
 	}
 
+	if d.requestApproval {
+		action, ok := twoPersonRuleActions[d.ref.Kind]
+		if !ok {
+			return trace.BadParameter("--request-approval is not supported for resources of type %q", d.ref.Kind)
+		}
+		return d.createPendingDelete(client, action)
+	}
+
 	switch d.ref.Kind {
 	case services.KindNode:
 		if err = client.DeleteNode(defaults.Namespace, d.ref.Name); err != nil {
@@ -312,12 +395,65 @@ func (d *ResourceCommand) Delete(client auth.ClientI) (err error) {
 			return trace.Wrap(err)
 		}
 		fmt.Printf("remote cluster %q has been deleted\n", d.ref.Name)
+	case services.KindRole:
+		if err = client.DeleteRole(d.ref.Name); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Printf("role %q has been deleted\n", d.ref.Name)
+	case services.KindTunnelConnection:
+		conns, err := client.GetAllTunnelConnections()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		var clusterName string
+		for _, conn := range conns {
+			if conn.GetName() == d.ref.Name {
+				clusterName = conn.GetClusterName()
+				break
+			}
+		}
+		if clusterName == "" {
+			return trace.NotFound("tunnel connection %q is not found", d.ref.Name)
+		}
+		if err = client.DeleteTunnelConnection(clusterName, d.ref.Name); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Printf("tunnel connection %q has been deleted\n", d.ref.Name)
 	default:
 		return trace.BadParameter("deleting resources of type %q is not supported", d.ref.Kind)
 	}
 	return nil
 }
 
+// createPendingDelete requests the given action against the command's
+// target resource instead of applying it immediately, so that a second
+// administrator must run `tctl request approve` before it takes effect.
+func (d *ResourceCommand) createPendingDelete(client auth.ClientI, action string) error {
+	requestedBy := currentOSUsername()
+	op, err := services.NewPendingOperation(uuid.New(), action, d.ref.Name, "", requestedBy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := client.CreatePendingOperation(op); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("pending operation %q created: deletion of %v %q awaits approval from a second administrator\n",
+		op.GetName(), d.ref.Kind, d.ref.Name)
+	return nil
+}
+
+// currentOSUsername returns the name of the OS user running tctl, used to
+// record who requested or approved a pending operation. tctl usually
+// authenticates as a shared administrator identity, so the OS username is
+// the best available stand-in for a named administrator.
+func currentOSUsername() string {
+	u, err := osuser.Current()
+	if err != nil {
+		return "unknown"
+	}
+	return u.Username
+}
+
 // IsForced returns true if -f flag was passed
 func (cmd *ResourceCommand) IsForced() bool {
 	return cmd.force
@@ -469,6 +605,26 @@ func (g *ResourceCommand) getCollection(client auth.ClientI) (c ResourceCollecti
 			return nil, trace.Wrap(err)
 		}
 		return &remoteClusterCollection{remoteClusters: []services.RemoteCluster{remoteCluster}}, nil
+	case services.KindTunnelConnection:
+		conns, err := client.GetAllTunnelConnections()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if g.ref.Name == "" {
+			return &tunnelConnectionCollection{tunnelConnections: conns}, nil
+		}
+		for _, conn := range conns {
+			if conn.GetName() == g.ref.Name {
+				return &tunnelConnectionCollection{tunnelConnections: []services.TunnelConnection{conn}}, nil
+			}
+		}
+		return nil, trace.NotFound("tunnel connection %q is not found", g.ref.Name)
+	case services.KindClusterConfig:
+		clusterConfig, err := client.GetClusterConfig()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &clusterConfigCollection{clusterConfig: clusterConfig}, nil
 	}
 	return nil, trace.BadParameter("'%v' is not supported", g.ref.Kind)
 }
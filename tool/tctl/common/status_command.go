@@ -79,6 +79,11 @@ func (c *StatusCommand) Status(client auth.ClientI) error {
 		return trace.Wrap(err)
 	}
 
+	alerts, err := client.GetClusterAlerts()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
 	authorities := append(userCAs, hostCAs...)
 	view := func() string {
 		table := asciitable.MakeHeadlessTable(2)
@@ -106,6 +111,10 @@ func (c *StatusCommand) Status(client auth.ClientI) error {
 	}
 	fmt.Printf(view())
 
+	for _, alert := range alerts {
+		fmt.Printf("\n[%v] %v\n", strings.ToUpper(string(alert.GetSeverity())), alert.GetMessage())
+	}
+
 	// in debug mode, output mode of remote certificate authorities
 	if c.config.Debug {
 		view := func() string {
@@ -183,6 +183,11 @@ func (c *TopCommand) render(ctx context.Context, re Report, eventID string) erro
 			fmt.Sprintf("Cluster %v", rc.Name), rc.IsConnected(),
 		})
 	}
+	for _, ts := range re.Cluster.TunnelsByState {
+		t1.Rows = append(t1.Rows, []string{
+			fmt.Sprintf("Tunnel %v [%v]", ts.Cluster, ts.State), humanize.FormatFloat("", ts.Count),
+		})
+	}
 
 	t2 := widgets.NewTable()
 	t2.Title = "Process Stats"
@@ -419,6 +424,20 @@ type ClusterStats struct {
 	GenerateRequestsThrottledCount Counter
 	// GenerateRequestsHistogram is a histogram of generate requests latencies
 	GenerateRequestsHistogram Histogram
+	// TunnelsByState is a list of reverse tunnels grouped by cluster and
+	// connection state.
+	TunnelsByState []TunnelState
+}
+
+// TunnelState is a count of reverse tunnels to a cluster that are
+// currently in a given connection state (connecting, connected, etc).
+type TunnelState struct {
+	// Cluster is the name of the cluster the tunnel connects to.
+	Cluster string
+	// State is the tunnel's connection state.
+	State string
+	// Count is the number of tunnels in this state.
+	Count float64
 }
 
 // RemoteCluster is a remote cluster (or local cluster)
@@ -621,6 +640,7 @@ func generateReport(metrics map[string]*dto.MetricFamily, prev *Report, period t
 		GenerateRequestsCount:          Counter{Count: getCounterValue(metrics[teleport.MetricGenerateRequests])},
 		GenerateRequestsThrottledCount: Counter{Count: getCounterValue(metrics[teleport.MetricGenerateRequestsThrottled])},
 		GenerateRequestsHistogram:      getHistogram(metrics[teleport.MetricGenerateRequestsHistogram]),
+		TunnelsByState:                 getTunnelsByState(metrics[teleport.MetricTrustedClusters]),
 	}
 
 	if prev != nil {
@@ -686,6 +706,34 @@ func getRemoteClusters(metric *dto.MetricFamily) []RemoteCluster {
 	return out
 }
 
+func getTunnelsByState(metric *dto.MetricFamily) []TunnelState {
+	if metric == nil || metric.GetType() != dto.MetricType_GAUGE || len(metric.Metric) == 0 {
+		return nil
+	}
+	out := make([]TunnelState, 0, len(metric.Metric))
+	for _, counter := range metric.Metric {
+		ts := TunnelState{
+			Count: counter.Gauge.GetValue(),
+		}
+		for _, label := range counter.Label {
+			switch label.GetName() {
+			case teleport.TagCluster:
+				ts.Cluster = label.GetValue()
+			case teleport.TagState:
+				ts.State = label.GetValue()
+			}
+		}
+		out = append(out, ts)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cluster == out[j].Cluster {
+			return out[i].State < out[j].State
+		}
+		return out[i].Cluster < out[j].Cluster
+	})
+	return out
+}
+
 func getComponentGaugeValue(component string, metric *dto.MetricFamily) float64 {
 	if metric == nil || metric.GetType() != dto.MetricType_GAUGE || len(metric.Metric) == 0 || metric.Metric[0].Gauge == nil || metric.Metric[0].Gauge.Value == nil {
 		return 0
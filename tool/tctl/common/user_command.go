@@ -44,6 +44,12 @@ type UserCommand struct {
 	identities    []string
 	ttl           time.Duration
 
+	// updateLogins and updateKubeGroups back 'users update's --set-logins
+	// and --set-kubernetes-groups flags, left unset ("") to mean "don't
+	// change this trait"
+	updateLogins     string
+	updateKubeGroups string
+
 	// format is the output format, e.g. text or json
 	format string
 
@@ -64,16 +70,22 @@ func (u *UserCommand) Initialize(app *kingpin.Application, config *service.Confi
 		Default("").StringVar(&u.allowedLogins)
 	u.userAdd.Flag("k8s-groups", "Kubernetes groups to assign to a user.").
 		Default("").StringVar(&u.kubeGroups)
+	u.userAdd.Flag("roles", "Comma-separated list of roles to assign to a user").
+		Default("").StringVar(&u.roles)
 	u.userAdd.Flag("ttl", fmt.Sprintf("Set expiration time for token, default is %v hour, maximum is %v hours",
 		int(defaults.SignupTokenTTL/time.Hour), int(defaults.MaxSignupTokenTTL/time.Hour))).
 		Default(fmt.Sprintf("%v", defaults.SignupTokenTTL)).DurationVar(&u.ttl)
 	u.userAdd.Flag("format", "Output format, 'text' or 'json'").Hidden().Default(teleport.Text).StringVar(&u.format)
 	u.userAdd.Alias(AddUserHelp)
 
-	u.userUpdate = users.Command("update", "Update properties for existing user").Hidden()
+	u.userUpdate = users.Command("update", "Update properties for existing user")
 	u.userUpdate.Arg("login", "Teleport user login").Required().StringVar(&u.login)
-	u.userUpdate.Flag("set-roles", "Roles to assign to this user").
+	u.userUpdate.Flag("set-roles", "Comma-separated list of roles to assign to this user").
 		Default("").StringVar(&u.roles)
+	u.userUpdate.Flag("set-logins", "Comma-separated list of UNIX logins to assign to this user").
+		Default("").StringVar(&u.updateLogins)
+	u.userUpdate.Flag("set-kubernetes-groups", "Comma-separated list of Kubernetes groups to assign to this user").
+		Default("").StringVar(&u.updateKubeGroups)
 
 	u.userList = users.Command("ls", "List all user accounts")
 	u.userList.Flag("format", "Output format, 'text' or 'json'").Hidden().Default(teleport.Text).StringVar(&u.format)
@@ -111,10 +123,20 @@ func (u *UserCommand) Add(client auth.ClientI) error {
 	if u.kubeGroups != "" {
 		kubeGroups = strings.Split(u.kubeGroups, ",")
 	}
+	var roles []string
+	if u.roles != "" {
+		roles = strings.Split(u.roles, ",")
+		for _, role := range roles {
+			if _, err := client.GetRole(role); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+	}
 	user := services.UserV1{
 		Name:          u.login,
 		AllowedLogins: strings.Split(u.allowedLogins, ","),
 		KubeGroups:    kubeGroups,
+		Roles:         roles,
 	}
 	token, err := client.CreateSignupToken(user, u.ttl)
 	if err != nil {
@@ -143,23 +165,47 @@ func (u *UserCommand) PrintSignupURL(client auth.ClientI, token string, ttl time
 	return nil
 }
 
-// Update updates existing user
+// Update updates properties of an existing user: roles, allowed logins, and
+// Kubernetes groups. Any --set-* flag left unset leaves that property alone.
 func (u *UserCommand) Update(client auth.ClientI) error {
+	if u.roles == "" && u.updateLogins == "" && u.updateKubeGroups == "" {
+		return trace.BadParameter("specify at least one of --set-roles, --set-logins or --set-kubernetes-groups")
+	}
 	user, err := client.GetUser(u.login)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	roles := strings.Split(u.roles, ",")
-	for _, role := range roles {
-		if _, err := client.GetRole(role); err != nil {
-			return trace.Wrap(err)
+
+	var updated []string
+	if u.roles != "" {
+		roles := strings.Split(u.roles, ",")
+		for _, role := range roles {
+			if _, err := client.GetRole(role); err != nil {
+				return trace.Wrap(err)
+			}
 		}
+		user.SetRoles(roles)
+		updated = append(updated, fmt.Sprintf("roles=%v", strings.Join(user.GetRoles(), ",")))
+	}
+
+	traits := user.GetTraits()
+	if traits == nil {
+		traits = make(map[string][]string)
 	}
-	user.SetRoles(roles)
+	if u.updateLogins != "" {
+		traits[teleport.TraitLogins] = strings.Split(u.updateLogins, ",")
+		updated = append(updated, fmt.Sprintf("logins=%v", u.updateLogins))
+	}
+	if u.updateKubeGroups != "" {
+		traits[teleport.TraitKubeGroups] = strings.Split(u.updateKubeGroups, ",")
+		updated = append(updated, fmt.Sprintf("kubernetes_groups=%v", u.updateKubeGroups))
+	}
+	user.SetTraits(traits)
+
 	if err := client.UpsertUser(user); err != nil {
 		return trace.Wrap(err)
 	}
-	fmt.Printf("%v has been updated with roles %v\n", user.GetName(), strings.Join(user.GetRoles(), ","))
+	fmt.Printf("%v has been updated: %v\n", user.GetName(), strings.Join(updated, ", "))
 	return nil
 }
 
@@ -27,8 +27,11 @@ func main() {
 		&common.TokenCommand{},
 		&common.AuthCommand{},
 		&common.ResourceCommand{},
+		&common.RequestCommand{},
 		&common.StatusCommand{},
 		&common.TopCommand{},
+		&common.VerifyLogCommand{},
+		&common.BackendCommand{},
 	}
 	common.Run(commands)
 }
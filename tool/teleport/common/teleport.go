@@ -28,6 +28,7 @@ import (
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/config"
 	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/diag"
 	"github.com/gravitational/teleport/lib/service"
 	"github.com/gravitational/teleport/lib/sshutils/scp"
 	"github.com/gravitational/teleport/lib/utils"
@@ -67,9 +68,13 @@ func Run(options Options) (executedCommand string, conf *service.Config) {
 	// define commands:
 	start := app.Command("start", "Starts the Teleport service.")
 	status := app.Command("status", "Print the status of the current SSH session.")
-	dump := app.Command("configure", "Print the sample config file into stdout.")
+	configure := app.Command("configure", "Work with the Teleport configuration file.")
+	dump := configure.Command("dump", "Print the sample config file into stdout.").Default()
+	check := configure.Command("check", "Read a Teleport configuration file and report whether it is valid.")
 	ver := app.Command("version", "Print the version.")
 	scpc := app.Command("scp", "server-side implementation of scp").Hidden()
+	node := app.Command("node", "Operations on this node.")
+	diag := node.Command("diag", "Run a local self-test of this node's connection health: auth server reachability, clock skew, and certificate validity.")
 	app.HelpFlag.Short('h')
 
 	// define start flags:
@@ -116,10 +121,22 @@ func Run(options Options) (executedCommand string, conf *service.Config) {
 		"Enables reading of ~/.tsh/environment when creating a session").Hidden().BoolVar(&ccf.PermitUserEnvironment)
 	start.Flag("insecure",
 		"Insecure mode disables certificate validation").BoolVar(&ccf.InsecureMode)
+	start.Flag("fips",
+		"Start Teleport in FedRAMP/FIPS 140-2 mode").BoolVar(&ccf.FIPS)
 
 	// define start's usage info (we use kingpin's "alias" field for this)
 	start.Alias(usageNotes + usageExamples)
 
+	// define configure check's flags:
+	check.Flag("config",
+		fmt.Sprintf("Path to a configuration file [%v]", defaults.ConfigFilePath)).
+		Short('c').StringVar(&ccf.ConfigFile)
+
+	// define node diag's flags:
+	diag.Flag("config",
+		fmt.Sprintf("Path to a configuration file [%v]", defaults.ConfigFilePath)).
+		Short('c').StringVar(&ccf.ConfigFile)
+
 	// define a hidden 'scp' command (it implements server-side implementation of handling
 	// 'scp' requests)
 	scpc.Flag("t", "sink mode (data consumer)").Short('t').Default("false").BoolVar(&scpFlags.Sink)
@@ -156,6 +173,10 @@ func Run(options Options) (executedCommand string, conf *service.Config) {
 		err = onStatus()
 	case dump.FullCommand():
 		onConfigDump()
+	case check.FullCommand():
+		err = onConfigCheck(ccf.ConfigFile)
+	case diag.FullCommand():
+		err = onNodeDiag(&ccf)
 	case ver.FullCommand():
 		utils.PrintVersion()
 	}
@@ -194,12 +215,66 @@ func onStatus() error {
 	return nil
 }
 
-// onConfigDump is the handler for "configure" CLI command
+// onConfigDump is the handler for the "configure dump" CLI command
 func onConfigDump() {
 	sfc := config.MakeSampleFileConfig()
 	fmt.Printf("%s\n%s\n", sampleConfComment, sfc.DebugDumpToYAML())
 }
 
+// onConfigCheck is the handler for the "configure check" CLI command. It
+// reads and validates a configuration file without starting the service,
+// so bad configuration (typos in keys, unsupported versions, broken
+// includes) can be caught before a restart.
+func onConfigCheck(configPath string) error {
+	fc, err := config.ReadConfigFile(configPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if fc == nil {
+		return trace.BadParameter("no configuration file found")
+	}
+	fmt.Println("configuration is valid")
+	return nil
+}
+
+// onNodeDiag is the handler for the "node diag" CLI command. It runs a
+// local connection health self-test and prints the result of each check,
+// so an operator can diagnose a node that "won't connect" without a
+// back-and-forth with support.
+func onNodeDiag(ccf *config.CommandLineFlags) error {
+	conf := service.MakeDefaultConfig()
+	if err := config.Configure(ccf, conf); err != nil {
+		return trace.Wrap(err)
+	}
+	hostUUID, err := utils.ReadHostUUID(conf.DataDir)
+	if err != nil {
+		return trace.Wrap(err, "could not read this node's host UUID, has it joined a cluster yet?")
+	}
+	report, err := diag.RunSelfTest(diag.Config{
+		AuthServers: conf.AuthServers,
+		DataDir:     conf.DataDir,
+		HostUUID:    hostUUID,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, check := range report.Checks {
+		status := "OK"
+		if check.Status != diag.StatusOK {
+			status = "FAILED"
+		}
+		if check.Details != "" {
+			fmt.Printf("%-24v %-7v %v\n", check.Name, status, check.Details)
+		} else {
+			fmt.Printf("%-24v %v\n", check.Name, status)
+		}
+	}
+	if !report.OK() {
+		return trace.Errorf("self-test failed, see above for details")
+	}
+	return nil
+}
+
 // onSCP implements handling of 'scp' requests on the server side. When the teleport SSH daemon
 // receives an SSH "scp" request, it launches itself with 'scp' flag under the requested
 // user's privileges
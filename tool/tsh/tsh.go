@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -27,8 +28,10 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -40,6 +43,7 @@ import (
 	"github.com/gravitational/teleport/lib/client"
 	"github.com/gravitational/teleport/lib/defaults"
 	kubeclient "github.com/gravitational/teleport/lib/kube/client"
+	kubeutils "github.com/gravitational/teleport/lib/kube/utils"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/sshutils"
@@ -51,6 +55,7 @@ import (
 	gops "github.com/google/gops/agent"
 	"github.com/jonboulle/clockwork"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
 )
 
 var log = logrus.WithFields(logrus.Fields{
@@ -73,18 +78,37 @@ type CLIConf struct {
 	NodePort int32
 	// Login on a remote SSH host
 	NodeLogin string
+	// NodeID, when set, identifies the target node by UUID instead of by
+	// hostname. Useful for disambiguating between multiple nodes that
+	// share a hostname.
+	NodeID string
 	// InsecureSkipVerify bypasses verification of HTTPS certificate when talking to web proxy
 	InsecureSkipVerify bool
+	// AddKeysToAgentOnly keeps session keys only in the running SSH agent,
+	// never writing them to disk under ~/.tsh
+	AddKeysToAgentOnly bool
 	// IsUnderTest is set to true for unit testing
 	IsUnderTest bool
 	// AgentSocketAddr is address for agent listeing socket
 	AgentSocketAddr utils.NetAddrVal
 	// Remote SSH session to join
 	SessionID string
+	// PlaySpeed is a playback speed multiplier for `tsh play`.
+	PlaySpeed float64
+	// PlaySkipIdle collapses idle gaps during `tsh play`.
+	PlaySkipIdle bool
+	// PlayFormat controls the output format for `tsh play`: "" (interactive
+	// terminal playback), "asciicast", or "text".
+	PlayFormat string
 	// Src:dest parameter for SCP
 	CopySpec []string
 	// -r flag for scp
 	RecursiveCopy bool
+	// -p flag for scp, preserves modification times, access times, and modes
+	PreserveAttrs bool
+	// BandwidthLimit caps scp transfer speed in bytes per second (0 means
+	// unlimited)
+	BandwidthLimit int64
 	// -L flag for ssh. Local port forwarding like 'ssh -L 80:remote.host:80 -L 443:remote.host:443'
 	LocalForwardPorts []string
 	// DynamicForwardedPorts is port forwarding using SOCKS5. It is similar to
@@ -151,6 +175,41 @@ type CLIConf struct {
 
 	// Verbose is used to print extra output.
 	Verbose bool
+
+	// StatusAll, when set with "tsh status", prints every profile the user
+	// is logged into concurrently, not just the active one.
+	StatusAll bool
+
+	// SOCKSListenAddr is the [bind_address:]port tsh proxy socks listens on
+	// for incoming SOCKS5 connections
+	SOCKSListenAddr string
+	// DatabaseName is the name of the database to log into or connect to
+	// with "tsh db login"/"tsh db connect"
+	DatabaseName string
+	// DesiredRoles is a comma-separated list of roles requested with
+	// "tsh request create"
+	DesiredRoles string
+	// RequestReason is the justification given with "tsh request create"
+	RequestReason string
+	// RequestID identifies an access request for "tsh request show"
+	RequestID string
+	// RequestWatch blocks "tsh request create"/"tsh request show" until
+	// the request is resolved, then logs in with the elevated roles
+	RequestWatch bool
+	// Headless requests an SSO login flow suitable for a host with no
+	// browser of its own: the callback is bound to a fixed, well-known
+	// port so it can be reached over a forwarded port from a machine
+	// that does have a browser. This proxy doesn't expose a device-code
+	// style polling endpoint, so unlike a true device-code flow this
+	// still requires forwarding the callback port to wherever the
+	// browser runs.
+	Headless bool
+	// NodesFormat controls the output format for "tsh ls": "" (table),
+	// "json", "yaml", or "names" (hostnames only, one per line).
+	NodesFormat string
+	// ListAll, when set with "tsh ls", lists nodes from every trusted
+	// (leaf) cluster in addition to the current one.
+	ListAll bool
 }
 
 func main() {
@@ -198,6 +257,7 @@ func Run(args []string, underTest bool) {
 	app.Flag("compat", "OpenSSH compatibility flag").Hidden().StringVar(&cf.Compatibility)
 	app.Flag("cert-format", "SSH certificate format").StringVar(&cf.CertificateFormat)
 	app.Flag("insecure", "Do not verify server's certificate and host name. Use only in test environments").Default("false").BoolVar(&cf.InsecureSkipVerify)
+	app.Flag("add-keys-to-agent-only", "Keep session keys only in the running SSH agent, never write them to disk").Default("false").BoolVar(&cf.AddKeysToAgentOnly)
 	app.Flag("auth", "Specify the type of authentication connector to use.").StringVar(&cf.AuthConnector)
 	app.Flag("namespace", "Namespace of the cluster").Default(defaults.Namespace).Hidden().StringVar(&cf.Namespace)
 	app.Flag("gops", "Start gops endpoint on a given address").Hidden().BoolVar(&cf.Gops)
@@ -218,6 +278,7 @@ func Run(args []string, underTest bool) {
 	ssh.Flag("tty", "Allocate TTY").Short('t').BoolVar(&cf.Interactive)
 	ssh.Flag("cluster", clusterHelp).Envar(clusterEnvVar).StringVar(&cf.SiteName)
 	ssh.Flag("option", "OpenSSH options in the format used in the configuration file").Short('o').AllowDuplicate().StringsVar(&cf.Options)
+	ssh.Flag("node-id", "Connect to the node with this UUID instead of resolving by hostname, useful when multiple nodes share a hostname").StringVar(&cf.NodeID)
 
 	// join
 	join := app.Command("join", "Join the active SSH session")
@@ -226,7 +287,10 @@ func Run(args []string, underTest bool) {
 	// play
 	play := app.Command("play", "Replay the recorded SSH session")
 	play.Flag("cluster", clusterHelp).Envar(clusterEnvVar).StringVar(&cf.SiteName)
-	play.Arg("session-id", "ID of the session to play").Required().StringVar(&cf.SessionID)
+	play.Arg("session-id", "ID of the session to play, or a path to a previously downloaded <session-id>.tar recording to play offline").Required().StringVar(&cf.SessionID)
+	play.Flag("speed", "Playback speed multiplier, e.g. 2 plays back twice as fast").Default("1").Float64Var(&cf.PlaySpeed)
+	play.Flag("skip-idle", "Skip idle gaps between session events during playback").BoolVar(&cf.PlaySkipIdle)
+	play.Flag("format", "Print the session as \"asciicast\" or \"text\" instead of replaying it interactively").EnumVar(&cf.PlayFormat, "asciicast", "text")
 	// scp
 	scp := app.Command("scp", "Secure file copy")
 	scp.Flag("cluster", clusterHelp).Envar(clusterEnvVar).StringVar(&cf.SiteName)
@@ -234,11 +298,15 @@ func Run(args []string, underTest bool) {
 	scp.Flag("recursive", "Recursive copy of subdirectories").Short('r').BoolVar(&cf.RecursiveCopy)
 	scp.Flag("port", "Port to connect to on the remote host").Short('P').Int32Var(&cf.NodePort)
 	scp.Flag("quiet", "Quiet mode").Short('q').BoolVar(&cf.Quiet)
+	scp.Flag("preserve", "Preserve modification times, access times, and modes").Short('p').BoolVar(&cf.PreserveAttrs)
+	scp.Flag("bwlimit", "Limit transfer speed to this many bytes per second (0 for unlimited)").Int64Var(&cf.BandwidthLimit)
 	// ls
 	ls := app.Command("ls", "List remote SSH nodes")
 	ls.Flag("cluster", clusterHelp).Envar(clusterEnvVar).StringVar(&cf.SiteName)
 	ls.Arg("labels", "List of labels to filter node list").StringVar(&cf.UserHost)
 	ls.Flag("verbose", clusterHelp).Short('v').BoolVar(&cf.Verbose)
+	ls.Flag("format", "Output format: 'text', 'json', 'yaml', or 'names' (hostnames only)").Default(teleport.Text).EnumVar(&cf.NodesFormat, teleport.Text, teleport.JSON, teleport.YAML, "names")
+	ls.Flag("all", "List nodes from all trusted clusters, not just the current one").BoolVar(&cf.ListAll)
 	// clusters
 	clusters := app.Command("clusters", "List available Teleport clusters")
 	clusters.Flag("quiet", "Quiet mode").Short('q').BoolVar(&cf.Quiet)
@@ -247,6 +315,8 @@ func Run(args []string, underTest bool) {
 	// stored in ~/.tsh directory
 	login := app.Command("login", "Log in to a cluster and retrieve the session certificate")
 	login.Flag("bind-addr", "Address in the form of host:port to bind to for login command webhook").Envar(bindAddrEnvVar).StringVar(&cf.BindAddr)
+	login.Flag("headless", "SSO login from a host without a browser: binds the callback to a fixed local port "+
+		"so it can be reached by forwarding that port (e.g. \"ssh -L 3036:localhost:3036 thishost\") from a machine that does have one").BoolVar(&cf.Headless)
 	login.Flag("out", "Identity output").Short('o').AllowDuplicate().StringVar(&cf.IdentityFileOut)
 	login.Flag("format", fmt.Sprintf("Identity format [%s] or %s (for OpenSSH compatibility)",
 		client.DefaultIdentityFormat,
@@ -275,6 +345,50 @@ func Run(args []string, underTest bool) {
 	// The status command shows which proxy the user is logged into and metadata
 	// about the certificate.
 	status := app.Command("status", "Display the list of proxy servers and retrieved certificates")
+	status.Flag("all", "Show every cluster currently logged into, not just the active one").BoolVar(&cf.StatusAll)
+
+	// The config command prints an OpenSSH client configuration snippet that
+	// wires "tsh proxy ssh" up as a ProxyCommand, so native ssh/scp/rsync/
+	// ansible can reach Teleport nodes without any other wrapper.
+	config := app.Command("config", "Print OpenSSH client configuration to connect to Teleport nodes")
+
+	// proxy
+	proxy := app.Command("proxy", "Run a local proxy tunnel to a Teleport node")
+	proxySSH := proxy.Command("ssh", "Set up a tunnel through the Teleport proxy, for use as an OpenSSH ProxyCommand")
+	proxySSH.Arg("host", "Remote hostname to connect to").Required().StringVar(&cf.UserHost)
+	proxySSH.Arg("port", "Remote SSH port to connect to").Default(strconv.Itoa(defaults.SSHServerListenPort)).Int32Var(&cf.NodePort)
+	proxySSH.Flag("cluster", clusterHelp).Envar(clusterEnvVar).StringVar(&cf.SiteName)
+	proxySOCKS := proxy.Command("socks", "Start a local SOCKS5 proxy that reaches nodes on the other side of the Teleport proxy, subject to port forwarding RBAC")
+	proxySOCKS.Arg("host", "Teleport node to use as the gateway for SOCKS5 connections").Required().StringVar(&cf.UserHost)
+	proxySOCKS.Arg("port", "SSH port of the gateway node").Default(strconv.Itoa(defaults.SSHServerListenPort)).Int32Var(&cf.NodePort)
+	proxySOCKS.Flag("listen", "Local [bind_address:]port to listen for SOCKS5 connections on").Default("127.0.0.1:1080").StringVar(&cf.SOCKSListenAddr)
+	proxySOCKS.Flag("cluster", clusterHelp).Envar(clusterEnvVar).StringVar(&cf.SiteName)
+
+	// kube
+	kube := app.Command("kube", "Access Kubernetes clusters through the Teleport proxy")
+	kubeLS := kube.Command("ls", "List Kubernetes clusters reachable through Teleport")
+	kubeLogin := kube.Command("login", "Write kubeconfig entries for a Teleport cluster's Kubernetes API")
+	kubeLogin.Arg("cluster", "Name of the Teleport cluster to log into").Required().StringVar(&cf.SiteName)
+	kubeCreds := kube.Command("credentials", "Print a Kubernetes exec credential, for use as a kubeconfig \"exec:\" command")
+
+	// db
+	db := app.Command("db", "Access databases through the Teleport proxy")
+	dbLS := db.Command("ls", "List databases available through Teleport")
+	dbLogin := db.Command("login", "Issue a database-scoped certificate for a Teleport-registered database")
+	dbLogin.Arg("db", "Name of the database to log into").Required().StringVar(&cf.DatabaseName)
+	dbConnect := db.Command("connect", "Start a local authenticated tunnel and connect to a Teleport-registered database")
+	dbConnect.Arg("db", "Name of the database to connect to").Required().StringVar(&cf.DatabaseName)
+
+	// request
+	request := app.Command("request", "Manage access requests for elevated roles")
+	requestCreate := request.Command("create", "Create an access request for one or more roles")
+	requestCreate.Arg("roles", "Comma-separated roles to request").Required().StringVar(&cf.DesiredRoles)
+	requestCreate.Flag("reason", "Reason for requesting the roles").StringVar(&cf.RequestReason)
+	requestCreate.Flag("watch", "Block until the request is approved or denied, then log in with the elevated roles").BoolVar(&cf.RequestWatch)
+	requestList := request.Command("list", "List access requests")
+	requestShow := request.Command("show", "Show the status of an access request")
+	requestShow.Arg("request-id", "ID of the access request to show").Required().StringVar(&cf.RequestID)
+	requestShow.Flag("watch", "Block until the request is approved or denied, then log in with the elevated roles").BoolVar(&cf.RequestWatch)
 
 	// On Windows, hide the "ssh", "join", "play", "scp", and "bench" commands
 	// because they all use a terminal.
@@ -344,20 +458,80 @@ func Run(args []string, underTest bool) {
 		onShow(&cf)
 	case status.FullCommand():
 		onStatus(&cf)
+	case config.FullCommand():
+		onConfig(&cf)
+	case proxySSH.FullCommand():
+		onProxyCommandSSH(&cf)
+	case proxySOCKS.FullCommand():
+		onProxyCommandSOCKS(&cf)
+	case kubeLS.FullCommand():
+		onKubeLS(&cf)
+	case kubeLogin.FullCommand():
+		onKubeLogin(&cf)
+	case kubeCreds.FullCommand():
+		onKubeCredentials(&cf)
+	case dbLS.FullCommand():
+		onDatabaseLS(&cf)
+	case dbLogin.FullCommand():
+		onDatabaseLogin(&cf)
+	case dbConnect.FullCommand():
+		onDatabaseConnect(&cf)
+	case requestCreate.FullCommand():
+		onRequestCreate(&cf)
+	case requestList.FullCommand():
+		onRequestList(&cf)
+	case requestShow.FullCommand():
+		onRequestShow(&cf)
 	}
 }
 
-// onPlay replays a session with a given ID
+// onPlay replays a session, either live from the cluster (cf.SessionID is a
+// session ID) or offline from a previously downloaded tarball (cf.SessionID
+// is a path to a "<session-id>.tar" file), which requires no connection to
+// the cluster at all.
 func onPlay(cf *CLIConf) {
+	if isSessionTarball(cf.SessionID) {
+		if cf.PlayFormat != "" {
+			out, err := client.ExportSessionFromFile(cf.SessionID, cf.PlayFormat)
+			if err != nil {
+				utils.FatalError(err)
+			}
+			os.Stdout.Write(out)
+			return
+		}
+		if err := client.PlayFromFile(cf.SessionID, cf.PlaySpeed, cf.PlaySkipIdle); err != nil {
+			utils.FatalError(err)
+		}
+		return
+	}
+
 	tc, err := makeClient(cf, true)
 	if err != nil {
 		utils.FatalError(err)
 	}
-	if err := tc.Play(context.TODO(), cf.Namespace, cf.SessionID); err != nil {
+	if cf.PlayFormat != "" {
+		out, err := tc.ExportSession(context.TODO(), cf.Namespace, cf.SessionID, cf.PlayFormat)
+		if err != nil {
+			utils.FatalError(err)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+	if err := tc.Play(context.TODO(), cf.Namespace, cf.SessionID, cf.PlaySpeed, cf.PlaySkipIdle); err != nil {
 		utils.FatalError(err)
 	}
 }
 
+// isSessionTarball reports whether arg looks like a path to a local session
+// recording tarball rather than a session ID.
+func isSessionTarball(arg string) bool {
+	if !strings.HasSuffix(arg, ".tar") {
+		return false
+	}
+	_, err := os.Stat(arg)
+	return err == nil
+}
+
 // onLogin logs in with remote proxy and gets signed certificates
 func onLogin(cf *CLIConf) {
 	var (
@@ -394,11 +568,17 @@ func onLogin(cf *CLIConf) {
 		switch {
 		// in case if nothing is specified, print current status
 		case cf.Proxy == "" && cf.SiteName == "":
-			printProfiles(profile, profiles)
+			printProfiles(profile, profiles, cf.StatusAll)
 			return
-		// in case if parameters match, print current status
-		case host(cf.Proxy) == host(profile.ProxyURL.Host) && cf.SiteName == profile.Cluster:
-			printProfiles(profile, profiles)
+		// a proxy (already logged into, possibly concurrently with other
+		// proxies) was given, and no cluster or a matching one: make it
+		// the active profile and print status, without a fresh login
+		case cf.Proxy != "" && (cf.SiteName == "" || cf.SiteName == profile.Cluster):
+			tc.SaveProfile("", "")
+			if err := kubeclient.UpdateKubeconfig(tc); err != nil {
+				utils.FatalError(err)
+			}
+			onStatus(cf)
 			return
 		// proxy is unspecified or the same as the currently provided proxy,
 		// but cluster is specified, treat this as selecting a new cluster
@@ -592,6 +772,27 @@ func onListNodes(cf *CLIConf) {
 		utils.FatalError(err)
 	}
 
+	// With --all, fan out across every trusted (leaf) cluster in addition
+	// to the current one, and tag each node with its cluster of origin.
+	if cf.ListAll {
+		var clusterNodes []client.ClusterNode
+		err = client.RetryWithRelogin(cf.Context, tc, func() error {
+			clusterNodes, err = tc.ListAllNodes(cf.Context)
+			return err
+		})
+		if err != nil {
+			utils.FatalError(err)
+		}
+		sort.Slice(clusterNodes, func(i, j int) bool {
+			if clusterNodes[i].ClusterName != clusterNodes[j].ClusterName {
+				return clusterNodes[i].ClusterName < clusterNodes[j].ClusterName
+			}
+			return clusterNodes[i].Server.GetHostname() < clusterNodes[j].Server.GetHostname()
+		})
+		printClusterNodes(cf, clusterNodes)
+		return
+	}
+
 	// Get list of all nodes in backend and sort by "Node Name".
 	var nodes []services.Server
 	err = client.RetryWithRelogin(cf.Context, tc, func() error {
@@ -605,6 +806,28 @@ func onListNodes(cf *CLIConf) {
 		return nodes[i].GetHostname() < nodes[j].GetHostname()
 	})
 
+	switch cf.NodesFormat {
+	case teleport.JSON:
+		out, err := json.MarshalIndent(nodes, "", "  ")
+		if err != nil {
+			utils.FatalError(err)
+		}
+		fmt.Println(string(out))
+		return
+	case teleport.YAML:
+		out, err := yaml.Marshal(nodes)
+		if err != nil {
+			utils.FatalError(err)
+		}
+		fmt.Println(string(out))
+		return
+	case "names":
+		for _, n := range nodes {
+			fmt.Println(n.GetHostname())
+		}
+		return
+	}
+
 	switch cf.Verbose {
 	// In verbose mode, print everything on a single line and include the Node
 	// ID (UUID). Useful for machines that need to parse the output of "tsh ls".
@@ -636,6 +859,58 @@ func onListNodes(cf *CLIConf) {
 	}
 }
 
+// printClusterNodes prints nodes gathered from "tsh ls --all" across the
+// root cluster and its leaf clusters, with an extra "Cluster" column.
+func printClusterNodes(cf *CLIConf, clusterNodes []client.ClusterNode) {
+	switch cf.NodesFormat {
+	case teleport.JSON:
+		out, err := json.MarshalIndent(clusterNodes, "", "  ")
+		if err != nil {
+			utils.FatalError(err)
+		}
+		fmt.Println(string(out))
+		return
+	case teleport.YAML:
+		out, err := yaml.Marshal(clusterNodes)
+		if err != nil {
+			utils.FatalError(err)
+		}
+		fmt.Println(string(out))
+		return
+	case "names":
+		for _, cn := range clusterNodes {
+			fmt.Println(cn.Server.GetHostname())
+		}
+		return
+	}
+
+	switch cf.Verbose {
+	case true:
+		t := asciitable.MakeTable([]string{"Node Name", "Node ID", "Address", "Cluster", "Labels"})
+		for _, cn := range clusterNodes {
+			t.AddRow([]string{
+				cn.Server.GetHostname(), cn.Server.GetName(), cn.Server.GetAddr(), cn.ClusterName, cn.Server.LabelsString(),
+			})
+		}
+		fmt.Println(t.AsBuffer().String())
+	case false:
+		t := asciitable.MakeTable([]string{"Node Name", "Address", "Cluster", "Labels"})
+		for _, cn := range clusterNodes {
+			labelChunks := chunkLabels(cn.Server.GetAllLabels(), 2)
+			for i, v := range labelChunks {
+				var hostname, addr, clusterName string
+				if i == 0 {
+					hostname = cn.Server.GetHostname()
+					addr = cn.Server.GetAddr()
+					clusterName = cn.ClusterName
+				}
+				t.AddRow([]string{hostname, addr, clusterName, strings.Join(v, ", ")})
+			}
+		}
+		fmt.Println(t.AsBuffer().String())
+	}
+}
+
 // chunkLabels breaks labels into sized chunks. Used to improve readability
 // of "tsh ls".
 func chunkLabels(labels map[string]string, chunkSize int) [][]string {
@@ -691,6 +966,108 @@ func onListClusters(cf *CLIConf) {
 	fmt.Println(t.AsBuffer().String())
 }
 
+// onKubeLS executes 'tsh kube ls' command. A Teleport cluster maps 1:1 onto
+// a single Kubernetes API endpoint behind its proxy, so this lists the same
+// clusters as "tsh clusters" together with the kube API address each one
+// resolves to.
+func onKubeLS(cf *CLIConf) {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	proxyClient, err := tc.ConnectToProxy(cf.Context)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	defer proxyClient.Close()
+
+	var sites []services.Site
+	err = client.RetryWithRelogin(cf.Context, tc, func() error {
+		sites, err = proxyClient.GetSites()
+		return err
+	})
+	if err != nil {
+		utils.FatalError(err)
+	}
+
+	proxyHost, proxyPort := tc.KubeProxyHostPort()
+	t := asciitable.MakeTable([]string{"Cluster Name", "Kube API Endpoint"})
+	for _, site := range sites {
+		addr := fmt.Sprintf("https://%v.%v:%v", kubeutils.EncodeClusterName(site.Name), proxyHost, proxyPort)
+		t.AddRow([]string{site.Name, addr})
+	}
+	fmt.Println(t.AsBuffer().String())
+}
+
+// onKubeLogin executes 'tsh kube login' command, switching the local
+// kubeconfig's current context to the named Teleport cluster's Kubernetes
+// API, using the certificate from the already-active tsh profile.
+func onKubeLogin(cf *CLIConf) {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	if err := kubeclient.UpdateKubeconfig(tc); err != nil {
+		utils.FatalError(err)
+	}
+	fmt.Printf("Kubeconfig updated for Teleport cluster %q.\n", cf.SiteName)
+}
+
+// onKubeCredentials executes 'tsh kube credentials', printing a Kubernetes
+// exec credential for the active tsh profile to stdout.
+func onKubeCredentials(cf *CLIConf) {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	out, err := kubeclient.FormatExecCredential(tc)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	os.Stdout.Write(out)
+}
+
+// onDatabaseLS executes 'tsh db ls' command. This Teleport version has no
+// database access feature: there is no database server resource, no
+// database service, and no database-scoped certificate issuance to list or
+// connect through, so this reports the limitation instead of pretending to
+// find databases that can't exist in this cluster.
+func onDatabaseLS(cf *CLIConf) {
+	utils.FatalError(trace.NotImplemented("this Teleport cluster does not support database access; no database servers are registered"))
+}
+
+// onDatabaseLogin executes 'tsh db login'. See onDatabaseLS for why this
+// isn't implemented in this version.
+func onDatabaseLogin(cf *CLIConf) {
+	utils.FatalError(trace.NotImplemented("this Teleport cluster does not support database access; cannot issue a certificate for database %q", cf.DatabaseName))
+}
+
+// onDatabaseConnect executes 'tsh db connect'. See onDatabaseLS for why this
+// isn't implemented in this version.
+func onDatabaseConnect(cf *CLIConf) {
+	utils.FatalError(trace.NotImplemented("this Teleport cluster does not support database access; cannot connect to database %q", cf.DatabaseName))
+}
+
+// onRequestCreate executes 'tsh request create'. This Teleport version has
+// no access request workflow: there is no AccessRequest resource and no
+// auth API to create, approve, or watch one, so this reports the
+// limitation instead of pretending to submit a request nobody can act on.
+func onRequestCreate(cf *CLIConf) {
+	utils.FatalError(trace.NotImplemented("this Teleport cluster does not support access requests; cannot request roles %q", cf.DesiredRoles))
+}
+
+// onRequestList executes 'tsh request list'. See onRequestCreate for why
+// this isn't implemented in this version.
+func onRequestList(cf *CLIConf) {
+	utils.FatalError(trace.NotImplemented("this Teleport cluster does not support access requests; there is nothing to list"))
+}
+
+// onRequestShow executes 'tsh request show'. See onRequestCreate for why
+// this isn't implemented in this version.
+func onRequestShow(cf *CLIConf) {
+	utils.FatalError(trace.NotImplemented("this Teleport cluster does not support access requests; cannot show request %q", cf.RequestID))
+}
+
 // onSSH executes 'tsh ssh' command
 func onSSH(cf *CLIConf) {
 	tc, err := makeClient(cf, false)
@@ -771,7 +1148,7 @@ func onSCP(cf *CLIConf) {
 		utils.FatalError(err)
 	}
 	err = client.RetryWithRelogin(cf.Context, tc, func() error {
-		return tc.SCP(context.TODO(), cf.CopySpec, int(cf.NodePort), cf.RecursiveCopy, cf.Quiet)
+		return tc.SCP(context.TODO(), cf.CopySpec, int(cf.NodePort), cf.RecursiveCopy, cf.Quiet, cf.PreserveAttrs, cf.BandwidthLimit)
 	})
 	if err != nil {
 		// exit with the same exit status as the failed command:
@@ -815,6 +1192,29 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (tc *client.TeleportClient, e
 			}
 		}
 	}
+
+	// --node-id identifies the target node by UUID, bypassing hostname
+	// resolution (and therefore proxy templates and label matching) entirely.
+	if cf.NodeID != "" {
+		cf.UserHost = cf.NodeID
+	}
+
+	// if the requested host matches a configured proxy template, rewrite it
+	// into the (cluster, search expression) pair the template specifies, so
+	// e.g. "node1.eu.internal" can be routed to the "eu" leaf cluster
+	// automatically.
+	var templateCluster string
+	if cf.NodeID == "" && labels == nil && cf.UserHost != "" {
+		templatesPath := filepath.Join(client.FullProfilePath(""), client.ProxyTemplatesConfigFile)
+		templates, err := client.LoadProxyTemplates(templatesPath)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if cluster, host, matched := templates.Apply(cf.UserHost); matched {
+			templateCluster = cluster
+			cf.UserHost = host
+		}
+	}
 	fPorts, err := client.ParsePortForwardSpec(cf.LocalForwardPorts)
 	if err != nil {
 		return nil, err
@@ -827,6 +1227,7 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (tc *client.TeleportClient, e
 
 	// 1: start with the defaults
 	c := client.MakeDefaultConfig()
+	c.AddKeysToAgentOnly = cf.AddKeysToAgentOnly
 
 	// Look if a user identity was given via -i flag
 	if cf.IdentityFileIn != "" {
@@ -897,6 +1298,8 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (tc *client.TeleportClient, e
 	}
 	if cf.SiteName != "" {
 		c.SiteName = cf.SiteName
+	} else if templateCluster != "" {
+		c.SiteName = templateCluster
 	}
 	// if host logins stored in profiles must be ignored...
 	if !useProfileLogin {
@@ -947,6 +1350,9 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (tc *client.TeleportClient, e
 		c.HostKeyCallback = client.InsecureSkipHostKeyChecking
 	}
 	c.BindAddr = cf.BindAddr
+	if cf.Headless && c.BindAddr == "" {
+		c.BindAddr = fmt.Sprintf("127.0.0.1:%v", defaults.HeadlessLoginPort)
+	}
 	return client.NewClient(c)
 }
 
@@ -982,7 +1388,7 @@ func refuseArgs(command string, args []string) {
 
 // loadIdentity loads the private key + certificate from a file
 // Returns:
-//	 - client key: user's private key+cert
+//   - client key: user's private key+cert
 //   - host auth callback: function to validate the host (may be null)
 //   - error, if somthing happens when reading the identityf file
 //
@@ -1161,18 +1567,20 @@ func onStatus(cf *CLIConf) {
 		}
 		utils.FatalError(err)
 	}
-	printProfiles(profile, profiles)
+	printProfiles(profile, profiles, cf.StatusAll)
 }
 
-func printProfiles(profile *client.ProfileStatus, profiles []*client.ProfileStatus) {
+func printProfiles(profile *client.ProfileStatus, profiles []*client.ProfileStatus, showAll bool) {
 	// Print the active profile.
 	if profile != nil {
 		printStatus(profile, true)
 	}
 
-	// Print all other profiles.
-	for _, p := range profiles {
-		printStatus(p, false)
+	// Print all other profiles, unless the caller only asked for the active one.
+	if showAll {
+		for _, p := range profiles {
+			printStatus(p, false)
+		}
 	}
 
 	// If we are printing profile, add a note that even though roles are listed
@@ -1193,3 +1601,112 @@ func host(in string) string {
 	}
 	return out
 }
+
+// onConfig handles "tsh config". For every cluster the user is currently
+// logged into it prints an OpenSSH client configuration stanza that routes
+// connections through "tsh proxy ssh", so that native ssh/scp/rsync/ansible
+// work against Teleport nodes without any other wrapper.
+func onConfig(cf *CLIConf) {
+	profile, profiles, err := client.Status("", cf.Proxy)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			fmt.Printf("Not logged in.\n")
+			return
+		}
+		utils.FatalError(err)
+	}
+
+	var all []*client.ProfileStatus
+	if profile != nil {
+		all = append(all, profile)
+	}
+	all = append(all, profiles...)
+	if len(all) == 0 {
+		fmt.Printf("Not logged in.\n")
+		return
+	}
+
+	tshPath, err := os.Executable()
+	if err != nil {
+		tshPath = "tsh"
+	}
+
+	fmt.Printf("\n# Begin generated Teleport configuration\n")
+	for _, p := range all {
+		proxyHost := host(p.ProxyURL.Host)
+		fmt.Printf("\nHost %s *.%s\n", proxyHost, p.Cluster)
+		fmt.Printf("    Port %d\n", defaults.SSHServerListenPort)
+		fmt.Printf("    ProxyCommand %s proxy ssh --cluster=%s --proxy=%s %%h %%p\n",
+			tshPath, p.Cluster, proxyHost)
+	}
+	fmt.Printf("\n# End generated Teleport configuration\n")
+}
+
+// onProxyCommandSSH handles "tsh proxy ssh". It opens a raw tunnel through
+// the Teleport proxy to cf.UserHost:cf.NodePort and relays it over
+// stdin/stdout, which is what OpenSSH expects of a ProxyCommand.
+func onProxyCommandSSH(cf *CLIConf) {
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		utils.FatalError(err)
+	}
+
+	proxyClient, err := tc.ConnectToProxy(cf.Context)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	defer proxyClient.Close()
+
+	nodeAddr := net.JoinHostPort(cf.UserHost, strconv.Itoa(int(cf.NodePort)))
+	conn, err := proxyClient.DialHost(cf.Context, nodeAddr)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	defer conn.Close()
+
+	errCh := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(conn, os.Stdin)
+		errCh <- err
+	}()
+	go func() {
+		_, err := io.Copy(os.Stdout, conn)
+		errCh <- err
+	}()
+	if err := <-errCh; err != nil && err != io.EOF {
+		utils.FatalError(err)
+	}
+}
+
+// onProxyCommandSOCKS handles "tsh proxy socks". It opens a SSH session to
+// cf.UserHost:cf.NodePort through the Teleport proxy and uses it as a
+// gateway for a local SOCKS5 listener, so SOCKS5-aware tools such as
+// database clients can reach any host the gateway node can reach, subject
+// to the same port forwarding RBAC as "tsh ssh -D".
+func onProxyCommandSOCKS(cf *CLIConf) {
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		utils.FatalError(err)
+	}
+
+	proxyClient, err := tc.ConnectToProxy(cf.Context)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	defer proxyClient.Close()
+
+	nodeAddr := net.JoinHostPort(cf.UserHost, strconv.Itoa(int(cf.NodePort)))
+	nodeClient, err := proxyClient.ConnectToNode(cf.Context, nodeAddr, tc.Config.HostLogin, false)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	defer nodeClient.Close()
+
+	listener, err := net.Listen("tcp", cf.SOCKSListenAddr)
+	if err != nil {
+		utils.FatalError(err)
+	}
+
+	fmt.Printf("Local SOCKS5 proxy listening on %v, forwarding through %v.\n", listener.Addr(), nodeAddr)
+	nodeClient.DynamicListenAndForward(cf.Context, listener)
+}